@@ -9,6 +9,7 @@ require (
 	github.com/gorilla/websocket v1.5.3
 	github.com/rocketlaunchr/dataframe-go v0.0.0-20211025052708-a1030444159b
 	github.com/samber/lo v1.52.0
+	github.com/tealeg/xlsx/v3 v3.0.0
 	github.com/yalue/onnxruntime_go v1.17.0
 	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.37.0
@@ -39,7 +40,6 @@ require (
 	github.com/rocketlaunchr/mysql-go v1.1.3 // indirect
 	github.com/rogpeppe/fastuuid v1.2.0 // indirect
 	github.com/shabbyrobe/xmlwriter v0.0.0-20200208144257-9fca06d00ffa // indirect
-	github.com/tealeg/xlsx/v3 v3.0.0 // indirect
 	github.com/xitongsys/parquet-go v1.5.2 // indirect
 	github.com/xitongsys/parquet-go-source v0.0.0-20200509081216-8db33acb0acf // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
@@ -0,0 +1,149 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"hft/internal/indicators"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Trade attribution.
+
+   Every trade in TradeDF carries its entry context (types.EntryContext).
+   Breakdown groups the last run's trades by one tag and reports count, win
+   rate and PnL per group. Numeric context is bucketed:
+
+     gap_bucket   gap_down (< −0.15%), flat, gap_up (> 0.15%) — the GapFollow threshold
+     prob_bucket  0.05-wide bins of the entry probability
+     atr_bucket   10-point bins of atr3
+*/
+
+// BreakdownTags lists the tags accepted by Breakdown.
+var BreakdownTags = []string{"tranche", "day_type", "gap_bucket", "regime", "prob_bucket", "atr_bucket", "weekday", "side", "reason", "strategy"}
+
+// BreakdownRow is one group of trades.
+type BreakdownRow struct {
+	Value       string  `json:"value"`
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`
+	WinRate     float64 `json:"winRate"`
+	NetPnL      float64 `json:"netPnl"`
+	AvgPnL      float64 `json:"avgPnl"`
+	GrossProfit float64 `json:"grossProfit"`
+	GrossLoss   float64 `json:"grossLoss"`
+}
+
+// Breakdown groups the last run's trades by tag, best net PnL first.
+func Breakdown(tag string) ([]*BreakdownRow, error) {
+	if Instance == nil || Instance.TradeDF == nil {
+		return nil, fmt.Errorf("no backtest has run")
+	}
+	df := Instance.TradeDF
+	value, err := tagReader(df, tag)
+	if err != nil {
+		return nil, err
+	}
+	profit := df.Series[indicators.FindIndexOf(df, "profit")]
+
+	groups := make(map[string]*BreakdownRow)
+	for i := 0; i < df.NRows(); i++ {
+		v := value(i)
+		if v == "" {
+			v = "unknown"
+		}
+		row := groups[v]
+		if row == nil {
+			row = &BreakdownRow{Value: v}
+			groups[v] = row
+		}
+		p, _ := profit.Value(i).(float64)
+		row.Trades++
+		row.NetPnL += p
+		if p > 0 {
+			row.Wins++
+			row.GrossProfit += p
+		} else {
+			row.GrossLoss += p
+		}
+	}
+
+	out := make([]*BreakdownRow, 0, len(groups))
+	for _, row := range groups {
+		row.WinRate = float64(row.Wins) / float64(row.Trades) * 100
+		row.AvgPnL = row.NetPnL / float64(row.Trades)
+		out = append(out, row)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].NetPnL != out[b].NetPnL {
+			return out[a].NetPnL > out[b].NetPnL
+		}
+		return out[a].Value < out[b].Value
+	})
+	return out, nil
+}
+
+// tagReader returns a function reading tag for trade row i.
+func tagReader(df *_df_.DataFrame, tag string) (func(i int) string, error) {
+	str := func(col string) func(int) string {
+		s := df.Series[indicators.FindIndexOf(df, col)]
+		return func(i int) string { v, _ := s.Value(i).(string); return v }
+	}
+	num := func(col string, bucket func(float64) string) func(int) string {
+		s := df.Series[indicators.FindIndexOf(df, col)]
+		return func(i int) string {
+			v, ok := s.Value(i).(float64)
+			if !ok {
+				return ""
+			}
+			return bucket(v)
+		}
+	}
+
+	switch tag {
+	case "tranche":
+		return str("tranche"), nil
+	case "day_type":
+		return str("dayType"), nil
+	case "regime":
+		return str("regime"), nil
+	case "weekday":
+		return str("weekday"), nil
+	case "side":
+		return str("type"), nil
+	case "reason":
+		return str("reason"), nil
+	case "strategy":
+		return str("strategy"), nil
+	case "gap_bucket":
+		return num("gapPct", func(v float64) string {
+			switch {
+			case v > 0.15:
+				return "gap_up"
+			case v < -0.15:
+				return "gap_down"
+			}
+			return "flat"
+		}), nil
+	case "prob_bucket":
+		return num("entryProb", func(v float64) string {
+			if v <= 0 {
+				return ""
+			}
+			lo := math.Floor(v*20) / 20
+			return fmt.Sprintf("%.2f-%.2f", lo, lo+0.05)
+		}), nil
+	case "atr_bucket":
+		return num("atr", func(v float64) string {
+			if v <= 0 {
+				return ""
+			}
+			lo := math.Floor(v/10) * 10
+			return fmt.Sprintf("%g-%g", lo, lo+10)
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown tag %q (have %v)", tag, BreakdownTags)
+}
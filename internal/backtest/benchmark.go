@@ -0,0 +1,434 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"hft/internal/dataframe"
+	"hft/internal/executor"
+	"hft/internal/indicators"
+	"hft/internal/manifest"
+	"hft/internal/ml_model"
+	"hft/internal/strategy"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Benchmark tracks for the last backtest run.
+
+   All tracks trade one unit of the underlying, so they share a notional:
+   equity starts at the first analysis close and moves by the PnL in points.
+   Tracks are compared on daily returns (equity at each day's last bar):
+
+     beta         cov(strategy, benchmark) / var(benchmark)
+     alpha        (mean(strategy) − beta·mean(benchmark)) × 252, in %
+     correlation  Pearson on daily returns
+     excess       strategy total return − benchmark total return, in %
+
+   The random-entry track keeps the strategy's trade count and holding
+   times (in bars) but picks entry bars and directions at random, among the
+   bars the strategy may enter on (strategy.SessionStrategy); it is
+   repeated RandomRuns times and the comparison is averaged over runs.
+
+   The second strategy is any registered strategy, run on the last run's
+   bars, symbol and timeframe with its own indicators.
+*/
+
+// BenchmarkConfig selects the benchmark tracks.
+type BenchmarkConfig struct {
+	RandomRuns     int    // random-entry repetitions (default 100)
+	Seed           int64  // random-entry seed (default 1)
+	SecondStrategy string // optional, a registered strategy name
+}
+
+// TrackSummary describes one equity track.
+type TrackSummary struct {
+	Name           string  `json:"name"`
+	Trades         int     `json:"trades"`
+	NetPoints      float64 `json:"netPoints"`
+	TotalReturnPct float64 `json:"totalReturnPct"`
+}
+
+// BenchmarkComparison is the strategy measured against one benchmark.
+type BenchmarkComparison struct {
+	Benchmark       TrackSummary `json:"benchmark"`
+	Alpha           float64      `json:"alpha"` // annualized, %
+	Beta            float64      `json:"beta"`
+	Correlation     float64      `json:"correlation"`
+	ExcessReturnPct float64      `json:"excessReturnPct"`
+
+	// Random-entry only: number of runs and the share of runs the strategy
+	// beat on net points.
+	Runs               int     `json:"runs,omitempty"`
+	StrategyPercentile float64 `json:"strategyPercentile,omitempty"`
+}
+
+// BenchmarkReport is the outcome of Benchmarks.
+type BenchmarkReport struct {
+	Days       int                    `json:"days"`
+	Strategy   TrackSummary           `json:"strategy"`
+	Benchmarks []*BenchmarkComparison `json:"benchmarks"`
+}
+
+// benchTrade is a round trip in bar indices.
+type benchTrade struct {
+	entry, exit int
+	dir         float64 // +1 long, -1 short
+}
+
+// Benchmarks compares the last backtest run against buy-and-hold, random
+// entries and, optionally, a second strategy.
+func Benchmarks(cfg BenchmarkConfig) (*BenchmarkReport, error) {
+	if cfg.RandomRuns <= 0 {
+		cfg.RandomRuns = 100
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = 1
+	}
+	if Instance == nil || Instance.DF == nil || Instance.DF.NRows() == 0 || Instance.Manifest == nil {
+		return nil, fmt.Errorf("no backtest has run")
+	}
+	run := Instance.Manifest.Run
+	strat, err := strategy.Get(run.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	df := Instance.DF
+	closeVals := df.Series[indicators.FindIndexOf(df, "close")].(*_df_.SeriesFloat64).Values
+	tsVals := df.Series[indicators.FindIndexOf(df, "timestamp")].(*_df_.SeriesTime).Values
+	from := Instance.StartIdx
+	if from >= len(closeVals) {
+		return nil, fmt.Errorf("backtest has no analysis rows")
+	}
+
+	rowOf := make(map[int64]int, len(tsVals))
+	for i, t := range tsVals {
+		if t != nil {
+			rowOf[t.UnixNano()] = i
+		}
+	}
+	dayEnds := dayEndRows(tsVals, from)
+	p0 := closeVals[from]
+
+	// ── Strategy track ───────────────────────────────────────────
+	stratTrades := tradesFromTradeDF(Instance.TradeDF, rowOf)
+	stratEquity := tradeEquity(closeVals, stratTrades, dayEnds, p0)
+	report := &BenchmarkReport{
+		Days:     len(dayEnds),
+		Strategy: summarize("strategy", len(stratTrades), stratEquity, p0),
+	}
+	stratRet := dailyReturns(stratEquity, p0)
+
+	// ── Buy and hold ─────────────────────────────────────────────
+	bh := make([]float64, len(dayEnds))
+	for k, i := range dayEnds {
+		bh[k] = closeVals[i]
+	}
+	report.Benchmarks = append(report.Benchmarks, compare(report.Strategy, stratRet,
+		summarize("buy_and_hold", 1, bh, p0), dailyReturns(bh, p0)))
+
+	// ── Random entry, same trade count and holding times ─────────
+	if len(stratTrades) > 0 {
+		agg, err := randomEntries(cfg, report.Strategy, stratRet, stratTrades, entryRows(strat, tsVals, from), closeVals, dayEnds, p0)
+		if err != nil {
+			return nil, err
+		}
+		report.Benchmarks = append(report.Benchmarks, agg)
+	}
+
+	// ── Optional second strategy ─────────────────────────────────
+	if cfg.SecondStrategy != "" {
+		events, err := runSecondStrategy(cfg.SecondStrategy, run, df)
+		if err != nil {
+			return nil, err
+		}
+		trades := tradesFromEvents(events, rowOf, from)
+		eq := tradeEquity(closeVals, trades, dayEnds, p0)
+		report.Benchmarks = append(report.Benchmarks, compare(report.Strategy, stratRet,
+			summarize(cfg.SecondStrategy, len(trades), eq, p0), dailyReturns(eq, p0)))
+	}
+
+	return report, nil
+}
+
+// randomEntries averages the comparison against cfg.RandomRuns tracks that
+// repeat trades' holding times from random rows of entries in random
+// directions.
+func randomEntries(cfg BenchmarkConfig, strat TrackSummary, stratRet []float64, trades []benchTrade, entries []int, closeVals []float64, dayEnds []int, p0 float64) (*BenchmarkComparison, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no bars the strategy can enter on")
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	n := len(closeVals)
+	agg := &BenchmarkComparison{Runs: cfg.RandomRuns}
+	agg.Benchmark.Name = "random_entry"
+	agg.Benchmark.Trades = len(trades)
+	beaten := 0
+	for run := 0; run < cfg.RandomRuns; run++ {
+		random := make([]benchTrade, len(trades))
+		for k, t := range trades {
+			hold := t.exit - t.entry
+			entry := entries[rng.Intn(len(entries))]
+			dir := 1.0
+			if rng.Intn(2) == 0 {
+				dir = -1
+			}
+			random[k] = benchTrade{entry: entry, exit: min(entry+hold, n-1), dir: dir}
+		}
+		eq := tradeEquity(closeVals, random, dayEnds, p0)
+		c := compare(strat, stratRet, summarize("random_entry", len(random), eq, p0), dailyReturns(eq, p0))
+		agg.Benchmark.NetPoints += c.Benchmark.NetPoints
+		agg.Benchmark.TotalReturnPct += c.Benchmark.TotalReturnPct
+		agg.Alpha += c.Alpha
+		agg.Beta += c.Beta
+		agg.Correlation += c.Correlation
+		agg.ExcessReturnPct += c.ExcessReturnPct
+		if strat.NetPoints > c.Benchmark.NetPoints {
+			beaten++
+		}
+	}
+	runs := float64(cfg.RandomRuns)
+	agg.Benchmark.NetPoints /= runs
+	agg.Benchmark.TotalReturnPct /= runs
+	agg.Alpha /= runs
+	agg.Beta /= runs
+	agg.Correlation /= runs
+	agg.ExcessReturnPct /= runs
+	agg.StrategyPercentile = float64(beaten) / runs * 100
+	return agg, nil
+}
+
+// entryRows are the rows from `from` on whose bars s may open a position:
+// every row when s does not restrict its entries to part of the session.
+func entryRows(s strategy.Strategy, ts []*time.Time, from int) []int {
+	session, restricted := s.(strategy.SessionStrategy)
+	var rows []int
+	for i := from; i < len(ts); i++ {
+		if ts[i] != nil && (!restricted || session.CanEnter(*ts[i])) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// runSecondStrategy runs the registered strategy name over the bars of df,
+// the frame of run, with its own indicators and in run's mode, and returns
+// its events.
+func runSecondStrategy(name string, run manifest.Run, df *_df_.DataFrame) ([]*types.Event, error) {
+	recorded := &Instance.Manifest.Config
+	s, err := strategy.GetWith(name, strategySettings(recorded))
+	if err != nil {
+		return nil, err
+	}
+	tf, err := runTimeframe(run.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if err := strategy.CheckTimeframe(s, tf); err != nil {
+		return nil, err
+	}
+	symbol := run.Symbols[0]
+	if run.Mode == ModeEvent && s.NewBar() == nil {
+		return nil, fmt.Errorf("strategy %s has no per-bar form for event mode", s.Name())
+	}
+
+	frame := barFrame(df)
+	if err := executor.ComputeFeatures(symbol, tf, frame, s, s.NeedsRegime(), recorded.Predictor.Stride, recorded.Predictor.Smoothing, nil); err != nil {
+		return nil, fmt.Errorf("second strategy %s: %w", s.Name(), err)
+	}
+	return collectEvents(frame, func(frame *_df_.DataFrame, events chan *types.Event) {
+		if run.Mode == ModeEvent {
+			executor.NewStrategyHandler(symbol, s.NewBar(), events).Replay(executor.NewFrameFeed(symbol, frame, s.Columns()...), 0)
+			return
+		}
+		s.Batch(frame, &types.Position{}, nil, events)
+	}), nil
+}
+
+// barFrame copies the bar columns of df, and its model predictions if it
+// has them, into a frame without any strategy's indicators.
+func barFrame(df *_df_.DataFrame) *_df_.DataFrame {
+	var names []string
+	for _, s := range dataframe.InitDataFrame().Series {
+		names = append(names, s.Name())
+	}
+	var series []_df_.Series
+	for _, name := range append(names, ml_model.PredictionColumns...) {
+		if idx := indicators.FindIndexOf(df, name); idx >= 0 {
+			series = append(series, df.Series[idx].Copy())
+		}
+	}
+	return _df_.NewDataFrame(series...)
+}
+
+// ── Tracks ───────────────────────────────────────────────────────────────────
+
+// dayEndRows returns the last row of each IST trading day from row `from`.
+func dayEndRows(ts []*time.Time, from int) []int {
+	ist := time.FixedZone("IST", 19800)
+	var ends []int
+	lastDay := ""
+	for i := from; i < len(ts); i++ {
+		if ts[i] == nil {
+			continue
+		}
+		day := ts[i].In(ist).Format("2006-01-02")
+		if day != lastDay {
+			ends = append(ends, i)
+			lastDay = day
+		} else {
+			ends[len(ends)-1] = i
+		}
+	}
+	return ends
+}
+
+// tradeEquity is p0 plus the points realized by each day's last bar.
+func tradeEquity(closeVals []float64, trades []benchTrade, dayEnds []int, p0 float64) []float64 {
+	sorted := append([]benchTrade(nil), trades...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].exit < sorted[b].exit })
+
+	eq := make([]float64, len(dayEnds))
+	realized := 0.0
+	k := 0
+	for d, end := range dayEnds {
+		for k < len(sorted) && sorted[k].exit <= end {
+			t := sorted[k]
+			realized += (closeVals[t.exit] - closeVals[t.entry]) * t.dir
+			k++
+		}
+		eq[d] = p0 + realized
+	}
+	return eq
+}
+
+func dailyReturns(eq []float64, p0 float64) []float64 {
+	out := make([]float64, len(eq))
+	prev := p0
+	for i, e := range eq {
+		if prev != 0 {
+			out[i] = e/prev - 1
+		}
+		prev = e
+	}
+	return out
+}
+
+func summarize(name string, trades int, eq []float64, p0 float64) TrackSummary {
+	s := TrackSummary{Name: name, Trades: trades}
+	if len(eq) > 0 && p0 != 0 {
+		s.NetPoints = eq[len(eq)-1] - p0
+		s.TotalReturnPct = s.NetPoints / p0 * 100
+	}
+	return s
+}
+
+func compare(strat TrackSummary, stratRet []float64, bench TrackSummary, benchRet []float64) *BenchmarkComparison {
+	c := &BenchmarkComparison{
+		Benchmark:       bench,
+		ExcessReturnPct: strat.TotalReturnPct - bench.TotalReturnPct,
+	}
+	n := float64(len(stratRet))
+	if n < 2 {
+		return c
+	}
+	var ms, mb float64
+	for i := range stratRet {
+		ms += stratRet[i]
+		mb += benchRet[i]
+	}
+	ms /= n
+	mb /= n
+	var cov, vs, vb float64
+	for i := range stratRet {
+		ds, db := stratRet[i]-ms, benchRet[i]-mb
+		cov += ds * db
+		vs += ds * ds
+		vb += db * db
+	}
+	if vb > 0 {
+		c.Beta = cov / vb
+	}
+	if vs > 0 && vb > 0 {
+		c.Correlation = cov / math.Sqrt(vs*vb)
+	}
+	c.Alpha = (ms - c.Beta*mb) * 252 * 100
+	return c
+}
+
+// ── Trade extraction ─────────────────────────────────────────────────────────
+
+func tradesFromTradeDF(df *_df_.DataFrame, rowOf map[int64]int) []benchTrade {
+	if df == nil || df.NRows() == 0 {
+		return nil
+	}
+	entryTimes := df.Series[indicators.FindIndexOf(df, "entryTime")].(*_df_.SeriesTime).Values
+	exitTimes := df.Series[indicators.FindIndexOf(df, "exitTime")].(*_df_.SeriesTime).Values
+	kinds := df.Series[indicators.FindIndexOf(df, "type")]
+
+	var out []benchTrade
+	for k := range entryTimes {
+		if entryTimes[k] == nil || exitTimes[k] == nil {
+			continue
+		}
+		entry, ok1 := rowOf[entryTimes[k].UnixNano()]
+		exit, ok2 := rowOf[exitTimes[k].UnixNano()]
+		if !ok1 || !ok2 {
+			continue
+		}
+		dir := 1.0
+		if kind, _ := kinds.Value(k).(string); kind == "SELL" {
+			dir = -1
+		}
+		out = append(out, benchTrade{entry: entry, exit: exit, dir: dir})
+	}
+	return out
+}
+
+func collectEvents(df *_df_.DataFrame, run func(*_df_.DataFrame, chan *types.Event)) []*types.Event {
+	events := make(chan *types.Event)
+	done := make(chan []*types.Event)
+	go func() {
+		var out []*types.Event
+		for e := range events {
+			out = append(out, e)
+		}
+		done <- out
+	}()
+	run(df, events)
+	close(events)
+	return <-done
+}
+
+// tradesFromEvents pairs ENTRY/EXIT events, keeping trades entered at or after from.
+func tradesFromEvents(events []*types.Event, rowOf map[int64]int, from int) []benchTrade {
+	var out []benchTrade
+	var open *benchTrade
+	for _, e := range events {
+		i, ok := rowOf[e.Timestamp.UnixNano()]
+		if !ok {
+			continue
+		}
+		if e.Type == "ENTRY" {
+			dir := 1.0
+			if e.Kind == "SELL" {
+				dir = -1
+			}
+			open = &benchTrade{entry: i, dir: dir}
+			continue
+		}
+		if open != nil {
+			open.exit = i
+			if open.entry >= from {
+				out = append(out, *open)
+			}
+			open = nil
+		}
+	}
+	return out
+}
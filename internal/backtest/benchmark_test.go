@@ -0,0 +1,145 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"hft/internal/dataframe"
+	"hft/internal/indicators"
+	"hft/internal/manifest"
+	"hft/internal/strategy"
+	"hft/internal/testutil"
+)
+
+func TestCompareKnownReturns(t *testing.T) {
+	bench := []float64{0.01, -0.02, 0.03, 0}
+	scaled := make([]float64, len(bench))
+	inverse := make([]float64, len(bench))
+	for i, r := range bench {
+		scaled[i] = 2*r + 0.001
+		inverse[i] = -r
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	for _, tc := range []struct {
+		name               string
+		strat, bench       []float64
+		alpha, beta, corr  float64
+		stratPct, benchPct float64
+		excess             float64
+	}{
+		// Twice the benchmark plus 0.1% a day: beta 2, fully correlated,
+		// alpha the 0.1% annualized.
+		{"scaled", scaled, bench, 0.001 * 252 * 100, 2, 1, 5, 2, 3},
+		{"inverse", inverse, bench, 0, -1, -1, -2, 2, -4},
+		// Returns that do not move together.
+		{"uncorrelated", []float64{0.01, 0.01, -0.01, -0.01}, []float64{0.01, -0.01, 0.01, -0.01}, 0, 0, 0, 0, 0, 0},
+		// One day is too few to measure anything but the excess return.
+		{"one day", []float64{0.02}, []float64{0.01}, 0, 0, 0, 2, 1, 1},
+	} {
+		c := compare(TrackSummary{TotalReturnPct: tc.stratPct}, tc.strat, TrackSummary{TotalReturnPct: tc.benchPct}, tc.bench)
+		if !near(c.Alpha, tc.alpha) || !near(c.Beta, tc.beta) || !near(c.Correlation, tc.corr) || !near(c.ExcessReturnPct, tc.excess) {
+			t.Errorf("%s: alpha %g beta %g correlation %g excess %g; want %g %g %g %g",
+				tc.name, c.Alpha, c.Beta, c.Correlation, c.ExcessReturnPct, tc.alpha, tc.beta, tc.corr, tc.excess)
+		}
+	}
+}
+
+func TestDailyReturnsAndEquity(t *testing.T) {
+	closes := []float64{100, 102, 101, 105, 104, 110}
+	dayEnds := []int{2, 5}
+	// Long 0→2 (+1), short 3→4 (+1).
+	eq := tradeEquity(closes, []benchTrade{{entry: 3, exit: 4, dir: -1}, {entry: 0, exit: 2, dir: 1}}, dayEnds, 100)
+	if eq[0] != 101 || eq[1] != 102 {
+		t.Fatalf("equity %v, want [101 102]", eq)
+	}
+	if r := dailyReturns(eq, 100); math.Abs(r[0]-0.01) > 1e-12 || math.Abs(r[1]-(102.0/101-1)) > 1e-12 {
+		t.Fatalf("daily returns %v", r)
+	}
+}
+
+// Random entries are drawn only from bars the strategy itself could enter on.
+func TestEntryRowsFollowSession(t *testing.T) {
+	ticks := testutil.Ticks("nifty", testutil.Candles(1, 1))
+	ts := make([]*time.Time, len(ticks))
+	for i := range ticks {
+		ts[i] = &ticks[i].Timestamp
+	}
+	for _, name := range []string{"orb", "regime", "kalman_v2"} {
+		s, _ := strategy.Get(name)
+		rows := entryRows(s, ts, 10)
+		if len(rows) == 0 || len(rows) >= len(ts)-10 {
+			t.Fatalf("%s: %d entry rows of %d", name, len(rows), len(ts)-10)
+		}
+		for _, i := range rows {
+			if i < 10 || !s.(strategy.SessionStrategy).CanEnter(*ts[i]) {
+				t.Fatalf("%s: row %d (%s) is not an entry bar", name, i, ts[i].Format("15:04"))
+			}
+		}
+	}
+
+	// ORB waits for its range: nothing in a tranche's first RangeMinutes.
+	s, _ := strategy.Get("orb")
+	open := strategy.ActiveORBConfig.Tranches[0].OpenMin
+	for _, i := range entryRows(s, ts, 0) {
+		if m := ts[i].Hour()*60 + ts[i].Minute(); m < open+strategy.ActiveORBConfig.RangeMinutes {
+			t.Fatalf("orb entry row at %s, inside the opening range", ts[i].Format("15:04"))
+		}
+	}
+
+	cfg := BenchmarkConfig{RandomRuns: 20, Seed: 1}
+	closes := make([]float64, len(ts))
+	for i := range closes {
+		closes[i] = ticks[i].Close
+	}
+	trade := []benchTrade{{entry: 0, exit: 5, dir: 1}}
+	if _, err := randomEntries(cfg, TrackSummary{}, []float64{0}, trade, nil, closes, []int{len(ts) - 1}, closes[0]); err == nil {
+		t.Fatal("random entries with no entry bars")
+	}
+	// With a single entry bar every run trades it, long or short.
+	r := entryRows(s, ts, 0)[0]
+	c, err := randomEntries(cfg, TrackSummary{}, []float64{0}, trade, []int{r}, closes, []int{len(ts) - 1}, closes[0])
+	if err != nil || c.Runs != 20 || c.Benchmark.Trades != 1 {
+		t.Fatalf("random entries: %+v, %v", c, err)
+	}
+	d := closes[r+5] - closes[r]
+	if k := c.Benchmark.NetPoints / d * 20; math.Abs(k-math.Round(k)) > 1e-6 || math.Abs(k) > 20 {
+		t.Fatalf("average %g points is not a mix of ±%g", c.Benchmark.NetPoints, d)
+	}
+}
+
+// The second strategy is resolved through the registry and runs with its
+// own indicators on a copy of the run's bars.
+func TestRunSecondStrategy(t *testing.T) {
+	df := dataframe.InitDataFrame()
+	dataframe.LoadHistoryBacktest(df, testutil.Ticks("banknifty", testutil.Candles(2, 3)))
+	saved := Instance
+	defer func() { Instance = saved }()
+	Instance = &Backtest{DF: df, Manifest: &manifest.Manifest{}}
+	run := manifest.Run{Strategy: "regime", Symbols: []string{"banknifty"}, Timeframe: "1m", Mode: ModeVectorized}
+
+	cols := len(df.Series)
+	events, err := runSecondStrategy("kalman_v2", run, df)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 {
+		t.Fatal("kalman_v2 produced no events on three sessions")
+	}
+	if len(df.Series) != cols || indicators.FindIndexOf(df, "swap") >= 0 {
+		t.Fatal("second strategy's indicators were added to the run's frame")
+	}
+
+	run.Mode = ModeEvent
+	if _, err := runSecondStrategy("kalman_v2", run, df); err != nil {
+		t.Fatalf("event mode: %v", err)
+	}
+	if _, err := runSecondStrategy("no_such_strategy", run, df); err == nil {
+		t.Fatal("unknown second strategy accepted")
+	}
+	run.Timeframe = "1d"
+	if _, err := runSecondStrategy("orb", run, df); err == nil {
+		t.Fatal("intraday second strategy accepted on daily bars")
+	}
+}
@@ -8,11 +8,15 @@ import (
 	"sync"
 	"time"
 
+	"hft/internal/config"
 	"hft/internal/dataframe"
+	"hft/internal/executor"
 	"hft/internal/indicators"
+	"hft/internal/manifest"
 	"hft/internal/ml_model"
 	"hft/internal/storage/sqlite"
 	"hft/internal/strategy"
+	"hft/internal/timeframe"
 	"hft/pkg/types"
 
 	_df_ "github.com/rocketlaunchr/dataframe-go"
@@ -26,6 +30,13 @@ type Backtest struct {
 	Events    chan *types.Event
 	TradeDF   *_df_.DataFrame
 	LogEvents chan *types.LogEvent
+
+	// StartIdx is the first DF row at or after the start date; rows before
+	// it are warmup and never trade.
+	StartIdx int
+
+	// Manifest records the data, model, config and result of the run.
+	Manifest *manifest.Manifest
 }
 
 var Instance *Backtest
@@ -106,6 +117,7 @@ type BacktestStats struct {
 
 // Pending position to match entries with exits
 var pendingPosition *types.Position
+var pendingContext *types.EntryContext
 var stats *BacktestStats
 
 func SubscribeSignals() {
@@ -120,6 +132,7 @@ func SubscribeSignals() {
 				EntryPrice: event.EntryPrice,
 				EntryTime:  event.Timestamp,
 			}
+			pendingContext = event.Context
 		} else if event.Type == "EXIT" && pendingPosition != nil {
 			pendingPosition.PeakProfit = event.PeakProfit
 			pendingPosition.PeakLoss = event.PeakLoss
@@ -151,6 +164,7 @@ func SubscribeSignals() {
 				event.Reason,
 				pendingPosition.PeakProfit,
 				pendingPosition.PeakLoss,
+				pendingContext,
 			)
 
 			// Update statistics
@@ -270,26 +284,30 @@ func GetBacktestStats() *BacktestStats {
 	return stats
 }
 
-// Run executes a minimal backtest pass: load all ticks and report count.
+// Run executes a backtest with the backtest section of the loaded config.
 func Run() {
 	log.Println("backtest: starting")
-	startDate := "2025-01-01"
-	endDate := "2026-03-12"
-	RunWithDatesWarmup(startDate, endDate, "2025-12-01")
+	b := loadedConfig().Backtest
+	if err := RunWithOptions(RunOptions{StartDate: b.StartDate, EndDate: b.EndDate, WarmupFrom: b.WarmupFrom, WarmupBars: b.WarmupBars}); err != nil {
+		log.Printf("backtest: %v", err)
+	}
+}
+
+// loadedConfig returns the loaded config, or the defaults when none is loaded.
+func loadedConfig() *config.Config {
+	if config.GlobalConfig != nil {
+		return config.GlobalConfig
+	}
+	return config.Defaults()
 }
 
-// RunWithDates executes a backtest pass with custom start and end dates.
-// Indicators are computed cold from startDate — use RunWithDatesWarmup to
-// avoid NaN warmup rows affecting early predictions.
+// RunWithDates executes a backtest pass with custom start and end dates,
+// warming indicators and the model window up on DefaultWarmupBars() bars
+// before startDate.
 func RunWithDates(startDate, endDate string) error {
-	return RunWithDatesWarmup(startDate, endDate, "")
+	return RunWithOptions(RunOptions{StartDate: startDate, EndDate: endDate})
 }
 
-// RunWithDatesWarmup executes a backtest with a warmup period before startDate.
-// warmupFromDate should be ~2 months before startDate to fully warm rolling
-// indicators (rolling_std_60 + vol_expansion need ~120 bars; seqLen=120 more).
-// Warmup rows are loaded and processed but predictions before startDate are
-// excluded from the exported CSV and trade signals.
 // IsRunning reports whether a backtest run is currently active.
 func IsRunning() bool {
 	runMu.Lock()
@@ -297,7 +315,126 @@ func IsRunning() bool {
 	return isRunning
 }
 
+// RunWithDatesWarmup executes a backtest with a warmup period before startDate.
+// Warmup rows are loaded and run through indicators and the model, but trades
+// and statistics only start at startDate. An empty warmupFromDate falls back
+// to DefaultWarmupBars().
 func RunWithDatesWarmup(startDate, endDate, warmupFromDate string) error {
+	return RunWithOptions(RunOptions{StartDate: startDate, EndDate: endDate, WarmupFrom: warmupFromDate})
+}
+
+// Backtest execution modes.
+const (
+	// ModeVectorized scans the whole DataFrame with the strategy's Batch.
+	ModeVectorized = "vectorized"
+	// ModeEvent replays bars one at a time through the live executor's
+	// BarHandler (strategy → risk → paper OMS).
+	ModeEvent = "event"
+)
+
+// RunEventDriven executes a backtest through the live bar handler.
+func RunEventDriven(startDate, endDate string) error {
+	return RunWithOptions(RunOptions{StartDate: startDate, EndDate: endDate, Mode: ModeEvent})
+}
+
+// RunOptions configures a backtest run.
+type RunOptions struct {
+	Strategy  string // registered strategy, default: strategy.ActiveStrategy
+	Symbol    string // default: config backtest.symbol
+	StartDate string
+	EndDate   string
+	Mode      string // default: config backtest.mode
+	Timeframe string // bar timeframe (timeframe.Parse), default: strategy.ActiveTimeframe
+
+	// WarmupFrom loads history from this date. It takes precedence over
+	// WarmupBars.
+	WarmupFrom string
+	// WarmupBars loads this many bars of the timeframe before StartDate.
+	// 0 uses DefaultWarmupBars(); a negative value disables warmup.
+	WarmupBars int
+	// Stride runs inference every Stride bars. 0 uses config
+	// predictor.stride.
+	Stride int
+	// Settings are the strategy, risk and predictor sections to run with,
+	// e.g. a manifest's recorded ones; nil, or a nil section, uses the
+	// active config. They are recorded in the run's manifest.
+	Settings *manifest.Settings
+}
+
+// strategySettings are the strategy sections of s, nil for none.
+func strategySettings(s *manifest.Settings) *strategy.Settings {
+	if s == nil {
+		return nil
+	}
+	return s.Strategy.Settings()
+}
+
+// smoothingOf is the predictor smoothing of s, nil for the predictor's own.
+func smoothingOf(s *manifest.Settings) *ml_model.SmoothingConfig {
+	if s == nil {
+		return nil
+	}
+	return s.Predictor.Smoothing
+}
+
+// DefaultWarmupBars is the history the active strategy needs before its
+// first tradable bar.
+func DefaultWarmupBars() int {
+	s, err := strategy.Get("")
+	if err != nil {
+		return strategy.KalmanV2WarmupBars
+	}
+	return warmupBars(s)
+}
+
+// warmupBars is s's indicator look-back plus, when it trades on regime
+// predictions, the model's seq_len window.
+func warmupBars(s strategy.Strategy) int {
+	bars := s.WarmupBars()
+	if p := ml_model.GetPredictor(); p != nil && s.NeedsRegime() {
+		bars += p.WarmupCandles()
+	}
+	return bars
+}
+
+// RunWithOptions executes a backtest.
+func RunWithOptions(opts RunOptions) error {
+	settings := loadedConfig()
+	symbol := opts.Symbol
+	if symbol == "" {
+		symbol = settings.Backtest.Symbol
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = settings.Backtest.Mode
+	}
+	if mode != ModeVectorized && mode != ModeEvent {
+		return fmt.Errorf("unknown backtest mode %q", mode)
+	}
+	strat, err := strategy.GetWith(opts.Strategy, strategySettings(opts.Settings))
+	if err != nil {
+		return err
+	}
+	if mode == ModeEvent && strat.NewBar() == nil {
+		return fmt.Errorf("strategy %s has no per-bar form for event mode", strat.Name())
+	}
+	tf, err := runTimeframe(opts.Timeframe)
+	if err != nil {
+		return err
+	}
+	if err := strategy.CheckTimeframe(strat, tf); err != nil {
+		return err
+	}
+	opts.Timeframe = tf.String()
+	stride := opts.Stride
+	if stride <= 0 {
+		stride = settings.Predictor.Stride
+	}
+	tradeFrom, err := time.Parse("2006-01-02", opts.StartDate)
+	if err != nil {
+		return fmt.Errorf("invalid start date: %w", err)
+	}
+
 	runMu.Lock()
 	if isRunning {
 		runMu.Unlock()
@@ -313,14 +450,8 @@ func RunWithDatesWarmup(startDate, endDate, warmupFromDate string) error {
 		runMu.Unlock()
 	}()
 
-	loadFrom := startDate
-	// if warmupFromDate != "" {
-	// 	loadFrom = warmupFromDate
-	// }
-	log.Printf("backtest: loading %s→%s (warmup from %s, analysis from %s)", loadFrom, endDate, loadFrom, startDate)
 	InitBacktest()
 
-	go SubscribeSignals()
 	ctx := context.Background()
 	df := Instance.DF
 	db := sqlite.DefaultDB()
@@ -329,30 +460,38 @@ func RunWithDatesWarmup(startDate, endDate, warmupFromDate string) error {
 		return fmt.Errorf("database not initialized")
 	}
 
-	ticks, err := db.Ticks.ListTicksFiltered(ctx, "nifty", "", 0, loadFrom, endDate)
+	go SubscribeSignals()
+
+	if opts.WarmupBars == 0 && opts.WarmupFrom == "" {
+		opts.WarmupBars = warmupBars(strat)
+	}
+	Instance.StartIdx, err = loadTicks(ctx, db, df, symbol, opts, tradeFrom)
 	if err != nil {
-		log.Printf("backtest: load ticks: %v", err)
-		return fmt.Errorf("failed to load ticks: %v", err)
+		close(Instance.Events)
+		return err
 	}
 
-	dataframe.LoadHistoryBacktest(df, ticks)
-
-	// strategy.RunKalman(df, Instance.LogEvents)
-	// strategy.FindKalmanSignal(df, Instance.Position, Instance.Positions, Instance.Events)
-
-	strategy.RunKalmanv2(df, Instance.LogEvents)
+	// Indicators and model predictions (pred_prob_* columns), restored
+	// from the feature cache when nothing they depend on changed.
+	if err := executor.ComputeFeatures(symbol, tf, df, strat, strat.NeedsRegime(), stride, smoothingOf(opts.Settings), Instance.LogEvents); err != nil {
+		log.Printf("backtest: %v", err)
+		close(Instance.Events)
+		return fmt.Errorf("failed to compute features: %w", err)
+	}
 
-	// Run model predictions (adds pred_prob_* columns to df).
+	// Entry/exit signals. Strategies see the warmup rows so their state is
+	// warm, but trades opened before startDate never reach the subscriber.
 	start := time.Now()
-	err = ml_model.PredictRegimeFromDFStrided(df, 1)
-	if err != nil {
-		log.Printf("backtest: predict regime: %v", err)
-		return fmt.Errorf("failed to predict regime: %v", err)
+	events, gateDone := tradeGate(tradeFrom, Instance.Events)
+	if mode == ModeEvent {
+		handler := executor.NewStrategyHandler(symbol, strat.NewBar(), events)
+		handler.Replay(executor.NewFrameFeed(symbol, df, strat.Columns()...), 0)
+	} else {
+		strat.Batch(df, Instance.Position, Instance.Positions, events)
 	}
-	log.Printf("backtest: predict regime: %v", time.Since(start))
-
-	// Regime-model entry/exit signals (uses prediction columns).
-	strategy.FindRegimeSignal(df, Instance.Position, Instance.Positions, Instance.Events)
+	close(events)
+	<-gateDone
+	log.Printf("backtest: %s %s signals: %v", strat.Name(), mode, time.Since(start))
 
 	// Close events channel to trigger summary printouts
 	close(Instance.Events)
@@ -360,6 +499,8 @@ func RunWithDatesWarmup(startDate, endDate, warmupFromDate string) error {
 	// Give subscriber time to process final events and print summary
 	time.Sleep(100 * time.Millisecond)
 
+	Instance.Manifest = backtestManifest(strat.Name(), symbol, mode, stride, opts)
+
 	// Pre-build and cache the JSON response so /backtest/data is instant.
 	runMu.Lock()
 	jsonCache = buildToJSON()
@@ -368,6 +509,111 @@ func RunWithDatesWarmup(startDate, endDate, warmupFromDate string) error {
 	return nil
 }
 
+// backtestManifest records the run that just finished in Instance.
+func backtestManifest(name, symbol, mode string, stride int, opts RunOptions) *manifest.Manifest {
+	m := manifest.New(manifest.KindBacktest, manifest.Run{
+		Strategy:   name,
+		Symbols:    []string{symbol},
+		StartDate:  opts.StartDate,
+		EndDate:    opts.EndDate,
+		Mode:       mode,
+		Timeframe:  opts.Timeframe,
+		WarmupFrom: opts.WarmupFrom,
+		WarmupBars: opts.WarmupBars,
+	})
+	m.Override(opts.Settings)
+	m.Config.Predictor.Stride = stride
+	m.AddFrame(symbol, Instance.DF)
+	rb := manifest.NewResultBuilder()
+	for _, t := range reportTrades(Instance.TradeDF) {
+		rb.Add(symbol, t.Type, t.EntryTime, t.ExitTime, t.EntryPrice, t.ExitPrice, t.Profit)
+	}
+	m.Result = rb.Result()
+	return m
+}
+
+// LastManifest returns the manifest of the most recent backtest run.
+func LastManifest() *manifest.Manifest {
+	if Instance == nil {
+		return nil
+	}
+	return Instance.Manifest
+}
+
+// runTimeframe parses a run's timeframe; "" is strategy.ActiveTimeframe.
+func runTimeframe(name string) (timeframe.Timeframe, error) {
+	if name == "" {
+		return strategy.ActiveTimeframe, nil
+	}
+	return timeframe.Parse(name)
+}
+
+// loadTicks loads symbol's ticks for opts (warmup included) into df,
+// resampled to opts.Timeframe, and returns the index of the first bar at or
+// after tradeFrom.
+func loadTicks(ctx context.Context, db *sqlite.DB, df *_df_.DataFrame, symbol string, opts RunOptions, tradeFrom time.Time) (int, error) {
+	tf, err := runTimeframe(opts.Timeframe)
+	if err != nil {
+		return 0, err
+	}
+	loadFrom := opts.StartDate
+	if opts.WarmupFrom != "" {
+		loadFrom = opts.WarmupFrom
+	} else if opts.WarmupBars >= 0 {
+		bars := opts.WarmupBars
+		if bars == 0 {
+			bars = DefaultWarmupBars()
+		}
+		if loadFrom, err = db.Ticks.EpochBefore(ctx, symbol, "1", opts.StartDate, bars*tf.Minutes()); err != nil {
+			log.Printf("backtest: %v", err)
+			return 0, fmt.Errorf("failed to resolve warmup: %w", err)
+		}
+	}
+	log.Printf("backtest: loading %s %s %s→%s (warmup from %s, analysis from %s)", symbol, tf, loadFrom, opts.EndDate, loadFrom, opts.StartDate)
+
+	ticks, err := db.Ticks.ListTicksFiltered(ctx, symbol, "1", 0, loadFrom, opts.EndDate)
+	if err != nil {
+		log.Printf("backtest: load ticks: %v", err)
+		return 0, fmt.Errorf("failed to load ticks: %v", err)
+	}
+	ticks = timeframe.Resample(ticks, tf)
+
+	dataframe.LoadHistoryBacktest(df, ticks)
+	startIdx := len(ticks)
+	for i, t := range ticks {
+		if !t.Timestamp.Before(tradeFrom) {
+			startIdx = i
+			break
+		}
+	}
+	log.Printf("backtest: %s %d warmup bars, %d analysis bars", symbol, startIdx, len(ticks)-startIdx)
+	return startIdx, nil
+}
+
+// tradeGate forwards strategy events to out, dropping every trade whose entry
+// is before from (and the exit that closes it). The returned done channel is
+// closed once the input channel has been closed and drained.
+func tradeGate(from time.Time, out chan *types.Event) (chan *types.Event, chan struct{}) {
+	in := make(chan *types.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dropping := false
+		for e := range in {
+			if e.Type == "ENTRY" {
+				dropping = e.Timestamp.Before(from)
+			}
+			if !dropping {
+				out <- e
+			}
+			if e.Type == "EXIT" {
+				dropping = false
+			}
+		}
+	}()
+	return in, done
+}
+
 func ToJSON() []map[string]interface{} {
 	runMu.Lock()
 	cached := jsonCache
@@ -415,6 +661,7 @@ func buildToJSON() []map[string]interface{} {
 				row[c.key] = df.Series[c.idx].Value(i)
 			}
 		}
+		row["warmup"] = i < Instance.StartIdx
 		_json[i] = row
 	}
 	return _json
@@ -438,6 +685,11 @@ func TradesToJSON() []map[string]interface{} {
 	idxPeakProfit := indicators.FindIndexOf(df, "peakProfit")
 	idxPeakLoss := indicators.FindIndexOf(df, "peakLoss")
 	idxReason := indicators.FindIndexOf(df, "reason")
+	tagCols := []string{"tranche", "dayType", "gapPct", "regime", "entryProb", "atr", "weekday", "strategy"}
+	tagIdx := make([]int, len(tagCols))
+	for k, name := range tagCols {
+		tagIdx[k] = indicators.FindIndexOf(df, name)
+	}
 
 	n := df.NRows()
 	_json := make([]map[string]interface{}, n)
@@ -454,6 +706,9 @@ func TradesToJSON() []map[string]interface{} {
 			"peakLoss":   df.Series[idxPeakLoss].Value(i),
 			"reason":     df.Series[idxReason].Value(i),
 		}
+		for k, name := range tagCols {
+			_json[i][name] = df.Series[tagIdx[k]].Value(i)
+		}
 	}
 	return _json
 }
@@ -466,6 +721,14 @@ func GetTradeCount() int {
 	return Instance.TradeDF.NRows()
 }
 
+// WarmupRows returns how many leading DF rows of the last run are warmup.
+func WarmupRows() int {
+	if Instance == nil {
+		return 0
+	}
+	return Instance.StartIdx
+}
+
 func DownloadData() {
 	// Download data frame to csv
 	ctx := context.Background()
@@ -0,0 +1,125 @@
+package backtest
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hft/internal/dataframe"
+	"hft/internal/featurecache"
+	"hft/internal/storage/sqlite"
+	"hft/internal/testutil"
+	"hft/internal/timeframe"
+	"hft/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	featurecache.Dir = "" // keep cached frames out of the source tree
+	os.Exit(m.Run())
+}
+
+// newTestDB opens a tick database in a temp dir holding days sessions of 1m
+// NIFTY bars from 2025-06-02 and, stored beside them, the same history as
+// 5m bars.
+func newTestDB(t *testing.T, days int) *sqlite.DB {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "ticks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ticks := testutil.Ticks("nifty", testutil.Candles(1, days))
+	for i := range ticks {
+		ticks[i].TF = "1"
+	}
+	five := timeframe.Resample(ticks, timeframe.Timeframe{N: 5, Unit: timeframe.Minute})
+	for i := range five {
+		five[i].TF = "5"
+		five[i].Time = five[i].Timestamp.Unix()
+	}
+	for _, ts := range [][]types.Tick{ticks, five} {
+		if _, err := db.Ticks.InsertTicks(context.Background(), ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// Warmup is counted in 1m rows of the run's own history, whatever else is
+// stored, and the rows it loads are flagged in the /backtest/data rows.
+func TestLoadTicksWarmup(t *testing.T) {
+	db := newTestDB(t, 2)
+	tradeFrom, _ := time.Parse("2006-01-02", "2025-06-03")
+
+	for _, tc := range []struct {
+		tf     string
+		warmup int
+	}{
+		{"1m", 30},
+		{"5m", 30},
+		{"15m", 10},
+	} {
+		df := dataframe.InitDataFrame()
+		opts := RunOptions{StartDate: "2025-06-03", EndDate: "2025-06-03", Timeframe: tc.tf, WarmupBars: tc.warmup}
+		startIdx, err := loadTicks(context.Background(), db, df, "nifty", opts, tradeFrom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tf, _ := timeframe.Parse(tc.tf)
+		if startIdx != tc.warmup || df.NRows() != tc.warmup+375/tf.N {
+			t.Errorf("%s: %d warmup of %d bars, want %d of %d", tc.tf, startIdx, df.NRows(), tc.warmup, tc.warmup+375/tf.N)
+		}
+
+		saved, cached := Instance, jsonCache
+		Instance = &Backtest{DF: df, StartIdx: startIdx}
+		rows := buildToJSON()
+		Instance, jsonCache = saved, cached
+		for i, row := range rows {
+			if row["warmup"] != (i < startIdx) {
+				t.Fatalf("%s: row %d warmup=%v with %d warmup rows", tc.tf, i, row["warmup"], startIdx)
+			}
+		}
+	}
+
+	// A negative count loads nothing before the start date.
+	df := dataframe.InitDataFrame()
+	opts := RunOptions{StartDate: "2025-06-03", EndDate: "2025-06-03", Timeframe: "1m", WarmupBars: -1}
+	if startIdx, err := loadTicks(context.Background(), db, df, "nifty", opts, tradeFrom); err != nil || startIdx != 0 || df.NRows() != 375 {
+		t.Fatalf("no warmup: start %d of %d rows, err %v", startIdx, df.NRows(), err)
+	}
+}
+
+func TestTradeGate(t *testing.T) {
+	from := time.Date(2025, 6, 3, 9, 15, 0, 0, testutil.IST)
+	at := func(min int) time.Time { return from.Add(time.Duration(min) * time.Minute) }
+	sent := []*types.Event{
+		{Type: "ENTRY", Kind: "BUY", Timestamp: at(-10)},
+		{Type: "EXIT", Kind: "BUY", Timestamp: at(5)}, // closes a warmup entry
+		{Type: "ENTRY", Kind: "SELL", Timestamp: at(0)},
+		{Type: "EXIT", Kind: "SELL", Timestamp: at(3)},
+		{Type: "ENTRY", Kind: "BUY", Timestamp: at(8)},
+	}
+
+	out := make(chan *types.Event, len(sent))
+	in, done := tradeGate(from, out)
+	for _, e := range sent {
+		in <- e
+	}
+	close(in)
+	<-done
+	close(out)
+
+	var got []*types.Event
+	for e := range out {
+		got = append(got, e)
+	}
+	if len(got) != 3 || got[0] != sent[2] || got[1] != sent[3] || got[2] != sent[4] {
+		t.Fatalf("forwarded %d events, want the last 3", len(got))
+	}
+}
@@ -0,0 +1,428 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"hft/internal/dataframe"
+	"hft/internal/executor"
+	"hft/internal/manifest"
+	"hft/internal/oms"
+	"hft/internal/risk"
+	"hft/internal/storage/sqlite"
+	"hft/internal/strategy"
+	"hft/pkg/types"
+)
+
+/*
+   Portfolio backtest.
+
+   Runs a per-bar strategy (the regime strategy by default) over several symbols on one shared time axis.
+   Every symbol has its own strategy state and OMS, but they draw on one
+   capital pool:
+
+     equity      = capital + realized PnL + unrealized PnL
+     entry size  = floor(room / price), where room is the smaller of
+                   MaxSymbolExposure×equity − symbol notional and
+                   MaxTotalExposure×equity − book notional
+
+   and at most MaxOpenPositions symbols hold a position at once. Entries
+   that do not fit are rejected and the strategy goes flat again.
+   Bars are processed in time order; bars sharing a timestamp are processed
+   in symbol order.
+*/
+
+// PortfolioConfig configures a portfolio backtest.
+type PortfolioConfig struct {
+	Strategy          string // registered per-bar strategy, default strategy.ActiveStrategy
+	Symbols           []string
+	StartDate         string
+	EndDate           string
+	Timeframe         string  // see RunOptions.Timeframe
+	WarmupBars        int     // see RunOptions.WarmupBars
+	Capital           float64 // starting capital, default config backtest.capital
+	MaxSymbolExposure float64 // fraction of equity per symbol, default the risk limits
+	MaxTotalExposure  float64 // fraction of equity in total, default the risk limits
+	MaxOpenPositions  int     // open positions across all symbols, default the risk limits
+	Stride            int     // inference stride, default config predictor.stride
+
+	// Settings are the sections to run with, as RunOptions.Settings; their
+	// risk limits, when set, replace risk.ActiveLimits as the defaults.
+	Settings *manifest.Settings
+}
+
+// PortfolioTrade is a closed trade in the portfolio.
+type PortfolioTrade struct {
+	Symbol     string    `json:"symbol"`
+	Type       string    `json:"type"`
+	Quantity   float64   `json:"quantity"`
+	EntryPrice float64   `json:"entryPrice"`
+	ExitPrice  float64   `json:"exitPrice"`
+	EntryTime  time.Time `json:"entryTime"`
+	ExitTime   time.Time `json:"exitTime"`
+	PnL        float64   `json:"pnl"`
+	Reason     string    `json:"reason"`
+}
+
+// EquityPoint is one sample of the combined equity curve.
+type EquityPoint struct {
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`
+	Exposure float64   `json:"exposure"` // book notional
+}
+
+// SymbolAttribution is one symbol's share of the portfolio result.
+type SymbolAttribution struct {
+	Symbol      string  `json:"symbol"`
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`
+	WinRate     float64 `json:"winRate"`
+	NetPnL      float64 `json:"netPnl"`
+	GrossProfit float64 `json:"grossProfit"`
+	GrossLoss   float64 `json:"grossLoss"`
+	PnLShare    float64 `json:"pnlShare"` // % of portfolio net PnL
+	MaxExposure float64 `json:"maxExposure"`
+	Rejected    int     `json:"rejected"` // entries that did not fit the caps or position limit
+}
+
+// PortfolioResult is the outcome of RunPortfolio.
+type PortfolioResult struct {
+	Config         PortfolioConfig      `json:"config"`
+	FinalEquity    float64              `json:"finalEquity"`
+	NetPnL         float64              `json:"netPnl"`
+	ReturnPct      float64              `json:"returnPct"`
+	MaxDrawdown    float64              `json:"maxDrawdown"`
+	MaxDrawdownPct float64              `json:"maxDrawdownPct"`
+	Symbols        []*SymbolAttribution `json:"symbols"`
+	Trades         []PortfolioTrade     `json:"trades"`
+	Equity         []EquityPoint        `json:"equity"`
+	Manifest       *manifest.Manifest   `json:"manifest"`
+}
+
+var (
+	portfolioMu   sync.Mutex
+	lastPortfolio *PortfolioResult
+)
+
+// LastPortfolio returns the result of the most recent portfolio run.
+func LastPortfolio() *PortfolioResult {
+	portfolioMu.Lock()
+	defer portfolioMu.Unlock()
+	return lastPortfolio
+}
+
+// portfolioLeg is one symbol's state during the run.
+type portfolioLeg struct {
+	symbol   string
+	feed     *executor.FrameFeed
+	pos      int
+	strategy strategy.BarStrategy
+	oms      *oms.OrderManager
+	last     float64 // last close
+	attr     *SymbolAttribution
+}
+
+func (l *portfolioLeg) exposure() float64 {
+	if p := l.oms.State().Position; p != nil {
+		return p.Quantity * l.last
+	}
+	return 0
+}
+
+func (l *portfolioLeg) unrealized() float64 {
+	if p := l.oms.State().Position; p != nil {
+		return p.Profit * p.Quantity
+	}
+	return 0
+}
+
+// RunPortfolio backtests the strategy across cfg.Symbols.
+func RunPortfolio(cfg PortfolioConfig) (*PortfolioResult, error) {
+	if len(cfg.Symbols) == 0 {
+		return nil, fmt.Errorf("no symbols")
+	}
+	strat, err := strategy.GetWith(cfg.Strategy, strategySettings(cfg.Settings))
+	if err != nil {
+		return nil, err
+	}
+	if strat.NewBar() == nil {
+		return nil, fmt.Errorf("strategy %s has no per-bar form", strat.Name())
+	}
+	cfg.Strategy = strat.Name()
+	if cfg.Capital <= 0 {
+		cfg.Capital = loadedConfig().Backtest.Capital
+	}
+	limits := risk.ActiveLimits
+	if cfg.Settings != nil && cfg.Settings.Risk.MaxOpenPositions > 0 {
+		limits = cfg.Settings.Risk
+	}
+	if cfg.MaxSymbolExposure <= 0 {
+		cfg.MaxSymbolExposure = limits.MaxSymbolExposure
+	}
+	if cfg.MaxTotalExposure <= 0 {
+		cfg.MaxTotalExposure = limits.MaxTotalExposure
+	}
+	if cfg.MaxOpenPositions <= 0 {
+		cfg.MaxOpenPositions = limits.MaxOpenPositions
+	}
+	if cfg.Stride <= 0 {
+		cfg.Stride = loadedConfig().Predictor.Stride
+	}
+	tf, err := runTimeframe(cfg.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if err := strategy.CheckTimeframe(strat, tf); err != nil {
+		return nil, err
+	}
+	cfg.Timeframe = tf.String()
+	tradeFrom, err := time.Parse("2006-01-02", cfg.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+
+	runMu.Lock()
+	if isRunning {
+		runMu.Unlock()
+		return nil, fmt.Errorf("backtest already running")
+	}
+	isRunning = true
+	runMu.Unlock()
+	defer func() {
+		runMu.Lock()
+		isRunning = false
+		runMu.Unlock()
+	}()
+
+	ctx := context.Background()
+	db := sqlite.DefaultDB()
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	// ── Per-symbol frames ────────────────────────────────────────
+	opts := RunOptions{StartDate: cfg.StartDate, EndDate: cfg.EndDate, Timeframe: cfg.Timeframe, WarmupBars: cfg.WarmupBars}
+	if opts.WarmupBars == 0 {
+		opts.WarmupBars = warmupBars(strat)
+	}
+	legs := make([]*portfolioLeg, 0, len(cfg.Symbols))
+	symbols := append([]string(nil), cfg.Symbols...)
+	sort.Strings(symbols)
+	m := manifest.New(manifest.KindPortfolio, manifest.Run{
+		Strategy:          strat.Name(),
+		Symbols:           symbols,
+		StartDate:         cfg.StartDate,
+		EndDate:           cfg.EndDate,
+		Timeframe:         cfg.Timeframe,
+		WarmupBars:        cfg.WarmupBars,
+		Capital:           cfg.Capital,
+		MaxSymbolExposure: cfg.MaxSymbolExposure,
+		MaxTotalExposure:  cfg.MaxTotalExposure,
+		MaxOpenPositions:  cfg.MaxOpenPositions,
+	})
+	m.Override(cfg.Settings)
+	m.Config.Predictor.Stride = cfg.Stride
+	for _, symbol := range symbols {
+		df := dataframe.InitDataFrame()
+		if _, err := loadTicks(ctx, db, df, symbol, opts, tradeFrom); err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		if df.NRows() == 0 {
+			log.Printf("backtest: portfolio: %s has no ticks, skipping", symbol)
+			continue
+		}
+		m.AddFrame(symbol, df)
+		if err := executor.ComputeFeatures(symbol, tf, df, strat, strat.NeedsRegime(), cfg.Stride, smoothingOf(cfg.Settings), nil); err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		legs = append(legs, &portfolioLeg{
+			symbol:   symbol,
+			feed:     executor.NewFrameFeed(symbol, df, strat.Columns()...),
+			strategy: strat.NewBar(),
+			oms:      oms.NewOrderManager(symbol, &oms.PaperBroker{}, nil),
+			attr:     &SymbolAttribution{Symbol: symbol},
+		})
+	}
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("no ticks for %v", cfg.Symbols)
+	}
+
+	res := runPortfolioLegs(cfg, legs, tradeFrom)
+	rb := manifest.NewResultBuilder()
+	for _, t := range res.Trades {
+		rb.Add(t.Symbol, t.Type, t.EntryTime, t.ExitTime, t.EntryPrice, t.ExitPrice, t.PnL)
+	}
+	m.Result = rb.Result()
+	res.Manifest = m
+
+	portfolioMu.Lock()
+	lastPortfolio = res
+	portfolioMu.Unlock()
+
+	log.Printf("backtest: portfolio %v — %d trades, net %.2f (%.2f%%), max DD %.2f%%",
+		symbols, len(res.Trades), res.NetPnL, res.ReturnPct, res.MaxDrawdownPct)
+	return res, nil
+}
+
+// runPortfolioLegs walks all legs on a merged time axis.
+func runPortfolioLegs(cfg PortfolioConfig, legs []*portfolioLeg, tradeFrom time.Time) *PortfolioResult {
+	res := &PortfolioResult{Config: cfg}
+	manager := &risk.Manager{MaxOpenPositions: cfg.MaxOpenPositions}
+	sizing := risk.PositionSizing{MaxSymbolExposure: cfg.MaxSymbolExposure, MaxTotalExposure: cfg.MaxTotalExposure}
+	realized := 0.0
+	peak := cfg.Capital
+
+	equity := func() float64 {
+		e := cfg.Capital + realized
+		for _, l := range legs {
+			e += l.unrealized()
+		}
+		return e
+	}
+	bookExposure := func() float64 {
+		x := 0.0
+		for _, l := range legs {
+			x += l.exposure()
+		}
+		return x
+	}
+	// The position limit is account-wide: count every leg's open position.
+	open := func() int {
+		n := 0
+		for _, l := range legs {
+			n += l.oms.State().Open()
+		}
+		return n
+	}
+
+	for {
+		// Next timestamp across all legs.
+		var now time.Time
+		found := false
+		for _, l := range legs {
+			for l.pos < l.feed.Len() {
+				if _, _, ok := l.feed.Bar(l.pos); ok {
+					break
+				}
+				l.pos++
+			}
+			if l.pos >= l.feed.Len() {
+				continue
+			}
+			c, _, _ := l.feed.Bar(l.pos)
+			if !found || c.Timestamp.Before(now) {
+				now, found = c.Timestamp, true
+			}
+		}
+		if !found {
+			break
+		}
+		live := !now.Before(tradeFrom)
+
+		for _, l := range legs {
+			if l.pos >= l.feed.Len() {
+				continue
+			}
+			c, feat, _ := l.feed.Bar(l.pos)
+			if !c.Timestamp.Equal(now) {
+				continue
+			}
+			l.pos++
+			l.last = c.Close
+			l.oms.Mark(c.Close)
+
+			for _, in := range l.strategy.OnBar(c, feat) {
+				state := l.oms.State()
+				if in.Type == "ENTRY" {
+					if !live {
+						l.strategy.Reject() // warmup: keep state warm, never trade
+						continue
+					}
+					in.Quantity = sizing.Size(in.Price, equity(), l.exposure(), bookExposure())
+					if in.Quantity < 1 {
+						l.attr.Rejected++
+						l.strategy.Reject()
+						continue
+					}
+				}
+				if err := manager.Approve(in, open(), state.Position); err != nil {
+					if in.Type == "ENTRY" {
+						l.attr.Rejected++
+						l.strategy.Reject()
+					}
+					continue
+				}
+				entry := state.Position
+				ev, err := l.oms.Submit(in)
+				if err != nil {
+					log.Printf("backtest: portfolio: %s %s: %v", l.symbol, in.Type, err)
+					continue
+				}
+				if ev.Type == "ENTRY" {
+					l.attr.MaxExposure = math.Max(l.attr.MaxExposure, l.exposure())
+					continue
+				}
+				res.Trades = append(res.Trades, closeTrade(l.attr, entry, ev))
+				realized += res.Trades[len(res.Trades)-1].PnL
+			}
+		}
+
+		if live {
+			e := equity()
+			res.Equity = append(res.Equity, EquityPoint{Time: now, Equity: e, Exposure: bookExposure()})
+			if e > peak {
+				peak = e
+			}
+			if dd := peak - e; dd > res.MaxDrawdown {
+				res.MaxDrawdown = dd
+				res.MaxDrawdownPct = dd / peak * 100
+			}
+		}
+	}
+
+	res.FinalEquity = cfg.Capital + realized
+	res.NetPnL = realized
+	res.ReturnPct = realized / cfg.Capital * 100
+	for _, l := range legs {
+		if l.attr.Trades > 0 {
+			l.attr.WinRate = float64(l.attr.Wins) / float64(l.attr.Trades) * 100
+		}
+		if realized != 0 {
+			l.attr.PnLShare = l.attr.NetPnL / realized * 100
+		}
+		res.Symbols = append(res.Symbols, l.attr)
+	}
+	return res
+}
+
+// closeTrade records a filled exit against the position it closed.
+func closeTrade(attr *SymbolAttribution, entry *types.Position, ev *types.Event) PortfolioTrade {
+	t := PortfolioTrade{
+		Symbol:    attr.Symbol,
+		Type:      ev.Kind,
+		ExitPrice: ev.EntryPrice,
+		ExitTime:  ev.Timestamp,
+		Reason:    ev.Reason,
+	}
+	if entry != nil {
+		t.Quantity = entry.Quantity
+		t.EntryPrice = entry.EntryPrice
+		t.EntryTime = entry.EntryTime
+		t.PnL = entry.Profit * entry.Quantity
+	}
+
+	attr.Trades++
+	attr.NetPnL += t.PnL
+	if t.PnL > 0 {
+		attr.Wins++
+		attr.GrossProfit += t.PnL
+	} else {
+		attr.GrossLoss += t.PnL
+	}
+	return t
+}
@@ -0,0 +1,141 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"hft/internal/executor"
+	"hft/internal/oms"
+	"hft/internal/strategy"
+	"hft/internal/testutil"
+	"hft/pkg/types"
+)
+
+// script is a BarStrategy that sends fixed intents on fixed bars.
+type script struct {
+	bar   int
+	pos   int
+	moves map[int][]types.Intent
+}
+
+func (s *script) OnBar(c types.Candle, f strategy.Features) []types.Intent {
+	out := s.moves[s.bar]
+	s.bar++
+	for i := range out {
+		out[i].Price, out[i].Timestamp = c.Close, c.Timestamp
+		switch {
+		case out[i].Type == "EXIT":
+			s.pos = 0
+		case out[i].Kind == "BUY":
+			s.pos = 1
+		default:
+			s.pos = -1
+		}
+	}
+	return out
+}
+
+func (s *script) Position() int              { return s.pos }
+func (s *script) Reject()                    { s.pos = 0 }
+func (s *script) Snapshot() ([]byte, error)  { return nil, nil }
+func (s *script) Restore(state []byte) error { return nil }
+
+func entry(kind string) types.Intent { return types.Intent{Type: "ENTRY", Kind: kind} }
+func exit(kind string) types.Intent  { return types.Intent{Type: "EXIT", Kind: kind} }
+
+// portfolioFrom is the first bar's time; legs trade from it.
+var portfolioFrom = time.Date(2025, 6, 3, 9, 15, 0, 0, testutil.IST)
+
+// newLeg is a leg that closes at prices, one bar a minute from
+// portfolioFrom, and follows moves.
+func newLeg(symbol string, moves map[int][]types.Intent, prices ...float64) *portfolioLeg {
+	candles := make([]types.Candle, len(prices))
+	for i, p := range prices {
+		candles[i] = types.Candle{Timestamp: portfolioFrom.Add(time.Duration(i) * time.Minute), Open: p, High: p, Low: p, Close: p}
+	}
+	return &portfolioLeg{
+		symbol:   symbol,
+		feed:     executor.NewFrameFeed(symbol, testutil.Frame(candles)),
+		strategy: &script{moves: moves},
+		oms:      oms.NewOrderManager(symbol, &oms.PaperBroker{}, nil),
+		attr:     &SymbolAttribution{Symbol: symbol},
+	}
+}
+
+func testPortfolioConfig(openPositions int) PortfolioConfig {
+	return PortfolioConfig{Capital: 1_000_000, MaxSymbolExposure: 0.5, MaxTotalExposure: 0.6, MaxOpenPositions: openPositions}
+}
+
+// Every leg has its own OMS, but the position limit counts all of them.
+func TestPortfolioOpenPositionsAcrossLegs(t *testing.T) {
+	both := func() []*portfolioLeg {
+		return []*portfolioLeg{
+			newLeg("a", map[int][]types.Intent{0: {entry("BUY")}, 2: {exit("BUY")}}, 100, 100, 100),
+			newLeg("b", map[int][]types.Intent{1: {entry("SELL")}, 2: {exit("SELL")}}, 100, 100, 100),
+		}
+	}
+
+	legs := both()
+	res := runPortfolioLegs(testPortfolioConfig(1), legs, portfolioFrom)
+	if len(res.Trades) != 1 || res.Trades[0].Symbol != "a" || legs[1].attr.Rejected != 1 {
+		t.Fatalf("limit 1: %d trades, b rejected %d; want only a's trade", len(res.Trades), legs[1].attr.Rejected)
+	}
+	if legs[1].strategy.Position() != 0 {
+		t.Fatal("rejected leg still holds a position")
+	}
+
+	legs = both()
+	res = runPortfolioLegs(testPortfolioConfig(2), legs, portfolioFrom)
+	if len(res.Trades) != 2 || legs[1].attr.Rejected != 0 {
+		t.Fatalf("limit 2: %d trades, b rejected %d; want both", len(res.Trades), legs[1].attr.Rejected)
+	}
+}
+
+// Entries are sized on the shared equity: the symbol cap for the first, the
+// room the book leaves for the next, nothing once the book is full.
+func TestPortfolioSizingAndAttribution(t *testing.T) {
+	legs := []*portfolioLeg{
+		newLeg("a", map[int][]types.Intent{0: {entry("BUY")}, 2: {exit("BUY")}}, 100, 100, 110),
+		newLeg("b", map[int][]types.Intent{0: {entry("SELL")}, 2: {exit("SELL")}}, 200, 200, 210),
+		newLeg("c", map[int][]types.Intent{1: {entry("BUY")}}, 50, 50, 50),
+	}
+	res := runPortfolioLegs(testPortfolioConfig(3), legs, portfolioFrom)
+
+	// a takes 0.5 of 1,000,000 at 100; b gets the 100,000 left of the 0.6
+	// book cap at 200; c finds the book full.
+	if len(res.Trades) != 2 {
+		t.Fatalf("%d trades, want 2", len(res.Trades))
+	}
+	a, b := res.Trades[0], res.Trades[1]
+	if a.Symbol != "a" || a.Quantity != 5000 || a.PnL != 50_000 {
+		t.Errorf("a: %s %g units, PnL %g; want 5000 units, +50,000", a.Symbol, a.Quantity, a.PnL)
+	}
+	if b.Symbol != "b" || b.Quantity != 500 || b.PnL != -5000 {
+		t.Errorf("b: %s %g units, PnL %g; want 500 units, -5,000", b.Symbol, b.Quantity, b.PnL)
+	}
+	if c := legs[2].attr; c.Rejected != 1 || c.Trades != 0 {
+		t.Errorf("c: %d rejected, %d trades; want the entry rejected", c.Rejected, c.Trades)
+	}
+
+	if res.NetPnL != 45_000 || res.FinalEquity != 1_045_000 {
+		t.Errorf("net %g, final equity %g", res.NetPnL, res.FinalEquity)
+	}
+	for _, tc := range []struct {
+		attr           *SymbolAttribution
+		trades, wins   int
+		net, share, mx float64
+	}{
+		{res.Symbols[0], 1, 1, 50_000, 50_000.0 / 45_000 * 100, 500_000},
+		{res.Symbols[1], 1, 0, -5000, -5000.0 / 45_000 * 100, 100_000},
+		{res.Symbols[2], 0, 0, 0, 0, 0},
+	} {
+		a := tc.attr
+		if a.Trades != tc.trades || a.Wins != tc.wins || a.NetPnL != tc.net || math.Abs(a.PnLShare-tc.share) > 1e-9 || a.MaxExposure != tc.mx {
+			t.Errorf("%s: %+v", a.Symbol, *a)
+		}
+	}
+	if e := res.Equity; len(e) != 3 || e[1].Exposure != 600_000 || e[2].Exposure != 0 {
+		t.Errorf("equity curve %+v", e)
+	}
+}
@@ -0,0 +1,544 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft/internal/indicators"
+	"hft/internal/manifest"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+	"github.com/tealeg/xlsx/v3"
+)
+
+/*
+   Backtest reports.
+
+   BuildReport collects the last run into one Report: stats, the trade list,
+   a per-trade equity curve with drawdown, monthly returns and benchmarks.
+   Writers render it as
+
+     html  self-contained page (inline CSS and SVG charts)
+     json  the Report itself
+     csv   trades.csv and equity.csv
+     xlsx  one workbook with Stats, Trades, Equity and Monthly sheets
+
+   Equity is in points on a one-unit notional starting at the first analysis
+   close, the same convention as the benchmark tracks.
+*/
+
+// Report formats.
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ReportFormats lists every supported format.
+var ReportFormats = []string{FormatHTML, FormatJSON, FormatCSV, FormatXLSX}
+
+// ReportStats is the summary block of a report.
+type ReportStats struct {
+	TotalTrades     int     `json:"totalTrades"`
+	WinningTrades   int     `json:"winningTrades"`
+	LosingTrades    int     `json:"losingTrades"`
+	BreakevenTrades int     `json:"breakevenTrades"`
+	WinRate         float64 `json:"winRate"`
+	NetProfit       float64 `json:"netProfit"`
+	GrossProfit     float64 `json:"grossProfit"`
+	GrossLoss       float64 `json:"grossLoss"`
+	AvgProfit       float64 `json:"avgProfit"`
+	ProfitFactor    float64 `json:"profitFactor"`
+	Expectancy      float64 `json:"expectancy"`
+	MaxDrawdown     float64 `json:"maxDrawdown"`
+	MaxProfit       float64 `json:"maxProfit"`
+	ReturnPct       float64 `json:"returnPct"`
+}
+
+// ReportTrade is one closed trade.
+type ReportTrade struct {
+	EntryTime  time.Time `json:"entryTime"`
+	ExitTime   time.Time `json:"exitTime"`
+	Type       string    `json:"type"`
+	EntryPrice float64   `json:"entryPrice"`
+	ExitPrice  float64   `json:"exitPrice"`
+	Profit     float64   `json:"profit"`
+	ProfitPct  float64   `json:"profitPct"`
+	PeakProfit float64   `json:"peakProfit"`
+	PeakLoss   float64   `json:"peakLoss"`
+	Reason     string    `json:"reason"`
+
+	// Entry context (see types.EntryContext).
+	Tranche   string  `json:"tranche"`
+	DayType   string  `json:"dayType"`
+	GapPct    float64 `json:"gapPct"`
+	Regime    string  `json:"regime"`
+	EntryProb float64 `json:"entryProb"`
+	ATR       float64 `json:"atr"`
+	Weekday   string  `json:"weekday"`
+	Strategy  string  `json:"strategy"`
+}
+
+// reportTradeColumns is the header of the trades CSV and sheet.
+var reportTradeColumns = []string{"entryTime", "exitTime", "type", "entryPrice", "exitPrice", "profit", "profitPct", "peakProfit", "peakLoss", "reason",
+	"tranche", "dayType", "gapPct", "regime", "entryProb", "atr", "weekday", "strategy"}
+
+// ReportEquityPoint is the equity after a trade closes.
+type ReportEquityPoint struct {
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`
+	Drawdown float64   `json:"drawdown"` // points below the running peak
+}
+
+// MonthlyReturn aggregates closed trades by exit month (IST).
+type MonthlyReturn struct {
+	Month     string  `json:"month"` // 2006-01
+	Trades    int     `json:"trades"`
+	PnL       float64 `json:"pnl"`
+	ReturnPct float64 `json:"returnPct"` // vs equity at month start
+}
+
+// Report is a format-agnostic view of one backtest run.
+type Report struct {
+	GeneratedAt time.Time           `json:"generatedAt"`
+	From        time.Time           `json:"from"`
+	To          time.Time           `json:"to"`
+	StartEquity float64             `json:"startEquity"`
+	Stats       ReportStats         `json:"stats"`
+	Trades      []ReportTrade       `json:"trades"`
+	Equity      []ReportEquityPoint `json:"equity"`
+	Monthly     []MonthlyReturn     `json:"monthly"`
+	Benchmarks  *BenchmarkReport    `json:"benchmarks,omitempty"`
+	Manifest    *manifest.Manifest  `json:"manifest,omitempty"`
+}
+
+// BuildReport collects the last backtest run into a Report.
+func BuildReport() (*Report, error) {
+	if Instance == nil || Instance.DF == nil || Instance.DF.NRows() == 0 {
+		return nil, fmt.Errorf("no backtest has run")
+	}
+	df := Instance.DF
+	closeVals := df.Series[indicators.FindIndexOf(df, "close")].(*_df_.SeriesFloat64).Values
+	tsVals := df.Series[indicators.FindIndexOf(df, "timestamp")].(*_df_.SeriesTime).Values
+	if Instance.StartIdx >= len(closeVals) {
+		return nil, fmt.Errorf("backtest has no analysis rows")
+	}
+
+	r := &Report{
+		GeneratedAt: time.Now(),
+		StartEquity: closeVals[Instance.StartIdx],
+		Trades:      reportTrades(Instance.TradeDF),
+		Manifest:    Instance.Manifest,
+	}
+	if t := tsVals[Instance.StartIdx]; t != nil {
+		r.From = *t
+	}
+	if t := tsVals[len(tsVals)-1]; t != nil {
+		r.To = *t
+	}
+
+	// ── Equity and drawdown ──────────────────────────────────────
+	equity, peak := r.StartEquity, r.StartEquity
+	r.Equity = append(r.Equity, ReportEquityPoint{Time: r.From, Equity: equity})
+	for _, t := range r.Trades {
+		equity += t.Profit
+		peak = math.Max(peak, equity)
+		r.Equity = append(r.Equity, ReportEquityPoint{Time: t.ExitTime, Equity: equity, Drawdown: peak - equity})
+	}
+
+	// ── Monthly returns ──────────────────────────────────────────
+	ist := time.FixedZone("IST", 19800)
+	monthStart := r.StartEquity
+	for _, t := range r.Trades {
+		month := t.ExitTime.In(ist).Format("2006-01")
+		if n := len(r.Monthly); n == 0 || r.Monthly[n-1].Month != month {
+			if n > 0 {
+				monthStart += r.Monthly[n-1].PnL
+			}
+			r.Monthly = append(r.Monthly, MonthlyReturn{Month: month})
+		}
+		m := &r.Monthly[len(r.Monthly)-1]
+		m.Trades++
+		m.PnL += t.Profit
+		if monthStart != 0 {
+			m.ReturnPct = m.PnL / monthStart * 100
+		}
+	}
+
+	// ── Stats ────────────────────────────────────────────────────
+	s := &r.Stats
+	for _, t := range r.Trades {
+		s.TotalTrades++
+		s.NetProfit += t.Profit
+		s.MaxProfit = math.Max(s.MaxProfit, t.Profit)
+		switch {
+		case t.Profit > 0:
+			s.WinningTrades++
+			s.GrossProfit += t.Profit
+		case t.Profit < 0:
+			s.LosingTrades++
+			s.GrossLoss += t.Profit
+		default:
+			s.BreakevenTrades++
+		}
+	}
+	for _, p := range r.Equity {
+		s.MaxDrawdown = math.Max(s.MaxDrawdown, p.Drawdown)
+	}
+	if s.TotalTrades > 0 {
+		s.WinRate = float64(s.WinningTrades) / float64(s.TotalTrades) * 100
+		s.AvgProfit = s.NetProfit / float64(s.TotalTrades)
+	}
+	if s.GrossLoss != 0 {
+		s.ProfitFactor = s.GrossProfit / -s.GrossLoss
+	}
+	if bs := GetBacktestStats(); bs != nil {
+		s.Expectancy = bs.ExpectancyRatio
+	}
+	if r.StartEquity != 0 {
+		s.ReturnPct = s.NetProfit / r.StartEquity * 100
+	}
+
+	if b, err := Benchmarks(BenchmarkConfig{}); err == nil {
+		r.Benchmarks = b
+	}
+	return r, nil
+}
+
+func reportTrades(df *_df_.DataFrame) []ReportTrade {
+	if df == nil {
+		return nil
+	}
+	idx := func(name string) _df_.Series { return df.Series[indicators.FindIndexOf(df, name)] }
+	f := func(s _df_.Series, i int) float64 { v, _ := s.Value(i).(float64); return v }
+	str := func(s _df_.Series, i int) string { v, _ := s.Value(i).(string); return v }
+	tm := func(s _df_.Series, i int) time.Time { v, _ := s.Value(i).(time.Time); return v }
+
+	entryPrice, exitPrice := idx("entryPrice"), idx("exitPrice")
+	entryTime, exitTime := idx("entryTime"), idx("exitTime")
+	profit, profitPct := idx("profit"), idx("profitPct")
+	kind, reason := idx("type"), idx("reason")
+	peakProfit, peakLoss := idx("peakProfit"), idx("peakLoss")
+	tranche, dayType, gapPct, regime := idx("tranche"), idx("dayType"), idx("gapPct"), idx("regime")
+	entryProb, atr, weekday, strat := idx("entryProb"), idx("atr"), idx("weekday"), idx("strategy")
+
+	out := make([]ReportTrade, df.NRows())
+	for i := range out {
+		out[i] = ReportTrade{
+			EntryTime:  tm(entryTime, i),
+			ExitTime:   tm(exitTime, i),
+			Type:       str(kind, i),
+			EntryPrice: f(entryPrice, i),
+			ExitPrice:  f(exitPrice, i),
+			Profit:     f(profit, i),
+			ProfitPct:  f(profitPct, i),
+			PeakProfit: f(peakProfit, i),
+			PeakLoss:   f(peakLoss, i),
+			Reason:     str(reason, i),
+			Tranche:    str(tranche, i),
+			DayType:    str(dayType, i),
+			GapPct:     f(gapPct, i),
+			Regime:     str(regime, i),
+			EntryProb:  f(entryProb, i),
+			ATR:        f(atr, i),
+			Weekday:    str(weekday, i),
+			Strategy:   str(strat, i),
+		}
+	}
+	return out
+}
+
+// ── Writers ──────────────────────────────────────────────────────────────────
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTradesCSV writes the trade list as CSV.
+func (r *Report) WriteTradesCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(reportTradeColumns)
+	for _, t := range r.Trades {
+		cw.Write([]string{
+			t.EntryTime.Format(time.RFC3339), t.ExitTime.Format(time.RFC3339), t.Type,
+			ftoa(t.EntryPrice), ftoa(t.ExitPrice), ftoa(t.Profit), ftoa(t.ProfitPct),
+			ftoa(t.PeakProfit), ftoa(t.PeakLoss), t.Reason,
+			t.Tranche, t.DayType, ftoa(t.GapPct), t.Regime, ftoa(t.EntryProb), ftoa(t.ATR), t.Weekday, t.Strategy,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEquityCSV writes the equity curve as CSV.
+func (r *Report) WriteEquityCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "equity", "drawdown"})
+	for _, p := range r.Equity {
+		cw.Write([]string{p.Time.Format(time.RFC3339), ftoa(p.Equity), ftoa(p.Drawdown)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteXLSX writes a workbook with Stats, Trades, Equity and Monthly sheets.
+func (r *Report) WriteXLSX(w io.Writer) error {
+	file := xlsx.NewFile()
+	sheet := func(name string, header ...string) (*xlsx.Sheet, error) {
+		s, err := file.AddSheet(name)
+		if err != nil {
+			return nil, fmt.Errorf("add sheet %s: %w", name, err)
+		}
+		row := s.AddRow()
+		for _, h := range header {
+			row.AddCell().SetString(h)
+		}
+		return s, nil
+	}
+
+	stats, err := sheet("Stats", "metric", "value")
+	if err != nil {
+		return err
+	}
+	for _, kv := range r.statRows() {
+		row := stats.AddRow()
+		row.AddCell().SetString(kv.Name)
+		row.AddCell().SetFloat(kv.Value)
+	}
+
+	trades, err := sheet("Trades", reportTradeColumns...)
+	if err != nil {
+		return err
+	}
+	for _, t := range r.Trades {
+		row := trades.AddRow()
+		row.AddCell().SetDateTime(t.EntryTime)
+		row.AddCell().SetDateTime(t.ExitTime)
+		row.AddCell().SetString(t.Type)
+		for _, v := range []float64{t.EntryPrice, t.ExitPrice, t.Profit, t.ProfitPct, t.PeakProfit, t.PeakLoss} {
+			row.AddCell().SetFloat(v)
+		}
+		row.AddCell().SetString(t.Reason)
+		row.AddCell().SetString(t.Tranche)
+		row.AddCell().SetString(t.DayType)
+		row.AddCell().SetFloat(t.GapPct)
+		row.AddCell().SetString(t.Regime)
+		row.AddCell().SetFloat(t.EntryProb)
+		row.AddCell().SetFloat(t.ATR)
+		row.AddCell().SetString(t.Weekday)
+		row.AddCell().SetString(t.Strategy)
+	}
+
+	equity, err := sheet("Equity", "time", "equity", "drawdown")
+	if err != nil {
+		return err
+	}
+	for _, p := range r.Equity {
+		row := equity.AddRow()
+		row.AddCell().SetDateTime(p.Time)
+		row.AddCell().SetFloat(p.Equity)
+		row.AddCell().SetFloat(p.Drawdown)
+	}
+
+	monthly, err := sheet("Monthly", "month", "trades", "pnl", "returnPct")
+	if err != nil {
+		return err
+	}
+	for _, m := range r.Monthly {
+		row := monthly.AddRow()
+		row.AddCell().SetString(m.Month)
+		row.AddCell().SetInt(m.Trades)
+		row.AddCell().SetFloat(m.PnL)
+		row.AddCell().SetFloat(m.ReturnPct)
+	}
+
+	return file.Write(w)
+}
+
+// WriteHTML writes a self-contained HTML report.
+func (r *Report) WriteHTML(w io.Writer) error {
+	equity := make([]float64, len(r.Equity))
+	drawdown := make([]float64, len(r.Equity))
+	for i, p := range r.Equity {
+		equity[i] = p.Equity
+		drawdown[i] = -p.Drawdown
+	}
+	return reportTmpl.Execute(w, map[string]interface{}{
+		"R":        r,
+		"Stats":    r.statRows(),
+		"Equity":   template.HTML(svgLine(equity, 900, 240, "#2563eb")),
+		"Drawdown": template.HTML(svgLine(drawdown, 900, 140, "#dc2626")),
+	})
+}
+
+// Export writes the report in each format to dir and returns the files
+// written. The file names start with prefix. The run's manifest is always
+// written alongside as manifest.json.
+func (r *Report) Export(dir, prefix string, formats ...string) ([]string, error) {
+	if len(formats) == 0 {
+		formats = ReportFormats
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create report dir: %w", err)
+	}
+
+	var files []string
+	write := func(name string, fn func(io.Writer) error) error {
+		path := filepath.Join(dir, prefix+name)
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", path, err)
+		}
+		if err := fn(f); err != nil {
+			f.Close()
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("close %s: %w", path, err)
+		}
+		files = append(files, path)
+		return nil
+	}
+
+	if r.Manifest != nil {
+		if err := write("manifest.json", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(r.Manifest)
+		}); err != nil {
+			return files, err
+		}
+	}
+	for _, format := range formats {
+		var err error
+		switch format {
+		case FormatHTML:
+			err = write("report.html", r.WriteHTML)
+		case FormatJSON:
+			err = write("report.json", r.WriteJSON)
+		case FormatCSV:
+			if err = write("trades.csv", r.WriteTradesCSV); err == nil {
+				err = write("equity.csv", r.WriteEquityCSV)
+			}
+		case FormatXLSX:
+			err = write("report.xlsx", r.WriteXLSX)
+		default:
+			err = fmt.Errorf("unknown report format %q", format)
+		}
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+type statRow struct {
+	Name  string
+	Value float64
+}
+
+func (r *Report) statRows() []statRow {
+	s := r.Stats
+	return []statRow{
+		{"Total trades", float64(s.TotalTrades)},
+		{"Winning trades", float64(s.WinningTrades)},
+		{"Losing trades", float64(s.LosingTrades)},
+		{"Breakeven trades", float64(s.BreakevenTrades)},
+		{"Win rate %", s.WinRate},
+		{"Net profit (pts)", s.NetProfit},
+		{"Gross profit (pts)", s.GrossProfit},
+		{"Gross loss (pts)", s.GrossLoss},
+		{"Avg profit/trade (pts)", s.AvgProfit},
+		{"Profit factor", s.ProfitFactor},
+		{"Expectancy (pts/trade)", s.Expectancy},
+		{"Max drawdown (pts)", s.MaxDrawdown},
+		{"Max single profit (pts)", s.MaxProfit},
+		{"Return %", s.ReturnPct},
+	}
+}
+
+func ftoa(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+// svgLine renders values as an inline SVG polyline scaled to w×h.
+func svgLine(values []float64, w, h float64, color string) string {
+	if len(values) < 2 {
+		return fmt.Sprintf(`<svg width="%g" height="%g"></svg>`, w, h)
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	var pts strings.Builder
+	for i, v := range values {
+		x := float64(i) / float64(len(values)-1) * w
+		y := h - (v-lo)/(hi-lo)*h
+		fmt.Fprintf(&pts, "%.1f,%.1f ", x, y)
+	}
+	return fmt.Sprintf(`<svg viewBox="0 0 %g %g" width="100%%" height="%g" preserveAspectRatio="none">`+
+		`<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/></svg>`,
+		w, h, h, color, pts.String())
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"f2":   func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"when": func(t time.Time) string { return t.In(time.FixedZone("IST", 19800)).Format("2006-01-02 15:04") },
+	"sign": func(v float64) string {
+		if v < 0 {
+			return "neg"
+		}
+		return "pos"
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Backtest report</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:24px;color:#111}
+h1{font-size:20px}h2{font-size:16px;margin-top:28px}
+table{border-collapse:collapse;font-size:13px}
+td,th{border:1px solid #ddd;padding:4px 8px;text-align:right}
+th{background:#f5f5f5}td.l,th.l{text-align:left}
+.pos{color:#15803d}.neg{color:#b91c1c}
+.chart{border:1px solid #eee;max-width:900px}
+</style></head><body>
+<h1>Backtest report</h1>
+<p>{{when .R.From}} → {{when .R.To}} · generated {{when .R.GeneratedAt}}</p>
+
+<h2>Stats</h2>
+<table>{{range .Stats}}<tr><td class="l">{{.Name}}</td><td>{{f2 .Value}}</td></tr>{{end}}</table>
+
+<h2>Equity curve (pts)</h2>
+<div class="chart">{{.Equity}}</div>
+<h2>Drawdown (pts)</h2>
+<div class="chart">{{.Drawdown}}</div>
+
+<h2>Monthly returns</h2>
+<table><tr><th class="l">Month</th><th>Trades</th><th>PnL (pts)</th><th>Return %</th></tr>
+{{range .R.Monthly}}<tr><td class="l">{{.Month}}</td><td>{{.Trades}}</td><td class="{{sign .PnL}}">{{f2 .PnL}}</td><td class="{{sign .ReturnPct}}">{{f2 .ReturnPct}}</td></tr>
+{{end}}</table>
+{{with .R.Benchmarks}}
+<h2>Benchmarks</h2>
+<table><tr><th class="l">Benchmark</th><th>Trades</th><th>Net (pts)</th><th>Return %</th><th>Alpha %</th><th>Beta</th><th>Correlation</th><th>Excess %</th></tr>
+{{range .Benchmarks}}<tr><td class="l">{{.Benchmark.Name}}</td><td>{{.Benchmark.Trades}}</td><td>{{f2 .Benchmark.NetPoints}}</td><td>{{f2 .Benchmark.TotalReturnPct}}</td><td>{{f2 .Alpha}}</td><td>{{f2 .Beta}}</td><td>{{f2 .Correlation}}</td><td class="{{sign .ExcessReturnPct}}">{{f2 .ExcessReturnPct}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Trades</h2>
+<table><tr><th class="l">Entry</th><th class="l">Exit</th><th class="l">Type</th><th>Entry px</th><th>Exit px</th><th>PnL (pts)</th><th>PnL %</th><th class="l">Reason</th></tr>
+{{range .R.Trades}}<tr><td class="l">{{when .EntryTime}}</td><td class="l">{{when .ExitTime}}</td><td class="l">{{.Type}}</td><td>{{f2 .EntryPrice}}</td><td>{{f2 .ExitPrice}}</td><td class="{{sign .Profit}}">{{f2 .Profit}}</td><td>{{f2 .ProfitPct}}</td><td class="l">{{.Reason}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
@@ -0,0 +1,165 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx/v3"
+)
+
+// testReport is a two-trade report over June and July 2025.
+func testReport() *Report {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	r := &Report{
+		GeneratedAt: at(8, 1, 0),
+		From:        at(6, 2, 4),
+		To:          at(7, 31, 9),
+		StartEquity: 22000,
+		Trades: []ReportTrade{
+			{EntryTime: at(6, 10, 4), ExitTime: at(6, 10, 6), Type: "BUY", EntryPrice: 22000, ExitPrice: 22050, Profit: 50, ProfitPct: 0.227, PeakProfit: 60, PeakLoss: -5, Reason: "TAKE_PROFIT",
+				Tranche: "morning", DayType: "trend", GapPct: 0.2, Regime: "bullish", EntryProb: 0.7, ATR: 12, Weekday: "Tuesday", Strategy: "regime"},
+			{EntryTime: at(7, 3, 5), ExitTime: at(7, 3, 8), Type: "SELL", EntryPrice: 22100, ExitPrice: 22130, Profit: -30, ProfitPct: -0.136, PeakProfit: 4, PeakLoss: -35, Reason: "STOP_LOSS",
+				Tranche: "afternoon", DayType: "gap_reversal", GapPct: -0.3, Regime: "bearish", EntryProb: 0.65, ATR: 15, Weekday: "Thursday", Strategy: "orb"},
+		},
+		Equity: []ReportEquityPoint{
+			{Time: at(6, 2, 4), Equity: 22000},
+			{Time: at(6, 10, 6), Equity: 22050},
+			{Time: at(7, 3, 8), Equity: 22020, Drawdown: 30},
+		},
+		Monthly: []MonthlyReturn{{Month: "2025-06", Trades: 1, PnL: 50, ReturnPct: 0.227}, {Month: "2025-07", Trades: 1, PnL: -30, ReturnPct: -0.136}},
+	}
+	r.Stats = ReportStats{TotalTrades: 2, WinningTrades: 1, LosingTrades: 1, WinRate: 50, NetProfit: 20, GrossProfit: 50, GrossLoss: -30, MaxDrawdown: 30}
+	return r
+}
+
+func TestReportJSON(t *testing.T) {
+	r := testReport()
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Trades) != 2 || got.Trades[1] != r.Trades[1] || got.Stats != r.Stats || len(got.Monthly) != 2 || len(got.Equity) != 3 {
+		t.Fatalf("round trip lost data:\n%s", buf.String())
+	}
+}
+
+func TestReportCSV(t *testing.T) {
+	r := testReport()
+	var trades, equity bytes.Buffer
+	if err := r.WriteTradesCSV(&trades); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteEquityCSV(&equity); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&trades).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "entryTime" || len(rows[1]) != len(rows[0]) {
+		t.Fatalf("trades.csv: %v", rows)
+	}
+	col := func(name string) int {
+		for i, h := range rows[0] {
+			if h == name {
+				return i
+			}
+		}
+		t.Fatalf("trades.csv has no %s column", name)
+		return -1
+	}
+	if got := rows[2][col("profit")]; got != "-30" {
+		t.Errorf("second trade profit %s", got)
+	}
+	if got := rows[1][col("exitTime")]; got != "2025-06-10T06:00:00Z" {
+		t.Errorf("first trade exit %s", got)
+	}
+	if got := rows[2][col("reason")]; got != "STOP_LOSS" {
+		t.Errorf("second trade reason %s", got)
+	}
+	// The entry context is exported with every trade.
+	for name, want := range map[string]string{"tranche": "afternoon", "dayType": "gap_reversal", "gapPct": "-0.3", "regime": "bearish",
+		"entryProb": "0.65", "atr": "15", "weekday": "Thursday", "strategy": "orb"} {
+		if got := rows[2][col(name)]; got != want {
+			t.Errorf("second trade %s %s, want %s", name, got, want)
+		}
+	}
+
+	rows, err = csv.NewReader(&equity).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || strings.Join(rows[3], ",") != "2025-07-03T08:00:00Z,22020,30" {
+		t.Fatalf("equity.csv: %v", rows)
+	}
+}
+
+func TestReportHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{"<svg", "<polyline", "TAKE_PROFIT", "STOP_LOSS", "2025-07"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report.html has no %q", want)
+		}
+	}
+}
+
+// Export writes every format, the xlsx workbook with one sheet per section.
+func TestReportExport(t *testing.T) {
+	dir := t.TempDir()
+	files, err := testReport().Export(dir, "run_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+		if st, err := os.Stat(f); err != nil || st.Size() == 0 {
+			t.Errorf("%s empty or missing: %v", f, err)
+		}
+	}
+	if got := strings.Join(names, " "); got != "run_report.html run_report.json run_trades.csv run_equity.csv run_report.xlsx" {
+		t.Fatalf("wrote %s", got)
+	}
+
+	sheets, err := xlsx.FileToSlice(filepath.Join(dir, "run_report.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 4 {
+		t.Fatalf("%d sheets, want Stats, Trades, Equity, Monthly", len(sheets))
+	}
+	stats, trades, equity, monthly := sheets[0], sheets[1], sheets[2], sheets[3]
+	if stats[1][0] != "Total trades" || stats[1][1] != "2" {
+		t.Errorf("stats sheet starts %v", stats[1])
+	}
+	if len(trades) != 3 || len(trades[0]) != len(reportTradeColumns) || trades[2][2] != "SELL" || trades[2][9] != "STOP_LOSS" {
+		t.Fatalf("trades sheet %v", trades)
+	}
+	if got := strings.Join(trades[1][10:], ","); got != "morning,trend,0.2,bullish,0.7,12,Tuesday,regime" {
+		t.Errorf("first trade's entry context %s", got)
+	}
+	if len(equity) != 4 || len(monthly) != 3 || monthly[2][0] != "2025-07" || monthly[2][2] != "-30" {
+		t.Errorf("equity sheet %d rows, monthly sheet %v", len(equity), monthly)
+	}
+
+	if _, err := testReport().Export(dir, "bad_", "pdf"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
@@ -0,0 +1,95 @@
+package backtest
+
+import (
+	"fmt"
+	"log"
+
+	"hft/internal/executor"
+	"hft/internal/manifest"
+	"hft/internal/timeframe"
+)
+
+// Verify reruns the run m describes under m's recorded configuration and
+// compares the rerun's manifest against m. The recorded settings are passed
+// to the rerun; the active ones, which the live executor trades with, are
+// never touched.
+func Verify(m *manifest.Manifest) (*manifest.Verification, error) {
+	if len(m.Run.Symbols) == 0 {
+		return nil, fmt.Errorf("manifest has no symbols")
+	}
+	settings := &m.Config
+	name := m.Run.Strategy
+	if name == "" {
+		name = settings.Strategy.Name
+	}
+
+	// Runs recorded before timeframes existed ran on 1-minute bars.
+	tf := m.Run.Timeframe
+	if tf == "" {
+		tf = timeframe.OneMinute.String()
+	}
+
+	log.Printf("backtest: verify: rerunning %s %v", m.Kind, m.Run.Symbols)
+	var rerun *manifest.Manifest
+	switch m.Kind {
+	case manifest.KindBacktest:
+		err := RunWithOptions(RunOptions{
+			Strategy:   name,
+			Symbol:     m.Run.Symbols[0],
+			StartDate:  m.Run.StartDate,
+			EndDate:    m.Run.EndDate,
+			Mode:       m.Run.Mode,
+			Timeframe:  tf,
+			WarmupFrom: m.Run.WarmupFrom,
+			WarmupBars: m.Run.WarmupBars,
+			Stride:     settings.Predictor.Stride,
+			Settings:   settings,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rerun backtest: %w", err)
+		}
+		rerun = LastManifest()
+	case manifest.KindPortfolio:
+		res, err := RunPortfolio(PortfolioConfig{
+			Strategy:          name,
+			Symbols:           m.Run.Symbols,
+			StartDate:         m.Run.StartDate,
+			EndDate:           m.Run.EndDate,
+			Timeframe:         tf,
+			WarmupBars:        m.Run.WarmupBars,
+			Capital:           m.Run.Capital,
+			MaxSymbolExposure: m.Run.MaxSymbolExposure,
+			MaxTotalExposure:  m.Run.MaxTotalExposure,
+			MaxOpenPositions:  m.Run.MaxOpenPositions,
+			Stride:            settings.Predictor.Stride,
+			Settings:          settings,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rerun portfolio: %w", err)
+		}
+		rerun = res.Manifest
+	case manifest.KindSimulation:
+		err := executor.RunSimulation(executor.SimConfig{
+			Strategy:   name,
+			SimDate:    m.Run.SimDate,
+			Timeframe:  tf,
+			WarmupDays: m.Run.WarmupDays,
+			NoDelay:    true,
+			Quiet:      true,
+			Settings:   settings,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rerun simulation: %w", err)
+		}
+		rerun = executor.LastSimulationManifest()
+	default:
+		return nil, fmt.Errorf("unknown manifest kind %q", m.Kind)
+	}
+	if rerun == nil {
+		return nil, fmt.Errorf("rerun produced no manifest")
+	}
+
+	v := manifest.Compare(m, rerun)
+	log.Printf("backtest: verify: reproduced=%v", v.OK)
+	return v, nil
+}
@@ -0,0 +1,102 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"hft/internal/config"
+	"hft/internal/executor"
+	"hft/internal/manifest"
+	"hft/internal/storage/sqlite"
+	"hft/internal/strategy"
+	"hft/internal/testutil"
+)
+
+var defaultDBOnce sync.Once
+
+// useDefaultDB points the shared tick database at a temp file holding three
+// sessions of 1m NIFTY bars from 2025-06-02. The shared DB is opened once
+// per process, so every test that calls this sees the same data.
+func useDefaultDB(t *testing.T) {
+	t.Helper()
+	defaultDBOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "backtest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := sqlite.InitDefault(filepath.Join(dir, "ticks.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ticks := testutil.Ticks("nifty", testutil.Candles(1, 3))
+		for i := range ticks {
+			ticks[i].TF = "1"
+		}
+		if _, err := db.Ticks.InsertTicks(context.Background(), ticks); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// Verify reruns a manifest under its recorded settings without making them
+// the live executor's.
+func TestVerifyLeavesActiveConfig(t *testing.T) {
+	useDefaultDB(t)
+	orb, regime := strategy.ActiveORBConfig, strategy.ActiveRegimeConfig()
+	before, _ := json.Marshal(orb)
+
+	recorded := strategy.DefaultORBConfig()
+	recorded.RangeMinutes = 10
+	recorded.BufferMode, recorded.Buffer = strategy.ORBBufferPoints, 0
+	recorded.MaxTradesPerTranche = 3
+	settings := &manifest.Settings{Strategy: config.StrategyConfig{ORB: recorded}}
+
+	run := func(settings *manifest.Settings) *manifest.Manifest {
+		t.Helper()
+		err := RunWithOptions(RunOptions{Strategy: "orb", Symbol: "nifty", StartDate: "2025-06-03", EndDate: "2025-06-04",
+			Mode: ModeEvent, Timeframe: "1m", WarmupBars: -1, Settings: settings})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return LastManifest()
+	}
+	active := run(nil)
+	m := run(settings)
+	if m.Result.Trades == 0 || m.Result.SHA256 == active.Result.SHA256 {
+		t.Fatalf("the recorded ORB config traded like the active one (%d trades)", m.Result.Trades)
+	}
+	if m.Config.Strategy.ORB != recorded {
+		t.Fatal("manifest does not record the settings the run traded with")
+	}
+
+	for _, kind := range []string{manifest.KindBacktest, manifest.KindSimulation} {
+		m := *m
+		m.Kind = kind
+		if kind == manifest.KindSimulation {
+			if err := executor.RunSimulation(executor.SimConfig{Strategy: "orb", SimDate: "2025-06-04", WarmupDays: 2, NoDelay: true, Quiet: true, Settings: settings}); err != nil {
+				t.Fatal(err)
+			}
+			m = *executor.LastSimulationManifest()
+		}
+		v, err := Verify(&m)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if !v.OK {
+			for _, c := range v.Checks {
+				if !c.OK {
+					t.Errorf("%s: %s: recorded %s, rerun %s", kind, c.Name, c.Want, c.Got)
+				}
+			}
+		}
+	}
+
+	after, _ := json.Marshal(strategy.ActiveORBConfig)
+	if strategy.ActiveORBConfig != orb || strategy.ActiveRegimeConfig() != regime || string(after) != string(before) {
+		t.Fatal("Verify changed the active strategy config")
+	}
+}
@@ -4,47 +4,57 @@ import (
 	"fmt"
 	"os"
 
+	"hft/internal/ml_model"
+	"hft/internal/risk"
+
 	"gopkg.in/yaml.v3"
 )
 
 // FyersConfig holds configuration for the Fyers broker.
 type FyersConfig struct {
-	AppID       string `yaml:"app_id"`
-	AppSecret   string `yaml:"app_secret"`
-	RedirectURI string `yaml:"redirect_uri"`
-	Pin         string `yaml:"pin"`
+	AppID       string `yaml:"app_id" json:"appId"`
+	AppSecret   string `yaml:"app_secret" json:"appSecret"`
+	RedirectURI string `yaml:"redirect_uri" json:"redirectUri"`
+	Pin         string `yaml:"pin" json:"pin"`
 }
 
 // BrokerConfig represents a single broker entry in configuration.
 type BrokerConfig struct {
-	Fyers FyersConfig `yaml:"fyers"`
+	Fyers FyersConfig `yaml:"fyers" json:"fyers"`
 }
 
 // ClockConfig controls market session times (useful for testing off-market hours).
 // Times are "time of day" strings like "09:15" or "9:15 AM" or "11PM".
 type ClockConfig struct {
-	Location   string `yaml:"location"`   // e.g. "Asia/Kolkata"
-	Start      string `yaml:"start"`      // e.g. "09:15"
-	End        string `yaml:"end"`        // e.g. "15:30"
-	Deactivate string `yaml:"deactivate"` // optional; if empty defaults to end + 10 minutes
+	Location   string `yaml:"location" json:"location"`     // e.g. "Asia/Kolkata"
+	Start      string `yaml:"start" json:"start"`           // e.g. "09:15"
+	End        string `yaml:"end" json:"end"`               // e.g. "15:30"
+	Deactivate string `yaml:"deactivate" json:"deactivate"` // optional; if empty defaults to end + 10 minutes
 }
 
 // Config holds environment and runtime configuration.
 type Config struct {
-	Mode       string         `yaml:"mode"`
-	APIPort    int            `yaml:"api_port"`
-	WebPort    int            `yaml:"web_port"`
-	DBPath     string         `yaml:"db_path"`
-	Broker     []BrokerConfig `yaml:"broker"`
-	Clock      ClockConfig    `yaml:"clock"`
-	ModelDir   string         `yaml:"model_dir"`
-	OrtLibPath string         `yaml:"ort_lib_path"` // path to libonnxruntime.dylib / .so
+	Mode       string         `yaml:"mode" json:"mode"`
+	APIPort    int            `yaml:"api_port" json:"apiPort"`
+	WebPort    int            `yaml:"web_port" json:"webPort"`
+	DBPath     string         `yaml:"db_path" json:"dbPath"`
+	Broker     []BrokerConfig `yaml:"broker" json:"broker"`
+	Clock      ClockConfig    `yaml:"clock" json:"clock"`
+	ModelDir   string         `yaml:"model_dir" json:"modelDir"`
+	OrtLibPath string         `yaml:"ort_lib_path" json:"ortLibPath"` // path to libonnxruntime.dylib / .so
+	Pipeline   string         `yaml:"pipeline" json:"pipeline"`       // indicator pipeline spec; empty = built-in kalman_v2
+
+	Strategy   StrategyConfig  `yaml:"strategy" json:"strategy"`
+	Risk       risk.Limits     `yaml:"risk" json:"risk"`
+	Allocation risk.Allocation `yaml:"allocation" json:"allocation"`
+	Predictor  PredictorConfig `yaml:"predictor" json:"predictor"`
+	Backtest   BacktestConfig  `yaml:"backtest" json:"backtest"`
 }
 
 var GlobalConfig *Config
 
-// Load reads YAML config from path, applying defaults where fields are missing.
-func Load(path string) (*Config, error) {
+// Defaults returns the configuration used when a field is not set in YAML.
+func Defaults() *Config {
 	cfg := &Config{
 		Mode:    "live",
 		APIPort: 5000,
@@ -57,6 +67,13 @@ func Load(path string) (*Config, error) {
 			Deactivate: "",
 		},
 	}
+	defaultSections(cfg)
+	return cfg
+}
+
+// Load reads YAML config from path, applying defaults where fields are missing.
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -66,6 +83,14 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	if cfg.Predictor.Smoothing != nil {
+		// Decode again over the defaults so unset smoothing fields are not zero.
+		s := ml_model.DefaultSmoothingConfig()
+		cfg.Predictor.Smoothing = &s
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse config: %w", err)
+		}
+	}
 
 	// Re-apply defaults if YAML omits a field (zero values).
 	if cfg.Mode == "" {
@@ -97,6 +122,10 @@ func Load(path string) (*Config, error) {
 	}
 	// If Deactivate is empty, clock package will default it to End + 10 minutes.
 
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
 	GlobalConfig = cfg
 	return cfg, nil
 }
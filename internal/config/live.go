@@ -0,0 +1,322 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"hft/internal/ml_model"
+	"hft/internal/strategy"
+)
+
+/*
+   Live config: the strategy and predictor settings that can be changed
+   while the executor runs.
+
+   Every change is validated against the rest of the effective config
+   before anything is touched, then swapped in as fresh structs (the active
+   ones are never mutated in place), so a bar sees either the old or the new
+   settings, never a mix. Each change becomes a numbered version kept in
+   memory and appended to LiveAuditPath; RevertLive re-applies an earlier
+   version as a new one, so the trail is never rewritten.
+*/
+
+// LiveSettings are the live-editable sections.
+type LiveSettings struct {
+	Regime     *strategy.RegimeSignalConfig `json:"regime"`
+	KalmanExit *strategy.KalmanExitConfigv2 `json:"kalmanExit"`
+	Smoothing  *ml_model.SmoothingConfig    `json:"smoothing"`
+}
+
+// LiveVersion is one entry of the live config audit trail.
+type LiveVersion struct {
+	Version  int          `json:"version"`
+	At       time.Time    `json:"at"`
+	Author   string       `json:"author"`
+	Note     string       `json:"note,omitempty"`
+	RevertOf int          `json:"revertOf,omitempty"` // version this one restores
+	Changes  []string     `json:"changes"`            // "path: old → new"
+	Settings LiveSettings `json:"settings"`
+}
+
+// LiveAuditPath receives every live config version as one JSON line; empty
+// keeps the trail in memory only.
+var LiveAuditPath = "tmp/live_config_audit.jsonl"
+
+var (
+	liveMu      sync.Mutex
+	liveHistory []LiveVersion
+)
+
+// LiveCurrent returns the live settings in force as the latest version.
+func LiveCurrent() LiveVersion {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	seedLive("startup")
+	v := liveHistory[len(liveHistory)-1]
+	v.Settings = activeLive()
+	return v
+}
+
+// LiveHistory returns every live config version, oldest first.
+func LiveHistory() []LiveVersion {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	seedLive("startup")
+	return append([]LiveVersion(nil), liveHistory...)
+}
+
+// UpdateLive merges patch (a partial LiveSettings JSON object; fields left
+// out keep their values, arrays such as tranches are replaced whole) into
+// the active settings, validates the result and swaps it in as a new
+// version. A patch that changes nothing returns the current version.
+func UpdateLive(patch []byte, author, note string) (*LiveVersion, error) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	seedLive("startup")
+
+	// Decoding into an existing slice merges element by element, so drop the
+	// tranches first when the patch brings its own.
+	var shape struct {
+		Regime *struct {
+			Tranches json.RawMessage `json:"tranches"`
+		} `json:"regime"`
+	}
+	if err := json.Unmarshal(patch, &shape); err != nil {
+		return nil, fmt.Errorf("parse live config: %w", err)
+	}
+	next, err := cloneLive(activeLive())
+	if err != nil {
+		return nil, err
+	}
+	if shape.Regime != nil && shape.Regime.Tranches != nil && next.Regime != nil {
+		next.Regime.Tranches = nil
+	}
+	if err := json.Unmarshal(patch, &next); err != nil {
+		return nil, fmt.Errorf("parse live config: %w", err)
+	}
+	return commitLive(next, author, note, 0)
+}
+
+// RevertLive re-applies the settings of an earlier version as a new version.
+func RevertLive(version int, author, note string) (*LiveVersion, error) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	seedLive("startup")
+
+	for _, v := range liveHistory {
+		if v.Version == version {
+			next, err := cloneLive(v.Settings)
+			if err != nil {
+				return nil, err
+			}
+			return commitLive(next, author, note, version)
+		}
+	}
+	return nil, fmt.Errorf("live config version %d not found", version)
+}
+
+// resetLive starts a new trail from the active settings. Apply calls it.
+func resetLive(author string) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	liveHistory = nil
+	seedLive(author)
+}
+
+// seedLive records the active settings as version 1 if there is no trail yet.
+func seedLive(author string) {
+	if len(liveHistory) > 0 {
+		return
+	}
+	settings, err := cloneLive(activeLive())
+	if err != nil {
+		// The active structs are never modified in place, so sharing them
+		// is safe; the copy only keeps the trail independent of them.
+		log.Printf("config: live config: %v", err)
+		settings = activeLive()
+	}
+	v := LiveVersion{Version: 1, At: time.Now(), Author: author, Changes: []string{}, Settings: settings}
+	liveHistory = append(liveHistory, v)
+	appendLiveAudit(v)
+}
+
+// commitLive validates next, swaps it in and records it. Callers hold liveMu.
+func commitLive(next LiveSettings, author, note string, revertOf int) (*LiveVersion, error) {
+	eff := Effective()
+	eff.Strategy.Regime = next.Regime
+	eff.Strategy.KalmanExit = next.KalmanExit
+	eff.Predictor.Smoothing = next.Smoothing
+	if err := eff.Validate(); err != nil {
+		return nil, err
+	}
+
+	prev := activeLive()
+	changes, err := diffLive(prev, next)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 && revertOf == 0 {
+		v := liveHistory[len(liveHistory)-1]
+		return &v, nil
+	}
+
+	strategy.SetActiveRegimeConfig(next.Regime)
+	strategy.SetActiveExitConfig(next.KalmanExit)
+	if p := ml_model.GetPredictor(); p != nil && next.Smoothing != nil && !reflect.DeepEqual(prev.Smoothing, next.Smoothing) {
+		p.SetSmoothing(*next.Smoothing)
+	}
+
+	if author == "" {
+		author = "api"
+	}
+	settings, err := cloneLive(next)
+	if err != nil {
+		return nil, err
+	}
+	v := LiveVersion{
+		Version:  liveHistory[len(liveHistory)-1].Version + 1,
+		At:       time.Now(),
+		Author:   author,
+		Note:     note,
+		RevertOf: revertOf,
+		Changes:  changes,
+		Settings: settings,
+	}
+	liveHistory = append(liveHistory, v)
+	appendLiveAudit(v)
+	log.Printf("config: live config v%d by %s: %d change(s)", v.Version, v.Author, len(changes))
+	return &v, nil
+}
+
+// activeLive reads the settings in force.
+func activeLive() LiveSettings {
+	s := LiveSettings{Regime: strategy.ActiveRegimeConfig(), KalmanExit: strategy.ActiveExitConfig()}
+	if p := ml_model.GetPredictor(); p != nil {
+		sm := p.Smoothing()
+		s.Smoothing = &sm
+	} else if GlobalConfig != nil {
+		s.Smoothing = GlobalConfig.Predictor.Smoothing
+	}
+	return s
+}
+
+// cloneLive deep-copies s so no version shares structs with the active ones.
+func cloneLive(s LiveSettings) (LiveSettings, error) {
+	var c LiveSettings
+	b, err := json.Marshal(s)
+	if err != nil {
+		return c, fmt.Errorf("copy live config: %w", err)
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("copy live config: %w", err)
+	}
+	return c, nil
+}
+
+// diffLive lists the leaf fields that differ between a and b.
+func diffLive(a, b LiveSettings) ([]string, error) {
+	fa, fb := make(map[string]string), make(map[string]string)
+	if err := flattenJSON("", a, fa); err != nil {
+		return nil, fmt.Errorf("diff live config: %w", err)
+	}
+	if err := flattenJSON("", b, fb); err != nil {
+		return nil, fmt.Errorf("diff live config: %w", err)
+	}
+	keys := make(map[string]bool)
+	for k := range fa {
+		keys[k] = true
+	}
+	for k := range fb {
+		keys[k] = true
+	}
+	var out []string
+	for k := range keys {
+		if fa[k] != fb[k] {
+			out = append(out, fmt.Sprintf("%s: %s → %s", k, orNone(fa[k]), orNone(fb[k])))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// flattenJSON adds the leaves of v's JSON form to out, keyed by their path
+// under prefix ("regime.tranches[0].name").
+func flattenJSON(prefix string, v interface{}, out map[string]string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var m interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	var walk func(p string, v interface{}) error
+	walk = func(p string, v interface{}) error {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, c := range t {
+				key := k
+				if p != "" {
+					key = p + "." + k
+				}
+				if err := walk(key, c); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for i, c := range t {
+				if err := walk(fmt.Sprintf("%s[%d]", p, i), c); err != nil {
+					return err
+				}
+			}
+		default:
+			b, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			out[p] = string(b)
+		}
+		return nil
+	}
+	return walk(prefix, m)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func appendLiveAudit(v LiveVersion) {
+	if LiveAuditPath == "" {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("config: live audit: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(LiveAuditPath), 0o755); err != nil {
+		log.Printf("config: live audit: %v", err)
+		return
+	}
+	f, err := os.OpenFile(LiveAuditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("config: live audit: %v", err)
+		return
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		log.Printf("config: live audit: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Printf("config: live audit: %v", err)
+	}
+}
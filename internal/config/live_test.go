@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"hft/internal/strategy"
+)
+
+// startLive applies the default config with the audit trail in a temporary
+// file, so each test starts from version 1.
+func startLive(t *testing.T) {
+	t.Helper()
+	LiveAuditPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	cfg := Defaults()
+	GlobalConfig = cfg
+	if err := cfg.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { GlobalConfig = nil })
+}
+
+func TestUpdateLiveMergesPatch(t *testing.T) {
+	startLive(t)
+	before := strategy.ActiveRegimeConfig()
+	orig := *before
+	want := orig.BullProbThresh + 0.05
+
+	v, err := UpdateLive([]byte(fmt.Sprintf(`{"regime": {"bullProbThresh": %g}}`, want)), "test", "raise bull threshold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 2 || v.Author != "test" || v.Note != "raise bull threshold" {
+		t.Fatalf("version %+v", v)
+	}
+	wantChange := fmt.Sprintf("regime.bullProbThresh: %g → %g", orig.BullProbThresh, want)
+	if len(v.Changes) != 1 || v.Changes[0] != wantChange {
+		t.Fatalf("changes %q, want [%q]", v.Changes, wantChange)
+	}
+
+	after := strategy.ActiveRegimeConfig()
+	if after == before {
+		t.Fatal("active config changed in place instead of swapped")
+	}
+	if !reflect.DeepEqual(*before, orig) {
+		t.Fatal("previous config was modified")
+	}
+	merged := orig
+	merged.BullProbThresh = want
+	if !reflect.DeepEqual(*after, merged) {
+		t.Fatalf("active config\n  %+v\nwant\n  %+v", *after, merged)
+	}
+
+	// The same patch again changes nothing and makes no new version.
+	again, err := UpdateLive([]byte(fmt.Sprintf(`{"regime": {"bullProbThresh": %g}}`, want)), "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Version != 2 || strategy.ActiveRegimeConfig() != after {
+		t.Fatalf("no-op patch made version %d", again.Version)
+	}
+}
+
+func TestUpdateLiveReplacesTranches(t *testing.T) {
+	startLive(t)
+	if n := len(strategy.ActiveRegimeConfig().Tranches); n < 2 {
+		t.Fatalf("default config has %d tranches; the test needs more than one", n)
+	}
+
+	patch := `{"regime": {"tranches": [{"name": "only", "openMin": 600, "cutoffMin": 700, "closeMin": 800}]}}`
+	if _, err := UpdateLive([]byte(patch), "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	want := []strategy.Tranche{{Name: "only", OpenMin: 600, CutoffMin: 700, CloseMin: 800}}
+	if got := strategy.ActiveRegimeConfig().Tranches; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tranches %+v, want %+v", got, want)
+	}
+
+	// A tranche is not merged into the one it replaces: without a name it
+	// does not keep "only" and is rejected.
+	if _, err := UpdateLive([]byte(`{"regime": {"tranches": [{"openMin": 610, "cutoffMin": 700, "closeMin": 800}]}}`), "test", ""); err == nil {
+		t.Fatal("unnamed tranche accepted")
+	}
+}
+
+func TestUpdateLiveRejectsInvalidConfig(t *testing.T) {
+	startLive(t)
+	before := strategy.ActiveRegimeConfig()
+	exit := strategy.ActiveExitConfig()
+
+	for _, patch := range []string{
+		`{"regime": {"bullProbThresh": 1.5}}`,
+		`{"regime": {"tranches": []}}`,
+		`{"regime": {"maxTradesPerDay": 5}, "kalmanExit": null}`,
+		`{"regime": `,
+	} {
+		if _, err := UpdateLive([]byte(patch), "test", ""); err == nil {
+			t.Errorf("%s: accepted", patch)
+		}
+		if strategy.ActiveRegimeConfig() != before || strategy.ActiveExitConfig() != exit {
+			t.Fatalf("%s: active config replaced by a rejected patch", patch)
+		}
+	}
+	if h := LiveHistory(); len(h) != 1 {
+		t.Fatalf("%d versions after rejected patches, want 1", len(h))
+	}
+}
+
+func TestRevertLive(t *testing.T) {
+	startLive(t)
+	orig := *strategy.ActiveRegimeConfig()
+
+	if _, err := UpdateLive([]byte(`{"regime": {"cooldownBars": 42}}`), "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateLive([]byte(`{"regime": {"maxTradesPerDay": 9}}`), "test", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := RevertLive(1, "test", "back to startup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 4 || v.RevertOf != 1 || len(v.Changes) != 2 {
+		t.Fatalf("revert version %+v", v)
+	}
+	if got := *strategy.ActiveRegimeConfig(); !reflect.DeepEqual(got, orig) {
+		t.Fatalf("reverted config\n  %+v\nwant\n  %+v", got, orig)
+	}
+
+	// Reverting to version 2 brings back its edit only.
+	if _, err := RevertLive(2, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := strategy.ActiveRegimeConfig(); got.CooldownBars != 42 || got.MaxTradesPerDay != orig.MaxTradesPerDay {
+		t.Fatalf("after revert to v2: cooldown %d, max trades %d", got.CooldownBars, got.MaxTradesPerDay)
+	}
+
+	if _, err := RevertLive(99, "test", ""); err == nil {
+		t.Fatal("revert to a missing version accepted")
+	}
+
+	// Every version, the startup one included, is in the audit file.
+	f, err := os.Open(LiveAuditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var versions []int
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var lv LiveVersion
+		if err := json.Unmarshal(sc.Bytes(), &lv); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, lv.Version)
+	}
+	if !reflect.DeepEqual(versions, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("audit versions %v", versions)
+	}
+}
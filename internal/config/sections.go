@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+
+	"hft/internal/featurecache"
+	"hft/internal/ml_model"
+	"hft/internal/pipeline"
+	"hft/internal/risk"
+	"hft/internal/strategy"
+	"hft/internal/timeframe"
+)
+
+// StrategyConfig holds strategy parameters. Sections omitted from YAML keep
+// the strategy package defaults; fields omitted inside a section keep theirs.
+type StrategyConfig struct {
+	// Name selects the registered strategy the backtest, simulation and live
+	// executor run (see strategy.Names).
+	Name string `yaml:"name" json:"name"`
+	// Timeframe is the bar timeframe they run on (1m, 5m, 1h, 1d, 1w, ...),
+	// resampled from the stored 1-minute bars.
+	Timeframe string `yaml:"timeframe" json:"timeframe"`
+
+	Regime       *strategy.RegimeSignalConfig `yaml:"regime" json:"regime"`
+	KalmanExit   *strategy.KalmanExitConfigv2 `yaml:"kalman_exit" json:"kalmanExit"`
+	TrailingStop *strategy.TrailingStopConfig `yaml:"trailing_stop" json:"trailingStop"`
+	ORB          *strategy.ORBConfig          `yaml:"orb" json:"orb"`
+	MeanRev      *strategy.MeanRevConfig      `yaml:"mean_reversion" json:"meanReversion"`
+	Ensemble     *strategy.EnsembleConfig     `yaml:"ensemble" json:"ensemble"`
+}
+
+// Settings returns the strategy sections of c for a run that trades with
+// them without making them active. Missing sections use the active ones.
+func (c StrategyConfig) Settings() *strategy.Settings {
+	return &strategy.Settings{Regime: c.Regime, KalmanExit: c.KalmanExit, ORB: c.ORB, MeanRev: c.MeanRev, Ensemble: c.Ensemble}
+}
+
+// PredictorConfig holds regime model settings.
+type PredictorConfig struct {
+	// Smoothing overrides the hysteresis filter from model.meta.json. Unset
+	// fields take ml_model.DefaultSmoothingConfig values.
+	Smoothing *ml_model.SmoothingConfig `yaml:"smoothing" json:"smoothing"`
+	// Stride runs backtest inference every Stride bars (1 = every bar).
+	Stride int `yaml:"stride" json:"stride"`
+}
+
+// BacktestConfig holds the defaults for backtest runs.
+type BacktestConfig struct {
+	Symbol     string  `yaml:"symbol" json:"symbol"`
+	StartDate  string  `yaml:"start_date" json:"startDate"`   // YYYY-MM-DD
+	EndDate    string  `yaml:"end_date" json:"endDate"`       // YYYY-MM-DD
+	Mode       string  `yaml:"mode" json:"mode"`              // vectorized | event
+	WarmupFrom string  `yaml:"warmup_from" json:"warmupFrom"` // YYYY-MM-DD, overrides warmup_bars
+	WarmupBars int     `yaml:"warmup_bars" json:"warmupBars"` // 0 = default, -1 = none
+	Capital    float64 `yaml:"capital" json:"capital"`        // portfolio backtests
+
+	// FeatureCache is the directory of the computed feature/prediction
+	// cache; "" disables it.
+	FeatureCache string `yaml:"feature_cache" json:"featureCache"`
+}
+
+func defaultSections(cfg *Config) {
+	cfg.Strategy = StrategyConfig{
+		Name:         strategy.DefaultStrategy,
+		Timeframe:    timeframe.OneMinute.String(),
+		Regime:       strategy.DefaultRegimeSignalConfig(),
+		KalmanExit:   strategy.DefaultKalmanExitConfigv2(),
+		TrailingStop: strategy.DefaultTrailingStopConfig(),
+		ORB:          strategy.DefaultORBConfig(),
+		MeanRev:      strategy.DefaultMeanRevConfig(),
+		Ensemble:     strategy.DefaultEnsembleConfig(),
+	}
+	cfg.Risk = risk.DefaultLimits()
+	cfg.Allocation = risk.DefaultAllocation()
+	cfg.Predictor = PredictorConfig{Stride: 1}
+	cfg.Backtest = BacktestConfig{
+		Symbol:    "nifty",
+		StartDate: "2025-01-01",
+		EndDate:   "2026-03-12",
+		Mode:      "vectorized",
+		Capital:   1_000_000,
+
+		FeatureCache: "tmp/feature_cache",
+	}
+}
+
+// Apply makes the indicator pipeline, the feature cache directory and the
+// strategy, risk and predictor sections the active settings of the live
+// executor and backtests. Call it after ml_model.InitPredictor: the
+// pipeline must compute every feature column of the loaded model, otherwise
+// nothing is applied.
+func (c *Config) Apply() error {
+	p := pipeline.Default()
+	if c.Pipeline != "" {
+		var err error
+		if p, err = pipeline.Load(c.Pipeline); err != nil {
+			return err
+		}
+	}
+	if pred := ml_model.GetPredictor(); pred != nil {
+		if err := p.Check(pred.FeatureCols()); err != nil {
+			return fmt.Errorf("model %s: %w", c.ModelDir, err)
+		}
+	}
+	tf, err := timeframe.Parse(c.Strategy.Timeframe)
+	if err != nil {
+		return fmt.Errorf("strategy.timeframe: %w", err)
+	}
+	strategy.ActivePipeline = p
+
+	strategy.ActiveStrategy = c.Strategy.Name
+	strategy.ActiveTimeframe = tf
+	strategy.SetActiveRegimeConfig(c.Strategy.Regime)
+	strategy.SetActiveExitConfig(c.Strategy.KalmanExit)
+	strategy.ActiveTrailingStopConfig = c.Strategy.TrailingStop
+	strategy.ActiveORBConfig = c.Strategy.ORB
+	strategy.ActiveMeanRevConfig = c.Strategy.MeanRev
+	strategy.ActiveEnsembleConfig = c.Strategy.Ensemble
+	risk.ActiveLimits = c.Risk
+	risk.ActiveAllocation = c.Allocation
+	featurecache.Dir = c.Backtest.FeatureCache
+	if p := ml_model.GetPredictor(); p != nil && c.Predictor.Smoothing != nil {
+		p.SetSmoothing(*c.Predictor.Smoothing)
+	}
+	resetLive("config")
+	return nil
+}
+
+// Effective returns the configuration in force: the loaded config (or the
+// defaults when none is loaded) with the active (possibly live-edited)
+// strategy and risk settings, the predictor's smoothing, and broker secrets
+// redacted.
+func Effective() *Config {
+	base := GlobalConfig
+	if base == nil {
+		base = Defaults()
+	}
+	eff := *base
+	eff.Strategy = StrategyConfig{
+		Name:         strategy.ActiveStrategy,
+		Timeframe:    strategy.ActiveTimeframe.String(),
+		Regime:       strategy.ActiveRegimeConfig(),
+		KalmanExit:   strategy.ActiveExitConfig(),
+		TrailingStop: strategy.ActiveTrailingStopConfig,
+		ORB:          strategy.ActiveORBConfig,
+		MeanRev:      strategy.ActiveMeanRevConfig,
+		Ensemble:     strategy.ActiveEnsembleConfig,
+	}
+	eff.Risk = risk.ActiveLimits
+	eff.Allocation = risk.ActiveAllocation
+	if p := ml_model.GetPredictor(); p != nil {
+		s := p.Smoothing()
+		eff.Predictor.Smoothing = &s
+	}
+	eff.Broker = make([]BrokerConfig, len(base.Broker))
+	for i, b := range base.Broker {
+		b.Fyers.AppSecret = redact(b.Fyers.AppSecret)
+		b.Fyers.Pin = redact(b.Fyers.Pin)
+		eff.Broker[i] = b
+	}
+	return &eff
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
@@ -0,0 +1,361 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hft/internal/risk"
+	"hft/internal/strategy"
+	"hft/internal/timeframe"
+)
+
+// Validate checks the configuration and reports every problem at once, each
+// prefixed with its YAML path.
+func (c *Config) Validate() error {
+	var errs []string
+	bad := func(path, format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	prob := func(path string, v float64) {
+		if v <= 0 || v > 1 {
+			bad(path, "must be in (0, 1], got %g", v)
+		}
+	}
+	nonNeg := func(path string, v float64) {
+		if v < 0 {
+			bad(path, "must be >= 0, got %g", v)
+		}
+	}
+	positive := func(path string, v float64) {
+		if v <= 0 {
+			bad(path, "must be > 0, got %g", v)
+		}
+	}
+	adx := func(path string, v float64) {
+		if v < 0 || v >= 100 {
+			bad(path, "must be in [0, 100) (0 disables), got %g", v)
+		}
+	}
+
+	tranches := func(path string, trs []strategy.Tranche) {
+		if len(trs) == 0 {
+			bad(path, "at least one tranche is required")
+		}
+		seen := make(map[string]bool)
+		prevClose := -1
+		for i, tr := range trs {
+			tp := fmt.Sprintf("%s[%d]", path, i)
+			if tr.Name == "" {
+				bad(tp+".name", "required")
+			} else if seen[tr.Name] {
+				bad(tp+".name", "duplicate tranche %q", tr.Name)
+			}
+			seen[tr.Name] = true
+			if tr.OpenMin < 0 || tr.CloseMin >= 24*60 || tr.OpenMin > tr.CutoffMin || tr.CutoffMin > tr.CloseMin {
+				bad(tp, "need 0 <= open_min <= cutoff_min <= close_min < 1440, got %d/%d/%d", tr.OpenMin, tr.CutoffMin, tr.CloseMin)
+			}
+			if tr.OpenMin <= prevClose {
+				bad(tp, "overlaps or precedes the previous tranche (open_min %d <= %d)", tr.OpenMin, prevClose)
+			}
+			prevClose = tr.CloseMin
+		}
+	}
+
+	// ── Server ───────────────────────────────────────────────────
+	if c.Mode != "live" && c.Mode != "dryrun" {
+		bad("mode", "must be live or dryrun, got %q", c.Mode)
+	}
+	for path, port := range map[string]int{"api_port": c.APIPort, "web_port": c.WebPort} {
+		if port < 1 || port > 65535 {
+			bad(path, "must be a port number, got %d", port)
+		}
+	}
+
+	// ── Strategy ─────────────────────────────────────────────────
+	// Strategies and sleeves are checked against the timeframe only once
+	// it parses.
+	tf, tfErr := timeframe.Parse(c.Strategy.Timeframe)
+	if tfErr != nil {
+		bad("strategy.timeframe", "%v", tfErr)
+	}
+	if s, err := strategy.Get(c.Strategy.Name); c.Strategy.Name == "" || err != nil {
+		bad("strategy.name", "must be one of %v, got %q", strategy.Names(), c.Strategy.Name)
+	} else if tfErr == nil && !c.Allocation.Enabled() {
+		if err := strategy.CheckTimeframe(s, tf); err != nil {
+			bad("strategy.timeframe", "%v", err)
+		}
+	}
+	if r := c.Strategy.Regime; r == nil {
+		bad("strategy.regime", "missing")
+	} else {
+		p := "strategy.regime."
+		prob(p+"bull_prob_thresh", r.BullProbThresh)
+		prob(p+"bear_prob_thresh", r.BearProbThresh)
+		positive(p+"long_sl_pct", r.LongSLPct)
+		positive(p+"short_sl_pct", r.ShortSLPct)
+		for name, v := range map[string]float64{
+			"long_atr_mult": r.LongATRMult, "long_be_pct": r.LongBEPct, "long_trail_act": r.LongTrailAct,
+			"short_atr_mult": r.ShortATRMult, "short_be_pct": r.ShortBEPct, "short_trail_act": r.ShortTrailAct,
+		} {
+			nonNeg(p+name, v)
+		}
+		positive(p+"long_trail_off", r.LongTrailOff)
+		positive(p+"short_trail_off", r.ShortTrailOff)
+		if r.MaxTradesPerDay < 1 {
+			bad(p+"max_trades_per_day", "must be >= 1, got %d", r.MaxTradesPerDay)
+		}
+		if r.CooldownBars < 0 {
+			bad(p+"cooldown_bars", "must be >= 0, got %d", r.CooldownBars)
+		}
+		if r.MaxVolProb < 0 || r.MaxVolProb > 1 {
+			bad(p+"max_vol_prob", "must be in [0, 1] (0 disables), got %g", r.MaxVolProb)
+		}
+		adx(p+"min_adx", r.MinADX)
+		tranches(p+"tranches", r.Tranches)
+	}
+
+	if o := c.Strategy.ORB; o == nil {
+		bad("strategy.orb", "missing")
+	} else {
+		p := "strategy.orb."
+		if o.RangeMinutes < 1 {
+			bad(p+"range_minutes", "must be >= 1, got %d", o.RangeMinutes)
+		}
+		if o.BufferMode != strategy.ORBBufferPoints && o.BufferMode != strategy.ORBBufferATR {
+			bad(p+"buffer_mode", "must be points or atr, got %q", o.BufferMode)
+		}
+		nonNeg(p+"buffer", o.Buffer)
+		if o.RegimeConfirm {
+			prob(p+"confirm_prob", o.ConfirmProb)
+		}
+		adx(p+"min_adx", o.MinADX)
+		switch o.StopMode {
+		case strategy.ORBStopRange:
+		case strategy.ORBStopATR:
+			positive(p+"stop_atr_mult", o.StopATRMult)
+		case strategy.ORBStopFixed:
+			positive(p+"stop_points", o.StopPoints)
+		default:
+			bad(p+"stop_mode", "must be range, atr or fixed, got %q", o.StopMode)
+		}
+		if o.MaxTradesPerTranche < 1 {
+			bad(p+"max_trades_per_tranche", "must be >= 1, got %d", o.MaxTradesPerTranche)
+		}
+		tranches(p+"tranches", o.Tranches)
+	}
+
+	if m := c.Strategy.MeanRev; m == nil {
+		bad("strategy.mean_reversion", "missing")
+	} else {
+		p := "strategy.mean_reversion."
+		prob(p+"min_vol_prob", m.MinVolProb)
+		positive(p+"dist_thresh", m.DistThresh)
+		if m.RSILow < 0 || m.RSILow >= m.RSIHigh || m.RSIHigh > 100 {
+			bad(p+"rsi_low", "must satisfy 0 <= rsi_low < rsi_high <= 100, got %g / %g", m.RSILow, m.RSIHigh)
+		}
+		if m.BandPeriod < 2 {
+			bad(p+"band_period", "must be >= 2, got %d", m.BandPeriod)
+		}
+		positive(p+"band_z", m.BandZ)
+		if m.MinSignals < 1 || m.MinSignals > 3 {
+			bad(p+"min_signals", "must be in [1, 3], got %d", m.MinSignals)
+		}
+		positive(p+"stop_pct", m.StopPct)
+		if m.TimeStopBars < 1 {
+			bad(p+"time_stop_bars", "must be >= 1, got %d", m.TimeStopBars)
+		}
+		if m.ExitZ < 0 || m.ExitZ >= m.BandZ {
+			bad(p+"exit_z", "must be in [0, band_z), got %g", m.ExitZ)
+		}
+		if m.CooldownBars < 0 {
+			bad(p+"cooldown_bars", "must be >= 0, got %d", m.CooldownBars)
+		}
+		if m.MaxTrades < 1 {
+			bad(p+"max_trades_per_tranche", "must be >= 1, got %d", m.MaxTrades)
+		}
+		tranches(p+"tranches", m.Tranches)
+	}
+
+	if e := c.Strategy.Ensemble; e == nil {
+		bad("strategy.ensemble", "missing")
+	} else {
+		p := "strategy.ensemble."
+		switch e.Mode {
+		case strategy.EnsembleMajority, strategy.EnsembleUnanimous:
+		case strategy.EnsembleWeighted:
+			prob(p+"score_thresh", e.ScoreThresh)
+		default:
+			bad(p+"mode", "must be majority, weighted or unanimous, got %q", e.Mode)
+		}
+		if len(e.Components) == 0 {
+			bad(p+"components", "at least one component is required")
+		}
+		seen := make(map[string]bool)
+		for i, comp := range e.Components {
+			cp := fmt.Sprintf("%scomponents[%d]", p, i)
+			known := false
+			for _, n := range strategy.EnsembleComponentNames {
+				known = known || comp.Name == n
+			}
+			if !known {
+				bad(cp+".name", "must be one of %s, got %q", strings.Join(strategy.EnsembleComponentNames, ", "), comp.Name)
+			} else if seen[comp.Name] {
+				bad(cp+".name", "duplicate component %q", comp.Name)
+			}
+			seen[comp.Name] = true
+			if e.Mode == strategy.EnsembleWeighted {
+				positive(cp+".weight", comp.Weight)
+			}
+		}
+		if e.Uses(strategy.EnsembleRegime) {
+			prob(p+"regime_prob", e.RegimeProb)
+		}
+		nonNeg(p+"mtf_min_cross", e.MTFMinCross)
+		nonNeg(p+"stop_pct", e.StopPct)
+		if e.CooldownBars < 0 {
+			bad(p+"cooldown_bars", "must be >= 0, got %d", e.CooldownBars)
+		}
+		switch e.LogVotes {
+		case strategy.EnsembleLogAll, strategy.EnsembleLogChanges, strategy.EnsembleLogOff:
+		default:
+			bad(p+"log_votes", "must be all, changes or off, got %q", e.LogVotes)
+		}
+		tranches(p+"tranches", e.Tranches)
+	}
+
+	if k := c.Strategy.KalmanExit; k == nil {
+		bad("strategy.kalman_exit", "missing")
+	} else {
+		nonNeg("strategy.kalman_exit.activation_mfe_pts", k.ActivationMFEPts)
+		if k.MFECaptureRatio < 0 || k.MFECaptureRatio > 1 {
+			bad("strategy.kalman_exit.mfe_capture_ratio", "must be in [0, 1], got %g", k.MFECaptureRatio)
+		}
+		if k.SignalConfirmBars < 0 {
+			bad("strategy.kalman_exit.signal_confirm_bars", "must be >= 0, got %d", k.SignalConfirmBars)
+		}
+		if k.EnableFixedSL && k.FixedSL >= 0 {
+			bad("strategy.kalman_exit.fixed_sl", "must be negative (points of loss), got %g", k.FixedSL)
+		}
+	}
+
+	if t := c.Strategy.TrailingStop; t == nil {
+		bad("strategy.trailing_stop", "missing")
+	} else {
+		p := "strategy.trailing_stop."
+		for name, v := range map[string]float64{
+			"trail_activation_points": t.TrailActivationPoints, "trail_distance_points": t.TrailDistancePoints,
+			"atr_trailing_multiplier": t.ATRTrailingMultiplier, "breakeven_activation_points": t.BreakevenActivationPoints,
+			"capture_points": t.CapturePoints, "stop_loss_points": t.StopLossPoints,
+			"stop_loss_atr_multiplier": t.StopLossAtrMultiplier, "tight_stop_loss_atr_multiplier": t.TightStopLossAtrMultiplier,
+		} {
+			nonNeg(p+name, v)
+		}
+		if t.StreakMinProfitPoints > t.StreakMaxProfitPoints {
+			bad(p+"streak_min_profit_points", "must be <= streak_max_profit_points (%g), got %g", t.StreakMaxProfitPoints, t.StreakMinProfitPoints)
+		}
+		if t.StreakLengthForTighten < 0 {
+			bad(p+"streak_length_for_tighten", "must be >= 0, got %d", t.StreakLengthForTighten)
+		}
+	}
+
+	// ── Risk ─────────────────────────────────────────────────────
+	if c.Risk.MaxOpenPositions < 1 {
+		bad("risk.max_open_positions", "must be >= 1, got %d", c.Risk.MaxOpenPositions)
+	}
+	positive("risk.max_symbol_exposure", c.Risk.MaxSymbolExposure)
+	positive("risk.max_total_exposure", c.Risk.MaxTotalExposure)
+	if c.Risk.MaxSymbolExposure > c.Risk.MaxTotalExposure {
+		bad("risk.max_symbol_exposure", "must be <= max_total_exposure (%g), got %g", c.Risk.MaxTotalExposure, c.Risk.MaxSymbolExposure)
+	}
+
+	if a := c.Allocation; a.Enabled() {
+		positive("allocation.capital", a.Capital)
+		if a.Netting != risk.NettingNet && a.Netting != risk.NettingBlock && a.Netting != risk.NettingHedge {
+			bad("allocation.netting", "must be net, block or hedge, got %q", a.Netting)
+		}
+		total := 0.0
+		seen := make(map[string]bool)
+		for i, sl := range a.Sleeves {
+			p := fmt.Sprintf("allocation.sleeves[%d].", i)
+			if s, err := strategy.Get(sl.Strategy); sl.Strategy == "" || err != nil {
+				bad(p+"strategy", "must be one of %v, got %q", strategy.Names(), sl.Strategy)
+			} else if s.NewBar() == nil {
+				bad(p+"strategy", "%s has no per-bar form", sl.Strategy)
+			} else if seen[sl.Strategy] {
+				bad(p+"strategy", "duplicate sleeve for %q", sl.Strategy)
+			} else if tfErr == nil {
+				if err := strategy.CheckTimeframe(s, tf); err != nil {
+					bad(p+"strategy", "%v", err)
+				}
+			}
+			seen[sl.Strategy] = true
+			prob(p+"weight", sl.Weight)
+			total += sl.Weight
+			if sl.MaxOpenPositions < 0 {
+				bad(p+"max_open_positions", "must be >= 0 (0 = risk.max_open_positions), got %d", sl.MaxOpenPositions)
+			}
+			if sl.MaxSymbolExposure < 0 || sl.MaxSymbolExposure > 1 {
+				bad(p+"max_symbol_exposure", "must be in [0, 1] (0 = risk.max_symbol_exposure), got %g", sl.MaxSymbolExposure)
+			}
+			if sl.MaxDailyLossPct < 0 || sl.MaxDailyLossPct > 100 {
+				bad(p+"max_daily_loss_pct", "must be in [0, 100] (0 disables), got %g", sl.MaxDailyLossPct)
+			}
+		}
+		if total > 1+1e-9 {
+			bad("allocation.sleeves", "weights must sum to <= 1, got %g", total)
+		}
+	}
+
+	// ── Predictor ────────────────────────────────────────────────
+	if c.Predictor.Stride < 1 {
+		bad("predictor.stride", "must be >= 1, got %d", c.Predictor.Stride)
+	}
+	if s := c.Predictor.Smoothing; s != nil {
+		if s.ConfirmBars < 1 {
+			bad("predictor.smoothing.confirm_bars", "must be >= 1, got %d", s.ConfirmBars)
+		}
+		if s.MinConfidence < 0 || s.MinConfidence > 1 {
+			bad("predictor.smoothing.min_confidence", "must be in [0, 1], got %g", s.MinConfidence)
+		}
+		if s.StartID < -1 || s.StartID > 2 {
+			bad("predictor.smoothing.start_id", "must be -1 (none), 0 (bullish), 1 (bearish) or 2 (volatile), got %d", s.StartID)
+		}
+	}
+
+	// ── Backtest ─────────────────────────────────────────────────
+	b := c.Backtest
+	if b.Symbol == "" {
+		bad("backtest.symbol", "required")
+	}
+	dates := make(map[string]time.Time)
+	for path, v := range map[string]string{"backtest.start_date": b.StartDate, "backtest.end_date": b.EndDate, "backtest.warmup_from": b.WarmupFrom} {
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			bad(path, "must be YYYY-MM-DD, got %q", v)
+			continue
+		}
+		dates[path] = t
+	}
+	start, okStart := dates["backtest.start_date"]
+	if end, ok := dates["backtest.end_date"]; ok && okStart && end.Before(start) {
+		bad("backtest.end_date", "must not be before start_date (%s), got %s", b.StartDate, b.EndDate)
+	}
+	if from, ok := dates["backtest.warmup_from"]; ok && okStart && from.After(start) {
+		bad("backtest.warmup_from", "must not be after start_date (%s), got %s", b.StartDate, b.WarmupFrom)
+	}
+	if b.Mode != "vectorized" && b.Mode != "event" {
+		bad("backtest.mode", "must be vectorized or event, got %q", b.Mode)
+	}
+	nonNeg("backtest.capital", b.Capital)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return fmt.Errorf("invalid config:\n  - %s", strings.Join(errs, "\n  - "))
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"hft/internal/ml_model"
+	"hft/internal/risk"
+	"hft/internal/strategy"
+)
+
+// Each case breaks one field of one section of the defaults; Validate must
+// report exactly that field, by its YAML path.
+func TestValidateReportsEachSection(t *testing.T) {
+	if err := Defaults().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		mutate func(c *Config)
+	}{
+		{"mode", func(c *Config) { c.Mode = "paper" }},
+		{"api_port", func(c *Config) { c.APIPort = 70000 }},
+		{"strategy.name", func(c *Config) { c.Strategy.Name = "no_such_strategy" }},
+		{"strategy.timeframe", func(c *Config) { c.Strategy.Timeframe = "7x" }},
+		{"strategy.regime.bull_prob_thresh", func(c *Config) { c.Strategy.Regime.BullProbThresh = 1.5 }},
+		{"strategy.regime.tranches[1]", func(c *Config) { c.Strategy.Regime.Tranches[1].OpenMin = c.Strategy.Regime.Tranches[0].CloseMin }},
+		{"strategy.orb.stop_mode", func(c *Config) { c.Strategy.ORB.StopMode = "trail" }},
+		{"strategy.mean_reversion.exit_z", func(c *Config) { c.Strategy.MeanRev.ExitZ = c.Strategy.MeanRev.BandZ }},
+		{"strategy.ensemble.components[0].name", func(c *Config) { c.Strategy.Ensemble.Components[0].Name = "rsi" }},
+		{"strategy.kalman_exit.fixed_sl", func(c *Config) { c.Strategy.KalmanExit.EnableFixedSL, c.Strategy.KalmanExit.FixedSL = true, 5 }},
+		{"strategy.trailing_stop.stop_loss_points", func(c *Config) { c.Strategy.TrailingStop.StopLossPoints = -1 }},
+		{"risk.max_open_positions", func(c *Config) { c.Risk.MaxOpenPositions = 0 }},
+		{"allocation.sleeves[0].weight", func(c *Config) {
+			c.Allocation.Sleeves = []risk.Sleeve{{Strategy: "regime", Weight: 0}}
+		}},
+		{"predictor.stride", func(c *Config) { c.Predictor.Stride = 0 }},
+		{"predictor.smoothing.start_id", func(c *Config) {
+			c.Predictor.Smoothing = &ml_model.SmoothingConfig{ConfirmBars: 1, StartID: 3}
+		}},
+		{"backtest.end_date", func(c *Config) { c.Backtest.EndDate = "2024-12-31" }},
+	} {
+		c := Defaults()
+		tc.mutate(c)
+		err := c.Validate()
+		if err == nil {
+			t.Errorf("%s: accepted", tc.path)
+			continue
+		}
+		lines := strings.Split(err.Error(), "\n  - ")[1:]
+		if len(lines) != 1 || !strings.HasPrefix(lines[0], tc.path+": ") {
+			t.Errorf("%s: reported %q", tc.path, lines)
+		}
+	}
+}
+
+// Every problem is reported at once, sorted by path.
+func TestValidateReportsAllProblems(t *testing.T) {
+	c := Defaults()
+	c.Backtest.Mode = "batch"
+	c.Risk.MaxOpenPositions = 0
+	c.Strategy.Ensemble.LogVotes = "some"
+	c.Strategy.Ensemble.Mode = strategy.EnsembleWeighted
+	c.Strategy.Ensemble.ScoreThresh = 0
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("accepted")
+	}
+	var paths []string
+	for _, l := range strings.Split(err.Error(), "\n  - ")[1:] {
+		paths = append(paths, l[:strings.Index(l, ": ")])
+	}
+	want := "backtest.mode risk.max_open_positions strategy.ensemble.log_votes strategy.ensemble.score_thresh"
+	if got := strings.Join(paths, " "); got != want {
+		t.Fatalf("reported %s, want %s", got, want)
+	}
+}
@@ -37,12 +37,25 @@ func InitTradeDataFrame() *dataframe.DataFrame {
 	_reason := dataframe.NewSeriesString("reason", nil)
 	_peakProfit := dataframe.NewSeriesFloat64("peakProfit", nil)
 	_peakLoss := dataframe.NewSeriesFloat64("peakLoss", nil)
-	_dataFrame := dataframe.NewDataFrame(_entryPrice, _exitPrice, _entryTime, _exitTime, _profit, _profitPct, _type, _reason, _peakProfit, _peakLoss)
+	// entry context (see types.EntryContext)
+	_tranche := dataframe.NewSeriesString("tranche", nil)
+	_dayType := dataframe.NewSeriesString("dayType", nil)
+	_gapPct := dataframe.NewSeriesFloat64("gapPct", nil)
+	_regime := dataframe.NewSeriesString("regime", nil)
+	_entryProb := dataframe.NewSeriesFloat64("entryProb", nil)
+	_atr := dataframe.NewSeriesFloat64("atr", nil)
+	_weekday := dataframe.NewSeriesString("weekday", nil)
+	_strategy := dataframe.NewSeriesString("strategy", nil)
+	_dataFrame := dataframe.NewDataFrame(_entryPrice, _exitPrice, _entryTime, _exitTime, _profit, _profitPct, _type, _reason, _peakProfit, _peakLoss,
+		_tranche, _dayType, _gapPct, _regime, _entryProb, _atr, _weekday, _strategy)
 	return _dataFrame
 }
 
-// AppendTrade adds a completed trade to the trade dataframe
-func AppendTrade(df *dataframe.DataFrame, entryPrice, exitPrice float64, entryTime, exitTime time.Time, profit, profitPct float64, tradeType, reason string, peakProfit, peakLoss float64) {
+// AppendTrade adds a completed trade to the trade dataframe. ctx may be nil.
+func AppendTrade(df *dataframe.DataFrame, entryPrice, exitPrice float64, entryTime, exitTime time.Time, profit, profitPct float64, tradeType, reason string, peakProfit, peakLoss float64, ctx *types.EntryContext) {
+	if ctx == nil {
+		ctx = &types.EntryContext{}
+	}
 	df.Append(nil, map[string]interface{}{
 		"entryPrice": entryPrice,
 		"exitPrice":  exitPrice,
@@ -54,6 +67,14 @@ func AppendTrade(df *dataframe.DataFrame, entryPrice, exitPrice float64, entryTi
 		"reason":     reason,
 		"peakProfit": peakProfit,
 		"peakLoss":   peakLoss,
+		"tranche":    ctx.Tranche,
+		"dayType":    ctx.DayType,
+		"gapPct":     ctx.GapPct,
+		"regime":     ctx.Regime,
+		"entryProb":  ctx.EntryProb,
+		"atr":        ctx.ATR,
+		"weekday":    ctx.Weekday,
+		"strategy":   ctx.Strategy,
 	})
 }
 
@@ -0,0 +1,471 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"hft/internal/oms"
+	"hft/internal/risk"
+	"hft/internal/strategy"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Multi-strategy allocator.
+
+   Runs several registered strategies over the same bars of one symbol. Each
+   strategy is a sleeve with its own BarHandler: its own strategy state, its
+   own risk budget and its own paper book, so its position and PnL are what
+   it would have had trading alone on its share of the capital:
+
+     sleeve capital  = Allocation.Capital × Weight
+     sleeve equity   = sleeve capital + realized + unrealized
+     entry size      = PositionSizing on sleeve equity (MaxSymbolExposure of
+                       the sleeve), capped by MaxTotalExposure of the account
+                       limits on the summed equity and exposure of all sleeves
+     daily loss      = entries stop for the day once the sleeve's realized
+                       loss reaches MaxDailyLossPct of its capital
+
+   What reaches the account is decided by the netting rule (risk.Netting*):
+   "net" trades the change of the summed sleeve positions once per bar,
+   "block" rejects entries against a side another sleeve holds, "hedge"
+   forwards every sleeve order. Events carry the sleeve's strategy name, so
+   stats and trades stay attributed per strategy.
+
+   A sleeve's accounting (realized PnL, the day's PnL and halt) is saved with
+   its strategy state, so a restart neither resets the loss budget nor
+   forgets the sleeve's open position.
+*/
+
+// Allocator runs several per-bar strategies side by side on one symbol.
+type Allocator struct {
+	symbol  string
+	netting string
+	capital float64
+	sleeves []*Sleeve
+	account oms.Broker
+	events  chan<- *types.Event
+
+	mu     sync.Mutex
+	net    float64 // account position, signed quantity
+	orders []types.Order
+	nextID int64
+}
+
+// Sleeve is one strategy's share of an Allocator.
+type Sleeve struct {
+	Name     string
+	Weight   float64
+	Strategy strategy.Strategy
+	Handler  *BarHandler
+
+	capital      float64
+	sizing       risk.PositionSizing
+	maxDailyLoss float64 // 0 = no limit
+	last         float64 // last close
+	realized     float64
+	day          string
+	dayPnL       float64
+	halted       bool // daily loss budget spent
+	rejected     int
+}
+
+// NewAllocator builds one sleeve per alloc.Sleeves entry. Filled intents are
+// published on events, tagged with the sleeve's strategy.
+func NewAllocator(symbol string, alloc risk.Allocation, events chan<- *types.Event) (*Allocator, error) {
+	a := &Allocator{
+		symbol:  symbol,
+		netting: alloc.Netting,
+		capital: alloc.Capital,
+		account: &oms.PaperBroker{},
+		events:  events,
+	}
+	if a.netting == "" {
+		a.netting = risk.NettingNet
+	}
+	for _, sl := range alloc.Sleeves {
+		s, err := strategy.Get(sl.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		bar := s.NewBar()
+		if bar == nil {
+			return nil, fmt.Errorf("strategy %s has no per-bar form", s.Name())
+		}
+		h := NewStrategyHandler(symbol, bar, nil)
+		if sl.MaxOpenPositions > 0 {
+			h.Risk.MaxOpenPositions = sl.MaxOpenPositions
+		}
+		sizing := risk.PositionSizing{MaxSymbolExposure: sl.MaxSymbolExposure, MaxTotalExposure: risk.ActiveLimits.MaxTotalExposure}
+		if sizing.MaxSymbolExposure <= 0 {
+			sizing.MaxSymbolExposure = risk.ActiveLimits.MaxSymbolExposure
+		}
+		capital := alloc.Capital * sl.Weight
+		sleeve := &Sleeve{
+			Name:         s.Name(),
+			Weight:       sl.Weight,
+			Strategy:     s,
+			Handler:      h,
+			capital:      capital,
+			sizing:       sizing,
+			maxDailyLoss: capital * sl.MaxDailyLossPct / 100,
+		}
+		h.book = sleeve
+		a.sleeves = append(a.sleeves, sleeve)
+	}
+	if len(a.sleeves) == 0 {
+		return nil, fmt.Errorf("allocation has no sleeves")
+	}
+	return a, nil
+}
+
+// Sleeves returns the sleeves in configuration order.
+func (a *Allocator) Sleeves() []*Sleeve {
+	return a.sleeves
+}
+
+// Columns is the union of the sleeves' feature columns.
+func (a *Allocator) Columns() []string {
+	var cols []string
+	seen := make(map[string]bool)
+	for _, s := range a.sleeves {
+		for _, c := range s.Strategy.Columns() {
+			if !seen[c] {
+				seen[c] = true
+				cols = append(cols, c)
+			}
+		}
+	}
+	return cols
+}
+
+// NeedsRegime reports whether any sleeve reads the regime predictions.
+func (a *Allocator) NeedsRegime() bool {
+	for _, s := range a.sleeves {
+		if s.Strategy.NeedsRegime() {
+			return true
+		}
+	}
+	return false
+}
+
+// Indicators adds every sleeve's indicator columns to df. Strategies share
+// indicator sets, so a column another sleeve already added is dropped
+// rather than duplicated.
+func (a *Allocator) Indicators(df *_df_.DataFrame, logEvents chan *types.LogEvent) {
+	done := make(map[string]bool)
+	for _, s := range a.sleeves {
+		if done[s.Name] {
+			continue
+		}
+		done[s.Name] = true
+		n := len(df.Series)
+		s.Strategy.Indicators(df, logEvents)
+		have := make(map[string]bool, n)
+		for _, ser := range df.Series[:n] {
+			have[ser.Name()] = true
+		}
+		kept := df.Series[:n]
+		for _, ser := range df.Series[n:] {
+			if !have[ser.Name()] {
+				have[ser.Name()] = true
+				kept = append(kept, ser)
+			}
+		}
+		df.Series = kept
+	}
+}
+
+// Resume makes the account position the sum of the sleeve positions. Call
+// it after restoring the sleeves from saved state: every netting rule leaves
+// the account holding what the sleeves hold.
+func (a *Allocator) Resume() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.net = 0
+	for _, s := range a.sleeves {
+		a.net += s.signedQty()
+	}
+}
+
+// OnBar feeds one closed bar to every sleeve, then lets the account follow.
+// A sleeve restored from saved state skips the bars it has already seen.
+func (a *Allocator) OnBar(c types.Candle, f strategy.Features) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Mark every sleeve first so entries are sized on this bar's exposure of
+	// all of them.
+	var active []*Sleeve
+	for _, s := range a.sleeves {
+		h := s.Handler
+		if !h.lastBar.IsZero() && !c.Timestamp.After(h.lastBar) {
+			continue
+		}
+		if day := c.Timestamp.Format("2006-01-02"); day != s.day {
+			s.day, s.dayPnL, s.halted = day, 0, false
+		}
+		s.last = c.Close
+		h.OMS.Mark(c.Close)
+		active = append(active, s)
+	}
+
+	for _, s := range active {
+		h := s.Handler
+		for _, in := range h.Strategy.OnBar(c, f) {
+			if err := a.submit(s, in); err != nil {
+				log.Printf("executor: %s: %s %s @ %.2f rejected: %v", s.Name, in.Type, in.Kind, in.Price, err)
+				if in.Type == "ENTRY" {
+					s.rejected++
+					h.Strategy.Reject()
+				}
+			}
+		}
+		h.done(c)
+	}
+
+	if a.netting == risk.NettingNet {
+		target := 0.0
+		for _, s := range a.sleeves {
+			target += s.signedQty()
+		}
+		if d := target - a.net; d != 0 {
+			a.place(d, c.Close, c.Timestamp)
+		}
+	}
+}
+
+// submit sizes, checks and fills one intent in the sleeve's book.
+func (a *Allocator) submit(s *Sleeve, in types.Intent) error {
+	h := s.Handler
+	state := h.OMS.State()
+	if in.Type == "ENTRY" {
+		if s.halted {
+			return fmt.Errorf("daily loss budget (%.2f) spent", s.maxDailyLoss)
+		}
+		if a.netting == risk.NettingBlock {
+			if other := a.opposing(s, in.Kind); other != nil {
+				return fmt.Errorf("%s holds the opposite side", other.Name)
+			}
+		}
+		in.Quantity = a.size(s, in.Price)
+		if in.Quantity < 1 {
+			return fmt.Errorf("no room in sleeve (equity %.2f)", s.equity())
+		}
+	}
+	if err := h.Risk.Approve(in, state.Open(), state.Position); err != nil {
+		return err
+	}
+	entry := state.Position
+	ev, err := h.OMS.Submit(in)
+	if err != nil {
+		return err
+	}
+
+	qty := 0.0
+	if ev.Type == "ENTRY" {
+		qty = state.Position.Quantity
+		if ev.Kind == "SELL" {
+			qty = -qty
+		}
+	} else if entry != nil {
+		pnl := entry.Profit * entry.Quantity
+		s.realized += pnl
+		s.dayPnL += pnl
+		if s.maxDailyLoss > 0 && s.dayPnL <= -s.maxDailyLoss && !s.halted {
+			s.halted = true
+			log.Printf("executor: %s: daily loss %.2f reached budget %.2f, no more entries today", s.Name, -s.dayPnL, s.maxDailyLoss)
+		}
+		qty = -entry.Quantity
+		if ev.Kind == "SELL" {
+			qty = entry.Quantity
+		}
+	}
+	if a.netting != risk.NettingNet {
+		a.place(qty, ev.EntryPrice, ev.Timestamp)
+	}
+
+	ev.Strategy = s.Name
+	if ev.Type == "ENTRY" {
+		ctx := types.EntryContext{}
+		if ev.Context != nil {
+			ctx = *ev.Context
+		}
+		ctx.Strategy = s.Name
+		ev.Context = &ctx
+	}
+	if a.events != nil {
+		a.events <- ev
+	}
+	return nil
+}
+
+// size returns the entry quantity for s at price: within the sleeve's caps
+// on its own equity, and within the account's total exposure cap on the
+// summed equity and exposure of every sleeve.
+func (a *Allocator) size(s *Sleeve, price float64) float64 {
+	var equity, exposure float64
+	for _, o := range a.sleeves {
+		equity += o.equity()
+		exposure += o.exposure()
+	}
+	account := risk.PositionSizing{MaxSymbolExposure: s.sizing.MaxTotalExposure, MaxTotalExposure: s.sizing.MaxTotalExposure}
+	return math.Min(s.sizing.Size(price, s.equity(), s.exposure(), s.exposure()), account.Size(price, equity, exposure, exposure))
+}
+
+// opposing returns a sleeve other than s holding the side opposite kind.
+func (a *Allocator) opposing(s *Sleeve, kind string) *Sleeve {
+	for _, o := range a.sleeves {
+		if p := o.Handler.OMS.State().Position; o != s && p != nil && p.Kind != kind {
+			return o
+		}
+	}
+	return nil
+}
+
+// place sends a signed quantity (buy > 0) to the account.
+func (a *Allocator) place(qty, price float64, t time.Time) {
+	if qty == 0 {
+		return
+	}
+	side := "BUY"
+	if qty < 0 {
+		side = "SELL"
+	}
+	a.nextID++
+	o := types.Order{
+		ID:        a.nextID,
+		Symbol:    a.symbol,
+		Side:      side,
+		Price:     price,
+		Quantity:  math.Abs(qty),
+		Status:    "NEW",
+		CreatedAt: t,
+		UpdatedAt: t,
+	}
+	if err := a.account.Place(&o); err != nil {
+		log.Printf("executor: account %s %.0f @ %.2f: %v", side, o.Quantity, price, err)
+	}
+	a.orders = append(a.orders, o)
+	if o.Status == "FILLED" {
+		a.net += qty
+	}
+}
+
+// Replay feeds rows [from, Len) through the allocator in order.
+func (a *Allocator) Replay(feed *FrameFeed, from int) {
+	for i := from; i < feed.Len(); i++ {
+		if c, feat, ok := feed.Bar(i); ok {
+			a.OnBar(c, feat)
+		}
+	}
+}
+
+// ── Sleeve book ─────────────────────────────────────────────────────────────
+
+func (s *Sleeve) signedQty() float64 {
+	p := s.Handler.OMS.State().Position
+	if p == nil {
+		return 0
+	}
+	if p.Kind == "SELL" {
+		return -p.Quantity
+	}
+	return p.Quantity
+}
+
+func (s *Sleeve) side() int {
+	switch q := s.signedQty(); {
+	case q > 0:
+		return 1
+	case q < 0:
+		return -1
+	}
+	return 0
+}
+
+func (s *Sleeve) exposure() float64 {
+	if p := s.Handler.OMS.State().Position; p != nil {
+		return p.Quantity * s.last
+	}
+	return 0
+}
+
+func (s *Sleeve) equity() float64 {
+	e := s.capital + s.realized
+	if p := s.Handler.OMS.State().Position; p != nil {
+		e += p.Profit * p.Quantity
+	}
+	return e
+}
+
+// sleeveBook is the on-disk form of a sleeve's accounting, saved with its
+// strategy state.
+type sleeveBook struct {
+	Last     float64 `json:"last"`
+	Realized float64 `json:"realized"`
+	Day      string  `json:"day"`
+	DayPnL   float64 `json:"dayPnl"`
+	Halted   bool    `json:"halted"`
+	Rejected int     `json:"rejected"`
+}
+
+func (s *Sleeve) saveBook() (json.RawMessage, error) {
+	return json.Marshal(sleeveBook{s.last, s.realized, s.day, s.dayPnL, s.halted, s.rejected})
+}
+
+func (s *Sleeve) loadBook(b json.RawMessage) error {
+	var bk sleeveBook
+	if err := json.Unmarshal(b, &bk); err != nil {
+		return fmt.Errorf("sleeve book: %w", err)
+	}
+	s.last, s.realized, s.day, s.dayPnL, s.halted, s.rejected = bk.Last, bk.Realized, bk.Day, bk.DayPnL, bk.Halted, bk.Rejected
+	return nil
+}
+
+// ── JSON ────────────────────────────────────────────────────────────────────
+
+// Summary returns the account position, the account orders and every
+// sleeve's book.
+func (a *Allocator) Summary() map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sleeves := make([]map[string]interface{}, 0, len(a.sleeves))
+	for _, s := range a.sleeves {
+		row := map[string]interface{}{
+			"strategy":     s.Name,
+			"weight":       s.Weight,
+			"capital":      s.capital,
+			"equity":       s.equity(),
+			"realized":     s.realized,
+			"dayPnl":       s.dayPnL,
+			"halted":       s.halted,
+			"rejected":     s.rejected,
+			"position":     sideStr(s.side()),
+			"quantity":     math.Abs(s.signedQty()),
+			"exposure":     s.exposure(),
+			"maxDailyLoss": s.maxDailyLoss,
+		}
+		if p := s.Handler.OMS.State().Position; p != nil {
+			row["entryPrice"] = p.EntryPrice
+			row["entryTime"] = p.EntryTime
+		}
+		sleeves = append(sleeves, row)
+	}
+	return map[string]interface{}{
+		"symbol":  a.symbol,
+		"netting": a.netting,
+		"capital": a.capital,
+		"net":     a.net,
+		"orders":  len(a.orders),
+		"sleeves": sleeves,
+	}
+}
@@ -0,0 +1,197 @@
+package executor
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hft/internal/risk"
+	"hft/internal/strategy"
+	"hft/internal/testutil"
+	"hft/pkg/types"
+)
+
+// script is a BarStrategy that sends fixed intents on fixed bars.
+type script struct {
+	Bar   int
+	Pos   int
+	moves map[int][]types.Intent
+}
+
+func (s *script) OnBar(c types.Candle, f strategy.Features) []types.Intent {
+	out := s.moves[s.Bar]
+	s.Bar++
+	for i := range out {
+		out[i].Price, out[i].Timestamp = c.Close, c.Timestamp
+		switch {
+		case out[i].Type == "EXIT":
+			s.Pos = 0
+		case out[i].Kind == "BUY":
+			s.Pos = 1
+		default:
+			s.Pos = -1
+		}
+	}
+	return out
+}
+
+func (s *script) Position() int              { return s.Pos }
+func (s *script) Reject()                    { s.Pos = 0 }
+func (s *script) Snapshot() ([]byte, error)  { return json.Marshal(s) }
+func (s *script) Restore(state []byte) error { return json.Unmarshal(state, s) }
+
+func entry(kind string) types.Intent { return types.Intent{Type: "ENTRY", Kind: kind} }
+func exit(kind string) types.Intent  { return types.Intent{Type: "EXIT", Kind: kind} }
+
+// newTestAllocator splits 1,000,000 evenly between two sleeves that follow
+// the scripts a and b.
+func newTestAllocator(t *testing.T, netting string, lossPct float64, a, b map[int][]types.Intent) *Allocator {
+	t.Helper()
+	alloc := risk.Allocation{Capital: 1_000_000, Netting: netting, Sleeves: []risk.Sleeve{
+		{Strategy: "orb", Weight: 0.5, MaxSymbolExposure: 0.5, MaxDailyLossPct: lossPct},
+		{Strategy: "mean_reversion", Weight: 0.5, MaxSymbolExposure: 0.5, MaxDailyLossPct: lossPct},
+	}}
+	al, err := NewAllocator("nifty", alloc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	al.sleeves[0].Handler.Strategy = &script{moves: a}
+	al.sleeves[1].Handler.Strategy = &script{moves: b}
+	return al
+}
+
+// run feeds one bar per price, a minute apart from 09:15 on day.
+func run(al *Allocator, day int, prices ...float64) {
+	start := time.Date(2025, 6, 2+day, 9, 15, 0, 0, testutil.IST)
+	for i, p := range prices {
+		ts := start.Add(time.Duration(i) * time.Minute)
+		al.OnBar(types.Candle{Symbol: "nifty", Timestamp: ts, Open: p, High: p, Low: p, Close: p}, nil)
+	}
+}
+
+func TestAllocatorNetting(t *testing.T) {
+	// On bar 0 the sleeves open opposite sides, on bar 1 the long one exits.
+	long := map[int][]types.Intent{0: {entry("BUY")}, 1: {exit("BUY")}}
+	short := map[int][]types.Intent{0: {entry("SELL")}}
+
+	for _, tc := range []struct {
+		netting string
+		orders  int
+		net     float64
+		side    int // of the second sleeve
+	}{
+		// Opposite entries cross inside the account; only the exit trades.
+		{risk.NettingNet, 1, -2500, -1},
+		// The short entry is refused while the long is open.
+		{risk.NettingBlock, 2, 0, 0},
+		// Every sleeve order reaches the account.
+		{risk.NettingHedge, 3, -2500, -1},
+	} {
+		al := newTestAllocator(t, tc.netting, 0, long, short)
+		run(al, 0, 100, 100)
+		if len(al.orders) != tc.orders || al.net != tc.net {
+			t.Errorf("%s: %d account orders, net %g; want %d, %g", tc.netting, len(al.orders), al.net, tc.orders, tc.net)
+		}
+		if s := al.sleeves[1]; s.side() != tc.side {
+			t.Errorf("%s: second sleeve side %d, want %d", tc.netting, s.side(), tc.side)
+		}
+		if al.sleeves[0].side() != 0 {
+			t.Errorf("%s: first sleeve still open", tc.netting)
+		}
+	}
+
+	al := newTestAllocator(t, risk.NettingBlock, 0, long, short)
+	run(al, 0, 100, 100)
+	if r := al.sleeves[1].rejected; r != 1 {
+		t.Fatalf("block: %d rejected entries, want 1", r)
+	}
+}
+
+func TestAllocatorSizesOnAccountExposure(t *testing.T) {
+	limits := risk.ActiveLimits
+	risk.ActiveLimits.MaxTotalExposure = 0.5
+	defer func() { risk.ActiveLimits = limits }()
+
+	// The short sleeve opens 2500 at 100; at 150 it holds 375,000 of
+	// exposure on 375,000 of equity, which leaves the account (875,000 of
+	// equity, half of it usable) 62,500 for the long sleeve.
+	al := newTestAllocator(t, risk.NettingHedge, 0,
+		map[int][]types.Intent{0: {entry("SELL")}},
+		map[int][]types.Intent{1: {entry("BUY")}})
+	run(al, 0, 100, 150)
+
+	if q := al.sleeves[0].signedQty(); q != -2500 {
+		t.Fatalf("short sleeve %g, want -2500", q)
+	}
+	if q := al.sleeves[1].signedQty(); q != 416 {
+		t.Fatalf("long sleeve %g, want 416 (the account's room, not the sleeve's 1666)", q)
+	}
+}
+
+func TestAllocatorDailyLossHalt(t *testing.T) {
+	// 1% of 500,000 is a 5,000 budget; 2500 units losing 3 spend it.
+	al := newTestAllocator(t, risk.NettingNet, 1,
+		map[int][]types.Intent{0: {entry("BUY")}, 1: {exit("BUY")}, 2: {entry("BUY")}, 3: {entry("BUY")}},
+		nil)
+	run(al, 0, 100, 97, 97)
+
+	s := al.sleeves[0]
+	if !s.halted || s.dayPnL != -7500 || s.rejected != 1 || s.side() != 0 {
+		t.Fatalf("halted %v, day PnL %g, rejected %d, side %d", s.halted, s.dayPnL, s.rejected, s.side())
+	}
+	if al.sleeves[1].halted {
+		t.Fatal("the other sleeve was halted")
+	}
+
+	// The budget resets the next day.
+	run(al, 1, 97)
+	if s.halted || s.dayPnL != 0 || s.side() != 1 {
+		t.Fatalf("next day: halted %v, day PnL %g, side %d", s.halted, s.dayPnL, s.side())
+	}
+}
+
+func TestAllocatorRestoresSleeves(t *testing.T) {
+	dir := t.TempDir()
+	long := map[int][]types.Intent{0: {entry("BUY")}, 1: {exit("BUY")}, 2: {entry("BUY")}, 3: {exit("BUY")}}
+	al := newTestAllocator(t, risk.NettingNet, 1, long, nil)
+	for _, s := range al.sleeves {
+		s.Handler.StatePath = filepath.Join(dir, s.Name+".json")
+	}
+	run(al, 0, 100, 97, 97) // halted after the loss, second entry rejected
+
+	re := newTestAllocator(t, risk.NettingNet, 1, long, nil)
+	for _, s := range re.sleeves {
+		if _, err := s.Handler.LoadState(filepath.Join(dir, s.Name+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	re.Resume()
+
+	s := re.sleeves[0]
+	if s.realized != -7500 || s.dayPnL != -7500 || !s.halted || s.rejected != 1 || s.last != 97 {
+		t.Fatalf("restored book: realized %g, day PnL %g, halted %v, rejected %d, last %g", s.realized, s.dayPnL, s.halted, s.rejected, s.last)
+	}
+
+	// A sleeve restored in a position exits it and the account follows.
+	al = newTestAllocator(t, risk.NettingNet, 0, long, nil)
+	for _, s := range al.sleeves {
+		s.Handler.StatePath = filepath.Join(dir, "open-"+s.Name+".json")
+	}
+	run(al, 0, 100)
+	re = newTestAllocator(t, risk.NettingNet, 0, long, nil)
+	for _, s := range re.sleeves {
+		if _, err := s.Handler.LoadState(filepath.Join(dir, "open-"+s.Name+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	re.Resume()
+	if re.net != 2500 || re.sleeves[0].signedQty() != 2500 {
+		t.Fatalf("restored net %g, sleeve %g; want 2500", re.net, re.sleeves[0].signedQty())
+	}
+	run(re, 0, 100, 101)
+	s = re.sleeves[0]
+	if s.side() != 0 || s.realized != 2500 || re.net != 0 || len(re.orders) != 1 {
+		t.Fatalf("after exit: side %d, realized %g, net %g, %d account orders", s.side(), s.realized, re.net, len(re.orders))
+	}
+}
@@ -0,0 +1,278 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hft/internal/indicators"
+	"hft/internal/oms"
+	"hft/internal/risk"
+	"hft/internal/strategy"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+// BarHandler pushes closed bars through strategy → risk → OMS. The live
+// executor and the event-driven backtest share it, so both take exactly the
+// same decisions for the same bars.
+type BarHandler struct {
+	Strategy strategy.BarStrategy
+	Risk     *risk.Manager
+	OMS      *oms.OrderManager
+
+	// StatePath, when set, is where the strategy state is saved after every
+	// bar so a restarted executor can resume it (see LoadState).
+	StatePath string
+
+	symbol  string
+	lastBar time.Time
+	book    book // extra accounting saved with the state, e.g. a sleeve's
+
+	traces  *strategy.TraceBuffer
+	traceAs string
+}
+
+// NewBarHandler wires the regime strategy to a paper-broker OMS that
+// publishes fills on events.
+func NewBarHandler(symbol string, cfg *strategy.RegimeSignalConfig, events chan<- *types.Event) *BarHandler {
+	return NewStrategyHandler(symbol, strategy.NewRegimeBar(cfg), events)
+}
+
+// NewStrategyHandler wires any per-bar strategy to a paper-broker OMS that
+// publishes fills on events.
+func NewStrategyHandler(symbol string, s strategy.BarStrategy, events chan<- *types.Event) *BarHandler {
+	return &BarHandler{
+		Strategy: s,
+		Risk:     risk.NewManager(),
+		OMS:      oms.NewOrderManager(symbol, &oms.PaperBroker{}, events),
+		symbol:   symbol,
+	}
+}
+
+// OnBar processes one closed bar.
+func (h *BarHandler) OnBar(c types.Candle, f strategy.Features) {
+	state := h.OMS.State()
+	h.OMS.Mark(c.Close)
+
+	for _, in := range h.Strategy.OnBar(c, f) {
+		err := h.Risk.Approve(in, state.Open(), state.Position)
+		if err == nil {
+			_, err = h.OMS.Submit(in)
+		}
+		if err != nil {
+			log.Printf("executor: %s %s @ %.2f rejected: %v", in.Type, in.Kind, in.Price, err)
+			if in.Type == "ENTRY" {
+				h.Strategy.Reject()
+			}
+		}
+	}
+
+	h.done(c)
+}
+
+// TraceTo turns on decision traces when the strategy can record them, and
+// appends each bar's trace to buf under the strategy name name.
+func (h *BarHandler) TraceTo(buf *strategy.TraceBuffer, name string) {
+	t, ok := h.Strategy.(strategy.Tracer)
+	if !ok {
+		return
+	}
+	t.SetTracing(true)
+	h.traces, h.traceAs = buf, name
+}
+
+// done records c as the last bar seen, keeps its decision trace and saves
+// the strategy state.
+func (h *BarHandler) done(c types.Candle) {
+	h.lastBar = c.Timestamp
+	if h.traces != nil {
+		if t, ok := h.Strategy.(strategy.Tracer); ok && t.LastTrace() != nil {
+			tr := *t.LastTrace()
+			tr.Strategy = h.traceAs
+			h.traces.Add(tr)
+		}
+	}
+	if h.StatePath != "" {
+		if err := h.SaveState(h.StatePath); err != nil {
+			log.Printf("executor: save strategy state: %v", err)
+		}
+	}
+}
+
+// ── Strategy state persistence ──────────────────────────────────────────────
+
+// handlerState is the on-disk form of a handler's strategy state.
+type handlerState struct {
+	Symbol   string          `json:"symbol"`
+	LastBar  time.Time       `json:"lastBar"`
+	State    json.RawMessage `json:"state"`
+	Position *types.Position `json:"position,omitempty"` // open OMS position
+	Book     json.RawMessage `json:"book,omitempty"`
+}
+
+// book is accounting kept next to a handler (see Sleeve) that must survive
+// a restart with its strategy state.
+type book interface {
+	saveBook() (json.RawMessage, error)
+	loadBook(json.RawMessage) error
+}
+
+// SaveState writes the strategy's snapshot, the open OMS position and the
+// last bar it saw to path.
+func (h *BarHandler) SaveState(path string) error {
+	state, err := h.Strategy.Snapshot()
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	st := handlerState{Symbol: h.symbol, LastBar: h.lastBar, State: state, Position: h.OMS.State().Position}
+	if h.book != nil {
+		if st.Book, err = h.book.saveBook(); err != nil {
+			return fmt.Errorf("snapshot: %w", err)
+		}
+	}
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write then rename so a crash never leaves a half-written state.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadState restores the strategy, the open OMS position and any book from
+// a file written by SaveState and returns the timestamp of the last bar it
+// had seen.
+func (h *BarHandler) LoadState(path string) (time.Time, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("read strategy state: %w", err)
+	}
+	var st handlerState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return time.Time{}, fmt.Errorf("parse strategy state %s: %w", path, err)
+	}
+	if st.Symbol != h.symbol {
+		return time.Time{}, fmt.Errorf("strategy state %s is for %s, not %s", path, st.Symbol, h.symbol)
+	}
+	if err := h.Strategy.Restore(st.State); err != nil {
+		return time.Time{}, err
+	}
+	if h.book != nil && st.Book != nil {
+		if err := h.book.loadBook(st.Book); err != nil {
+			return time.Time{}, fmt.Errorf("parse strategy state %s: %w", path, err)
+		}
+	}
+	h.OMS.Restore(st.Position)
+	h.lastBar = st.LastBar
+	return st.LastBar, nil
+}
+
+// ── DataFrame bar feed ──────────────────────────────────────────────────────
+
+// FrameFeed reads bars and strategy features row by row from a DataFrame
+// that already has the strategy's indicator (and pred_prob_*) columns.
+type FrameFeed struct {
+	symbol   string
+	tsVals   []*time.Time
+	open     []float64
+	high     []float64
+	low      []float64
+	close    []float64
+	volume   []float64
+	names    []string
+	features [][]float64
+	n        int
+}
+
+// NewFrameFeed resolves the columns once; columns defaults to
+// strategy.RegimeColumns. Missing columns read as zero, which keeps the
+// regime strategy flat.
+func NewFrameFeed(symbol string, df *_df_.DataFrame, columns ...string) *FrameFeed {
+	if len(columns) == 0 {
+		columns = strategy.RegimeColumns
+	}
+	n := df.NRows()
+	col := func(name string) []float64 {
+		if idx := indicators.FindIndexOf(df, name); idx >= 0 {
+			if s, ok := df.Series[idx].(*_df_.SeriesFloat64); ok {
+				return s.Values
+			}
+		}
+		return make([]float64, n)
+	}
+	f := &FrameFeed{
+		symbol: symbol,
+		open:   col("open"),
+		high:   col("high"),
+		low:    col("low"),
+		close:  col("close"),
+		volume: col("volume"),
+		names:  columns,
+		n:      n,
+	}
+	for _, name := range columns {
+		f.features = append(f.features, col(name))
+	}
+	if idx := indicators.FindIndexOf(df, "timestamp"); idx >= 0 {
+		f.tsVals = df.Series[idx].(*_df_.SeriesTime).Values
+	}
+	return f
+}
+
+// Len returns the number of rows in the feed.
+func (f *FrameFeed) Len() int {
+	return f.n
+}
+
+// Bar returns row i as a candle plus its features. ok is false for rows
+// without a timestamp.
+func (f *FrameFeed) Bar(i int) (c types.Candle, feat strategy.Features, ok bool) {
+	if f.tsVals == nil || f.tsVals[i] == nil {
+		return c, feat, false
+	}
+	c = types.Candle{
+		Symbol:    f.symbol,
+		Timestamp: *f.tsVals[i],
+		Open:      f.open[i],
+		High:      f.high[i],
+		Low:       f.low[i],
+		Close:     f.close[i],
+		Volume:    f.volume[i],
+	}
+	feat = make(strategy.Features, len(f.names))
+	for k, name := range f.names {
+		feat[name] = f.features[k][i]
+	}
+	return c, feat, true
+}
+
+// After returns the index of the first row stamped after t, or Len when
+// there is none.
+func (f *FrameFeed) After(t time.Time) int {
+	for i := 0; i < f.n; i++ {
+		if f.tsVals != nil && f.tsVals[i] != nil && f.tsVals[i].After(t) {
+			return i
+		}
+	}
+	return f.n
+}
+
+// Replay feeds rows [from, Len) through the handler in order.
+func (h *BarHandler) Replay(feed *FrameFeed, from int) {
+	for i := from; i < feed.Len(); i++ {
+		if c, feat, ok := feed.Bar(i); ok {
+			h.OnBar(c, feat)
+		}
+	}
+}
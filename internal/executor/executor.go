@@ -1,13 +1,20 @@
 package executor
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"hft/internal/brokers"
 	"hft/internal/dataframe"
 	"hft/internal/ml_model"
+	"hft/internal/risk"
 	"hft/internal/strategy"
+	"hft/internal/timeframe"
 	"hft/pkg/types"
 
 	_df_ "github.com/rocketlaunchr/dataframe-go"
@@ -22,8 +29,32 @@ type Executor struct {
 	Events    chan *types.Event
 	TradeDF   *_df_.DataFrame
 	LogEvents chan *types.LogEvent
+	Handler   *BarHandler
+	Allocator *Allocator // set instead of Handler when an allocation is configured
 }
 
+// StateDir is where the live executor keeps each strategy's saved state:
+// <strategy>.json for a single strategy, alloc_<sleeve>.json for the
+// sleeves of an allocation, so a sleeve never resumes from the single
+// strategy's file or overwrites it.
+var StateDir = "tmp/strategy_state"
+
+// sleeveStatePath is the state file of the allocation sleeve called name.
+func sleeveStatePath(name string) string {
+	return filepath.Join(StateDir, "alloc_"+name+".json")
+}
+
+// TraceCapacity is how many per-bar decision traces LiveTraces and
+// SimTraces keep (about two months of minute bars).
+const TraceCapacity = 25_000
+
+// Decision traces of the live executor (history replay included) and of the
+// latest simulation.
+var (
+	LiveTraces = strategy.NewTraceBuffer(TraceCapacity)
+	SimTraces  = strategy.NewTraceBuffer(TraceCapacity)
+)
+
 // CurrentHFT holds the last connected HFT reference (global for quick access).
 var CurrentHFT *types.HFT
 var Instance *Executor
@@ -47,7 +78,14 @@ type ExecutorStats struct {
 	ExpectancyRatio   float64
 }
 
-var pendingPosition *types.Position
+// Open positions awaiting their exit, keyed by Event.Strategy ("" in
+// single-strategy runs). statsMu guards them and the stats.
+var (
+	statsMu          sync.Mutex
+	pendingPositions map[string]*types.Position
+	pendingContexts  map[string]*types.EntryContext
+	strategyStats    map[string]*ExecutorStats
+)
 
 // NewExecutor constructs an executor configured for the provided mode.
 func NewExecutor(mode string) *Executor {
@@ -85,113 +123,140 @@ func InitExecutor() {
 }
 
 func SubscribeSignals() {
+	statsMu.Lock()
 	stats = &ExecutorStats{}
-	pendingPosition = nil
+	strategyStats = make(map[string]*ExecutorStats)
+	pendingPositions = make(map[string]*types.Position)
+	pendingContexts = make(map[string]*types.EntryContext)
+	statsMu.Unlock()
 
 	for event := range Instance.Events {
-		if event.Type == "ENTRY" {
-			// Store pending position for matching with exit
-			pendingPosition = &types.Position{
-				Kind:       event.Kind,
-				EntryPrice: event.EntryPrice,
-				EntryTime:  event.Timestamp,
-			}
-		} else if event.Type == "EXIT" && pendingPosition != nil {
-			pendingPosition.PeakProfit = event.PeakProfit
-			pendingPosition.PeakLoss = event.PeakLoss
-
-			// Calculate profit for the completed trade
-			var profit float64
-			if pendingPosition.Kind == "BUY" {
-				profit = event.EntryPrice - pendingPosition.EntryPrice
-			} else { // SELL/SHORT
-				profit = pendingPosition.EntryPrice - event.EntryPrice
-			}
-
-			// Calculate profit percentage
-			var profitPct float64
-			if pendingPosition.EntryPrice != 0 {
-				profitPct = (profit / pendingPosition.EntryPrice) * 100
-			}
-
-			// Append trade to TradeDF
-			dataframe.AppendTrade(
-				Instance.TradeDF,
-				pendingPosition.EntryPrice,
-				event.EntryPrice,
-				pendingPosition.EntryTime,
-				event.Timestamp,
-				profit,
-				profitPct,
-				pendingPosition.Kind,
-				event.Reason,
-				pendingPosition.PeakProfit,
-				pendingPosition.PeakLoss,
-			)
-
-			// Update statistics
-			stats.TotalTrades++
-			stats.NetProfit += profit
-
-			if profit > 0 {
-				stats.WinningTrades++
-				stats.GrossProfit += profit
-			} else if profit < 0 {
-				stats.LosingTrades++
-				stats.GrossLoss += profit // negative value
-			} else {
-				stats.BreakevenTrades++
-			}
-
-			// Track max profit and drawdown
-			if stats.NetProfit > stats.PeakProfit {
-				stats.PeakProfit = stats.NetProfit
-			}
-			drawdown := stats.PeakProfit - stats.NetProfit
-			if drawdown > stats.MaxDrawdown {
-				stats.MaxDrawdown = drawdown
-			}
-			if profit > stats.MaxProfit {
-				stats.MaxProfit = profit
-			}
-
-			// Track exit reasons
-			switch event.Reason {
-			case "PROFIT_TARGET":
-				stats.ProfitTargetExits++
-			case "STOP_LOSS":
-				stats.StopLossExits++
-			case "TRAILING_STOP":
-				stats.TrailingStopExits++
-			case "SIGNAL":
-				stats.SignalExits++
-			}
-
-			// Calculate Expectancy Ratio
-			// Expectancy = (P_w × A_w) - (P_l × A_l)
-			// Where P_l = 1 - P_w
-			if stats.TotalTrades > 0 {
-				winRate := float64(stats.WinningTrades) / float64(stats.TotalTrades)
-				lossRate := 1 - winRate // P_l = 1 - P_w
-
-				var avgWin, avgLoss float64
-				if stats.WinningTrades > 0 {
-					avgWin = stats.GrossProfit / float64(stats.WinningTrades)
-				}
-				if stats.LosingTrades > 0 {
-					avgLoss = -stats.GrossLoss / float64(stats.LosingTrades) // GrossLoss is negative
-				}
-
-				stats.ExpectancyRatio = (winRate * avgWin) - (lossRate * avgLoss)
-			}
-
-			// Log trade summary
-			// log.Printf("backtest: trade closed | %s | entry: %.2f | exit: %.2f | profit: %.2f pts | reason: %s",
-			// 	pendingPosition.Kind, pendingPosition.EntryPrice, event.EntryPrice, profit, event.Reason)
-
-			// Reset pending position
-			pendingPosition = nil
+		statsMu.Lock()
+		onEvent(event)
+		statsMu.Unlock()
+	}
+}
+
+// onEvent books one event. Entries and exits are matched per strategy, so
+// strategies run by the allocator keep separate trades. Callers hold statsMu.
+func onEvent(event *types.Event) {
+	key := event.Strategy
+	if event.Type == "ENTRY" {
+		// Store pending position for matching with exit
+		pendingPositions[key] = &types.Position{
+			Kind:       event.Kind,
+			EntryPrice: event.EntryPrice,
+			EntryTime:  event.Timestamp,
+		}
+		pendingContexts[key] = event.Context
+		return
+	}
+	pendingPosition := pendingPositions[key]
+	if event.Type != "EXIT" || pendingPosition == nil {
+		return
+	}
+	pendingPosition.PeakProfit = event.PeakProfit
+	pendingPosition.PeakLoss = event.PeakLoss
+
+	// Calculate profit for the completed trade
+	var profit float64
+	if pendingPosition.Kind == "BUY" {
+		profit = event.EntryPrice - pendingPosition.EntryPrice
+	} else { // SELL/SHORT
+		profit = pendingPosition.EntryPrice - event.EntryPrice
+	}
+
+	// Calculate profit percentage
+	var profitPct float64
+	if pendingPosition.EntryPrice != 0 {
+		profitPct = (profit / pendingPosition.EntryPrice) * 100
+	}
+
+	// Append trade to TradeDF
+	dataframe.AppendTrade(
+		Instance.TradeDF,
+		pendingPosition.EntryPrice,
+		event.EntryPrice,
+		pendingPosition.EntryTime,
+		event.Timestamp,
+		profit,
+		profitPct,
+		pendingPosition.Kind,
+		event.Reason,
+		pendingPosition.PeakProfit,
+		pendingPosition.PeakLoss,
+		pendingContexts[key],
+	)
+
+	// Update statistics, overall and for the event's strategy.
+	stats.record(profit, event.Reason)
+	if key != "" {
+		if strategyStats[key] == nil {
+			strategyStats[key] = &ExecutorStats{}
+		}
+		strategyStats[key].record(profit, event.Reason)
+	}
+
+	// Reset pending position
+	delete(pendingPositions, key)
+	delete(pendingContexts, key)
+}
+
+// record adds one closed trade of profit points, closed for reason.
+func (stats *ExecutorStats) record(profit float64, reason string) {
+	stats.TotalTrades++
+	stats.NetProfit += profit
+
+	if profit > 0 {
+		stats.WinningTrades++
+		stats.GrossProfit += profit
+	} else if profit < 0 {
+		stats.LosingTrades++
+		stats.GrossLoss += profit // negative value
+	} else {
+		stats.BreakevenTrades++
+	}
+
+	// Track max profit and drawdown
+	if stats.NetProfit > stats.PeakProfit {
+		stats.PeakProfit = stats.NetProfit
+	}
+	drawdown := stats.PeakProfit - stats.NetProfit
+	if drawdown > stats.MaxDrawdown {
+		stats.MaxDrawdown = drawdown
+	}
+	if profit > stats.MaxProfit {
+		stats.MaxProfit = profit
+	}
+
+	// Track exit reasons
+	switch reason {
+	case "PROFIT_TARGET":
+		stats.ProfitTargetExits++
+	case "STOP_LOSS":
+		stats.StopLossExits++
+	case "TRAILING_STOP":
+		stats.TrailingStopExits++
+	case "SIGNAL":
+		stats.SignalExits++
+	}
+
+	// Calculate Expectancy Ratio
+	// Expectancy = (P_w × A_w) - (P_l × A_l)
+	// Where P_l = 1 - P_w
+	if stats.TotalTrades > 0 {
+		winRate := float64(stats.WinningTrades) / float64(stats.TotalTrades)
+		lossRate := 1 - winRate // P_l = 1 - P_w
+
+		var avgWin, avgLoss float64
+		if stats.WinningTrades > 0 {
+			avgWin = stats.GrossProfit / float64(stats.WinningTrades)
+		}
+		if stats.LosingTrades > 0 {
+			avgLoss = -stats.GrossLoss / float64(stats.LosingTrades) // GrossLoss is negative
 		}
+
+		stats.ExpectancyRatio = (winRate * avgWin) - (lossRate * avgLoss)
 	}
 }
 
@@ -231,24 +296,125 @@ func (e *Executor) Run() {
 	if len(ticks) == 0 {
 		log.Printf("executor: no history loaded — skipping strategy run (check Fyers auth and date range)")
 	}
+	ticks = timeframe.Resample(ticks, strategy.ActiveTimeframe)
 
 	dataframe.LoadHistoryLive(e.DF, ticks)
-	strategy.RunKalmanv2(e.DF, e.LogEvents)
-
-	// Run regime model predictions (adds pred_prob_* columns to df).
-	if p := ml_model.GetPredictor(); p != nil {
-		if err := p.PredictRegimeFromDFStrided(e.DF, 1); err != nil {
-			log.Printf("executor: predict regime: %v", err)
+	if risk.ActiveAllocation.Enabled() {
+		if err := e.runAllocation(symbol); err != nil {
+			log.Printf("executor: %v", err)
+			return
 		}
-	} else {
-		log.Printf("executor: predictor not initialized, skipping regime predictions")
+	} else if err := e.runStrategy(symbol); err != nil {
+		log.Printf("executor: %v", err)
+		return
 	}
 
-	strategy.FindRegimeSignal(e.DF, Instance.Position, Instance.Positions, Instance.Events)
-
 	close(Instance.Events)
 	// Give subscriber time to process final events and print summary
 	time.Sleep(100 * time.Millisecond)
 	// TODO: implement live/backtest execution behavior.
 	select {} // block to simulate a long-lived executor loop
 }
+
+// runStrategy replays history through the single configured strategy.
+func (e *Executor) runStrategy(symbol string) error {
+	strat, err := strategy.Get("")
+	if err != nil {
+		return err
+	}
+	if err := strategy.CheckTimeframe(strat, strategy.ActiveTimeframe); err != nil {
+		return err
+	}
+	e.Log("running strategy " + strat.Name())
+	strat.Indicators(e.DF, e.LogEvents)
+	e.predictRegime(strat.NeedsRegime())
+
+	// Replay history bar by bar through the same handler new bars will use.
+	// A saved state resumes the strategy where the last run stopped; only
+	// the bars after it are replayed.
+	if bar := strat.NewBar(); bar != nil {
+		e.Handler = NewStrategyHandler(symbol, bar, Instance.Events)
+		feed := NewFrameFeed(symbol, e.DF, strat.Columns()...)
+		statePath := filepath.Join(StateDir, strat.Name()+".json")
+		from := 0
+		if last, err := e.Handler.LoadState(statePath); err == nil {
+			from = feed.After(last)
+			log.Printf("executor: restored %s state at %s, replaying %d bars", strat.Name(), last.Format(time.RFC3339), feed.Len()-from)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("executor: %v — replaying full history", err)
+			e.Handler = NewStrategyHandler(symbol, strat.NewBar(), Instance.Events)
+		}
+		e.Handler.TraceTo(LiveTraces, strat.Name())
+		e.Handler.Replay(feed, from)
+		e.Handler.StatePath = statePath
+		if err := e.Handler.SaveState(statePath); err != nil {
+			log.Printf("executor: save strategy state: %v", err)
+		}
+	} else {
+		log.Printf("executor: strategy %s has no per-bar form, not trading", strat.Name())
+	}
+	return nil
+}
+
+// runAllocation replays history through every sleeve of the configured
+// allocation. Each sleeve resumes from its own saved state.
+func (e *Executor) runAllocation(symbol string) error {
+	a, err := NewAllocator(symbol, risk.ActiveAllocation, Instance.Events)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(a.Sleeves()))
+	for _, s := range a.Sleeves() {
+		if err := strategy.CheckTimeframe(s.Strategy, strategy.ActiveTimeframe); err != nil {
+			return err
+		}
+		names = append(names, s.Name)
+	}
+	e.Allocator = a
+	e.Log(fmt.Sprintf("running strategies %v (netting=%s)", names, risk.ActiveAllocation.Netting))
+	a.Indicators(e.DF, e.LogEvents)
+	e.predictRegime(a.NeedsRegime())
+
+	feed := NewFrameFeed(symbol, e.DF, a.Columns()...)
+	from := feed.Len()
+	for _, s := range a.Sleeves() {
+		statePath := sleeveStatePath(s.Name)
+		start := 0
+		if last, err := s.Handler.LoadState(statePath); err == nil {
+			start = feed.After(last)
+			log.Printf("executor: restored %s state at %s", s.Name, last.Format(time.RFC3339))
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("executor: %v — replaying full history for %s", err, s.Name)
+			s.Handler.Strategy = s.Strategy.NewBar()
+		}
+		if start < from {
+			from = start
+		}
+		s.Handler.TraceTo(LiveTraces, s.Name)
+	}
+	a.Resume()
+	a.Replay(feed, from)
+	for _, s := range a.Sleeves() {
+		s.Handler.StatePath = sleeveStatePath(s.Name)
+		if err := s.Handler.SaveState(s.Handler.StatePath); err != nil {
+			log.Printf("executor: save %s state: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// predictRegime adds the regime model's pred_prob_* columns to e.DF when a
+// strategy needs them.
+func (e *Executor) predictRegime(needed bool) {
+	if !needed {
+		return
+	}
+	p := ml_model.GetPredictor()
+	if p == nil {
+		log.Printf("executor: predictor not initialized, skipping regime predictions")
+		return
+	}
+	if err := p.PredictRegimeFromDFStrided(e.DF, 1); err != nil {
+		log.Printf("executor: predict regime: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hft/internal/featurecache"
+	"hft/internal/indicators"
+	"hft/internal/manifest"
+	"hft/internal/ml_model"
+	"hft/internal/strategy"
+	"hft/internal/timeframe"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+// ComputeFeatures adds s's indicator columns to df, whose bars are of tf,
+// and, when predict is set, the model's pred_* columns (inference every
+// stride rows, smoothed with smoothing or, when nil, the predictor's own
+// hysteresis config). For strategies built on the indicator pipeline the pipeline
+// and prediction columns come from the feature cache when an entry for the
+// same bars, pipeline, model, predictor settings and build exists, and are
+// stored there otherwise. Cache errors are logged and treated as a miss.
+func ComputeFeatures(symbol string, tf timeframe.Timeframe, df *_df_.DataFrame, s strategy.Strategy, predict bool, stride int, smoothing *ml_model.SmoothingConfig, logEvents chan *types.LogEvent) error {
+	ps, cacheable := s.(strategy.PipelineStrategy)
+	cacheable = cacheable && ps.UsesPipeline() && featurecache.Dir != "" && df.NRows() >= 2
+
+	var key featurecache.Key
+	restored := false
+	if cacheable {
+		key = featureKey(symbol, tf, df, stride, smoothing)
+		names, ok, err := featurecache.Load(key, df)
+		if err != nil {
+			log.Printf("featurecache: %s: %v", symbol, err)
+		}
+		if ok {
+			restored = true
+			log.Printf("featurecache: %s: restored %d columns", symbol, len(names))
+		}
+	}
+
+	s.Indicators(df, logEvents)
+
+	predicted := false
+	if predict && indicators.FindIndexOf(df, "pred_prob_bullish") < 0 {
+		start := time.Now()
+		var err error
+		if smoothing != nil {
+			err = ml_model.PredictRegimeFromDFWith(df, stride, *smoothing)
+		} else {
+			err = ml_model.PredictRegimeFromDFStrided(df, stride)
+		}
+		if err != nil {
+			return fmt.Errorf("predict regime: %w", err)
+		}
+		predicted = true
+		log.Printf("features: %s predict regime: %v", symbol, time.Since(start))
+	}
+
+	if cacheable && (!restored || predicted) {
+		names := append(strategy.ActivePipeline.Columns(), ml_model.PredictionColumns...)
+		if err := featurecache.Store(key, df, names); err != nil {
+			log.Printf("featurecache: %s: %v", symbol, err)
+		}
+	}
+	return nil
+}
+
+// featureKey describes everything the cached columns of df depend on.
+func featureKey(symbol string, tf timeframe.Timeframe, df *_df_.DataFrame, stride int, smoothing *ml_model.SmoothingConfig) featurecache.Key {
+	ds := manifest.HashFrame(symbol, df)
+	k := featurecache.Key{
+		Symbol:    symbol,
+		Timeframe: tf.String(),
+		From:      ds.From,
+		To:        ds.To,
+		Data:      ds.SHA256,
+		Pipeline:  strategy.ActivePipeline.Hash(),
+		Source:    buildHash(),
+	}
+	if p := ml_model.GetPredictor(); p != nil {
+		files := manifest.HashModel(p.ModelDir()).Files
+		parts := make([]string, 0, len(files))
+		for name, sum := range files {
+			parts = append(parts, name+"="+sum)
+		}
+		sort.Strings(parts)
+		k.Model = strings.Join(parts, ",")
+		sm := p.Smoothing()
+		if smoothing != nil {
+			sm = *smoothing
+		}
+		k.Predictor = fmt.Sprintf("stride=%d smoothing=%+v", stride, sm)
+	}
+	return k
+}
+
+var (
+	buildOnce sync.Once
+	buildSum  string
+)
+
+// buildHash is the SHA-256 of the running binary, so any change to the
+// indicator or inference code invalidates the cache. It falls back to the
+// git commit when the binary cannot be read.
+func buildHash() string {
+	buildOnce.Do(func() {
+		buildSum = "git:" + manifest.GitInfo().Commit
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return
+		}
+		buildSum = hex.EncodeToString(h.Sum(nil))
+	})
+	return buildSum
+}
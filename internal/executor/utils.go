@@ -2,6 +2,8 @@ package executor
 
 import (
 	"fmt"
+	"sort"
+
 	"hft/internal/indicators"
 )
 
@@ -30,25 +32,50 @@ func ToJSON() []map[string]interface{} {
 	return _json
 }
 
-// PositionToJSON returns the current open position (or nil if flat).
+// PositionToJSON returns the current open position (or nil if flat). When
+// several strategies hold positions, the first by strategy name is returned.
 func PositionToJSON() map[string]interface{} {
-	if Instance == nil || pendingPosition == nil {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	if Instance == nil || len(pendingPositions) == 0 {
 		return nil
 	}
+	keys := make([]string, 0, len(pendingPositions))
+	for k := range pendingPositions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pendingPosition := pendingPositions[keys[0]]
 	return map[string]interface{}{
 		"kind":       pendingPosition.Kind,
 		"entryPrice": pendingPosition.EntryPrice,
 		"entryTime":  pendingPosition.EntryTime,
 		"peakProfit": pendingPosition.PeakProfit,
 		"peakLoss":   pendingPosition.PeakLoss,
+		"strategy":   keys[0],
 	}
 }
 
-// StatsToJSON returns the current executor statistics.
+// StatsToJSON returns the current executor statistics. Allocator runs add
+// the same statistics per strategy under "strategies".
 func StatsToJSON() map[string]interface{} {
+	statsMu.Lock()
+	defer statsMu.Unlock()
 	if stats == nil {
 		return map[string]interface{}{}
 	}
+	out := stats.toJSON()
+	if len(strategyStats) > 0 {
+		per := make(map[string]interface{}, len(strategyStats))
+		for name, s := range strategyStats {
+			per[name] = s.toJSON()
+		}
+		out["strategies"] = per
+	}
+	return out
+}
+
+func (stats *ExecutorStats) toJSON() map[string]interface{} {
 	return map[string]interface{}{
 		"totalTrades":       stats.TotalTrades,
 		"winningTrades":     stats.WinningTrades,
@@ -68,6 +95,15 @@ func StatsToJSON() map[string]interface{} {
 	}
 }
 
+// AllocatorToJSON returns the allocator's account and sleeve books, or nil
+// when a single strategy runs.
+func AllocatorToJSON() map[string]interface{} {
+	if Instance == nil || Instance.Allocator == nil {
+		return nil
+	}
+	return Instance.Allocator.Summary()
+}
+
 func TradesToJSON() []map[string]interface{} {
 	if Instance == nil || Instance.TradeDF == nil {
 		return []map[string]interface{}{}
@@ -87,6 +123,7 @@ func TradesToJSON() []map[string]interface{} {
 			"peakProfit": tradeDF.Series[indicators.FindIndexOf(tradeDF, "peakProfit")].Value(i),
 			"peakLoss":   tradeDF.Series[indicators.FindIndexOf(tradeDF, "peakLoss")].Value(i),
 			"reason":     tradeDF.Series[indicators.FindIndexOf(tradeDF, "reason")].Value(i),
+			"strategy":   tradeDF.Series[indicators.FindIndexOf(tradeDF, "strategy")].Value(i),
 		}
 	}
 	return _json
@@ -0,0 +1,210 @@
+package featurecache
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Computed feature cache.
+
+   Backtests and simulations recompute the indicator pipeline and rerun
+   ONNX inference over the whole history on every run, although both only
+   depend on the bars, the pipeline, the model and the predictor settings.
+   The cache stores the computed columns of a loaded frame per symbol,
+   timeframe and date range:
+
+     <Dir>/<symbol>/<timeframe>_<from>_<to>_<hash>.gob
+
+   where hash covers everything the columns depend on (Key). A run whose
+   key matches restores the columns instead of computing them; any change
+   (a new tick, an edited pipeline spec, retrained model files, another
+   stride or smoothing, a rebuilt binary) gives another hash, so a
+   stale entry is never read, and storing the new entry removes the
+   entries of the same timeframe and range it replaces.
+
+   Indicator values depend on every bar before them, so an entry covers the
+   whole loaded range, warmup included, not single days.
+*/
+
+// Dir is the cache directory; empty disables the cache. Set from config
+// backtest.feature_cache.
+var Dir = "tmp/feature_cache"
+
+// Key identifies the computed columns of one frame. Each field is a hash or
+// a canonical description of one input.
+type Key struct {
+	Symbol    string
+	Timeframe string    // bar timeframe, e.g. "1m", "5m"
+	From, To  time.Time // first and last bar
+	Data      string    // bars (manifest.HashFrame)
+	Pipeline  string    // indicator pipeline (pipeline.Pipeline.Hash)
+	Model     string    // model files
+	Predictor string    // stride and smoothing
+	Source    string    // build of the indicator and inference code
+}
+
+// Hash returns the SHA-256 over every field of k.
+func (k Key) Hash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%d\n%d\n", k.Symbol, k.Timeframe, k.From.Unix(), k.To.Unix())
+	for _, part := range []string{k.Data, k.Pipeline, k.Model, k.Predictor, k.Source} {
+		fmt.Fprintf(h, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rangePrefix is the file name prefix shared by every entry of k's
+// timeframe and range.
+func (k Key) rangePrefix() string {
+	return k.Timeframe + "_" + k.From.Format("20060102T1504") + "_" + k.To.Format("20060102T1504") + "_"
+}
+
+func (k Key) path() string {
+	return filepath.Join(Dir, k.Symbol, k.rangePrefix()+k.Hash()[:16]+".gob")
+}
+
+// ── Entries ──────────────────────────────────────────────────────────────────
+
+type entry struct {
+	Key     string
+	Rows    int
+	Columns []column
+}
+
+// column is a float or string series; Null marks nil string values.
+type column struct {
+	Name    string
+	Floats  []float64
+	Strings []string
+	Null    []bool
+	String  bool
+}
+
+// Load appends the columns cached under k to df and returns their names.
+// ok is false (and df untouched) when there is no entry for k or it does
+// not fit df.
+func Load(k Key, df *dataframe.DataFrame) (names []string, ok bool, err error) {
+	if Dir == "" {
+		return nil, false, nil
+	}
+	f, err := os.Open(k.path())
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("open feature cache: %w", err)
+	}
+	defer f.Close()
+
+	var e entry
+	if err := gob.NewDecoder(f).Decode(&e); err != nil {
+		return nil, false, fmt.Errorf("decode feature cache %s: %w", k.path(), err)
+	}
+	if e.Key != k.Hash() || e.Rows != df.NRows() {
+		return nil, false, nil
+	}
+	have := make(map[string]bool)
+	for _, name := range df.Names() {
+		have[name] = true
+	}
+	for _, c := range e.Columns {
+		if have[c.Name] {
+			return nil, false, fmt.Errorf("feature cache %s: frame already has %s", k.path(), c.Name)
+		}
+	}
+
+	for _, c := range e.Columns {
+		if !c.String {
+			df.AddSeries(dataframe.NewSeriesFloat64(c.Name, nil, c.Floats), nil)
+			names = append(names, c.Name)
+			continue
+		}
+		s := dataframe.NewSeriesString(c.Name, &dataframe.SeriesInit{Capacity: e.Rows})
+		for i, v := range c.Strings {
+			if c.Null[i] {
+				s.Append(nil)
+			} else {
+				s.Append(v)
+			}
+		}
+		df.AddSeries(s, nil)
+		names = append(names, c.Name)
+	}
+	return names, true, nil
+}
+
+// Store writes the named columns of df under k, replacing the other
+// entries of k's symbol, timeframe and date range. Names missing from df are skipped.
+func Store(k Key, df *dataframe.DataFrame, names []string) error {
+	if Dir == "" {
+		return nil
+	}
+	e := entry{Key: k.Hash(), Rows: df.NRows()}
+	for _, name := range names {
+		idx := -1
+		for i, n := range df.Names() {
+			if n == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		switch s := df.Series[idx].(type) {
+		case *dataframe.SeriesFloat64:
+			e.Columns = append(e.Columns, column{Name: name, Floats: s.Values})
+		case *dataframe.SeriesString:
+			n := s.NRows()
+			c := column{Name: name, String: true, Strings: make([]string, n), Null: make([]bool, n)}
+			for i := 0; i < n; i++ {
+				if v, ok := s.Value(i).(string); ok {
+					c.Strings[i] = v
+				} else {
+					c.Null[i] = true
+				}
+			}
+			e.Columns = append(e.Columns, c)
+		default:
+			return fmt.Errorf("feature cache: column %s has unsupported type %T", name, s)
+		}
+	}
+
+	dir := filepath.Join(Dir, k.Symbol)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create feature cache dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("create feature cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(&e); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode feature cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write feature cache entry: %w", err)
+	}
+
+	// Drop the entries this one supersedes.
+	path := k.path()
+	old, _ := filepath.Glob(filepath.Join(dir, k.rangePrefix()+"*.gob"))
+	for _, p := range old {
+		if p != path {
+			os.Remove(p)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write feature cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package featurecache
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+func frame() *dataframe.DataFrame {
+	return dataframe.NewDataFrame(dataframe.NewSeriesFloat64("close", nil, 100.0, 101.0, 102.0))
+}
+
+func testKey() Key {
+	ist := time.FixedZone("IST", 19800)
+	return Key{
+		Symbol:    "nifty",
+		Timeframe: "1m",
+		From:      time.Date(2025, 1, 2, 9, 15, 0, 0, ist),
+		To:        time.Date(2025, 1, 2, 9, 17, 0, 0, ist),
+		Data:      "data",
+		Pipeline:  "pipeline",
+		Model:     "model",
+	}
+}
+
+func TestStoreLoadRoundTrip(t *testing.T) {
+	Dir = t.TempDir()
+	k := testKey()
+
+	src := frame()
+	src.AddSeries(dataframe.NewSeriesFloat64("ema", nil, math.NaN(), 100.5, 101.25), nil)
+	src.AddSeries(dataframe.NewSeriesString("pred_regime", nil, nil, "bullish", "volatile"), nil)
+	if err := Store(k, src, []string{"ema", "pred_regime", "missing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	df := frame()
+	names, ok, err := Load(k, df)
+	if err != nil || !ok {
+		t.Fatalf("load: ok=%v err=%v", ok, err)
+	}
+	if len(names) != 2 || names[0] != "ema" || names[1] != "pred_regime" {
+		t.Fatalf("restored %v", names)
+	}
+	ema := df.Series[1].(*dataframe.SeriesFloat64).Values
+	if !math.IsNaN(ema[0]) || ema[1] != 100.5 || ema[2] != 101.25 {
+		t.Fatalf("ema %v", ema)
+	}
+	regime := df.Series[2]
+	if regime.Value(0) != nil || regime.Value(1) != "bullish" || regime.Value(2) != "volatile" {
+		t.Fatalf("pred_regime %v %v %v", regime.Value(0), regime.Value(1), regime.Value(2))
+	}
+}
+
+func TestLoadMissesOnKeyChange(t *testing.T) {
+	Dir = t.TempDir()
+	k := testKey()
+	src := frame()
+	src.AddSeries(dataframe.NewSeriesFloat64("ema", nil, 1.0, 2.0, 3.0), nil)
+	if err := Store(k, src, []string{"ema"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, change := range []func(k *Key){
+		func(k *Key) { k.Data = "new tick" },
+		func(k *Key) { k.Pipeline = "edited spec" },
+		func(k *Key) { k.Model = "retrained" },
+		func(k *Key) { k.Predictor = "stride=10" },
+	} {
+		other := k
+		change(&other)
+		df := frame()
+		if _, ok, err := Load(other, df); ok || err != nil {
+			t.Fatalf("%+v: ok=%v err=%v", other, ok, err)
+		}
+		if df.NRows() != 3 || len(df.Names()) != 1 {
+			t.Fatalf("frame changed by a miss: %v", df.Names())
+		}
+	}
+}
+
+func TestStoreReplacesSupersededEntry(t *testing.T) {
+	Dir = t.TempDir()
+	k := testKey()
+	src := frame()
+	src.AddSeries(dataframe.NewSeriesFloat64("ema", nil, 1.0, 2.0, 3.0), nil)
+	if err := Store(k, src, []string{"ema"}); err != nil {
+		t.Fatal(err)
+	}
+	k2 := k
+	k2.Model = "retrained"
+	if err := Store(k2, src, []string{"ema"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(Dir, "nifty", "*"))
+	if len(files) != 1 || files[0] != k2.path() {
+		t.Fatalf("cache holds %v, want only %s", files, k2.path())
+	}
+	if _, ok, _ := Load(k2, frame()); !ok {
+		t.Fatal("new entry not loaded")
+	}
+}
+
+// Runs on another timeframe over the same range keep their own entries.
+func TestStoreKeepsOtherTimeframes(t *testing.T) {
+	Dir = t.TempDir()
+	src := frame()
+	src.AddSeries(dataframe.NewSeriesFloat64("ema", nil, 1.0, 2.0, 3.0), nil)
+	k := testKey()
+	k5 := k
+	k5.Timeframe = "5m"
+	for _, key := range []Key{k, k5} {
+		if err := Store(key, src, []string{"ema"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range []Key{k, k5} {
+		if _, ok, err := Load(key, frame()); !ok || err != nil {
+			t.Fatalf("%s entry lost (err %v)", key.Timeframe, err)
+		}
+	}
+}
@@ -1,4 +1,125 @@
 package indicators
 
-// ADX placeholder for average directional index indicator.
-type ADX struct{}
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Wilder's Average Directional Index with the directional indicators.
+
+     up, down = high − prevHigh, prevLow − low
+     +DM      = up   if up > down and up > 0, else 0
+     −DM      = down if down > up and down > 0, else 0
+     TR       = max(high − low, |high − prevClose|, |low − prevClose|)
+
+   TR, +DM and −DM are Wilder-smoothed over period bars (seeded with the
+   mean of the first period values, then avg = (avg·(period−1) + x)/period):
+
+     +DI = 100 · avg(+DM) / avg(TR)      −DI = 100 · avg(−DM) / avg(TR)
+     DX  = 100 · |+DI − −DI| / (+DI + −DI)
+     ADX = Wilder average of DX, seeded with the mean of its first period values
+
+   The DIs are NaN before row period, the ADX before row 2·period − 1
+   (27 for the usual 14). ADX measures trend strength, not direction: above
+   ~25 the market trends, below ~20 it ranges; +DI above −DI says the trend
+   is up.
+*/
+
+// ADXStream is the streaming form of ADX.
+type ADXStream struct {
+	period int
+	n      int
+
+	prevHigh, prevLow, prevClose float64
+	tr, plusDM, minusDM          float64 // sums until row period, then Wilder averages
+	adx                          float64 // DX sum until row 2·period−1, then the ADX
+}
+
+// NewADXStream returns an ADX/DMI stream over period bars.
+func NewADXStream(period int) *ADXStream {
+	return &ADXStream{period: period}
+}
+
+// Update consumes one bar and returns its ADX, +DI and −DI.
+func (s *ADXStream) Update(high, low, close float64) (adx, plusDI, minusDI float64) {
+	i := s.n
+	s.n++
+	defer func() { s.prevHigh, s.prevLow, s.prevClose = high, low, close }()
+	adx, plusDI, minusDI = math.NaN(), math.NaN(), math.NaN()
+	if i == 0 {
+		return
+	}
+
+	up, down := high-s.prevHigh, s.prevLow-low
+	var pdm, mdm float64
+	if up > down && up > 0 {
+		pdm = up
+	}
+	if down > up && down > 0 {
+		mdm = down
+	}
+	tr := math.Max(high-low, math.Max(math.Abs(high-s.prevClose), math.Abs(low-s.prevClose)))
+
+	p := float64(s.period)
+	switch {
+	case i < s.period:
+		s.tr += tr
+		s.plusDM += pdm
+		s.minusDM += mdm
+		return
+	case i == s.period:
+		s.tr = (s.tr + tr) / p
+		s.plusDM = (s.plusDM + pdm) / p
+		s.minusDM = (s.minusDM + mdm) / p
+	default:
+		s.tr = (s.tr*(p-1) + tr) / p
+		s.plusDM = (s.plusDM*(p-1) + pdm) / p
+		s.minusDM = (s.minusDM*(p-1) + mdm) / p
+	}
+
+	plusDI, minusDI = 0, 0
+	if s.tr > 0 {
+		plusDI = 100 * s.plusDM / s.tr
+		minusDI = 100 * s.minusDM / s.tr
+	}
+	dx := 0.0
+	if sum := plusDI + minusDI; sum > 0 {
+		dx = 100 * math.Abs(plusDI-minusDI) / sum
+	}
+
+	switch last := 2*s.period - 1; {
+	case i < last:
+		s.adx += dx
+	case i == last:
+		s.adx = (s.adx + dx) / p
+		adx = s.adx
+	default:
+		s.adx = (s.adx*(p-1) + dx) / p
+		adx = s.adx
+	}
+	return
+}
+
+// ADX appends Wilder's ADX as seriesname and the directional indicators as
+// seriesname+"_plus_di" and seriesname+"_minus_di" (e.g. "adx",
+// "adx_plus_di", "adx_minus_di").
+func ADX(df *dataframe.DataFrame, seriesname string, period int) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	n := len(_close)
+
+	adx := make([]float64, n)
+	plusDI := make([]float64, n)
+	minusDI := make([]float64, n)
+	s := NewADXStream(period)
+	for i := 0; i < n; i++ {
+		adx[i], plusDI[i], minusDI[i] = s.Update(_high[i], _low[i], _close[i])
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, adx), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_plus_di", nil, plusDI), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_minus_di", nil, minusDI), nil)
+}
@@ -0,0 +1,32 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// TestIndicatorsGolden checks the batch ADX; this checks the stream bar by
+// bar against the same reference values, with where its output starts.
+func TestADXStreamMatchesGolden(t *testing.T) {
+	golden := loadGolden(t)
+	df := niftyFrame(t)
+	h, l, c := values(df, "high"), values(df, "low"), values(df, "close")
+
+	for period, name := range map[int]string{14: "adx", 7: "adx7"} {
+		n := len(c)
+		adx, plus, minus := make([]float64, n), make([]float64, n), make([]float64, n)
+		s := NewADXStream(period)
+		for i := range c {
+			adx[i], plus[i], minus[i] = s.Update(h[i], l[i], c[i])
+			if a := adx[i]; !math.IsNaN(a) && (a < 0 || a > 100) {
+				t.Fatalf("period %d row %d: ADX %v outside [0, 100]", period, i, a)
+			}
+		}
+		checkGolden(t, golden, name, adx)
+		checkGolden(t, golden, name+"_plus_di", plus)
+		checkGolden(t, golden, name+"_minus_di", minus)
+		if first := 2*period - 1; math.IsNaN(adx[first]) || !math.IsNaN(adx[first-1]) {
+			t.Fatalf("period %d: ADX should start at row %d", period, first)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Bands and channels: Bollinger, Donchian and Keltner.
+
+   Each appends its lines as seriesname + a suffix (seriesname "bb" gives
+   "bb_mid", "bb_upper", ...). Rows before a full window are NaN, like the
+   pandas rolling() features.
+*/
+
+// ── Rolling helpers ──────────────────────────────────────────────────────────
+
+// rollingMean is pandas rolling(period).mean(): NaN before a full window,
+// and NaN while the window holds a NaN.
+func rollingMean(src []float64, period int) []float64 {
+	out := make([]float64, len(src))
+	for i := range src {
+		if i < period-1 {
+			out[i] = math.NaN()
+			continue
+		}
+		sum := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			sum += src[j]
+		}
+		out[i] = sum / float64(period)
+	}
+	return out
+}
+
+// rollingMax and rollingMin are the highest and lowest value of the last
+// period values; NaN before a full window.
+func rollingMax(src []float64, period int) []float64 {
+	return rollingExtreme(src, period, math.Max)
+}
+
+func rollingMin(src []float64, period int) []float64 {
+	return rollingExtreme(src, period, math.Min)
+}
+
+func rollingExtreme(src []float64, period int, pick func(a, b float64) float64) []float64 {
+	out := make([]float64, len(src))
+	for i := range src {
+		if i < period-1 {
+			out[i] = math.NaN()
+			continue
+		}
+		v := src[i-period+1]
+		for j := i - period + 2; j <= i; j++ {
+			v = pick(v, src[j])
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// wilderATR is TradingView's ta.atr: Wilder's moving average (RMA) of the
+// true range, seeded with the mean of the first period values. TR[0] is
+// high − low; rows before period−1 are NaN. (ATRSmoothed is the pandas
+// rolling-mean ATR of the model features.)
+func wilderATR(high, low, close []float64, period int) []float64 {
+	n := len(close)
+	out := make([]float64, n)
+	p := float64(period)
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		tr := high[i] - low[i]
+		if i > 0 {
+			tr = math.Max(tr, math.Max(math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1])))
+		}
+		switch {
+		case i < period-1:
+			sum += tr
+			out[i] = math.NaN()
+		case i == period-1:
+			out[i] = (sum + tr) / p
+		default:
+			out[i] = (out[i-1]*(p-1) + tr) / p
+		}
+	}
+	return out
+}
+
+// ── Bollinger Bands ──────────────────────────────────────────────────────────
+
+// Bollinger appends Bollinger Bands on source: seriesname+"_mid" (SMA),
+// "_upper" and "_lower" (mid ± mult sample standard deviations, like
+// BollingerZ), "_width" ((upper − lower) / mid) and "_pct_b" (where source
+// sits in the band, 0 at the lower and 1 at the upper band; 0.5 when the
+// band is flat).
+func Bollinger(df *dataframe.DataFrame, seriesname string, source string, period int, mult float64) {
+	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
+	n := len(_source)
+	mid := rollingMean(_source, period)
+	upper := make([]float64, n)
+	lower := make([]float64, n)
+	width := make([]float64, n)
+	pctB := make([]float64, n)
+
+	for i := 0; i < n; i++ {
+		if i < period-1 || period < 2 {
+			upper[i], lower[i], width[i], pctB[i] = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		ss := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			ss += (_source[j] - mid[i]) * (_source[j] - mid[i])
+		}
+		std := math.Sqrt(ss / float64(period-1))
+		upper[i] = mid[i] + mult*std
+		lower[i] = mid[i] - mult*std
+		width[i] = (upper[i] - lower[i]) / mid[i]
+		pctB[i] = 0.5
+		if upper[i] > lower[i] {
+			pctB[i] = (_source[i] - lower[i]) / (upper[i] - lower[i])
+		}
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_mid", nil, mid), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_upper", nil, upper), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_lower", nil, lower), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_width", nil, width), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_pct_b", nil, pctB), nil)
+}
+
+// ── Donchian channel ─────────────────────────────────────────────────────────
+
+// Donchian appends the Donchian channel: seriesname+"_upper" (highest high
+// of the last period bars, this bar included), "_lower" (lowest low) and
+// "_mid". A breakout test compares close with the previous bar's channel.
+func Donchian(df *dataframe.DataFrame, seriesname string, period int) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	upper := rollingMax(_high, period)
+	lower := rollingMin(_low, period)
+	mid := make([]float64, len(upper))
+	for i := range mid {
+		mid[i] = (upper[i] + lower[i]) / 2
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_upper", nil, upper), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_lower", nil, lower), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_mid", nil, mid), nil)
+}
+
+// ── Keltner channel ──────────────────────────────────────────────────────────
+
+// Keltner appends the Keltner channel: seriesname+"_mid" (EMA of close
+// over emaPeriod, seeded like EMA), "_upper" and "_lower" (mid ± mult ×
+// Wilder ATR over atrPeriod; NaN until the ATR is seeded).
+func Keltner(df *dataframe.DataFrame, seriesname string, emaPeriod, atrPeriod int, mult float64) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	mid := emaArray(_close, emaPeriod)
+	atr := wilderATR(_high, _low, _close, atrPeriod)
+	upper := make([]float64, len(mid))
+	lower := make([]float64, len(mid))
+	for i := range mid {
+		upper[i] = mid[i] + mult*atr[i]
+		lower[i] = mid[i] - mult*atr[i]
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_mid", nil, mid), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_upper", nil, upper), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_lower", nil, lower), nil)
+}
@@ -105,7 +105,7 @@ func Slope(df *dataframe.DataFrame, seriesname string, source string, period int
 	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
 	slope := make([]float64, length)
 
-	for i := 0; i < period; i++ {
+	for i := 0; i < period && i < length; i++ {
 		slope[i] = math.NaN()
 	}
 	for i := period; i < length; i++ {
@@ -136,7 +136,7 @@ func LogReturn(df *dataframe.DataFrame, seriesname string, source string, shift
 	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
 	logReturn := make([]float64, length)
 
-	for i := 0; i < shift; i++ {
+	for i := 0; i < shift && i < length; i++ {
 		logReturn[i] = math.NaN()
 	}
 	for i := shift; i < length; i++ {
@@ -150,7 +150,7 @@ func CalculateRollingStd(logRet []float64, window int) []float64 {
 	n := len(logRet)
 	result := make([]float64, n)
 
-	for i := 0; i < window-1; i++ {
+	for i := 0; i < window-1 && i < n; i++ {
 		result[i] = math.NaN()
 	}
 	for i := window - 1; i < n; i++ {
@@ -204,7 +204,7 @@ func CalculateVolExpansion(rollingStd []float64, window int) []float64 {
 	n := len(rollingStd)
 	result := make([]float64, n)
 
-	for i := 0; i < window-1; i++ {
+	for i := 0; i < window-1 && i < n; i++ {
 		result[i] = math.NaN()
 	}
 	for i := window - 1; i < n; i++ {
@@ -230,3 +230,31 @@ func VolExpansion(df *dataframe.DataFrame, seriesname string, source string, per
 	_volExpansion := dataframe.NewSeriesFloat64(seriesname, nil, volExpansion)
 	df.AddSeries(_volExpansion, nil)
 }
+
+// BollingerZ appends the position of source inside its Bollinger band as a
+// z-score: (source - SMA(period)) / std(period), with the sample std like
+// pandas rolling().std(). ±2 is the classic 2σ band; rows before a full
+// window (or with a flat window) are 0.
+func BollingerZ(df *dataframe.DataFrame, seriesname string, source string, period int) {
+	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
+	length := len(_source)
+	z := make([]float64, length)
+
+	for i := period - 1; period >= 2 && i < length; i++ {
+		window := _source[i-period+1 : i+1]
+		var mean float64
+		for _, v := range window {
+			mean += v
+		}
+		mean /= float64(period)
+		var ss float64
+		for _, v := range window {
+			ss += (v - mean) * (v - mean)
+		}
+		if std := math.Sqrt(ss / float64(period-1)); std > 1e-9 {
+			z[i] = (_source[i] - mean) / std
+		}
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, z), nil)
+}
@@ -0,0 +1,132 @@
+package indicators
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// niftyFrame loads the stored NIFTY 1-minute bars in example/postgres.json
+// (a database export, newest first) in time order.
+func niftyFrame(t *testing.T) *dataframe.DataFrame {
+	t.Helper()
+	raw, err := os.ReadFile("../../example/postgres.json")
+	if err != nil {
+		t.Fatalf("read NIFTY bars: %v", err)
+	}
+	var rows []struct {
+		Timestamp string  `json:"timestamp"`
+		Open      float64 `json:"open"`
+		High      float64 `json:"high"`
+		Low       float64 `json:"low"`
+		Close     float64 `json:"close"`
+	}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		t.Fatalf("parse NIFTY bars: %v", err)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Timestamp < rows[j].Timestamp })
+
+	n := len(rows)
+	o, h, l, c := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	ts := make([]*time.Time, n)
+	for i, r := range rows {
+		tm, err := time.Parse("2006-01-02T15:04:05-0700", r.Timestamp)
+		if err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		ts[i] = &tm
+		o[i], h[i], l[i], c[i] = r.Open, r.High, r.Low, r.Close
+	}
+	timestamps := dataframe.NewSeriesTime("timestamp", nil)
+	timestamps.Values = ts
+	return dataframe.NewDataFrame(
+		dataframe.NewSeriesFloat64("open", nil, o),
+		dataframe.NewSeriesFloat64("high", nil, h),
+		dataframe.NewSeriesFloat64("low", nil, l),
+		dataframe.NewSeriesFloat64("close", nil, c),
+		timestamps,
+	)
+}
+
+// golden is testdata/golden.json: reference values computed by
+// testdata/golden.py from the textbook definitions over the stored NIFTY
+// bars, at the sampled Rows (nil for NaN).
+type golden struct {
+	Bars    int                   `json:"bars"`
+	Rows    []int                 `json:"rows"`
+	Columns map[string][]*float64 `json:"columns"`
+}
+
+func loadGolden(t *testing.T) golden {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatalf("read golden values: %v", err)
+	}
+	var g golden
+	if err := json.Unmarshal(raw, &g); err != nil {
+		t.Fatalf("parse golden values: %v", err)
+	}
+	return g
+}
+
+// checkGolden compares got at the golden rows with the reference column
+// name.
+func checkGolden(t *testing.T, g golden, name string, got []float64) {
+	t.Helper()
+	for k, i := range g.Rows {
+		w, v := g.Columns[name][k], got[i]
+		if w == nil {
+			if !math.IsNaN(v) {
+				t.Errorf("%s row %d: want NaN, got %v", name, i, v)
+			}
+			continue
+		}
+		if math.IsNaN(v) || math.Abs(v-*w) > 1e-9*math.Max(1, math.Abs(*w)) {
+			t.Errorf("%s row %d: want %v, got %v", name, i, *w, v)
+		}
+	}
+}
+
+// TestIndicatorsGolden checks the indicator library against
+// testdata/golden.json.
+func TestIndicatorsGolden(t *testing.T) {
+	golden := loadGolden(t)
+	df := niftyFrame(t)
+	if df.NRows() != golden.Bars {
+		t.Fatalf("%d stored bars, golden values cover %d", df.NRows(), golden.Bars)
+	}
+	Bollinger(df, "bb", "close", 20, 2)
+	MACD(df, "macd", "close", 12, 26, 9)
+	Supertrend(df, "st", 10, 3)
+	SessionVWAP(df, "vwap", 2) // no volume column: unit weights
+	Stochastic(df, "stoch", 14, 3, 3)
+	Donchian(df, "dc", 20)
+	Keltner(df, "kc", 20, 10, 2)
+	Ichimoku(df, "ichi", 9, 26, 52)
+	ParabolicSAR(df, "psar", 0.02, 0.2)
+	PivotLevels(df, "pv")
+
+	vol := make([]float64, df.NRows())
+	for i := range vol {
+		vol[i] = float64(1000 + (i*7919)%500)
+	}
+	df.AddSeries(dataframe.NewSeriesFloat64("volume", nil, vol), nil)
+	SessionVWAP(df, "vwapv", 2)
+
+	ADX(df, "adx", 14)
+	ADX(df, "adx7", 7)
+
+	for name := range golden.Columns {
+		if FindIndexOf(df, name) < 0 {
+			t.Errorf("%s: not computed", name)
+			continue
+		}
+		checkGolden(t, golden, name, values(df, name))
+	}
+}
@@ -0,0 +1,58 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Ichimoku Kinko Hyo.
+
+     tenkan  = (highest high + lowest low) / 2 over tenkan bars   (9)
+     kijun   = the same over kijun bars                           (26)
+     span A  = (tenkan + kijun) / 2, plotted kijun bars ahead
+     span B  = the midpoint over senkou bars (52), plotted kijun bars ahead
+
+   The spans are written at the bar they are plotted on, so row i holds the
+   cloud computed kijun bars earlier and reads no future bar. The chikou
+   span (close plotted kijun bars back) is left out: as a feature of row i
+   it would be close[i+kijun]; compare close with close kijun bars ago
+   instead.
+*/
+
+// Ichimoku appends seriesname+"_tenkan", "_kijun", "_span_a" and "_span_b";
+// rows before their window (plus the kijun displacement for the spans) are
+// NaN.
+func Ichimoku(df *dataframe.DataFrame, seriesname string, tenkan, kijun, senkou int) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	n := len(_high)
+
+	midpoint := func(period int) []float64 {
+		hh, ll := rollingMax(_high, period), rollingMin(_low, period)
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = (hh[i] + ll[i]) / 2
+		}
+		return out
+	}
+	tenkanLine := midpoint(tenkan)
+	kijunLine := midpoint(kijun)
+	senkouLine := midpoint(senkou)
+
+	spanA := make([]float64, n)
+	spanB := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if j := i - kijun; j >= 0 {
+			spanA[i] = (tenkanLine[j] + kijunLine[j]) / 2
+			spanB[i] = senkouLine[j]
+		} else {
+			spanA[i], spanB[i] = math.NaN(), math.NaN()
+		}
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_tenkan", nil, tenkanLine), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_kijun", nil, kijunLine), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_span_a", nil, spanA), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_span_b", nil, spanB), nil)
+}
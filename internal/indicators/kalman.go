@@ -6,11 +6,6 @@ import (
 	"github.com/rocketlaunchr/dataframe-go"
 )
 
-var (
-	KalmanFFTWindow        = 64
-	KalmanFFTCutoffDivisor = 128
-)
-
 func CalcSWAPKalman(df *dataframe.DataFrame, seriesname string, source string, factor float64) {
 	_length := df.NRows()
 	// _swap_base := make([]float64, _length)
@@ -167,20 +162,17 @@ func KalmanFilter(df *dataframe.DataFrame, seriesname string, source string, win
 	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
 	fftSmoothed := make([]float64, length)
 
-	KalmanFFTWindow = window
-	KalmanFFTCutoffDivisor = cutoffDivisor
-
 	// Pre-allocate the FFT buffer once for the full loop — avoids one heap
 	// allocation per row (was 97k+ allocs per KalmanFilter call).
 	fftBuf := make([]complex128, nextPow2(window))
 
 	for i := 0; i < length; i++ {
 		start := 0
-		if i+1 > KalmanFFTWindow {
-			start = i + 1 - KalmanFFTWindow
+		if i+1 > window {
+			start = i + 1 - window
 		}
 		win := _source[start : i+1]
-		fftSmoothed[i] = lowPassFFTLastReuse(win, KalmanFFTCutoffDivisor, fftBuf)
+		fftSmoothed[i] = lowPassFFTLastReuse(win, cutoffDivisor, fftBuf)
 	}
 
 	r := residualVariance(_source, fftSmoothed)
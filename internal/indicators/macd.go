@@ -0,0 +1,27 @@
+package indicators
+
+import "github.com/rocketlaunchr/dataframe-go"
+
+// MACD appends the moving average convergence/divergence of source:
+// seriesname = EMA(fast) − EMA(slow), seriesname+"_signal" = EMA(signal) of
+// it and seriesname+"_hist" = MACD − signal. The EMAs are pandas
+// ewm(span, adjust=False), seeded with the first value like EMA, so every
+// row has a value; the usual periods are 12, 26 and 9.
+func MACD(df *dataframe.DataFrame, seriesname string, source string, fast, slow, signal int) {
+	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
+	emaFast := emaArray(_source, fast)
+	emaSlow := emaArray(_source, slow)
+	macd := make([]float64, len(_source))
+	for i := range macd {
+		macd[i] = emaFast[i] - emaSlow[i]
+	}
+	sig := emaArray(macd, signal)
+	hist := make([]float64, len(macd))
+	for i := range hist {
+		hist[i] = macd[i] - sig[i]
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, macd), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_signal", nil, sig), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_hist", nil, hist), nil)
+}
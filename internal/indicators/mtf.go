@@ -5,15 +5,17 @@ import (
 	"math"
 	"time"
 
+	"hft/internal/timeframe"
+
 	"github.com/rocketlaunchr/dataframe-go"
 )
 
 /* Mirrors regime-model/features/indicators.py:add_multi_timeframe_features.
 
    For each higher timeframe `tfMin` (e.g. 5, 15) we:
-     1. group 1m bars into HT buckets via t.Truncate(tfMin*Minute)
-        (Truncate operates on absolute time; IST offset 05:30 is divisible
-        by 5min and 15min, so the boundaries align with NSE 09:15+).
+     1. group 1m bars into HT buckets via timeframe.Start, aligned to the
+        09:15 session open (for 5 and 15 minutes the same buckets as the
+        Python side's floor on absolute time).
      2. build HT OHLC by walking the 1m series in order
      3. compute EMA(5), EMA(21), RSI(7), ATR(7) on the HT close
      4. derive 5 scale-invariant features per HT bar
@@ -54,7 +56,7 @@ func AddMTFFeatures(df *dataframe.DataFrame, tfMin int) {
 	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
 	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
 
-	bucket := time.Duration(tfMin) * time.Minute
+	tf := timeframe.Timeframe{N: tfMin, Unit: timeframe.Minute}
 
 	// Build HT bars + per-1m bucket index. bucketIdx[i] = -1 until first valid bar.
 	htOpen := make([]float64, 0, n/tfMin+1)
@@ -73,7 +75,7 @@ func AddMTFFeatures(df *dataframe.DataFrame, tfMin int) {
 			bucketIdx[i] = bIdx
 			continue
 		}
-		bs := _ts[i].Truncate(bucket)
+		bs := tf.Start(*_ts[i])
 		if bIdx < 0 || !bs.Equal(lastBucket) {
 			bIdx++
 			lastBucket = bs
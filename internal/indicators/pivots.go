@@ -0,0 +1,121 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Daily levels: pivot points and the Central Pivot Range from the previous
+   IST day's high, low and close (H, L, C over every bar of that day,
+   range R = H − L).
+
+     classic    P  = (H + L + C)/3
+                R1 = 2P − L          S1 = 2P − H
+                R2 = P + R           S2 = P − R
+                R3 = H + 2(P − L)    S3 = L − 2(H − P)
+     fibonacci  R1..R3 = P + 0.382R, 0.618R, 1.0R    S1..S3 = P − the same
+     camarilla  R1..R4 = C + 1.1R/12, /6, /4, /2     S1..S4 = C − the same
+     CPR        BC = (H + L)/2, TC = 2P − BC (swapped when TC < BC),
+                width = (TC − BC)/P × 100
+
+   Each level is appended as seriesname + "_" + its name in PivotLevelNames
+   ("pv_r1", "pv_cam_s3", "pv_prev_high", ...), together with the distance
+   of close to it in percent, (close − level)/level × 100, as
+   seriesname + "_dist_" + name. The levels are constant through a day and
+   NaN on the first day of the frame, which has no previous day.
+
+   (The strategies' day-type label builds its day OHLC from in-session
+   closes; pivots use the whole day's highs and lows, as
+   the exchange and charting platforms do.)
+*/
+
+// PivotLevelNames are the level suffixes appended by PivotLevels, in the
+// order of the array returned by pivotLevels.
+var PivotLevelNames = []string{
+	"p", "r1", "r2", "r3", "s1", "s2", "s3",
+	"fib_r1", "fib_r2", "fib_r3", "fib_s1", "fib_s2", "fib_s3",
+	"cam_r1", "cam_r2", "cam_r3", "cam_r4", "cam_s1", "cam_s2", "cam_s3", "cam_s4",
+	"cpr_tc", "cpr_bc",
+	"prev_high", "prev_low", "prev_close",
+}
+
+// pivotLevels returns the levels of PivotLevelNames for a day's high, low
+// and close, plus the CPR width.
+func pivotLevels(h, l, c float64) (levels [26]float64, cprWidth float64) {
+	p := (h + l + c) / 3
+	r := h - l
+	bc := (h + l) / 2
+	tc := 2*p - bc
+	if tc < bc {
+		tc, bc = bc, tc
+	}
+	levels = [26]float64{
+		p, 2*p - l, p + r, h + 2*(p-l), 2*p - h, p - r, l - 2*(h-p),
+		p + 0.382*r, p + 0.618*r, p + r, p - 0.382*r, p - 0.618*r, p - r,
+		c + 1.1*r/12, c + 1.1*r/6, c + 1.1*r/4, c + 1.1*r/2,
+		c - 1.1*r/12, c - 1.1*r/6, c - 1.1*r/4, c - 1.1*r/2,
+		tc, bc,
+		h, l, c,
+	}
+	return levels, (tc - bc) / p * 100
+}
+
+// PivotLevels appends the previous day's pivot levels, the CPR width as
+// seriesname+"_cpr_width" and the distance of close to every level.
+func PivotLevels(df *dataframe.DataFrame, seriesname string) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	_ts := df.Series[FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
+	n := len(_close)
+
+	k := len(PivotLevelNames)
+	levels := make([][]float64, k)
+	dists := make([][]float64, k)
+	for j := range levels {
+		levels[j] = make([]float64, n)
+		dists[j] = make([]float64, n)
+	}
+	width := make([]float64, n)
+
+	ist := time.FixedZone("IST", 19800)
+	var day string
+	var dayHigh, dayLow, dayClose float64 // the day in progress
+	var cur [26]float64                   // levels from the previous day
+	curWidth, havePrev := math.NaN(), false
+	for i := 0; i < n; i++ {
+		if _ts[i] != nil {
+			if d := _ts[i].In(ist).Format("2006-01-02"); d != day {
+				if day != "" {
+					cur, curWidth = pivotLevels(dayHigh, dayLow, dayClose)
+					havePrev = true
+				}
+				day = d
+				dayHigh, dayLow = _high[i], _low[i]
+			}
+			dayHigh = math.Max(dayHigh, _high[i])
+			dayLow = math.Min(dayLow, _low[i])
+			dayClose = _close[i]
+		}
+
+		width[i] = curWidth
+		for j := 0; j < k; j++ {
+			if !havePrev {
+				levels[j][i], dists[j][i] = math.NaN(), math.NaN()
+				continue
+			}
+			levels[j][i] = cur[j]
+			dists[j][i] = (_close[i] - cur[j]) / cur[j] * 100
+		}
+	}
+
+	for j, name := range PivotLevelNames {
+		df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_"+name, nil, levels[j]), nil)
+	}
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_cpr_width", nil, width), nil)
+	for j, name := range PivotLevelNames {
+		df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_dist_"+name, nil, dists[j]), nil)
+	}
+}
@@ -0,0 +1,82 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Wilder's Parabolic SAR.
+
+   Bar 1 opens the first trend: long when its up move (high − prev high) is
+   at least its down move (prev low − low), with the SAR at the lower of the
+   two lows and the extreme point (EP) at the higher high; short mirrors it.
+   On every later bar
+
+     sar = sar + af × (ep − sar), kept at or below the last two lows in a
+           long (at or above the last two highs in a short)
+
+   A low below the SAR (a high above it, short) reverses the trend: the SAR
+   jumps to the old EP, the EP resets to this bar's extreme and af to step.
+   Otherwise a new extreme moves the EP and raises af by step, up to maxStep.
+*/
+
+// ParabolicSAR appends the stop-and-reverse level as seriesname and the
+// trend it trails (+1 long, −1 short) as seriesname+"_dir"; row 0 is NaN.
+// The usual step and maxStep are 0.02 and 0.2.
+func ParabolicSAR(df *dataframe.DataFrame, seriesname string, step, maxStep float64) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	n := len(_high)
+	sarOut := make([]float64, n)
+	dirOut := make([]float64, n)
+	if n > 0 {
+		sarOut[0], dirOut[0] = math.NaN(), math.NaN()
+	}
+	if n < 2 {
+		df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, sarOut), nil)
+		df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_dir", nil, dirOut), nil)
+		return
+	}
+
+	long := _high[1]-_high[0] >= _low[0]-_low[1]
+	var sar, ep float64
+	if long {
+		sar, ep = math.Min(_low[0], _low[1]), math.Max(_high[0], _high[1])
+	} else {
+		sar, ep = math.Max(_high[0], _high[1]), math.Min(_low[0], _low[1])
+	}
+	af := step
+	sarOut[1], dirOut[1] = sar, trendSign(long)
+
+	for i := 2; i < n; i++ {
+		next := sar + af*(ep-sar)
+		if long {
+			next = math.Min(next, math.Min(_low[i-1], _low[i-2]))
+			if _low[i] < next {
+				long, next, ep, af = false, ep, _low[i], step
+			} else if _high[i] > ep {
+				ep, af = _high[i], math.Min(af+step, maxStep)
+			}
+		} else {
+			next = math.Max(next, math.Max(_high[i-1], _high[i-2]))
+			if _high[i] > next {
+				long, next, ep, af = true, ep, _high[i], step
+			} else if _low[i] < ep {
+				ep, af = _low[i], math.Min(af+step, maxStep)
+			}
+		}
+		sar = next
+		sarOut[i], dirOut[i] = sar, trendSign(long)
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, sarOut), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_dir", nil, dirOut), nil)
+}
+
+func trendSign(long bool) float64 {
+	if long {
+		return 1
+	}
+	return -1
+}
@@ -12,7 +12,7 @@ func ROC(df *dataframe.DataFrame, seriesname string, source string, period int)
 	_source := df.Series[FindIndexOf(df, source)].(*dataframe.SeriesFloat64).Values
 	rocValues := make([]float64, length)
 
-	for i := 0; i < period; i++ {
+	for i := 0; i < period && i < length; i++ {
 		rocValues[i] = math.NaN()
 	}
 	for i := period; i < length; i++ {
@@ -0,0 +1,40 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// Stochastic appends the slow stochastic oscillator:
+//
+//	raw %K = 100 × (close − lowest low) / (highest high − lowest low) over kPeriod
+//	%K     = SMA(raw %K, smoothK)            → seriesname+"_k"
+//	%D     = SMA(%K, dPeriod)                → seriesname+"_d"
+//
+// A flat window (highest high = lowest low) reads 50. Rows before a full
+// window of each stage are NaN; smoothK = 1 gives the fast stochastic. The
+// usual parameters are 14, 3, 3.
+func Stochastic(df *dataframe.DataFrame, seriesname string, kPeriod, smoothK, dPeriod int) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	hh, ll := rollingMax(_high, kPeriod), rollingMin(_low, kPeriod)
+
+	raw := make([]float64, len(_close))
+	for i := range raw {
+		switch {
+		case math.IsNaN(hh[i]):
+			raw[i] = math.NaN()
+		case hh[i] == ll[i]:
+			raw[i] = 50
+		default:
+			raw[i] = 100 * (_close[i] - ll[i]) / (hh[i] - ll[i])
+		}
+	}
+	k := rollingMean(raw, smoothK)
+	d := rollingMean(k, dPeriod)
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_k", nil, k), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_d", nil, d), nil)
+}
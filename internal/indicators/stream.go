@@ -0,0 +1,769 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"hft/internal/timeframe"
+)
+
+/* Streaming (incremental) forms of the batch indicators.
+
+   Each stream takes one new bar per Update and returns the value the batch
+   function would write for that row, bit for bit: the same operations in
+   the same order, with O(1) or O(window) state instead of a pass over the
+   whole frame. "The batch value" is the last row of the batch function run
+   over the bars seen so far, which for a causal indicator is also its row
+   in a batch over the full history. The exceptions look at the whole
+   frame: warmup rules (AddMTFFeatures needs 30 higher-timeframe bars,
+   AddVolumeFeatures needs non-zero volume), which a stream applies to the
+   bars it has seen, as the live executor's growing frame does, and the
+   noise KalmanFilter estimates, which KalmanStream is given (KalmanNoise).
+
+   Warmup rows keep the batch conventions: NaN where the batch writes NaN,
+   0 where it leaves the zero value.
+*/
+
+// ring is a fixed-size window over the most recent values, oldest first.
+type ring struct {
+	buf  []float64
+	head int // next write position
+	n    int
+}
+
+func newRing(size int) *ring {
+	return &ring{buf: make([]float64, size)}
+}
+
+func (r *ring) push(v float64) {
+	r.buf[r.head] = v
+	r.head = (r.head + 1) % len(r.buf)
+	if r.n < len(r.buf) {
+		r.n++
+	}
+}
+
+// ago returns the value pushed k updates before the latest (ago(0) is the
+// latest). k must be < r.n.
+func (r *ring) ago(k int) float64 {
+	return r.buf[(r.head-1-k+2*len(r.buf))%len(r.buf)]
+}
+
+// ── Moving averages ──────────────────────────────────────────────────────────
+
+// EMAStream is the streaming form of EMA.
+type EMAStream struct {
+	alpha float64
+	v     float64
+	n     int
+}
+
+func NewEMAStream(period int) *EMAStream {
+	return &EMAStream{alpha: 2.0 / float64(period+1)}
+}
+
+func (s *EMAStream) Update(x float64) float64 {
+	if s.n == 0 {
+		s.v = x
+	} else {
+		s.v = s.alpha*x + (1-s.alpha)*s.v
+	}
+	s.n++
+	return s.v
+}
+
+// WMAStream is the streaming form of WMA.
+type WMAStream struct {
+	period int
+	win    *ring
+}
+
+func NewWMAStream(period int) *WMAStream {
+	return &WMAStream{period: period, win: newRing(period)}
+}
+
+func (s *WMAStream) Update(x float64) float64 {
+	s.win.push(x)
+	if s.win.n < s.period {
+		return 0
+	}
+	sum, weightSum := 0.0, 0.0
+	for j := 0; j < s.period; j++ {
+		weight := float64(j + 1)
+		sum += s.win.ago(j) * weight
+		weightSum += weight
+	}
+	return sum / weightSum
+}
+
+// smaStream is ta.Sma: a running sum over period values, averaging the
+// values seen so far until the window is full.
+type smaStream struct {
+	period int
+	win    *ring
+	sum    float64
+	n      int
+}
+
+func newSMAStream(period int) *smaStream {
+	return &smaStream{period: period, win: newRing(period + 1)}
+}
+
+func (s *smaStream) update(x float64) float64 {
+	s.win.push(x)
+	count := s.n + 1
+	s.sum += x
+	if s.n >= s.period {
+		s.sum -= s.win.ago(s.period)
+		count = s.period
+	}
+	s.n++
+	return s.sum / float64(count)
+}
+
+// ── Oscillators ──────────────────────────────────────────────────────────────
+
+// CCIStream is the streaming form of CCI on one source column. The batch
+// copies row 1 into row 0 once a second row exists; the stream returns the
+// first row as computed (0) — CalcXStream accounts for the copy.
+type CCIStream struct {
+	ma, md *smaStream
+	n      int
+}
+
+func NewCCIStream(period int) *CCIStream {
+	return &CCIStream{ma: newSMAStream(period), md: newSMAStream(period)}
+}
+
+func (s *CCIStream) Update(x float64) float64 {
+	tp := (x + x + x) / float64(3)
+	ma := s.ma.update(tp)
+	dev := tp + ma*float64(-1)
+	md := s.md.update(math.Abs(dev))
+	cci := dev / (md * 0.015)
+	if s.n == 0 {
+		cci = 0
+	}
+	s.n++
+	return math.Round(cci*100) / 100
+}
+
+// RSIStream is the streaming form of RSI.
+type RSIStream struct {
+	alpha            float64
+	prev             float64
+	avgGain, avgLoss float64
+	n                int
+}
+
+func NewRSIStream(period int) *RSIStream {
+	return &RSIStream{alpha: 2.0 / float64(period+1)}
+}
+
+func (s *RSIStream) Update(x float64) float64 {
+	defer func() { s.prev, s.n = x, s.n+1 }()
+	if s.n == 0 {
+		return 0
+	}
+	d := x - s.prev
+	gain := math.Max(d, 0)
+	loss := math.Max(-d, 0)
+	if s.n == 1 {
+		s.avgGain, s.avgLoss = gain, loss
+	} else {
+		s.avgGain = s.alpha*gain + (1-s.alpha)*s.avgGain
+		s.avgLoss = s.alpha*loss + (1-s.alpha)*s.avgLoss
+	}
+	rs := s.avgGain / (s.avgLoss + 1e-10)
+	return 100.0 - (100.0 / (1.0 + rs))
+}
+
+// ── Differences over a lag ───────────────────────────────────────────────────
+
+// LagStream keeps the last period values and applies f(current, period
+// bars ago); rows before a full lag are NaN. It is the streaming form of
+// Slope, ROC and LogReturn.
+type LagStream struct {
+	period int
+	win    *ring
+	f      func(cur, past float64) float64
+}
+
+func NewSlopeStream(period int) *LagStream {
+	return &LagStream{period: period, win: newRing(period + 1), f: func(cur, past float64) float64 {
+		return (cur - past) / float64(period)
+	}}
+}
+
+func NewROCStream(period int) *LagStream {
+	return &LagStream{period: period, win: newRing(period + 1), f: func(cur, past float64) float64 {
+		return ((cur - past) / past) * 100
+	}}
+}
+
+func NewLogReturnStream(shift int) *LagStream {
+	return &LagStream{period: shift, win: newRing(shift + 1), f: func(cur, past float64) float64 {
+		return math.Log(cur / past)
+	}}
+}
+
+func (s *LagStream) Update(x float64) float64 {
+	s.win.push(x)
+	if s.win.n <= s.period {
+		return math.NaN()
+	}
+	return s.f(x, s.win.ago(s.period))
+}
+
+// ── Volatility ───────────────────────────────────────────────────────────────
+
+// BarTrueRange is the value ATR writes for a bar (ta.Atr's TR, which
+// measures against the bar's own close, so it needs no history).
+func BarTrueRange(high, low, close float64) float64 {
+	return math.Max(high-low, math.Max(high-close, close-low))
+}
+
+// ATRSmoothedStream is the streaming form of ATRSmoothed.
+type ATRSmoothedStream struct {
+	period    int
+	tr        *ring
+	prevClose float64
+}
+
+func NewATRSmoothedStream(period int) *ATRSmoothedStream {
+	return &ATRSmoothedStream{period: period, tr: newRing(period)}
+}
+
+func (s *ATRSmoothedStream) Update(high, low, close float64) float64 {
+	hl := high - low
+	tr := hl
+	if s.tr.n > 0 {
+		hpc := math.Abs(high - s.prevClose)
+		lpc := math.Abs(low - s.prevClose)
+		tr = math.Max(hl, math.Max(hpc, lpc))
+	}
+	s.prevClose = close
+	s.tr.push(tr)
+	if s.tr.n < s.period {
+		return math.NaN()
+	}
+	sum := 0.0
+	for j := s.period - 1; j >= 0; j-- {
+		sum += s.tr.ago(j)
+	}
+	return sum / float64(s.period)
+}
+
+// RollingStdStream is the streaming form of RollingStd (sample std).
+type RollingStdStream struct {
+	window int
+	win    *ring
+}
+
+func NewRollingStdStream(window int) *RollingStdStream {
+	return &RollingStdStream{window: window, win: newRing(window)}
+}
+
+func (s *RollingStdStream) Update(x float64) float64 {
+	s.win.push(x)
+	if s.win.n < s.window {
+		return math.NaN()
+	}
+	sum := 0.0
+	for j := s.window - 1; j >= 0; j-- {
+		sum += s.win.ago(j)
+	}
+	mean := sum / float64(s.window)
+	variance := 0.0
+	for j := s.window - 1; j >= 0; j-- {
+		diff := s.win.ago(j) - mean
+		variance += diff * diff
+	}
+	return math.Sqrt(variance / float64(s.window-1))
+}
+
+// VolExpansionStream is the streaming form of VolExpansion.
+type VolExpansionStream struct {
+	window int
+	win    *ring
+}
+
+func NewVolExpansionStream(window int) *VolExpansionStream {
+	return &VolExpansionStream{window: window, win: newRing(window)}
+}
+
+func (s *VolExpansionStream) Update(x float64) float64 {
+	s.win.push(x)
+	if s.win.n < s.window {
+		return math.NaN()
+	}
+	sum := 0.0
+	for j := s.window - 1; j >= 0; j-- {
+		sum += s.win.ago(j)
+	}
+	mean := sum / float64(s.window)
+	return x / (mean + 1e-10)
+}
+
+// ── Kalman / CalcX ───────────────────────────────────────────────────────────
+
+// CalcXStream is the streaming form of CalcX. The batch CCI copies its
+// second row into the first, so on the second bar the previous CCI is taken
+// to be the current one.
+type CalcXStream struct {
+	param          float64
+	prevCCI        float64
+	prevUp, prevDn float64
+	prevX          float64
+	n              int
+}
+
+func NewCalcXStream(param float64) *CalcXStream {
+	return &CalcXStream{param: param}
+}
+
+func (s *CalcXStream) Update(source, cci, wma float64) float64 {
+	bufferDn := source + s.param*wma
+	bufferUp := source - s.param*wma
+	var x float64
+	if s.n == 0 {
+		x = math.Round(source*1000) / 1000
+	} else {
+		prevCCI := s.prevCCI
+		if s.n == 1 {
+			prevCCI = cci
+		}
+		if cci >= 0 && prevCCI < 0 {
+			bufferUp = s.prevDn
+		}
+		if cci <= 0 && prevCCI > 0 {
+			bufferDn = s.prevUp
+		}
+		if cci >= 0 {
+			if bufferUp < s.prevUp {
+				bufferUp = s.prevUp
+			}
+		} else if cci <= 0 {
+			if bufferDn > s.prevDn {
+				bufferDn = s.prevDn
+			}
+		}
+		switch {
+		case cci >= 0:
+			x = math.Round(bufferUp*1000) / 1000
+		case cci <= 0:
+			x = math.Round(bufferDn*1000) / 1000
+		default:
+			x = math.Round(s.prevX*1000) / 1000
+		}
+	}
+	s.prevCCI, s.prevUp, s.prevDn, s.prevX = cci, bufferUp, bufferDn, x
+	s.n++
+	return x
+}
+
+// KalmanNoise returns the measurement noise r and process noise q that
+// KalmanFilter estimates for source: the variance of source around its FFT
+// low-pass, and 1% of it, each floored at 1e-6.
+func KalmanNoise(source []float64, window, cutoffDivisor int) (r, q float64) {
+	smoothed := make([]float64, len(source))
+	fftBuf := make([]complex128, nextPow2(window))
+	for i := range source {
+		start := 0
+		if i+1 > window {
+			start = i + 1 - window
+		}
+		smoothed[i] = lowPassFFTLastReuse(source[start:i+1], cutoffDivisor, fftBuf)
+	}
+	r = residualVariance(source, smoothed)
+	if r < 1e-6 {
+		r = 1e-6
+	}
+	q = r * 0.01
+	if q < 1e-6 {
+		q = 1e-6
+	}
+	return r, q
+}
+
+// KalmanStream is the streaming form of KalmanFilter: an FFT low-pass over
+// the last window values, then the scalar Kalman update with noise r and q.
+// The batch estimates them over the whole frame (see KalmanNoise), which no
+// causal stream can; given the batch's r and q the stream reproduces every
+// row of it. Estimated over a history and kept for the bars after it, they
+// differ from a batch rerun over the longer frame only in rounding, since
+// the gain depends on q/r alone once both are above their floors.
+type KalmanStream struct {
+	cutoffDivisor int
+	r, q          float64
+	win           *ring
+	fft           []complex128
+	buf           []float64
+	x, p          float64
+	n             int
+}
+
+func NewKalmanStream(window, cutoffDivisor int, r, q float64) *KalmanStream {
+	return &KalmanStream{
+		cutoffDivisor: cutoffDivisor,
+		r:             r,
+		q:             q,
+		win:           newRing(window),
+		fft:           make([]complex128, nextPow2(window)),
+		buf:           make([]float64, 0, window),
+	}
+}
+
+func (s *KalmanStream) Update(x float64) float64 {
+	s.win.push(x)
+	s.buf = s.buf[:0]
+	for j := s.win.n - 1; j >= 0; j-- {
+		s.buf = append(s.buf, s.win.ago(j))
+	}
+	smoothed := lowPassFFTLastReuse(s.buf, s.cutoffDivisor, s.fft)
+
+	if s.n == 0 {
+		s.x, s.p = smoothed, s.r
+	} else {
+		s.p += s.q
+	}
+	s.n++
+	k := s.p / (s.p + s.r)
+	s.x = s.x + k*(smoothed-s.x)
+	s.p = (1 - k) * s.p
+	return math.Ceil(s.x)
+}
+
+// SwapKalmanStream is the streaming form of CalcSWAPKalman.
+type SwapKalmanStream struct {
+	factor     float64
+	prevSource float64
+	swap       float64
+	n          int
+}
+
+func NewSwapKalmanStream(factor float64) *SwapKalmanStream {
+	return &SwapKalmanStream{factor: factor}
+}
+
+func (s *SwapKalmanStream) Update(t time.Time, source, atr3 float64) float64 {
+	defer func() { s.prevSource, s.n = source, s.n+1 }()
+	if s.n < 2 {
+		return 0
+	}
+	expansion := math.Abs(source-s.prevSource) > s.factor*atr3
+	if source > s.prevSource && expansion {
+		s.swap = 1
+	} else if source < s.prevSource && expansion {
+		s.swap = -1
+	}
+	if !IsActiveSession(&t) {
+		s.swap = 0
+	}
+	return s.swap
+}
+
+// ── Candle features ──────────────────────────────────────────────────────────
+
+// MicrostructureNames are the columns AddMicrostructureFeatures appends, in
+// the order MicrostructureStream.Update returns them.
+var MicrostructureNames = []string{
+	"candle_body_ratio", "candle_direction", "upper_wick_ratio", "lower_wick_ratio",
+	"consec_candle_count", "kalman_fast_dist", "kalman_slow_dist", "kalman_crossover",
+}
+
+// MicrostructureStream is the streaming form of AddMicrostructureFeatures.
+type MicrostructureStream struct {
+	prevDir float64
+	count   int
+	n       int
+}
+
+func (s *MicrostructureStream) Update(open, high, low, close, fastKalman, slowKalman float64) [8]float64 {
+	fullRange := high - low + 1e-10
+	dir := Sign(close - open)
+	if s.n == 0 || dir != s.prevDir {
+		s.count = 1
+	} else {
+		s.count++
+	}
+	consec := float64(s.count) * dir
+	if s.n == 0 {
+		consec = dir
+	}
+	s.prevDir = dir
+	s.n++
+	return [8]float64{
+		math.Abs(close-open) / fullRange,
+		dir,
+		(high - math.Max(open, close)) / fullRange,
+		(math.Min(open, close) - low) / fullRange,
+		consec,
+		(close - fastKalman) / (close + 1e-10),
+		(close - slowKalman) / (close + 1e-10),
+		(fastKalman - slowKalman) / (close + 1e-10),
+	}
+}
+
+// MinuteOfDay is the value AddMinuteOfDay writes for a bar at t.
+func MinuteOfDay(t time.Time) float64 {
+	t = t.In(time.FixedZone("IST", 5*3600+30*60))
+	mins := float64(t.Hour()*60+t.Minute()) - float64(9*60+15)
+	if mins < 0 {
+		mins = 0
+	}
+	return mins / 375.0
+}
+
+// ── Volume features ──────────────────────────────────────────────────────────
+
+// VolumeNames are the columns AddVolumeFeatures appends, in the order
+// VolumeStream.Update returns them.
+var VolumeNames = []string{"vol_sma_ratio", "vol_roc", "vol_price_corr", "obv_slope", "vwap_dist"}
+
+// VolumeStream is the streaming form of AddVolumeFeatures. Like the batch it
+// writes zeros while the volume seen so far sums to zero (index data).
+type VolumeStream struct {
+	n         int
+	sumV      float64
+	smaSum    float64
+	vol       *ring // 30, the longest window
+	pv        *ring // close×volume, 30
+	absRet    *ring // 20
+	prevClose float64
+	obv       float64
+	obvSum    float64
+	obvs      *ring // OBV, 10
+	obvSMA    *ring // SMA10(OBV), 11
+	num, den  float64
+}
+
+func NewVolumeStream() *VolumeStream {
+	return &VolumeStream{
+		vol:    newRing(31),
+		pv:     newRing(31),
+		absRet: newRing(20),
+		obvs:   newRing(11),
+		obvSMA: newRing(11),
+	}
+}
+
+func (s *VolumeStream) Update(close, vol float64) [5]float64 {
+	i := s.n
+	s.n++
+	if !math.IsNaN(vol) {
+		s.sumV += vol
+	}
+	s.vol.push(vol)
+	s.pv.push(close * vol)
+
+	// vol_sma_ratio: SMA20 with an expanding warmup.
+	s.smaSum += vol
+	if i >= 20 {
+		s.smaSum -= s.vol.ago(20)
+	}
+	cnt := 20
+	if i+1 < 20 {
+		cnt = i + 1
+	}
+	smaRatio := vol/(s.smaSum/float64(cnt)+1e-10) - 1.0
+
+	volROC := math.NaN()
+	if i >= 5 {
+		volROC = (vol - s.vol.ago(5)) / (s.vol.ago(5) + 1e-10)
+	}
+
+	// |close.pct_change()| and OBV direction.
+	absRet, dir := math.NaN(), 0.0
+	if i > 0 {
+		absRet = 0
+		if s.prevClose != 0 {
+			absRet = math.Abs((close - s.prevClose) / s.prevClose)
+		}
+		switch d := close - s.prevClose; {
+		case d > 0:
+			dir = 1
+		case d < 0:
+			dir = -1
+		}
+	}
+	s.prevClose = close
+	s.absRet.push(absRet)
+	corr := s.corr(i)
+
+	if i == 0 {
+		s.obv = vol * dir
+	} else {
+		s.obv = s.obv + vol*dir
+	}
+	s.obvs.push(s.obv)
+	s.obvSum += s.obv
+	if i >= 10 {
+		s.obvSum -= s.obvs.ago(10)
+	}
+	cnt = 10
+	if i+1 < 10 {
+		cnt = i + 1
+	}
+	s.obvSMA.push(s.obvSum / float64(cnt))
+	obvSlope := math.NaN()
+	if i >= 10 {
+		prev := s.obvSMA.ago(10)
+		obvSlope = (s.obvSMA.ago(0) - prev) / (math.Abs(prev) + 1e-10)
+	}
+
+	s.num += close * vol
+	s.den += vol
+	if i >= 30 {
+		s.num -= s.pv.ago(30)
+		s.den -= s.vol.ago(30)
+	}
+	vwap := s.num / (s.den + 1e-10)
+	vwapDist := (close - vwap) / (vwap + 1e-10)
+
+	if s.sumV == 0 {
+		return [5]float64{}
+	}
+	return [5]float64{smaRatio, volROC, corr, obvSlope, vwapDist}
+}
+
+// corr is rollingCorr(vol, absRet, 20, 10) at row i.
+func (s *VolumeStream) corr(i int) float64 {
+	count := i + 1
+	if count > 20 {
+		count = 20
+	}
+	if count < 10 {
+		return math.NaN()
+	}
+	var sumX, sumY float64
+	for k := count - 1; k >= 0; k-- {
+		sumX += s.vol.ago(k)
+		sumY += s.absRet.ago(k)
+	}
+	mx := sumX / float64(count)
+	my := sumY / float64(count)
+	var num, varX, varY float64
+	for k := count - 1; k >= 0; k-- {
+		dx := s.vol.ago(k) - mx
+		dy := s.absRet.ago(k) - my
+		num += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	den := math.Sqrt(varX * varY)
+	if den == 0 {
+		return 0
+	}
+	c := num / den
+	if math.IsNaN(c) || math.IsInf(c, 0) {
+		return 0
+	}
+	return c
+}
+
+// ── Multi-timeframe features ─────────────────────────────────────────────────
+
+// MTFNames returns the columns AddMTFFeatures(df, tfMin) appends, in the
+// order MTFStream.Update returns them.
+func MTFNames(tfMin int) []string {
+	suffix := fmt.Sprintf("%dm", tfMin)
+	return []string{
+		"dist_ema_fast_" + suffix,
+		"dist_ema_slow_" + suffix,
+		"ema_crossover_" + suffix,
+		"rsi_" + suffix,
+		"atr_pct_" + suffix,
+	}
+}
+
+// MTFStream is the streaming form of AddMTFFeatures. A higher-timeframe
+// bar's EMA, RSI and ATR are updated once it is complete, i.e. when the
+// first 1m bar of the next bucket arrives; every 1m bar reads the features
+// of the previous complete bar, and zeros until 30 HT bars have started.
+type MTFStream struct {
+	tf timeframe.Timeframe
+
+	nHT        int
+	lastBucket time.Time
+	high, low  float64
+	close      float64
+
+	emaFast, emaSlow *EMAStream
+	rsi              *RSIStream
+	tr               *ring
+	prevClose        float64
+	features         [5]float64 // of the previous complete HT bar
+}
+
+func NewMTFStream(tfMin int) *MTFStream {
+	return &MTFStream{
+		tf:      timeframe.Timeframe{N: tfMin, Unit: timeframe.Minute},
+		emaFast: NewEMAStream(5),
+		emaSlow: NewEMAStream(21),
+		rsi:     NewRSIStream(7),
+		tr:      newRing(7),
+	}
+}
+
+func (s *MTFStream) Update(t time.Time, high, low, close float64) [5]float64 {
+	bs := s.tf.Start(t)
+	if s.nHT == 0 || !bs.Equal(s.lastBucket) {
+		if s.nHT > 0 {
+			s.complete()
+		}
+		s.nHT++
+		s.lastBucket = bs
+		s.high, s.low, s.close = high, low, close
+	} else {
+		if high > s.high {
+			s.high = high
+		}
+		if low < s.low {
+			s.low = low
+		}
+		s.close = close
+	}
+
+	if s.nHT < 30 {
+		return [5]float64{}
+	}
+	return s.features
+}
+
+// complete folds the finished HT bar into the HT indicators.
+func (s *MTFStream) complete() {
+	k := s.nHT - 1
+	c := s.close
+	emaFast := s.emaFast.Update(c)
+	emaSlow := s.emaSlow.Update(c)
+	rsi := s.rsi.Update(c)
+
+	hl := s.high - s.low
+	tr := hl
+	if k > 0 {
+		tr = math.Max(hl, math.Max(math.Abs(s.high-s.prevClose), math.Abs(s.low-s.prevClose)))
+	}
+	s.prevClose = c
+	s.tr.push(tr)
+	atr := 0.0
+	if k >= 6 {
+		for j := 6; j >= 0; j-- {
+			atr += s.tr.ago(j)
+		}
+		atr /= 7
+	}
+
+	atrPct := 0.0
+	if c != 0 {
+		atrPct = atr / (c + 1e-10)
+	}
+	s.features = [5]float64{
+		(c - emaFast) / (emaFast + 1e-10),
+		(c - emaSlow) / (emaSlow + 1e-10),
+		(emaFast - emaSlow) / (emaSlow + 1e-10),
+		rsi,
+		atrPct,
+	}
+}
@@ -0,0 +1,273 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"hft/internal/testutil"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// testFrame builds days of 1-minute bars. volume(i) gives each bar's
+// volume.
+func testFrame(days int, volume func(i int) float64) *dataframe.DataFrame {
+	candles := testutil.Candles(7, days)
+	for i := range candles {
+		candles[i].Volume = volume(i)
+	}
+	return testutil.Frame(candles)
+}
+
+func randomVolume(i int) float64 { return 1000 + float64((i*7919)%500) }
+
+func values(df *dataframe.DataFrame, name string) []float64 {
+	idx := FindIndexOf(df, name)
+	if idx < 0 {
+		panic("missing column " + name)
+	}
+	return df.Series[idx].(*dataframe.SeriesFloat64).Values
+}
+
+// prefix returns a copy of the first n rows of df.
+func prefix(df *dataframe.DataFrame, n int) *dataframe.DataFrame {
+	return df.Copy(dataframe.RangeFinite(0, n-1))
+}
+
+func compare(t *testing.T, name string, from int, want, got []float64) {
+	t.Helper()
+	for i := from; i < len(want); i++ {
+		if !testutil.SameFloat(want[i], got[i]) {
+			t.Fatalf("%s row %d: batch %v, stream %v", name, i, want[i], got[i])
+		}
+	}
+}
+
+// baseFrame is a 3-day frame with the RunKalmanv2 inputs of the streamed
+// indicators already computed by the batch functions.
+func baseFrame() *dataframe.DataFrame {
+	df := testFrame(3, randomVolume)
+	CCI(df, "cci", "close", 2)
+	ATR(df, "tr", "close", 5)
+	WMA(df, "wma", "tr", 30)
+	CalcX(df, "x", "close", 0.1, 2, "cci", "wma")
+	EMA(df, "ema_x", "x", 9)
+	LogReturn(df, "log_ret", "close", 1)
+	RollingStd(df, "rolling_std", "log_ret", 21)
+	return df
+}
+
+func TestStreamsMatchBatch(t *testing.T) {
+	df := baseFrame()
+	n := df.NRows()
+	o, h, l, c := values(df, "open"), values(df, "high"), values(df, "low"), values(df, "close")
+	_ts := df.Series[FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
+
+	run := func(update func(i int) float64) []float64 {
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = update(i)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name   string
+		from   int // first compared row
+		batch  func(df *dataframe.DataFrame, name string)
+		stream func() func(i int) float64
+	}{
+		{"ema", 0, func(df *dataframe.DataFrame, s string) { EMA(df, s, "close", 21) },
+			func() func(int) float64 { s := NewEMAStream(21); return func(i int) float64 { return s.Update(c[i]) } }},
+		{"wma", 0, func(df *dataframe.DataFrame, s string) { WMA(df, s, "tr", 30) },
+			func() func(int) float64 {
+				s := NewWMAStream(30)
+				return func(i int) float64 { return s.Update(BarTrueRange(h[i], l[i], c[i])) }
+			}},
+		// The batch copies CCI row 1 into row 0.
+		{"cci", 1, func(df *dataframe.DataFrame, s string) { CCI(df, s, "close", 2) },
+			func() func(int) float64 { s := NewCCIStream(2); return func(i int) float64 { return s.Update(c[i]) } }},
+		{"cci14", 1, func(df *dataframe.DataFrame, s string) { CCI(df, s, "close", 14) },
+			func() func(int) float64 { s := NewCCIStream(14); return func(i int) float64 { return s.Update(c[i]) } }},
+		{"tr", 0, func(df *dataframe.DataFrame, s string) { ATR(df, s, "close", 2) },
+			func() func(int) float64 { return func(i int) float64 { return BarTrueRange(h[i], l[i], c[i]) } }},
+		{"calcx", 0, func(df *dataframe.DataFrame, s string) { CalcX(df, s, "close", 0.1, 2, "cci", "wma") },
+			func() func(int) float64 {
+				cci, wma, s := values(df, "cci"), values(df, "wma"), NewCalcXStream(0.1)
+				return func(i int) float64 { return s.Update(c[i], cci[i], wma[i]) }
+			}},
+		{"kalman16", 0, func(df *dataframe.DataFrame, s string) { KalmanFilter(df, s, "ema_x", 16, 16, true) },
+			func() func(int) float64 {
+				src := values(df, "ema_x")
+				r, q := KalmanNoise(src, 16, 16)
+				s := NewKalmanStream(16, 16, r, q)
+				return func(i int) float64 { return s.Update(src[i]) }
+			}},
+		{"kalman32", 0, func(df *dataframe.DataFrame, s string) { KalmanFilter(df, s, "ema_x", 32, 32, true) },
+			func() func(int) float64 {
+				src := values(df, "ema_x")
+				r, q := KalmanNoise(src, 32, 32)
+				s := NewKalmanStream(32, 32, r, q)
+				return func(i int) float64 { return s.Update(src[i]) }
+			}},
+		{"swap", 0, func(df *dataframe.DataFrame, s string) {
+			ATR(df, "atr3", "close", 2)
+			CalcSWAPKalman(df, s, "ema_x", 0.25)
+		}, func() func(int) float64 {
+			src, s := values(df, "ema_x"), NewSwapKalmanStream(0.25)
+			return func(i int) float64 { return s.Update(*_ts[i], src[i], BarTrueRange(h[i], l[i], c[i])) }
+		}},
+		{"slope", 0, func(df *dataframe.DataFrame, s string) { Slope(df, s, "close", 10) },
+			func() func(int) float64 {
+				s := NewSlopeStream(10)
+				return func(i int) float64 { return s.Update(c[i]) }
+			}},
+		{"roc", 0, func(df *dataframe.DataFrame, s string) { ROC(df, s, "close", 5) },
+			func() func(int) float64 { s := NewROCStream(5); return func(i int) float64 { return s.Update(c[i]) } }},
+		{"log_ret_15", 0, func(df *dataframe.DataFrame, s string) { LogReturn(df, s, "close", 15) },
+			func() func(int) float64 {
+				s := NewLogReturnStream(15)
+				return func(i int) float64 { return s.Update(c[i]) }
+			}},
+		{"rsi", 0, func(df *dataframe.DataFrame, s string) { RSI(df, s, "close", 7) },
+			func() func(int) float64 { s := NewRSIStream(7); return func(i int) float64 { return s.Update(c[i]) } }},
+		{"atr_smoothed", 0, func(df *dataframe.DataFrame, s string) { ATRSmoothed(df, s, 7) },
+			func() func(int) float64 {
+				s := NewATRSmoothedStream(7)
+				return func(i int) float64 { return s.Update(h[i], l[i], c[i]) }
+			}},
+		{"rolling_std", 0, func(df *dataframe.DataFrame, s string) { RollingStd(df, s, "log_ret", 60) },
+			func() func(int) float64 {
+				src, s := values(df, "log_ret"), NewRollingStdStream(60)
+				return func(i int) float64 { return s.Update(src[i]) }
+			}},
+		{"vol_expansion", 0, func(df *dataframe.DataFrame, s string) { VolExpansion(df, s, "rolling_std", 60) },
+			func() func(int) float64 {
+				src, s := values(df, "rolling_std"), NewVolExpansionStream(60)
+				return func(i int) float64 { return s.Update(src[i]) }
+			}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "test_" + tc.name
+			tc.batch(df, name)
+			compare(t, tc.name, tc.from, values(df, name), run(tc.stream()))
+		})
+	}
+
+	t.Run("microstructure", func(t *testing.T) {
+		KalmanFilter(df, "fast_tempx_kalman", "ema_x", 16, 16, true)
+		KalmanFilter(df, "slow_tempx_kalman", "ema_x", 32, 32, true)
+		AddMicrostructureFeatures(df)
+		fast, slow := values(df, "fast_tempx_kalman"), values(df, "slow_tempx_kalman")
+		var s MicrostructureStream
+		got := make([][8]float64, n)
+		for i := range got {
+			got[i] = s.Update(o[i], h[i], l[i], c[i], fast[i], slow[i])
+		}
+		for k, name := range MicrostructureNames {
+			compare(t, name, 0, values(df, name), column(got, k))
+		}
+	})
+
+	t.Run("minute_of_day", func(t *testing.T) {
+		AddMinuteOfDay(df)
+		compare(t, "minute_of_day", 0, values(df, "minute_of_day"), run(func(i int) float64 { return MinuteOfDay(*_ts[i]) }))
+	})
+}
+
+func column[T [5]float64 | [8]float64](rows []T, k int) []float64 {
+	out := make([]float64, len(rows))
+	for i := range rows {
+		out[i] = rows[i][k]
+	}
+	return out
+}
+
+// The volume and MTF features decide their warmup from the whole frame, so
+// each streamed row is checked against the batch over the rows seen so far.
+func TestVolumeStreamMatchesPrefixBatch(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		volume func(i int) float64
+	}{
+		{"volume", randomVolume},
+		{"index", func(int) float64 { return 0 }},
+		{"late_volume", func(i int) float64 {
+			if i < 40 {
+				return 0
+			}
+			return randomVolume(i)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			df := testFrame(1, tc.volume)
+			c, v := values(df, "close"), values(df, "volume")
+			s := NewVolumeStream()
+			for i := 0; i < df.NRows(); i++ {
+				got := s.Update(c[i], v[i])
+				if i < 10 {
+					continue // the batch needs 10 rows (obv_slope's lag)
+				}
+				p := prefix(df, i+1)
+				AddVolumeFeatures(p)
+				for k, name := range VolumeNames {
+					if want := values(p, name)[i]; !testutil.SameFloat(want, got[k]) {
+						t.Fatalf("%s row %d: batch %v, stream %v", name, i, want, got[k])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMTFStreamMatchesBatch(t *testing.T) {
+	df := testFrame(3, randomVolume)
+	n := df.NRows()
+	h, l, c := values(df, "high"), values(df, "low"), values(df, "close")
+	_ts := df.Series[FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
+
+	for _, tf := range []int{5, 15} {
+		names := MTFNames(tf)
+		s := NewMTFStream(tf)
+		got := make([][5]float64, n)
+		for i := range got {
+			got[i] = s.Update(*_ts[i], h[i], l[i], c[i])
+		}
+
+		// Against the batch over the bars seen so far, through the warmup.
+		for i := 0; i < 32*tf; i++ {
+			p := prefix(df, i+1)
+			AddMTFFeatures(p, tf)
+			for k, name := range names {
+				if want := values(p, name)[i]; !testutil.SameFloat(want, got[i][k]) {
+					t.Fatalf("%s row %d: prefix batch %v, stream %v", name, i, want, got[i][k])
+				}
+			}
+		}
+
+		// Against the full batch once 30 HT bars have started.
+		AddMTFFeatures(df, tf)
+		for k, name := range names {
+			compare(t, name, 29*tf, values(df, name), column(got, k))
+		}
+	}
+}
+
+// With the noise estimated over the first two days, the third day streams
+// to the batch over all three up to rounding: the ceiling can land one point
+// apart.
+func TestKalmanStreamContinuesPastHistory(t *testing.T) {
+	df := baseFrame()
+	src := values(df, "ema_x")
+	KalmanFilter(df, "kalman", "ema_x", 16, 16, true)
+	want := values(df, "kalman")
+
+	r, q := KalmanNoise(src[:2*375], 16, 16)
+	s := NewKalmanStream(16, 16, r, q)
+	for i := range src {
+		if got := s.Update(src[i]); math.Abs(got-want[i]) > 1 {
+			t.Fatalf("row %d: batch %v, stream %v", i, want[i], got)
+		}
+	}
+}
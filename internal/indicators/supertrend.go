@@ -0,0 +1,79 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Supertrend, as TradingView's ta.supertrend.
+
+   basic upper/lower = hl2 ± mult × ATR   (Wilder ATR over period)
+   final upper       = basic upper if it is below the previous final
+                       upper or the previous close broke above it, else
+                       the previous final upper (the band only tightens)
+   final lower       = the mirror image
+   direction         = down on the first bar; an uptrend turns down when
+                       close falls below the final lower band, a downtrend
+                       turns up when close rises above the final upper band
+   supertrend        = the final lower band in an uptrend, the upper one
+                       in a downtrend
+*/
+
+// Supertrend appends the supertrend line as seriesname and its direction
+// (+1 up, −1 down) as seriesname+"_dir". Both are NaN until the ATR is
+// seeded (row period−1).
+func Supertrend(df *dataframe.DataFrame, seriesname string, period int, mult float64) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	n := len(_close)
+	atr := wilderATR(_high, _low, _close, period)
+
+	st := make([]float64, n)
+	dir := make([]float64, n)
+	var upper, lower float64
+	for i := 0; i < n; i++ {
+		if math.IsNaN(atr[i]) {
+			st[i], dir[i] = math.NaN(), math.NaN()
+			continue
+		}
+		hl2 := (_high[i] + _low[i]) / 2
+		basicUpper := hl2 + mult*atr[i]
+		basicLower := hl2 - mult*atr[i]
+
+		if i == period-1 {
+			upper, lower = basicUpper, basicLower
+			st[i], dir[i] = upper, -1
+			continue
+		}
+		prevUpper, prevLower, prevClose := upper, lower, _close[i-1]
+		if basicUpper < prevUpper || prevClose > prevUpper {
+			upper = basicUpper
+		} else {
+			upper = prevUpper
+		}
+		if basicLower > prevLower || prevClose < prevLower {
+			lower = basicLower
+		} else {
+			lower = prevLower
+		}
+
+		switch {
+		case dir[i-1] < 0 && _close[i] > upper:
+			dir[i] = 1
+		case dir[i-1] > 0 && _close[i] < lower:
+			dir[i] = -1
+		default:
+			dir[i] = dir[i-1]
+		}
+		if dir[i] > 0 {
+			st[i] = lower
+		} else {
+			st[i] = upper
+		}
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, st), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_dir", nil, dir), nil)
+}
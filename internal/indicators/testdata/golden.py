@@ -0,0 +1,239 @@
+"""Reference values for golden_test.go (TestIndicatorsGolden).
+
+Computes each indicator from its textbook definition, in plain Python and
+independently of the Go code, over the stored NIFTY 1-minute bars in
+example/postgres.json, and writes the sampled rows to golden.json:
+
+    python3 internal/indicators/testdata/golden.py
+
+"vwapv" uses a synthetic volume, 1000 + (i*7919) % 500, since index bars
+carry none.
+"""
+import json
+import math
+import os
+
+NAN = float("nan")
+HERE = os.path.dirname(os.path.abspath(__file__))
+
+with open(os.path.join(HERE, "..", "..", "..", "example", "postgres.json")) as f:
+    bars = sorted(json.load(f), key=lambda b: b["timestamp"])
+H = [b["high"] for b in bars]
+L = [b["low"] for b in bars]
+C = [b["close"] for b in bars]
+DAY = [b["timestamp"][:10] for b in bars]  # stored in IST (+0530)
+N = len(C)
+
+
+def sma(x, p):
+    return [NAN if i < p - 1 else sum(x[i - p + 1:i + 1]) / p for i in range(len(x))]
+
+
+def ema(x, p):
+    a = 2.0 / (p + 1)
+    out = [x[0]]
+    for v in x[1:]:
+        out.append(a * v + (1 - a) * out[-1])
+    return out
+
+
+def hh(x, p):
+    return [NAN if i < p - 1 else max(x[i - p + 1:i + 1]) for i in range(len(x))]
+
+
+def ll(x, p):
+    return [NAN if i < p - 1 else min(x[i - p + 1:i + 1]) for i in range(len(x))]
+
+
+def atr(p):
+    tr = [H[0] - L[0]] + [max(H[i] - L[i], abs(H[i] - C[i - 1]), abs(L[i] - C[i - 1])) for i in range(1, N)]
+    out = [NAN] * N
+    out[p - 1] = sum(tr[:p]) / p
+    for i in range(p, N):
+        out[i] = (out[i - 1] * (p - 1) + tr[i]) / p
+    return out
+
+
+out = {}
+
+# Bollinger(20, 2), sample std
+mid = sma(C, 20)
+for name in ("upper", "lower", "width", "pct_b"):
+    out["bb_" + name] = [NAN] * N
+for i in range(19, N):
+    w = C[i - 19:i + 1]
+    sd = math.sqrt(sum((v - mid[i]) ** 2 for v in w) / 19)
+    up, lo = mid[i] + 2 * sd, mid[i] - 2 * sd
+    out["bb_upper"][i], out["bb_lower"][i] = up, lo
+    out["bb_width"][i] = (up - lo) / mid[i]
+    out["bb_pct_b"][i] = (C[i] - lo) / (up - lo) if up > lo else 0.5
+out["bb_mid"] = mid
+
+# MACD(12, 26, 9)
+macd = [a - b for a, b in zip(ema(C, 12), ema(C, 26))]
+sig = ema(macd, 9)
+out["macd"], out["macd_signal"], out["macd_hist"] = macd, sig, [a - b for a, b in zip(macd, sig)]
+
+# Supertrend(10, 3), TradingView ta.supertrend with +1 = up
+a = atr(10)
+st, d = [NAN] * N, [NAN] * N
+up = lo = None
+for i in range(9, N):
+    hl2 = (H[i] + L[i]) / 2
+    bu, bl = hl2 + 3 * a[i], hl2 - 3 * a[i]
+    if i == 9:
+        up, lo, d[i] = bu, bl, -1
+    else:
+        up = bu if (bu < up or C[i - 1] > up) else up
+        lo = bl if (bl > lo or C[i - 1] < lo) else lo
+        if d[i - 1] == -1:
+            d[i] = 1 if C[i] > up else -1
+        else:
+            d[i] = -1 if C[i] < lo else 1
+    st[i] = lo if d[i] == 1 else up
+out["st"], out["st_dir"] = st, d
+
+
+# Session VWAP, bands at 2 sd; unit weights while the session has no volume
+def vwap(vol, prefix):
+    m, u, l = [], [], []
+    day = None
+    for i in range(N):
+        if DAY[i] != day:
+            day, sv, spv, sp2v, n, sp, sp2 = DAY[i], 0, 0, 0, 0, 0, 0
+        tp = (H[i] + L[i] + C[i]) / 3
+        if vol[i] > 0:
+            sv += vol[i]; spv += vol[i] * tp; sp2v += vol[i] * tp * tp
+        n += 1; sp += tp; sp2 += tp * tp
+        if sv > 0:
+            mean, var = spv / sv, sp2v / sv - (spv / sv) ** 2
+        else:
+            mean, var = sp / n, sp2 / n - (sp / n) ** 2
+        sd = math.sqrt(max(var, 0))
+        m.append(mean); u.append(mean + 2 * sd); l.append(mean - 2 * sd)
+    out[prefix], out[prefix + "_upper"], out[prefix + "_lower"] = m, u, l
+
+
+vwap([0] * N, "vwap")
+vwap([1000 + (i * 7919) % 500 for i in range(N)], "vwapv")
+
+# Stochastic(14, 3, 3)
+h14, l14 = hh(H, 14), ll(L, 14)
+raw = [NAN if math.isnan(h14[i]) else (50 if h14[i] == l14[i] else 100 * (C[i] - l14[i]) / (h14[i] - l14[i])) for i in range(N)]
+k = [NAN if i < 2 or any(math.isnan(v) for v in raw[i - 2:i + 1]) else sum(raw[i - 2:i + 1]) / 3 for i in range(N)]
+out["stoch_k"] = k
+out["stoch_d"] = [NAN if i < 2 or any(math.isnan(v) for v in k[i - 2:i + 1]) else sum(k[i - 2:i + 1]) / 3 for i in range(N)]
+
+# Donchian(20)
+out["dc_upper"], out["dc_lower"] = hh(H, 20), ll(L, 20)
+out["dc_mid"] = [(a + b) / 2 for a, b in zip(out["dc_upper"], out["dc_lower"])]
+
+# Keltner(EMA 20, ATR 10, 2)
+kc_mid, a10 = ema(C, 20), atr(10)
+out["kc_mid"] = kc_mid
+out["kc_upper"] = [m + 2 * x for m, x in zip(kc_mid, a10)]
+out["kc_lower"] = [m - 2 * x for m, x in zip(kc_mid, a10)]
+
+# Ichimoku(9, 26, 52), spans displaced 26 bars forward
+mp = lambda p: [(a + b) / 2 for a, b in zip(hh(H, p), ll(L, p))]
+t9, k26, s52 = mp(9), mp(26), mp(52)
+out["ichi_tenkan"], out["ichi_kijun"] = t9, k26
+out["ichi_span_a"] = [NAN if i < 26 else (t9[i - 26] + k26[i - 26]) / 2 for i in range(N)]
+out["ichi_span_b"] = [NAN if i < 26 else s52[i - 26] for i in range(N)]
+
+# Parabolic SAR(0.02, 0.2)
+sar_out, dir_out = [NAN] * N, [NAN] * N
+is_long = H[1] - H[0] >= L[0] - L[1]
+sar = min(L[0], L[1]) if is_long else max(H[0], H[1])
+ep = max(H[0], H[1]) if is_long else min(L[0], L[1])
+af = 0.02
+sar_out[1], dir_out[1] = sar, 1 if is_long else -1
+for i in range(2, N):
+    nxt = sar + af * (ep - sar)
+    if is_long:
+        nxt = min(nxt, L[i - 1], L[i - 2])
+        if L[i] < nxt:
+            is_long, nxt, ep, af = False, ep, L[i], 0.02
+        elif H[i] > ep:
+            ep, af = H[i], min(af + 0.02, 0.2)
+    else:
+        nxt = max(nxt, H[i - 1], H[i - 2])
+        if H[i] > nxt:
+            is_long, nxt, ep, af = True, ep, H[i], 0.02
+        elif L[i] < ep:
+            ep, af = L[i], min(af + 0.02, 0.2)
+    sar = nxt
+    sar_out[i], dir_out[i] = sar, 1 if is_long else -1
+out["psar"], out["psar_dir"] = sar_out, dir_out
+
+# Pivot levels and CPR from the previous IST day's high, low and close
+PV = ["p", "r1", "r2", "r3", "s1", "s2", "s3",
+      "fib_r1", "fib_r2", "fib_r3", "fib_s1", "fib_s2", "fib_s3",
+      "cam_r1", "cam_r2", "cam_r3", "cam_r4", "cam_s1", "cam_s2", "cam_s3", "cam_s4",
+      "cpr_tc", "cpr_bc", "prev_high", "prev_low", "prev_close"]
+ohlc = {}
+for i in range(N):
+    d = ohlc.setdefault(DAY[i], [H[i], L[i], C[i]])
+    d[0], d[1], d[2] = max(d[0], H[i]), min(d[1], L[i]), C[i]
+days = sorted(ohlc)
+for name in PV + ["cpr_width"]:
+    out["pv_" + name] = [NAN] * N
+    if name != "cpr_width":
+        out["pv_dist_" + name] = [NAN] * N
+for i in range(N):
+    k = days.index(DAY[i])
+    if k == 0:
+        continue
+    h, l, c = ohlc[days[k - 1]]
+    p, r = (h + l + c) / 3, h - l
+    bc, tc = sorted([(h + l) / 2, 2 * p - (h + l) / 2])
+    lv = dict(zip(PV, [
+        p, 2 * p - l, p + r, h + 2 * (p - l), 2 * p - h, p - r, l - 2 * (h - p),
+        p + 0.382 * r, p + 0.618 * r, p + r, p - 0.382 * r, p - 0.618 * r, p - r,
+        c + 1.1 * r / 12, c + 1.1 * r / 6, c + 1.1 * r / 4, c + 1.1 * r / 2,
+        c - 1.1 * r / 12, c - 1.1 * r / 6, c - 1.1 * r / 4, c - 1.1 * r / 2,
+        tc, bc, h, l, c]))
+    for name, v in lv.items():
+        out["pv_" + name][i] = v
+        out["pv_dist_" + name][i] = (C[i] - v) / v * 100
+    out["pv_cpr_width"][i] = (tc - bc) / p * 100
+
+# ADX(14) and ADX(7) with +DI and -DI, in Wilder's running-sum form:
+# TR, +DM and -DM summed over the first period moves, then
+# sum - sum/period + x; the DIs are the ratios of those sums.
+def adx(p, prefix):
+    a, pd, md = [NAN] * N, [NAN] * N, [NAN] * N
+    dx = [NAN] * N
+    str_ = spd = smd = 0.0
+    for i in range(1, N):
+        up, down = H[i] - H[i - 1], L[i - 1] - L[i]
+        pdm = up if up > down and up > 0 else 0.0
+        mdm = down if down > up and down > 0 else 0.0
+        tr = max(H[i] - L[i], abs(H[i] - C[i - 1]), abs(L[i] - C[i - 1]))
+        if i <= p:
+            str_, spd, smd = str_ + tr, spd + pdm, smd + mdm
+            if i < p:
+                continue
+        else:
+            str_, spd, smd = str_ - str_ / p + tr, spd - spd / p + pdm, smd - smd / p + mdm
+        pd[i], md[i] = (100 * spd / str_, 100 * smd / str_) if str_ > 0 else (0.0, 0.0)
+        dx[i] = 100 * abs(pd[i] - md[i]) / (pd[i] + md[i]) if pd[i] + md[i] > 0 else 0.0
+        if i == 2 * p - 1:
+            a[i] = sum(dx[p:i + 1]) / p
+        elif i > 2 * p - 1:
+            a[i] = (a[i - 1] * (p - 1) + dx[i]) / p
+    out[prefix], out[prefix + "_plus_di"], out[prefix + "_minus_di"] = a, pd, md
+
+
+adx(14, "adx")
+adx(7, "adx7")
+
+rows = list(range(80)) + list(range(80, N, 37)) + [N - 1]
+golden = {
+    "bars": N,
+    "rows": rows,
+    "columns": {name: [None if math.isnan(v[i]) else v[i] for i in rows] for name, v in sorted(out.items())},
+}
+with open(os.path.join(HERE, "golden.json"), "w") as f:
+    json.dump(golden, f, indent=None, separators=(",", ":"))
+    f.write("\n")
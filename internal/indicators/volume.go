@@ -71,7 +71,7 @@ func AddVolumeFeatures(df *dataframe.DataFrame) {
 
 	// 2) vol_roc = (vol - vol[t-5]) / vol[t-5]  — NaN for rows [0,5)
 	vROC := make([]float64, n)
-	for i := 0; i < 5; i++ {
+	for i := 0; i < 5 && i < n; i++ {
 		vROC[i] = math.NaN()
 	}
 	for i := 5; i < n; i++ {
@@ -124,7 +124,7 @@ func AddVolumeFeatures(df *dataframe.DataFrame) {
 		}
 	}
 	obvSlope := make([]float64, n)
-	for i := 0; i < 10; i++ {
+	for i := 0; i < 10 && i < n; i++ {
 		obvSlope[i] = math.NaN()
 	}
 	for i := 10; i < n; i++ {
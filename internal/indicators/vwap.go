@@ -0,0 +1,83 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Session VWAP with standard-deviation bands.
+
+   The average restarts at the first bar of each IST day. With typical
+   price tp = (high + low + close) / 3 and weight w = volume:
+
+     vwap  = Σ w·tp / Σ w
+     sd    = sqrt(Σ w·tp² / Σ w − vwap²)
+     bands = vwap ± mult × sd
+
+   Index data (Nifty) has no volume; while a session's volume so far sums
+   to zero every bar weighs 1, which makes it the session's average typical
+   price. (The rolling 30-bar VWAP of the model features is vwap_dist in
+   AddVolumeFeatures.)
+*/
+
+// SessionVWAP appends the session VWAP as seriesname and its bands as
+// seriesname+"_upper" and seriesname+"_lower".
+func SessionVWAP(df *dataframe.DataFrame, seriesname string, mult float64) {
+	_high := df.Series[FindIndexOf(df, "high")].(*dataframe.SeriesFloat64).Values
+	_low := df.Series[FindIndexOf(df, "low")].(*dataframe.SeriesFloat64).Values
+	_close := df.Series[FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
+	_ts := df.Series[FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
+	n := len(_close)
+	var _vol []float64
+	if idx := FindIndexOf(df, "volume"); idx >= 0 {
+		_vol = df.Series[idx].(*dataframe.SeriesFloat64).Values
+	}
+
+	ist := time.FixedZone("IST", 19800)
+	vwap := make([]float64, n)
+	upper := make([]float64, n)
+	lower := make([]float64, n)
+
+	var day string
+	var sumV, sumPV, sumP2V float64 // volume-weighted
+	var count, sumP, sumP2 float64  // unit-weighted
+	for i := 0; i < n; i++ {
+		if _ts[i] == nil {
+			vwap[i], upper[i], lower[i] = math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		if d := _ts[i].In(ist).Format("2006-01-02"); d != day {
+			day = d
+			sumV, sumPV, sumP2V = 0, 0, 0
+			count, sumP, sumP2 = 0, 0, 0
+		}
+		tp := (_high[i] + _low[i] + _close[i]) / 3
+		if _vol != nil && _vol[i] > 0 {
+			sumV += _vol[i]
+			sumPV += _vol[i] * tp
+			sumP2V += _vol[i] * tp * tp
+		}
+		count++
+		sumP += tp
+		sumP2 += tp * tp
+
+		var mean, variance float64
+		if sumV > 0 {
+			mean = sumPV / sumV
+			variance = sumP2V/sumV - mean*mean
+		} else {
+			mean = sumP / count
+			variance = sumP2/count - mean*mean
+		}
+		sd := math.Sqrt(math.Max(variance, 0))
+		vwap[i] = mean
+		upper[i] = mean + mult*sd
+		lower[i] = mean - mult*sd
+	}
+
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname, nil, vwap), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_upper", nil, upper), nil)
+	df.AddSeries(dataframe.NewSeriesFloat64(seriesname+"_lower", nil, lower), nil)
+}
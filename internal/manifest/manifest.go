@@ -0,0 +1,355 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"hft/internal/config"
+	"hft/internal/indicators"
+	"hft/internal/ml_model"
+	"hft/internal/risk"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Reproducibility manifest.
+
+   Every backtest, portfolio backtest and simulation records what it ran on:
+
+     data     per symbol: first/last bar, row count and a SHA-256 over the
+              timestamp + OHLCV of every row fed to the strategy
+     model    SHA-256 of model.onnx, scaler.json and model.meta.json
+     config   the effective strategy, risk and predictor sections
+     git      the commit the binary was built from (and whether the tree
+              was dirty)
+     result   trade count, wins, net PnL and a SHA-256 over the trade list
+
+   A manifest can be saved as JSON and rerun later; Compare reports every
+   field that differs between the recorded run and the rerun.
+*/
+
+// Run kinds.
+const (
+	KindBacktest   = "backtest"
+	KindPortfolio  = "portfolio"
+	KindSimulation = "simulation"
+)
+
+// ModelFiles are the model artifacts hashed into every manifest.
+var ModelFiles = []string{"model.onnx", "scaler.json", "model.meta.json"}
+
+// Manifest describes one run well enough to repeat it.
+type Manifest struct {
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"createdAt"`
+	Git       Git       `json:"git"`
+	Run       Run       `json:"run"`
+	Data      []DataSet `json:"data"`
+	Model     Model     `json:"model"`
+	Config    Settings  `json:"config"`
+	Result    Result    `json:"result"`
+}
+
+// Git identifies the source tree.
+type Git struct {
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// Run holds the run parameters. Fields that do not apply to Kind are empty.
+type Run struct {
+	Strategy   string   `json:"strategy"`
+	Symbols    []string `json:"symbols"`
+	StartDate  string   `json:"startDate,omitempty"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Mode       string   `json:"mode,omitempty"`
+	Timeframe  string   `json:"timeframe,omitempty"`
+	WarmupFrom string   `json:"warmupFrom,omitempty"`
+	WarmupBars int      `json:"warmupBars,omitempty"`
+
+	// Portfolio.
+	Capital           float64 `json:"capital,omitempty"`
+	MaxSymbolExposure float64 `json:"maxSymbolExposure,omitempty"`
+	MaxTotalExposure  float64 `json:"maxTotalExposure,omitempty"`
+	MaxOpenPositions  int     `json:"maxOpenPositions,omitempty"`
+
+	// Simulation.
+	SimDate    string `json:"simDate,omitempty"`
+	WarmupDays int    `json:"warmupDays,omitempty"`
+}
+
+// DataSet fingerprints the bars loaded for one symbol.
+type DataSet struct {
+	Symbol string    `json:"symbol"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Rows   int       `json:"rows"`
+	SHA256 string    `json:"sha256"`
+}
+
+// Model fingerprints the model artifacts.
+type Model struct {
+	Dir   string            `json:"dir"`
+	Files map[string]string `json:"files"` // file name → SHA-256, "" if missing
+}
+
+// Settings is the effective configuration of the run.
+type Settings struct {
+	Strategy  config.StrategyConfig  `json:"strategy"`
+	Risk      risk.Limits            `json:"risk"`
+	Predictor config.PredictorConfig `json:"predictor"`
+}
+
+// Result summarizes the trades of the run.
+type Result struct {
+	Trades int     `json:"trades"`
+	Wins   int     `json:"wins"`
+	NetPnL float64 `json:"netPnl"`
+	SHA256 string  `json:"sha256"` // over the trade list
+}
+
+// New starts a manifest for a run, capturing the git commit, model hashes
+// and effective configuration now.
+func New(kind string, run Run) *Manifest {
+	eff := config.Effective()
+	m := &Manifest{
+		Kind:      kind,
+		CreatedAt: time.Now(),
+		Git:       GitInfo(),
+		Run:       run,
+		Config: Settings{
+			Strategy:  eff.Strategy,
+			Risk:      eff.Risk,
+			Predictor: eff.Predictor,
+		},
+	}
+	dir := eff.ModelDir
+	if p := ml_model.GetPredictor(); p != nil {
+		dir = p.ModelDir()
+	}
+	m.Model = HashModel(dir)
+	return m
+}
+
+// Override records the sections s sets in place of the effective ones, for
+// a run that traded with s instead of the active configuration. A nil s
+// changes nothing.
+func (m *Manifest) Override(s *Settings) {
+	if s == nil {
+		return
+	}
+	st, c := s.Strategy, &m.Config.Strategy
+	if st.Regime != nil {
+		c.Regime = st.Regime
+	}
+	if st.KalmanExit != nil {
+		c.KalmanExit = st.KalmanExit
+	}
+	if st.TrailingStop != nil {
+		c.TrailingStop = st.TrailingStop
+	}
+	if st.ORB != nil {
+		c.ORB = st.ORB
+	}
+	if st.MeanRev != nil {
+		c.MeanRev = st.MeanRev
+	}
+	if st.Ensemble != nil {
+		c.Ensemble = st.Ensemble
+	}
+	if s.Risk.MaxOpenPositions > 0 {
+		m.Config.Risk = s.Risk
+	}
+	if s.Predictor.Smoothing != nil {
+		m.Config.Predictor.Smoothing = s.Predictor.Smoothing
+	}
+}
+
+// AddFrame records the fingerprint of symbol's bars in df.
+func (m *Manifest) AddFrame(symbol string, df *_df_.DataFrame) {
+	m.Data = append(m.Data, HashFrame(symbol, df))
+}
+
+// HashFrame hashes the timestamp and OHLCV columns of every row of df.
+// Columns missing from df hash as zeros.
+func HashFrame(symbol string, df *_df_.DataFrame) DataSet {
+	ds := DataSet{Symbol: symbol, Rows: df.NRows()}
+	var ts []*time.Time
+	if idx := indicators.FindIndexOf(df, "timestamp"); idx >= 0 {
+		ts = df.Series[idx].(*_df_.SeriesTime).Values
+	}
+	cols := make([][]float64, 0, 5)
+	for _, name := range []string{"open", "high", "low", "close", "volume"} {
+		if idx := indicators.FindIndexOf(df, name); idx >= 0 {
+			cols = append(cols, df.Series[idx].(*_df_.SeriesFloat64).Values)
+		} else {
+			cols = append(cols, nil)
+		}
+	}
+
+	h := sha256.New()
+	var buf [8]byte
+	for i := 0; i < ds.Rows; i++ {
+		var unix int64
+		if i < len(ts) && ts[i] != nil {
+			unix = ts[i].Unix()
+			if ds.From.IsZero() {
+				ds.From = *ts[i]
+			}
+			ds.To = *ts[i]
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(unix))
+		h.Write(buf[:])
+		for _, col := range cols {
+			var v float64
+			if i < len(col) {
+				v = col[i]
+			}
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+			h.Write(buf[:])
+		}
+	}
+	ds.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return ds
+}
+
+// HashModel hashes ModelFiles in dir.
+func HashModel(dir string) Model {
+	m := Model{Dir: dir, Files: make(map[string]string, len(ModelFiles))}
+	for _, name := range ModelFiles {
+		sum, err := hashFile(filepath.Join(dir, name))
+		if err != nil {
+			sum = ""
+		}
+		m.Files[name] = sum
+	}
+	return m
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ── Result ───────────────────────────────────────────────────
+
+// ResultBuilder accumulates closed trades into a Result.
+type ResultBuilder struct {
+	h hash.Hash
+	r Result
+}
+
+// NewResultBuilder returns an empty ResultBuilder.
+func NewResultBuilder() *ResultBuilder {
+	return &ResultBuilder{h: sha256.New()}
+}
+
+// Add records one closed trade. Prices and PnL are hashed to 4 decimals.
+func (b *ResultBuilder) Add(symbol, side string, entryTime, exitTime time.Time, entryPrice, exitPrice, pnl float64) {
+	b.r.Trades++
+	if pnl > 0 {
+		b.r.Wins++
+	}
+	b.r.NetPnL += pnl
+	fmt.Fprintf(b.h, "%s|%s|%d|%d|%.4f|%.4f|%.4f\n",
+		symbol, side, entryTime.Unix(), exitTime.Unix(), entryPrice, exitPrice, pnl)
+}
+
+// Result returns the summary of the trades added so far.
+func (b *ResultBuilder) Result() Result {
+	r := b.r
+	r.NetPnL = math.Round(r.NetPnL*1e4) / 1e4
+	r.SHA256 = hex.EncodeToString(b.h.Sum(nil))
+	return r
+}
+
+// ── Git ──────────────────────────────────────────────────────
+
+var (
+	gitOnce sync.Once
+	gitRev  Git
+)
+
+// GitInfo reads the commit from the build info, falling back to the git
+// binary for `go run` builds, which are not VCS-stamped.
+func GitInfo() Git {
+	gitOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					gitRev.Commit = s.Value
+				case "vcs.modified":
+					gitRev.Dirty = s.Value == "true"
+				}
+			}
+		}
+		if gitRev.Commit != "" {
+			return
+		}
+		out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+		if err != nil {
+			gitRev.Commit = "unknown"
+			return
+		}
+		gitRev.Commit = strings.TrimSpace(string(out))
+		if out, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil {
+			gitRev.Dirty = len(strings.TrimSpace(string(out))) > 0
+		}
+	})
+	return gitRev
+}
+
+// ── Persistence ──────────────────────────────────────────────
+
+// Save writes m as indented JSON to path, creating parent directories.
+func (m *Manifest) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create manifest dir: %w", err)
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Load reads a manifest written by Save.
+func Load(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", path, err)
+	}
+	switch m.Kind {
+	case KindBacktest, KindPortfolio, KindSimulation:
+	default:
+		return nil, fmt.Errorf("manifest %s: unknown kind %q", path, m.Kind)
+	}
+	return m, nil
+}
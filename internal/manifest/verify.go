@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Check is one field compared between a recorded run and its rerun.
+type Check struct {
+	Name string `json:"name"`
+	Want string `json:"want"`
+	Got  string `json:"got"`
+	OK   bool   `json:"ok"`
+	// Warning marks a difference that does not fail verification on its
+	// own (the git commit: a different commit may well reproduce).
+	Warning bool `json:"warning,omitempty"`
+}
+
+// Verification is the outcome of comparing a rerun to its manifest.
+type Verification struct {
+	OK       bool      `json:"ok"`
+	Recorded *Manifest `json:"recorded"`
+	Rerun    *Manifest `json:"rerun"`
+	Checks   []Check   `json:"checks"`
+}
+
+// Compare checks rerun against the recorded manifest. The run reproduces
+// when data, model, config and result all match.
+func Compare(recorded, rerun *Manifest) *Verification {
+	v := &Verification{OK: true, Recorded: recorded, Rerun: rerun}
+	check := func(name, want, got string) {
+		c := Check{Name: name, Want: want, Got: got, OK: want == got}
+		if !c.OK {
+			v.OK = false
+		}
+		v.Checks = append(v.Checks, c)
+	}
+
+	// ── Source ───────────────────────────────────────────────────
+	v.Checks = append(v.Checks, Check{
+		Name:    "git.commit",
+		Want:    gitString(recorded.Git),
+		Got:     gitString(rerun.Git),
+		OK:      recorded.Git == rerun.Git,
+		Warning: recorded.Git != rerun.Git,
+	})
+
+	// ── Data ─────────────────────────────────────────────────────
+	got := make(map[string]DataSet, len(rerun.Data))
+	for _, ds := range rerun.Data {
+		got[ds.Symbol] = ds
+	}
+	for _, want := range recorded.Data {
+		g := got[want.Symbol]
+		p := "data." + want.Symbol + "."
+		check(p+"rows", fmt.Sprint(want.Rows), fmt.Sprint(g.Rows))
+		check(p+"sha256", want.SHA256, g.SHA256)
+		delete(got, want.Symbol)
+	}
+	for _, extra := range sortedSymbols(got) {
+		check("data."+extra+".rows", "0", fmt.Sprint(got[extra].Rows))
+	}
+
+	// ── Model ────────────────────────────────────────────────────
+	for _, name := range ModelFiles {
+		check("model."+name, recorded.Model.Files[name], rerun.Model.Files[name])
+	}
+
+	// ── Config ───────────────────────────────────────────────────
+	check("config.strategy.regime", jsonString(recorded.Config.Strategy.Regime), jsonString(rerun.Config.Strategy.Regime))
+	check("config.strategy.kalman_exit", jsonString(recorded.Config.Strategy.KalmanExit), jsonString(rerun.Config.Strategy.KalmanExit))
+	check("config.strategy.orb", jsonString(recorded.Config.Strategy.ORB), jsonString(rerun.Config.Strategy.ORB))
+	check("config.strategy.mean_reversion", jsonString(recorded.Config.Strategy.MeanRev), jsonString(rerun.Config.Strategy.MeanRev))
+	check("config.strategy.ensemble", jsonString(recorded.Config.Strategy.Ensemble), jsonString(rerun.Config.Strategy.Ensemble))
+	check("config.strategy.trailing_stop", jsonString(recorded.Config.Strategy.TrailingStop), jsonString(rerun.Config.Strategy.TrailingStop))
+	check("config.risk", jsonString(recorded.Config.Risk), jsonString(rerun.Config.Risk))
+	check("config.predictor", jsonString(recorded.Config.Predictor), jsonString(rerun.Config.Predictor))
+
+	// ── Result ───────────────────────────────────────────────────
+	check("result.trades", fmt.Sprint(recorded.Result.Trades), fmt.Sprint(rerun.Result.Trades))
+	check("result.wins", fmt.Sprint(recorded.Result.Wins), fmt.Sprint(rerun.Result.Wins))
+	check("result.netPnl", fmt.Sprintf("%.4f", recorded.Result.NetPnL), fmt.Sprintf("%.4f", rerun.Result.NetPnL))
+	check("result.sha256", recorded.Result.SHA256, rerun.Result.SHA256)
+	return v
+}
+
+func gitString(g Git) string {
+	if g.Dirty {
+		return g.Commit + " (dirty)"
+	}
+	return g.Commit
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(b)
+}
+
+func sortedSymbols(m map[string]DataSet) []string {
+	out := make([]string, 0, len(m))
+	for s := range m {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -255,6 +255,13 @@ func (p *Predictor) SetSmoothing(cfg SmoothingConfig) {
 	p.smoothState = newHysteresisState(cfg.StartID)
 }
 
+// Smoothing returns the hysteresis filter config in use.
+func (p *Predictor) Smoothing() SmoothingConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.smoothing
+}
+
 // IsWarmedUp reports whether seqLen candles have been buffered and inference is valid.
 func (p *Predictor) IsWarmedUp() bool {
 	p.mu.Lock()
@@ -262,6 +269,9 @@ func (p *Predictor) IsWarmedUp() bool {
 	return p.candleCount >= p.seqLen
 }
 
+// ModelDir returns the directory the model was loaded from.
+func (p *Predictor) ModelDir() string { return p.modelDir }
+
 // WarmupCandles returns the number of candles required before inference is valid.
 func (p *Predictor) WarmupCandles() int { return p.seqLen }
 
@@ -508,7 +518,7 @@ func (p *Predictor) PredictRegimeFromTick(tick *types.Tick, extra Features) (Reg
 //	pred_status         string   – "invalid_features" | "buffering" | "ready"
 //	regime              string   – alias for pred_regime (backward-compat)
 func (p *Predictor) PredictRegimeFromDF(df *dataframe.DataFrame) error {
-	return p.predictRegimeFromDF(df, 1)
+	return p.predictRegimeFromDF(df, 1, p.Smoothing())
 }
 
 // PredictRegimeFromDFStrided is like PredictRegimeFromDF but runs inference
@@ -519,10 +529,19 @@ func (p *Predictor) PredictRegimeFromDFStrided(df *dataframe.DataFrame, stride i
 	if stride < 1 {
 		stride = 1
 	}
-	return p.predictRegimeFromDF(df, stride)
+	return p.predictRegimeFromDF(df, stride, p.Smoothing())
+}
+
+// PredictRegimeFromDFWith is PredictRegimeFromDFStrided with smoothing in
+// place of the predictor's own hysteresis config, which is left untouched.
+func (p *Predictor) PredictRegimeFromDFWith(df *dataframe.DataFrame, stride int, smoothing SmoothingConfig) error {
+	if stride < 1 {
+		stride = 1
+	}
+	return p.predictRegimeFromDF(df, stride, smoothing)
 }
 
-func (p *Predictor) predictRegimeFromDF(df *dataframe.DataFrame, stride int) error {
+func (p *Predictor) predictRegimeFromDF(df *dataframe.DataFrame, stride int, smoothing SmoothingConfig) error {
 	if p == nil || p.session == nil {
 		return fmt.Errorf("predictor not initialised")
 	}
@@ -630,8 +649,8 @@ func (p *Predictor) predictRegimeFromDF(df *dataframe.DataFrame, stride int) err
 	// ── 5. Smoothing ──────────────────────────────────────────────────────
 	smoothedIDs := make([]int, n)
 	copy(smoothedIDs, rawIDs)
-	if p.smoothing.Enabled {
-		smoothedIDs = hysteresisFilterSeries(rawIDs, rawProbs, &p.smoothing)
+	if smoothing.Enabled {
+		smoothedIDs = hysteresisFilterSeries(rawIDs, rawProbs, &smoothing)
 	}
 
 	// ── 6. Build output slices ────────────────────────────────────────────
@@ -712,6 +731,13 @@ func (p *Predictor) predictRegimeFromDF(df *dataframe.DataFrame, stride int) err
 	return nil
 }
 
+// PredictionColumns are the columns PredictRegimeFromDF appends.
+var PredictionColumns = []string{
+	"pred_regime_id_raw", "pred_regime_raw", "pred_regime_id", "pred_regime",
+	"pred_prob_bullish", "pred_prob_bearish", "pred_prob_volatile",
+	"pred_confidence", "pred_status", "regime",
+}
+
 // ─── Single-row DF inference (for simulation) ────────────────────────────────
 
 // TickPrediction holds the result of a single-row prediction.
@@ -845,3 +871,12 @@ func PredictRegimeFromDFStrided(df *dataframe.DataFrame, stride int) error {
 	}
 	return p.PredictRegimeFromDFStrided(df, stride)
 }
+
+// PredictRegimeFromDFWith calls PredictRegimeFromDFWith on the global singleton.
+func PredictRegimeFromDFWith(df *dataframe.DataFrame, stride int, smoothing SmoothingConfig) error {
+	p := GetPredictor()
+	if p == nil {
+		return fmt.Errorf("predictor not initialised; call InitPredictor first")
+	}
+	return p.PredictRegimeFromDFWith(df, stride, smoothing)
+}
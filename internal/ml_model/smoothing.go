@@ -17,23 +17,23 @@ import "math"
 type SmoothingConfig struct {
 	// Enabled enables / disables smoothing entirely.
 	// When false all other fields are ignored.
-	Enabled bool
+	Enabled bool `yaml:"enabled" json:"enabled"`
 
 	// ConfirmBars is the number of consecutive bars a new regime must appear
 	// before the switch is committed. Must be >= 1. (Python: confirm_bars)
-	ConfirmBars int
+	ConfirmBars int `yaml:"confirm_bars" json:"confirmBars"`
 
 	// MinConfidence gates confirmation: a bar only counts if softmax
 	// probability of the candidate regime >= this value. 0 disables the gate.
 	// (Python: min_confidence)
-	MinConfidence float64
+	MinConfidence float64 `yaml:"min_confidence" json:"minConfidence"`
 
 	// ForbidVolatileAfterNonVolatile blocks transitions back to Volatile once
 	// the current regime is Bullish or Bearish. Implements the intraday rule:
 	//   Volatile → Bullish / Bearish allowed
 	//   Bullish / Bearish → Volatile blocked
 	// (Python: forbid_volatile_after_nonvolatile)
-	ForbidVolatileAfterNonVolatile bool
+	ForbidVolatileAfterNonVolatile bool `yaml:"forbid_volatile_after_nonvolatile" json:"forbidVolatileAfterNonVolatile"`
 
 	// StartID is the class index used to pre-seed the hysteresis state before
 	// any predictions are seen. Use -1 (or leave zero-value of int and
@@ -46,7 +46,7 @@ type SmoothingConfig struct {
 	//    2 = Volatile
 	//
 	// (Python: start_state: "volatile" → 2)
-	StartID int
+	StartID int `yaml:"start_id" json:"startId"`
 }
 
 // DefaultSmoothingConfig returns the config from model.yaml (enabled, 5-bar,
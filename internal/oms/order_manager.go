@@ -1,4 +1,133 @@
 package oms
 
+import (
+	"fmt"
+	"math"
+
+	"hft/pkg/types"
+)
+
 // OrderManager tracks and manages order state.
-type OrderManager struct{}
+//
+// It turns approved intents into orders, sends them to the broker and, once
+// filled, updates the open position and publishes an Event — the same events
+// the backtest and executor subscribers already turn into trades and stats.
+type OrderManager struct {
+	symbol string
+	broker Broker
+	state  *State
+	events chan<- *types.Event
+	nextID int64
+}
+
+// NewOrderManager creates an OMS for one symbol.
+func NewOrderManager(symbol string, broker Broker, events chan<- *types.Event) *OrderManager {
+	return &OrderManager{
+		symbol: symbol,
+		broker: broker,
+		state:  &State{},
+		events: events,
+	}
+}
+
+// State returns the live OMS state.
+func (m *OrderManager) State() *State {
+	return m.state
+}
+
+// Restore sets the open position, e.g. from state saved before a restart,
+// without placing an order. nil leaves the OMS flat.
+func (m *OrderManager) Restore(p *types.Position) {
+	if p == nil {
+		m.state.Position = nil
+		return
+	}
+	c := *p
+	m.state.Position = &c
+}
+
+// Mark updates the open position's running and peak profit at price.
+func (m *OrderManager) Mark(price float64) {
+	p := m.state.Position
+	if p == nil {
+		return
+	}
+	if p.Kind == "BUY" {
+		p.Profit = price - p.EntryPrice
+	} else {
+		p.Profit = p.EntryPrice - price
+	}
+	p.PeakProfit = math.Max(p.PeakProfit, p.Profit)
+	p.PeakLoss = math.Min(p.PeakLoss, p.Profit)
+}
+
+// Submit places the order for an intent, applies the fill and returns the
+// resulting event (also published on the events channel, if any).
+func (m *OrderManager) Submit(in types.Intent) (*types.Event, error) {
+	side := in.Kind
+	if in.Type == "EXIT" {
+		// Closing a long sells, closing a short buys.
+		side = "SELL"
+		if in.Kind == "SELL" {
+			side = "BUY"
+		}
+	}
+
+	qty := in.Quantity
+	if qty <= 0 {
+		qty = 1
+	}
+	if in.Type == "EXIT" && m.state.Position != nil {
+		qty = m.state.Position.Quantity
+	}
+
+	m.nextID++
+	o := types.Order{
+		ID:        m.nextID,
+		Symbol:    m.symbol,
+		Side:      side,
+		Price:     in.Price,
+		Quantity:  qty,
+		Status:    "NEW",
+		CreatedAt: in.Timestamp,
+		UpdatedAt: in.Timestamp,
+	}
+	err := m.broker.Place(&o)
+	m.state.Orders = append(m.state.Orders, o)
+	if err != nil {
+		return nil, fmt.Errorf("place order: %w", err)
+	}
+	if o.Status != "FILLED" {
+		return nil, fmt.Errorf("order %d not filled: %s", o.ID, o.Status)
+	}
+
+	ev := &types.Event{
+		Kind:       in.Kind,
+		Type:       in.Type,
+		EntryPrice: o.Price,
+		Timestamp:  in.Timestamp,
+		Reason:     in.Reason,
+		Context:    in.Context,
+	}
+
+	if in.Type == "ENTRY" {
+		p := &types.Position{}
+		if in.Kind == "BUY" {
+			p.Buy(o.Price, in.Timestamp)
+		} else {
+			p.Sell(o.Price, in.Timestamp)
+		}
+		p.Quantity = o.Quantity
+		m.state.Position = p
+	} else if p := m.state.Position; p != nil {
+		p.Exit(o.Price, in.Timestamp)
+		ev.PeakProfit = p.PeakProfit
+		ev.PeakLoss = p.PeakLoss
+		m.state.Position = nil
+	}
+
+	if m.events != nil {
+		m.events <- ev
+	}
+	return ev, nil
+}
@@ -0,0 +1,36 @@
+package oms
+
+import (
+	"fmt"
+	"time"
+
+	"hft/pkg/types"
+)
+
+// Broker executes orders on behalf of the OMS.
+type Broker interface {
+	// Place submits the order and updates its Status and fill Price.
+	Place(o *types.Order) error
+}
+
+// PaperBroker fills every order immediately at its limit price, adjusted by a
+// fixed slippage in points against the order side.
+type PaperBroker struct {
+	Slippage float64
+}
+
+// Place fills the order in-process.
+func (b *PaperBroker) Place(o *types.Order) error {
+	switch o.Side {
+	case "BUY":
+		o.Price += b.Slippage
+	case "SELL":
+		o.Price -= b.Slippage
+	default:
+		o.Status = "REJECTED"
+		return fmt.Errorf("paper broker: unknown side %q", o.Side)
+	}
+	o.Status = "FILLED"
+	o.UpdatedAt = time.Now()
+	return nil
+}
@@ -1,4 +1,17 @@
 package oms
 
+import "hft/pkg/types"
+
 // State represents order management system state.
-type State struct{}
+type State struct {
+	Position *types.Position // open position, nil when flat
+	Orders   []types.Order   // every order placed this session
+}
+
+// Open reports the number of open positions.
+func (s *State) Open() int {
+	if s.Position == nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,470 @@
+package parity
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"hft/internal/config"
+	"hft/internal/executor"
+	"hft/internal/indicators"
+	"hft/internal/manifest"
+	"hft/internal/ml_model"
+	"hft/internal/strategy"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Backtest ↔ simulation ↔ live parity harness.
+
+   Runs the same prepared DataFrame (indicators + pred_prob_* columns) through
+   the three code paths that make trading decisions:
+
+     vectorized  strategy.FindRegimeSignalWithConfig (RunBars over RegimeBar)
+     simulation  executor.RunSimulation with NoDelay
+     event       executor.BarHandler (strategy → risk → paper OMS), i.e. live
+
+   Each leg is reduced to a trade list and one decision per bar (inputs,
+   action, resulting position). Every pair of legs is diffed and the first
+   divergence is reported with the bars leading up to it.
+*/
+
+// Leg names.
+const (
+	Vectorized = "vectorized"
+	Simulation = "simulation"
+	Event      = "event"
+)
+
+// Config describes one parity run.
+type Config struct {
+	Symbol string
+	Frame  *_df_.DataFrame
+	Regime *strategy.RegimeSignalConfig
+
+	// Predict feeds the simulation leg. nil reads the frame's pred_prob_*
+	// columns, which isolates the decision logic from inference.
+	Predict func(i int) (ml_model.TickPrediction, error)
+
+	// ContextBars is how many bars before a divergence are reported (default 5).
+	ContextBars int
+}
+
+// Trade is one round trip as seen by a leg.
+type Trade struct {
+	Kind       string
+	EntryTime  time.Time
+	EntryPrice float64
+	ExitTime   time.Time
+	ExitPrice  float64
+	Reason     string
+	DayType    string // entry's day-type tag
+}
+
+func (t Trade) String() string {
+	return fmt.Sprintf("%s %s@%.2f → %s@%.2f (%s, %s day)", t.Kind,
+		t.EntryTime.Format("2006-01-02 15:04"), t.EntryPrice,
+		t.ExitTime.Format("2006-01-02 15:04"), t.ExitPrice, t.Reason, t.DayType)
+}
+
+// Decision is what a leg saw and did on one bar.
+type Decision struct {
+	Index    int
+	Time     time.Time
+	Close    float64
+	ProbBull float64
+	ProbBear float64
+	ProbVol  float64
+	Action   string // e.g. "EXIT BUY STOP_LOSS; ENTRY SELL", "" for none
+	Position int    // after the bar: +1 long, -1 short, 0 flat
+}
+
+func (d Decision) String() string {
+	action := d.Action
+	if action == "" {
+		action = "-"
+	}
+	return fmt.Sprintf("#%d %s close=%.2f bull=%.4f bear=%.4f vol=%.4f pos=%+d action=%s",
+		d.Index, d.Time.Format("2006-01-02 15:04"), d.Close, d.ProbBull, d.ProbBear, d.ProbVol, d.Position, action)
+}
+
+// Leg is the outcome of one code path.
+type Leg struct {
+	Name      string
+	Trades    []Trade
+	Decisions []Decision
+}
+
+// Divergence is the first point where two legs disagree.
+type Divergence struct {
+	A, B    string
+	Index   int // bar index, -1 if only the trade lists differ
+	Reason  string
+	Context [][2]Decision // preceding bars and the divergent bar, A then B
+	TradeA  *Trade        // first mismatching trade of each leg (nil if none)
+	TradeB  *Trade
+}
+
+// Report holds every leg and the first divergence of each pair.
+type Report struct {
+	Legs        []*Leg
+	Divergences []*Divergence
+}
+
+// OK reports whether all legs agree.
+func (r *Report) OK() bool {
+	return len(r.Divergences) == 0
+}
+
+// Divergence returns the first divergence between legs a and b, or nil.
+func (r *Report) Divergence(a, b string) *Divergence {
+	for _, d := range r.Divergences {
+		if (d.A == a && d.B == b) || (d.A == b && d.B == a) {
+			return d
+		}
+	}
+	return nil
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, l := range r.Legs {
+		fmt.Fprintf(&sb, "%-10s %d trades\n", l.Name, len(l.Trades))
+	}
+	if r.OK() {
+		sb.WriteString("parity: all legs agree\n")
+		return sb.String()
+	}
+	for _, d := range r.Divergences {
+		fmt.Fprintf(&sb, "\n%s vs %s: %s\n", d.A, d.B, d.Reason)
+		for _, c := range d.Context {
+			fmt.Fprintf(&sb, "  %-10s %s\n", d.A, c[0])
+			fmt.Fprintf(&sb, "  %-10s %s\n", d.B, c[1])
+		}
+		if d.TradeA != nil || d.TradeB != nil {
+			fmt.Fprintf(&sb, "  %-10s trade: %s\n", d.A, tradeStr(d.TradeA))
+			fmt.Fprintf(&sb, "  %-10s trade: %s\n", d.B, tradeStr(d.TradeB))
+		}
+	}
+	return sb.String()
+}
+
+func tradeStr(t *Trade) string {
+	if t == nil {
+		return "<none>"
+	}
+	return t.String()
+}
+
+// Run executes all three legs and diffs them.
+func Run(cfg Config) (*Report, error) {
+	if cfg.Frame == nil || cfg.Frame.NRows() == 0 {
+		return nil, fmt.Errorf("parity: empty frame")
+	}
+	if cfg.Symbol == "" {
+		cfg.Symbol = "nifty"
+	}
+	if cfg.Regime == nil {
+		cfg.Regime = strategy.DefaultRegimeSignalConfig()
+	}
+	if cfg.ContextBars <= 0 {
+		cfg.ContextBars = 5
+	}
+
+	in, err := readInputs(cfg.Frame)
+	if err != nil {
+		return nil, err
+	}
+
+	vec := &Leg{Name: Vectorized}
+	vecEvents := collect(func(events chan *types.Event) {
+		strategy.FindRegimeSignalWithConfig(cfg.Frame, &types.Position{}, nil, events, cfg.Regime)
+	})
+	vec.Trades, vec.Decisions = reduce(in, in.probs, vecEvents)
+
+	ev := &Leg{Name: Event}
+	evEvents := collect(func(events chan *types.Event) {
+		h := executor.NewBarHandler(cfg.Symbol, cfg.Regime, events)
+		h.Replay(executor.NewFrameFeed(cfg.Symbol, cfg.Frame), 0)
+	})
+	ev.Trades, ev.Decisions = reduce(in, in.probs, evEvents)
+
+	sim, err := runSimulation(cfg, in)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Legs: []*Leg{vec, sim, ev}}
+	for _, pair := range [][2]*Leg{{vec, sim}, {vec, ev}, {sim, ev}} {
+		if d := diff(pair[0], pair[1], cfg.ContextBars); d != nil {
+			r.Divergences = append(r.Divergences, d)
+		}
+	}
+	return r, nil
+}
+
+// ── Legs ─────────────────────────────────────────────────────────────────────
+
+type inputs struct {
+	ts    []*time.Time
+	close []float64
+	probs [][3]float64
+	index map[int64]int // unix nano → row
+}
+
+func readInputs(df *_df_.DataFrame) (*inputs, error) {
+	tsIdx := indicators.FindIndexOf(df, "timestamp")
+	closeIdx := indicators.FindIndexOf(df, "close")
+	if tsIdx < 0 || closeIdx < 0 {
+		return nil, fmt.Errorf("parity: frame needs timestamp and close columns")
+	}
+	n := df.NRows()
+	in := &inputs{
+		ts:    df.Series[tsIdx].(*_df_.SeriesTime).Values,
+		close: df.Series[closeIdx].(*_df_.SeriesFloat64).Values,
+		probs: make([][3]float64, n),
+		index: make(map[int64]int, n),
+	}
+	for k, name := range []string{"pred_prob_bullish", "pred_prob_bearish", "pred_prob_volatile"} {
+		idx := indicators.FindIndexOf(df, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("parity: frame is missing %s", name)
+		}
+		vals := df.Series[idx].(*_df_.SeriesFloat64).Values
+		for i := 0; i < n; i++ {
+			in.probs[i][k] = vals[i]
+		}
+	}
+	for i, t := range in.ts {
+		if t != nil {
+			in.index[t.UnixNano()] = i
+		}
+	}
+	return in, nil
+}
+
+// collect runs fn with an events channel and returns everything it sent.
+func collect(fn func(events chan *types.Event)) []*types.Event {
+	events := make(chan *types.Event)
+	done := make(chan []*types.Event)
+	go func() {
+		var out []*types.Event
+		for e := range events {
+			out = append(out, e)
+		}
+		done <- out
+	}()
+	fn(events)
+	close(events)
+	return <-done
+}
+
+func runSimulation(cfg Config, in *inputs) (*Leg, error) {
+	probs := make([][3]float64, len(in.probs))
+	predict := cfg.Predict
+	if predict == nil {
+		predict = func(i int) (ml_model.TickPrediction, error) {
+			p := in.probs[i]
+			return ml_model.TickPrediction{ProbBull: p[0], ProbBear: p[1], ProbVol: p[2]}, nil
+		}
+	}
+
+	var first time.Time
+	for _, t := range in.ts {
+		if t != nil {
+			first = *t
+			break
+		}
+	}
+
+	var events []*types.Event
+	err := executor.RunSimulation(executor.SimConfig{
+		SimDate:  first.In(time.FixedZone("IST", 19800)).Format("2006-01-02"),
+		NoDelay:  true,
+		Quiet:    true,
+		Settings: &manifest.Settings{Strategy: config.StrategyConfig{Regime: cfg.Regime}},
+		Frame:    cfg.Frame,
+		Predict: func(i int) (ml_model.TickPrediction, error) {
+			tp, err := predict(i)
+			if err == nil {
+				probs[i] = [3]float64{tp.ProbBull, tp.ProbBear, tp.ProbVol}
+			}
+			return tp, err
+		},
+		OnEvent: func(eventType string, data map[string]interface{}) {
+			var typ string
+			switch eventType {
+			case "sim_entry":
+				typ = "ENTRY"
+			case "sim_exit":
+				typ = "EXIT"
+			default:
+				return
+			}
+			kind := "BUY"
+			if data["side"] == "SHORT" {
+				kind = "SELL"
+			}
+			price, _ := data["price"].(float64)
+			if typ == "EXIT" {
+				price, _ = data["exitPrice"].(float64)
+			}
+			reason, _ := data["reason"].(string)
+			ts, _ := data["timestamp"].(time.Time)
+			ev := &types.Event{Kind: kind, Type: typ, EntryPrice: price, Timestamp: ts, Reason: reason}
+			if dayType, ok := data["dayType"].(string); ok {
+				ev.Context = &types.EntryContext{DayType: dayType}
+			}
+			events = append(events, ev)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parity: simulation: %w", err)
+	}
+
+	leg := &Leg{Name: Simulation}
+	leg.Trades, leg.Decisions = reduce(in, probs, events)
+	return leg, nil
+}
+
+// reduce turns a leg's events into trades and per-bar decisions.
+func reduce(in *inputs, probs [][3]float64, events []*types.Event) ([]Trade, []Decision) {
+	actions := make(map[int][]string)
+	var trades []Trade
+	var open *Trade
+	for _, e := range events {
+		i, ok := in.index[e.Timestamp.UnixNano()]
+		if !ok {
+			i = -1
+		}
+		if e.Type == "ENTRY" {
+			actions[i] = append(actions[i], "ENTRY "+e.Kind)
+			open = &Trade{Kind: e.Kind, EntryTime: e.Timestamp, EntryPrice: e.EntryPrice}
+			if e.Context != nil {
+				open.DayType = e.Context.DayType
+			}
+			continue
+		}
+		actions[i] = append(actions[i], "EXIT "+e.Kind+" "+e.Reason)
+		if open != nil {
+			open.ExitTime = e.Timestamp
+			open.ExitPrice = e.EntryPrice
+			open.Reason = e.Reason
+			trades = append(trades, *open)
+			open = nil
+		}
+	}
+
+	decisions := make([]Decision, 0, len(in.ts))
+	position := 0
+	for i, t := range in.ts {
+		if t == nil {
+			continue
+		}
+		acts := actions[i]
+		for _, a := range acts {
+			switch a {
+			case "ENTRY BUY":
+				position = 1
+			case "ENTRY SELL":
+				position = -1
+			default:
+				position = 0
+			}
+		}
+		decisions = append(decisions, Decision{
+			Index:    i,
+			Time:     *t,
+			Close:    in.close[i],
+			ProbBull: probs[i][0],
+			ProbBear: probs[i][1],
+			ProbVol:  probs[i][2],
+			Action:   strings.Join(acts, "; "),
+			Position: position,
+		})
+	}
+	return trades, decisions
+}
+
+// ── Diff ─────────────────────────────────────────────────────────────────────
+
+// sameInput treats NaN and 0 as equal: batch inference leaves NaN where the
+// per-row path returns "not warmed up", and neither can pass a threshold.
+func sameInput(a, b float64) bool {
+	if math.IsNaN(a) {
+		a = 0
+	}
+	if math.IsNaN(b) {
+		b = 0
+	}
+	return math.Abs(a-b) <= 1e-9
+}
+
+func diff(a, b *Leg, contextBars int) *Divergence {
+	n := len(a.Decisions)
+	if len(b.Decisions) < n {
+		n = len(b.Decisions)
+	}
+	for k := 0; k < n; k++ {
+		da, db := a.Decisions[k], b.Decisions[k]
+		var reason string
+		switch {
+		case da.Index != db.Index:
+			reason = "bar sequence differs"
+		case da.Action != db.Action:
+			reason = "action differs"
+		case da.Position != db.Position:
+			reason = "position differs"
+		case !sameInput(da.ProbBull, db.ProbBull) || !sameInput(da.ProbBear, db.ProbBear) || !sameInput(da.ProbVol, db.ProbVol):
+			reason = "model inputs differ"
+		}
+		if reason == "" {
+			continue
+		}
+		d := &Divergence{
+			A:      a.Name,
+			B:      b.Name,
+			Index:  da.Index,
+			Reason: fmt.Sprintf("%s at bar %d (%s)", reason, da.Index, da.Time.Format("2006-01-02 15:04")),
+		}
+		for c := max(0, k-contextBars); c <= k; c++ {
+			d.Context = append(d.Context, [2]Decision{a.Decisions[c], b.Decisions[c]})
+		}
+		d.TradeA, d.TradeB = firstTradeMismatch(a.Trades, b.Trades)
+		return d
+	}
+
+	if len(a.Decisions) != len(b.Decisions) {
+		return &Divergence{A: a.Name, B: b.Name, Index: -1,
+			Reason: fmt.Sprintf("bar count differs: %d vs %d", len(a.Decisions), len(b.Decisions))}
+	}
+	if ta, tb := firstTradeMismatch(a.Trades, b.Trades); ta != nil || tb != nil {
+		return &Divergence{A: a.Name, B: b.Name, Index: -1, Reason: "trade lists differ", TradeA: ta, TradeB: tb}
+	}
+	return nil
+}
+
+func firstTradeMismatch(a, b []Trade) (*Trade, *Trade) {
+	for k := 0; k < len(a) || k < len(b); k++ {
+		var ta, tb *Trade
+		if k < len(a) {
+			ta = &a[k]
+		}
+		if k < len(b) {
+			tb = &b[k]
+		}
+		if ta == nil || tb == nil || !sameTrade(*ta, *tb) {
+			return ta, tb
+		}
+	}
+	return nil, nil
+}
+
+func sameTrade(a, b Trade) bool {
+	return a.Kind == b.Kind && a.Reason == b.Reason && a.DayType == b.DayType &&
+		a.EntryTime.Equal(b.EntryTime) && a.ExitTime.Equal(b.ExitTime) &&
+		a.EntryPrice == b.EntryPrice && a.ExitPrice == b.ExitPrice
+}
@@ -0,0 +1,213 @@
+package parity
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+
+	"hft/internal/dataframe"
+	"hft/internal/indicators"
+	"hft/internal/ml_model"
+	"hft/internal/strategy"
+	"hft/internal/testutil"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// syntheticFrame builds days of 1-minute bars with smooth,
+// threshold-crossing regime probabilities.
+func syntheticFrame(days int) *_df_.DataFrame {
+	ticks := testutil.Ticks("nifty", testutil.Candles(42, days))
+
+	df := dataframe.InitDataFrame()
+	dataframe.LoadHistoryBacktest(df, ticks)
+
+	n := len(ticks)
+	atr := make([]float64, n)
+	bull := make([]float64, n)
+	bear := make([]float64, n)
+	vol := make([]float64, n)
+	for i, t := range ticks {
+		atr[i] = (t.High - t.Low) * 1.5
+		bull[i] = 0.5 + 0.45*math.Sin(float64(i)/23)
+		bear[i] = 0.5 - 0.45*math.Sin(float64(i)/23+0.3)
+		vol[i] = 0.15 + 0.15*math.Pow(math.Sin(float64(i)/211), 2)
+	}
+	df.AddSeries(_df_.NewSeriesFloat64("atr3", nil, atr), nil)
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_bullish", nil, bull), nil)
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_bearish", nil, bear), nil)
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_volatile", nil, vol), nil)
+	return df
+}
+
+func legByName(r *Report, name string) *Leg {
+	for _, l := range r.Legs {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// The vectorized scan loops the per-bar strategy, so all three paths must
+// agree bar for bar with the default config, early-window filters included.
+func TestParityAllLegsDefaultConfig(t *testing.T) {
+	r, err := Run(Config{Frame: syntheticFrame(6)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Fatalf("legs diverge:\n%s", r)
+	}
+	if n := len(legByName(r, Event).Trades); n == 0 {
+		t.Fatal("expected trades on synthetic data")
+	}
+}
+
+// All three paths must also agree without the filters that need a tranche's
+// first 30 minutes.
+func TestParityAllLegsWithoutEarlyWindowFilters(t *testing.T) {
+	cfg := strategy.DefaultRegimeSignalConfig()
+	cfg.EarlyDirConfirm = false
+	cfg.MaxVolProb = 0
+
+	r, err := Run(Config{Frame: syntheticFrame(6), Regime: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Fatalf("legs diverge:\n%s", r)
+	}
+	if n := len(legByName(r, Event).Trades); n == 0 {
+		t.Fatal("expected trades on synthetic data")
+	}
+}
+
+// A single perturbed prediction in the simulation leg must be reported at
+// exactly that bar, with context.
+func TestParityReportsFirstDivergence(t *testing.T) {
+	df := syntheticFrame(4)
+	cfg := strategy.DefaultRegimeSignalConfig()
+	cfg.EarlyDirConfirm = false
+	cfg.MaxVolProb = 0
+
+	base, err := Run(Config{Frame: df, Regime: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trades := legByName(base, Event).Trades
+	if len(trades) == 0 {
+		t.Fatal("expected trades on synthetic data")
+	}
+	entry := trades[0].EntryTime
+
+	in, _ := readInputs(df)
+	target := in.index[entry.UnixNano()]
+
+	r, err := Run(Config{
+		Frame:  df,
+		Regime: cfg,
+		Predict: func(i int) (ml_model.TickPrediction, error) {
+			p := in.probs[i]
+			if i == target {
+				return ml_model.TickPrediction{ProbVol: p[2]}, nil
+			}
+			return ml_model.TickPrediction{ProbBull: p[0], ProbBear: p[1], ProbVol: p[2]}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Divergence(Vectorized, Event) != nil {
+		t.Fatalf("vectorized vs event should still agree:\n%s", r)
+	}
+	d := r.Divergence(Simulation, Event)
+	if d == nil {
+		t.Fatal("expected simulation vs event divergence")
+	}
+	if d.Index != target {
+		t.Fatalf("first divergence at bar %d, want %d:\n%s", d.Index, target, r)
+	}
+	if len(d.Context) != 6 || d.TradeA == nil {
+		t.Fatalf("divergence context incomplete:\n%s", r)
+	}
+}
+
+// strided returns a copy of df whose pred_prob_* columns hold what batch
+// inference with the given stride writes: a prediction every stride rows,
+// carried forward over the rows in between (see PredictRegimeFromDFStrided).
+func strided(df *_df_.DataFrame, stride int) *_df_.DataFrame {
+	out := df.Copy()
+	for _, name := range []string{"pred_prob_bullish", "pred_prob_bearish", "pred_prob_volatile"} {
+		vals := out.Series[indicators.FindIndexOf(out, name)].(*_df_.SeriesFloat64).Values
+		for i := range vals {
+			if i%stride != 0 {
+				vals[i] = vals[i-1]
+			}
+		}
+	}
+	return out
+}
+
+// The backtest runs strided batch inference over the whole frame while the
+// simulation calls PredictSingleRow on every bar. With stride 1 the two feed
+// identical probabilities; with a larger stride the simulation must diverge
+// from both batch legs, and only because of the carried-forward rows.
+func TestParityStridedBatchVsPerRowInference(t *testing.T) {
+	df := syntheticFrame(6)
+	in, _ := readInputs(df)
+	perRow := func(i int) (ml_model.TickPrediction, error) {
+		p := in.probs[i]
+		return ml_model.TickPrediction{ProbBull: p[0], ProbBear: p[1], ProbVol: p[2]}, nil
+	}
+
+	r, err := Run(Config{Frame: strided(df, 1), Predict: perRow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Fatalf("stride 1 diverges from per-row inference:\n%s", r)
+	}
+
+	batch := strided(df, 5)
+	r, err = Run(Config{Frame: batch, Predict: perRow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := r.Divergence(Vectorized, Event); d != nil {
+		t.Fatalf("batch legs disagree on the same strided frame:\n%s", r)
+	}
+	d := r.Divergence(Vectorized, Simulation)
+	if d == nil || r.Divergence(Simulation, Event) == nil {
+		t.Fatalf("per-row simulation agrees with stride-5 batch inference:\n%s", r)
+	}
+	// Up to the divergence the legs saw the same inputs on every inference
+	// row and differ only on carried-forward ones.
+	for _, c := range d.Context {
+		v, s := c[0], c[1]
+		same := v.ProbBull == s.ProbBull && v.ProbBear == s.ProbBear && v.ProbVol == s.ProbVol
+		if v.Index%5 == 0 && !same {
+			t.Fatalf("inference row %d fed different probabilities:\n%s", v.Index, r)
+		}
+	}
+
+	// A per-row predictor that follows the batch schedule closes the gap.
+	bin, _ := readInputs(batch)
+	r, err = Run(Config{Frame: batch, Predict: func(i int) (ml_model.TickPrediction, error) {
+		p := bin.probs[i]
+		return ml_model.TickPrediction{ProbBull: p[0], ProbBear: p[1], ProbVol: p[2]}, nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Fatalf("legs diverge with the stride applied to the simulation too:\n%s", r)
+	}
+}
@@ -0,0 +1,271 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"hft/internal/indicators"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// ── Function registry ────────────────────────────────────────────────────────
+
+type paramKind int
+
+const (
+	intParam paramKind = iota
+	floatParam
+	boolParam
+)
+
+type param struct {
+	name string
+	kind paramKind
+}
+
+// funcDef describes one indicator function: how many source columns it
+// takes, its parameters, the columns it reads without naming them and the
+// columns it appends (nil: the step name alone).
+type funcDef struct {
+	sources int
+	params  []param
+	inputs  []string
+	outs    func(s Step) []string
+	run     func(df *dataframe.DataFrame, s Step)
+}
+
+var (
+	hlc  = []string{"high", "low", "close"}
+	ohlc = []string{"open", "high", "low", "close"}
+)
+
+func ints(names ...string) []param   { return kinds(intParam, names) }
+func floats(names ...string) []param { return kinds(floatParam, names) }
+
+func kinds(kind paramKind, names []string) []param {
+	out := make([]param, len(names))
+	for i, n := range names {
+		out[i] = param{n, kind}
+	}
+	return out
+}
+
+// suffixed returns the outputs seriesname + each suffix.
+func suffixed(suffixes ...string) func(s Step) []string {
+	return func(s Step) []string {
+		out := make([]string, len(suffixes))
+		for i, sfx := range suffixes {
+			out[i] = s.Name + sfx
+		}
+		return out
+	}
+}
+
+func fixed(names []string) func(Step) []string {
+	return func(Step) []string { return names }
+}
+
+var funcs = map[string]*funcDef{
+	// Kalman / CCI chain
+	"cci": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.CCI(df, s.Name, s.Sources[0], s.int("period")) }},
+	"true_range": {inputs: hlc,
+		run: func(df *dataframe.DataFrame, s Step) { indicators.ATR(df, s.Name, "close", 1) }},
+	"calcx": {sources: 3, params: append(floats("param"), ints("atr_period")...),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.CalcX(df, s.Name, s.Sources[0], s.float("param"), s.int("atr_period"), s.Sources[1], s.Sources[2])
+		}},
+	"kalman": {sources: 1, params: append(ints("window", "cutoff_divisor"), param{"time_enabled", boolParam}),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.KalmanFilter(df, s.Name, s.Sources[0], s.int("window"), s.int("cutoff_divisor"), s.bool("time_enabled"))
+		}},
+	"swap_kalman": {sources: 1, params: floats("factor"), inputs: []string{"atr3", "timestamp"},
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.CalcSWAPKalman(df, s.Name, s.Sources[0], s.float("factor"))
+		}},
+
+	// Moving averages, trend and momentum
+	"ema": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.EMA(df, s.Name, s.Sources[0], s.int("period")) }},
+	"sma": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.SMA(df, s.Name, s.Sources[0], s.int("period")) }},
+	"wma": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.WMA(df, s.Name, s.Sources[0], s.int("period")) }},
+	"slope": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.Slope(df, s.Name, s.Sources[0], s.int("period")) }},
+	"price_distance": {sources: 2,
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.PriceDistance(df, s.Name, s.Sources[0], s.Sources[1])
+		}},
+	"rsi": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.RSI(df, s.Name, s.Sources[0], s.int("period")) }},
+	"roc": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.ROC(df, s.Name, s.Sources[0], s.int("period")) }},
+	"log_return": {sources: 1, params: ints("shift"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.LogReturn(df, s.Name, s.Sources[0], s.int("shift")) }},
+	"macd": {sources: 1, params: ints("fast", "slow", "signal"), outs: suffixed("", "_signal", "_hist"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.MACD(df, s.Name, s.Sources[0], s.int("fast"), s.int("slow"), s.int("signal"))
+		}},
+	"adx": {params: ints("period"), inputs: hlc, outs: suffixed("", "_plus_di", "_minus_di"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.ADX(df, s.Name, s.int("period")) }},
+	"supertrend": {params: append(ints("period"), floats("mult")...), inputs: hlc, outs: suffixed("", "_dir"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.Supertrend(df, s.Name, s.int("period"), s.float("mult"))
+		}},
+	"psar": {params: floats("step", "max_step"), inputs: []string{"high", "low"}, outs: suffixed("", "_dir"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.ParabolicSAR(df, s.Name, s.float("step"), s.float("max_step"))
+		}},
+	"stochastic": {params: ints("k_period", "smooth_k", "d_period"), inputs: hlc, outs: suffixed("_k", "_d"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.Stochastic(df, s.Name, s.int("k_period"), s.int("smooth_k"), s.int("d_period"))
+		}},
+	"ichimoku": {params: ints("tenkan", "kijun", "senkou"), inputs: []string{"high", "low"},
+		outs: suffixed("_tenkan", "_kijun", "_span_a", "_span_b"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.Ichimoku(df, s.Name, s.int("tenkan"), s.int("kijun"), s.int("senkou"))
+		}},
+
+	// Volatility, bands and levels
+	"atr_smoothed": {params: ints("period"), inputs: hlc,
+		run: func(df *dataframe.DataFrame, s Step) { indicators.ATRSmoothed(df, s.Name, s.int("period")) }},
+	"rolling_std": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.RollingStd(df, s.Name, s.Sources[0], s.int("period"))
+		}},
+	"hl_range_pct": {inputs: hlc,
+		run: func(df *dataframe.DataFrame, s Step) { indicators.HLRangePct(df, s.Name) }},
+	"vol_expansion": {sources: 1, params: ints("period"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.VolExpansion(df, s.Name, s.Sources[0], s.int("period"))
+		}},
+	"bollinger": {sources: 1, params: append(ints("period"), floats("mult")...),
+		outs: suffixed("_mid", "_upper", "_lower", "_width", "_pct_b"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.Bollinger(df, s.Name, s.Sources[0], s.int("period"), s.float("mult"))
+		}},
+	"donchian": {params: ints("period"), inputs: []string{"high", "low"}, outs: suffixed("_upper", "_lower", "_mid"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.Donchian(df, s.Name, s.int("period")) }},
+	"keltner": {params: append(ints("ema_period", "atr_period"), floats("mult")...), inputs: hlc,
+		outs: suffixed("_mid", "_upper", "_lower"),
+		run: func(df *dataframe.DataFrame, s Step) {
+			indicators.Keltner(df, s.Name, s.int("ema_period"), s.int("atr_period"), s.float("mult"))
+		}},
+	"session_vwap": {params: floats("mult"), inputs: append(hlc, "volume", "timestamp"), outs: suffixed("", "_upper", "_lower"),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.SessionVWAP(df, s.Name, s.float("mult")) }},
+	"pivots": {inputs: append(hlc, "timestamp"), outs: pivotOutputs,
+		run: func(df *dataframe.DataFrame, s Step) { indicators.PivotLevels(df, s.Name) }},
+
+	// Feature groups with fixed column names
+	"microstructure": {inputs: append(ohlc, "fast_tempx_kalman", "slow_tempx_kalman"), outs: fixed(indicators.MicrostructureNames),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.AddMicrostructureFeatures(df) }},
+	"minute_of_day": {inputs: []string{"timestamp"}, outs: fixed([]string{"minute_of_day"}),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.AddMinuteOfDay(df) }},
+	"volume_features": {inputs: []string{"close", "volume"}, outs: fixed(indicators.VolumeNames),
+		run: func(df *dataframe.DataFrame, s Step) { indicators.AddVolumeFeatures(df) }},
+	"mtf": {params: ints("tf"), inputs: append(ohlc, "timestamp"),
+		outs: func(s Step) []string { return indicators.MTFNames(s.int("tf")) },
+		run:  func(df *dataframe.DataFrame, s Step) { indicators.AddMTFFeatures(df, s.int("tf")) }},
+}
+
+func pivotOutputs(s Step) []string {
+	var out []string
+	for _, name := range indicators.PivotLevelNames {
+		out = append(out, s.Name+"_"+name)
+	}
+	out = append(out, s.Name+"_cpr_width")
+	for _, name := range indicators.PivotLevelNames {
+		out = append(out, s.Name+"_dist_"+name)
+	}
+	return out
+}
+
+// Funcs returns the registered function names.
+func Funcs() []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *funcDef) outputs(s Step) []string {
+	if d.outs == nil {
+		return []string{s.Name}
+	}
+	return d.outs(s)
+}
+
+// check lists what is wrong with the step's sources and parameters.
+func (d *funcDef) check(s Step) []string {
+	var msgs []string
+	if len(s.Sources) != d.sources {
+		msgs = append(msgs, fmt.Sprintf("%s takes %d source(s), got %d", s.Func, d.sources, len(s.Sources)))
+	}
+	known := make(map[string]bool, len(d.params))
+	for _, p := range d.params {
+		known[p.name] = true
+		v, ok := s.Params[p.name]
+		if !ok {
+			msgs = append(msgs, fmt.Sprintf("param %s is required", p.name))
+			continue
+		}
+		var valid bool
+		switch p.kind {
+		case intParam:
+			f, isNum := number(v)
+			valid = isNum && f == math.Trunc(f) && f > 0
+		case floatParam:
+			_, valid = number(v)
+		case boolParam:
+			_, valid = v.(bool)
+		}
+		if !valid {
+			msgs = append(msgs, fmt.Sprintf("param %s: invalid value %v", p.name, v))
+		}
+	}
+	var unknown []string
+	for name := range s.Params {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		msgs = append(msgs, fmt.Sprintf("unknown param %s for %s", name, s.Func))
+	}
+	return msgs
+}
+
+// ── Parameter access ─────────────────────────────────────────────────────────
+
+// number converts a decoded YAML/JSON number.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func (s Step) float(name string) float64 {
+	f, _ := number(s.Params[name])
+	return f
+}
+
+func (s Step) int(name string) int {
+	return int(s.float(name))
+}
+
+func (s Step) bool(name string) bool {
+	b, _ := s.Params[name].(bool)
+	return b
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"hft/internal/indicators"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/* Parallel execution.
+
+   RunParallel starts one goroutine per step; a step waits for its
+   dependencies, then takes one of the worker slots. Independent branches
+   of kalman_v2 (the Kalman/CCI chain, EMAs and slopes, momentum,
+   volatility, volume, the 5m and 15m MTF groups, ADX) therefore run side
+   by side, and the microstructure step starts as soon as both Kalman lines
+   are done.
+
+   No goroutine touches the caller's frame. Each step runs on a private
+   frame holding the bar series and its dependencies' outputs (the series
+   are shared, and only read), and keeps the series it appended. Once every
+   step is done the outputs are added to the caller's frame in Run's order,
+   so both produce the same columns in the same order with identical
+   values.
+*/
+
+// RunParallel is Run with independent steps computed concurrently on up to
+// workers goroutines (GOMAXPROCS when workers <= 0). A panic in a step is
+// re-raised here after the running steps finish, leaving df unchanged.
+func (p *Pipeline) RunParallel(df *dataframe.DataFrame, workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var bars []dataframe.Series
+	for _, c := range BaseColumns {
+		if idx := indicators.FindIndexOf(df, c); idx >= 0 {
+			bars = append(bars, df.Series[idx])
+		}
+	}
+
+	outputs := make([][]dataframe.Series, len(p.Nodes))
+	done := make([]chan struct{}, len(p.Nodes))
+	for k := range done {
+		done[k] = make(chan struct{})
+	}
+	slots := make(chan struct{}, workers)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failure interface{}
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return failure != nil
+	}
+
+	for k := range p.Nodes {
+		wg.Add(1)
+		go func(n *Node, k int) {
+			defer wg.Done()
+			defer close(done[k])
+			for _, d := range n.Deps {
+				<-done[d]
+			}
+			if failed() {
+				return
+			}
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					if failure == nil {
+						failure = fmt.Sprintf("pipeline: step %s: %v", n.Name, r)
+					}
+					mu.Unlock()
+				}
+			}()
+
+			in := append([]dataframe.Series(nil), bars...)
+			for _, d := range n.Deps {
+				in = append(in, outputs[d]...)
+			}
+			frame := dataframe.NewDataFrame(in...)
+			n.Run(frame)
+
+			out := make([]dataframe.Series, len(n.Outputs))
+			for i, col := range n.Outputs {
+				idx := indicators.FindIndexOf(frame, col)
+				if idx < 0 {
+					panic(fmt.Sprintf("did not append %s", col))
+				}
+				out[i] = frame.Series[idx]
+			}
+			outputs[k] = out
+		}(&p.Nodes[k], k)
+	}
+	wg.Wait()
+	if failure != nil {
+		panic(failure)
+	}
+
+	for _, out := range outputs {
+		for _, s := range out {
+			df.AddSeries(s, nil)
+		}
+	}
+}
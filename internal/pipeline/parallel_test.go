@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"hft/internal/indicators"
+	"hft/internal/storage/sqlite"
+	"hft/internal/testutil"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// barSeries builds days of 1-minute bars, with volume when withVolume is
+// set.
+func barSeries(days int, withVolume bool) []dataframe.Series {
+	candles := testutil.Candles(5, days)
+	if !withVolume {
+		testutil.WithoutVolume(candles)
+	}
+	return testutil.Series(candles)
+}
+
+func TestRunParallelMatchesRun(t *testing.T) {
+	for _, withVolume := range []bool{true, false} {
+		bars := barSeries(3, withVolume)
+		seq := dataframe.NewDataFrame(bars...)
+		Default().Run(seq)
+
+		for _, workers := range []int{1, 3, 16} {
+			par := dataframe.NewDataFrame(bars...)
+			Default().RunParallel(par, workers)
+
+			if got, want := par.Names(), seq.Names(); len(got) != len(want) {
+				t.Fatalf("volume=%v workers=%d: %d columns, Run appends %d", withVolume, workers, len(got), len(want))
+			}
+			for i, name := range seq.Names() {
+				if par.Names()[i] != name {
+					t.Fatalf("volume=%v workers=%d: column %d is %s, Run has %s", withVolume, workers, i, par.Names()[i], name)
+				}
+				want, ok := seq.Series[i].(*dataframe.SeriesFloat64)
+				if !ok {
+					continue
+				}
+				got := par.Series[i].(*dataframe.SeriesFloat64).Values
+				for r := range want.Values {
+					if !testutil.SameFloat(want.Values[r], got[r]) {
+						t.Fatalf("volume=%v workers=%d %s row %d: Run %v, RunParallel %v", withVolume, workers, name, r, want.Values[r], got[r])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRunParallelReportsStepPanic(t *testing.T) {
+	spec, err := Parse([]byte(`
+steps:
+  - {name: ema, func: ema, sources: [close], params: {period: 5}}
+  - {name: slope, func: slope, sources: [ema], params: {period: 5}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Resolve(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Nodes[0].Outputs = []string{"ema", "ema_extra"}
+
+	df := dataframe.NewDataFrame(barSeries(1, false)...)
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("no panic")
+		}
+		if msg, _ := r.(string); msg != "pipeline: step ema: did not append ema_extra" {
+			t.Fatalf("panic %v", r)
+		}
+		if indicators.FindIndexOf(df, "ema") >= 0 {
+			t.Fatal("frame changed by a failed run")
+		}
+	}()
+	p.RunParallel(df, 4)
+}
+
+// benchDB is a tick database (see internal/storage/sqlite) whose stored
+// NIFTY 1-minute history the benchmarks run on; without it they run on
+// synthetic bars.
+var benchDB = os.Getenv("HFT_BENCH_DB")
+
+// benchBars returns the last two years (500 sessions, ~190k rows) of
+// stored NIFTY bars from benchDB, or 500 synthetic sessions when it is
+// unset or holds fewer.
+func benchBars(b *testing.B) []dataframe.Series {
+	const sessions = 500
+	if benchDB != "" {
+		store, err := sqlite.NewTickStore(benchDB)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer store.Close()
+		ticks, err := store.ListTicksFiltered(context.Background(), "nifty", "1", 0, "", "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(ticks) >= sessions*375 {
+			ticks = ticks[len(ticks)-sessions*375:]
+			candles := make([]types.Candle, len(ticks))
+			for i, t := range ticks {
+				candles[i] = types.Candle{Timestamp: t.Timestamp, Open: t.Open, High: t.High, Low: t.Low, Close: t.Close, Volume: t.Volume}
+			}
+			b.Logf("%d stored bars from %s", len(candles), benchDB)
+			return testutil.Series(candles)
+		}
+		b.Logf("%s holds %d NIFTY bars, fewer than %d sessions; using synthetic bars", benchDB, len(ticks), sessions)
+	}
+	return barSeries(sessions, false)
+}
+
+// The kalman_v2 pipeline over two years of 1-minute bars. Compare ns/op of
+// the two to see the speedup; it grows with GOMAXPROCS up to the length of
+// the longest chain (cci → wma → calcx → ema → kalman → microstructure).
+func benchmarkRun(b *testing.B, run func(p *Pipeline, df *dataframe.DataFrame)) {
+	bars := benchBars(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run(Default(), dataframe.NewDataFrame(bars...))
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	benchmarkRun(b, func(p *Pipeline, df *dataframe.DataFrame) { p.Run(df) })
+}
+
+func BenchmarkRunParallel(b *testing.B) {
+	benchmarkRun(b, func(p *Pipeline, df *dataframe.DataFrame) { p.RunParallel(df, 0) })
+}
@@ -0,0 +1,304 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rocketlaunchr/dataframe-go"
+	"gopkg.in/yaml.v3"
+)
+
+/* Declarative indicator pipeline.
+
+   A Spec lists indicator steps in YAML (or JSON): each step names its
+   output column, the registered function that computes it, its source
+   columns and its parameters. Resolve checks every step against the
+   function registry and orders the steps as a dependency DAG: a step runs
+   after the steps producing its sources and the columns its function reads
+   implicitly (swap_kalman reads atr3, microstructure the two Kalman lines).
+   Independent steps keep their spec order, so a spec listed in dependency
+   order appends its columns exactly in that order.
+
+   Problems are reported all at once, each prefixed with the step:
+
+     steps[3] fast_tempx: unknown column "wma_tr_3"
+     steps[9] atr3: column "atr3" is also produced by steps[8]
+
+   The built-in spec (kalman_v2.yaml) is the RunKalmanv2 feature set;
+   Check compares a pipeline's columns with the model's feature_cols so a
+   mismatch fails at startup instead of feeding the model zeros.
+*/
+
+// BaseColumns are the bar columns every pipeline starts from.
+var BaseColumns = []string{"open", "high", "low", "close", "volume", "timestamp"}
+
+// Step is one indicator call of a Spec.
+type Step struct {
+	Name    string                 `yaml:"name" json:"name"`
+	Func    string                 `yaml:"func" json:"func"`
+	Sources []string               `yaml:"sources,omitempty" json:"sources,omitempty"`
+	Params  map[string]interface{} `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
+// Spec is a declarative indicator pipeline.
+type Spec struct {
+	Name  string `yaml:"name" json:"name"`
+	Steps []Step `yaml:"steps" json:"steps"`
+}
+
+// Parse decodes a YAML or JSON spec.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse pipeline: %w", err)
+	}
+	return &spec, nil
+}
+
+// Load reads and resolves the spec at path.
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pipeline: %w", err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p, err := Resolve(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+//go:embed kalman_v2.yaml
+var defaultSpec []byte
+
+var (
+	defaultOnce     sync.Once
+	defaultPipeline *Pipeline
+)
+
+// Default returns the built-in kalman_v2 pipeline. It panics if the
+// embedded spec does not resolve, which the package tests rule out.
+func Default() *Pipeline {
+	defaultOnce.Do(func() {
+		spec, err := Parse(defaultSpec)
+		if err == nil {
+			defaultPipeline, err = Resolve(spec)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("pipeline: built-in kalman_v2: %v", err))
+		}
+	})
+	return defaultPipeline
+}
+
+// ── Resolution ───────────────────────────────────────────────────────────────
+
+// Node is a resolved step.
+type Node struct {
+	Step
+	Outputs []string // columns the step appends
+	Deps    []int    // indices (in Pipeline.Nodes) of the steps it reads from
+
+	def *funcDef
+}
+
+// Pipeline is a resolved Spec, its nodes in execution order.
+type Pipeline struct {
+	Name  string
+	Nodes []Node
+}
+
+// Resolve validates spec against the function registry and orders its
+// steps by their dependencies.
+func Resolve(spec *Spec) (*Pipeline, error) {
+	var errs []string
+	bad := func(i int, format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("steps[%d] %s: ", i, spec.Steps[i].Name)+fmt.Sprintf(format, args...))
+	}
+
+	base := make(map[string]bool, len(BaseColumns))
+	for _, c := range BaseColumns {
+		base[c] = true
+	}
+
+	// Outputs and their producers.
+	nodes := make([]Node, len(spec.Steps))
+	producer := make(map[string]int)
+	names := make(map[string]int)
+	for i, s := range spec.Steps {
+		nodes[i].Step = s
+		if s.Name == "" {
+			bad(i, "name is required")
+		} else if j, dup := names[s.Name]; dup {
+			bad(i, "duplicate step name (steps[%d])", j)
+		}
+		names[s.Name] = i
+
+		def, ok := funcs[s.Func]
+		if !ok {
+			bad(i, "unknown func %q", s.Func)
+			continue
+		}
+		nodes[i].def = def
+		for _, msg := range def.check(s) {
+			bad(i, "%s", msg)
+		}
+		nodes[i].Outputs = def.outputs(s)
+		for _, col := range nodes[i].Outputs {
+			if base[col] {
+				bad(i, "column %q would overwrite a bar column", col)
+			} else if j, dup := producer[col]; dup {
+				bad(i, "column %q is also produced by steps[%d]", col, j)
+			} else {
+				producer[col] = i
+			}
+		}
+	}
+
+	// Dependencies.
+	deps := make([][]int, len(nodes))
+	for i := range nodes {
+		if nodes[i].def == nil {
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, col := range nodes[i].inputs() {
+			if base[col] {
+				continue
+			}
+			j, ok := producer[col]
+			switch {
+			case !ok:
+				bad(i, "unknown column %q", col)
+			case j == i:
+				bad(i, "reads its own output %q", col)
+			case !seen[j]:
+				seen[j] = true
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid pipeline:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+
+	// Topological order; among ready steps the earliest in the spec first.
+	order := make([]int, 0, len(nodes))
+	pos := make([]int, len(nodes)) // spec index → position in order
+	done := make([]bool, len(nodes))
+	for len(order) < len(nodes) {
+		next := -1
+		for i := range nodes {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, j := range deps[i] {
+				ready = ready && done[j]
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i := range nodes {
+				if !done[i] {
+					cycle = append(cycle, spec.Steps[i].Name)
+				}
+			}
+			return nil, fmt.Errorf("invalid pipeline: dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		pos[next] = len(order)
+		order = append(order, next)
+	}
+
+	p := &Pipeline{Name: spec.Name, Nodes: make([]Node, len(order))}
+	for k, i := range order {
+		n := nodes[i]
+		n.Deps = make([]int, len(deps[i]))
+		for d, j := range deps[i] {
+			n.Deps[d] = pos[j]
+		}
+		sort.Ints(n.Deps)
+		p.Nodes[k] = n
+	}
+	return p, nil
+}
+
+// inputs are the columns the step reads: its sources, then the columns its
+// function reads implicitly.
+func (n *Node) inputs() []string {
+	return append(append([]string(nil), n.Sources...), n.def.inputs...)
+}
+
+// ── Running ──────────────────────────────────────────────────────────────────
+
+// Run appends every column of the pipeline to df, step by step.
+func (p *Pipeline) Run(df *dataframe.DataFrame) {
+	for k := range p.Nodes {
+		p.Nodes[k].Run(df)
+	}
+}
+
+// Run appends the step's columns to df.
+func (n *Node) Run(df *dataframe.DataFrame) {
+	n.def.run(df, n.Step)
+}
+
+// Columns returns the columns the pipeline appends, in the order Run
+// appends them.
+func (p *Pipeline) Columns() []string {
+	var cols []string
+	for _, n := range p.Nodes {
+		cols = append(cols, n.Outputs...)
+	}
+	return cols
+}
+
+// Check reports the required columns (e.g. a model's feature_cols) that
+// neither the pipeline nor the bars provide.
+func (p *Pipeline) Check(required []string) error {
+	have := make(map[string]bool)
+	for _, c := range BaseColumns {
+		have[c] = true
+	}
+	for _, c := range p.Columns() {
+		have[c] = true
+	}
+	var missing []string
+	for _, c := range required {
+		if !have[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("pipeline %q does not compute %s", p.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Hash returns a SHA-256 over the resolved steps (name, function, sources
+// and parameters) in execution order. Specs that differ only in their name,
+// comments or formatting hash the same.
+func (p *Pipeline) Hash() string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, n := range p.Nodes {
+		enc.Encode(n.Step) // map keys are sorted, so the encoding is canonical
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+func TestDefaultComputesModelFeatures(t *testing.T) {
+	raw, err := os.ReadFile("../../models/model.meta.json")
+	if err != nil {
+		t.Fatalf("read model meta: %v", err)
+	}
+	var meta struct {
+		FeatureCols []string `json:"feature_cols"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatalf("parse model meta: %v", err)
+	}
+	if err := Default().Check(meta.FeatureCols); err != nil {
+		t.Fatal(err)
+	}
+	if err := Default().Check(append(meta.FeatureCols, "vwap")); err == nil || !strings.Contains(err.Error(), "vwap") {
+		t.Fatalf("missing column not reported: %v", err)
+	}
+}
+
+func TestResolveOrdersByDependency(t *testing.T) {
+	spec, err := Parse([]byte(`{"name": "t", "steps": [
+		{"name": "slope", "func": "slope", "sources": ["ema"], "params": {"period": 5}},
+		{"name": "rsi", "func": "rsi", "sources": ["close"], "params": {"period": 7}},
+		{"name": "ema", "func": "ema", "sources": ["close"], "params": {"period": 5}},
+		{"name": "swap", "func": "swap_kalman", "sources": ["ema"], "params": {"factor": 0.25}},
+		{"name": "atr3", "func": "true_range"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Resolve(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, n := range p.Nodes {
+		order = append(order, n.Name)
+		for _, d := range n.Deps {
+			if p.Nodes[d].Name == n.Name || d >= len(order) {
+				t.Fatalf("%s runs before its dependency %s", n.Name, p.Nodes[d].Name)
+			}
+		}
+	}
+	if got, want := strings.Join(order, ","), "rsi,ema,slope,atr3,swap"; got != want {
+		t.Fatalf("order %s, want %s", got, want)
+	}
+}
+
+func TestResolveReportsEveryProblem(t *testing.T) {
+	spec, err := Parse([]byte(`
+name: broken
+steps:
+  - {name: a, func: ema, sources: [b], params: {period: 5}}
+  - {name: b, func: ema, sources: [a], params: {period: 5}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resolve(spec); err == nil || !strings.Contains(err.Error(), "cycle among a, b") {
+		t.Fatalf("cycle not reported: %v", err)
+	}
+
+	spec, err = Parse([]byte(`
+steps:
+  - {name: x, func: nope}
+  - {name: e, func: ema, sources: [close, open], params: {period: 2.5, alpha: 1}}
+  - {name: r, func: rsi, sources: [missing], params: {period: 7}}
+  - {name: m, func: macd, sources: [close], params: {fast: 12, slow: 26, signal: 9}}
+  - {name: m_signal, func: ema, sources: [close], params: {period: 9}}
+  - {name: close, func: ema, sources: [close], params: {period: 9}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Resolve(spec)
+	if err == nil {
+		t.Fatal("broken spec resolved")
+	}
+	for _, want := range []string{
+		`steps[0] x: unknown func "nope"`,
+		"steps[1] e: ema takes 1 source(s), got 2",
+		"steps[1] e: param period: invalid value 2.5",
+		"steps[1] e: unknown param alpha for ema",
+		`steps[2] r: unknown column "missing"`,
+		`steps[4] m_signal: column "m_signal" is also produced by steps[3]`,
+		`steps[5] close: column "close" would overwrite a bar column`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in\n%v", want, err)
+		}
+	}
+}
+
+// Coarse timeframes give short frames (a quarter of weekly bars is 13 rows);
+// no step may index past a frame shorter than its period.
+func TestRunShortFrames(t *testing.T) {
+	bars := barSeries(1, true)
+	for n := 2; n <= 130; n++ {
+		var cut []dataframe.Series
+		for _, s := range bars {
+			cut = append(cut, s.Copy(dataframe.Range{End: &[]int{n - 1}[0]}))
+		}
+		df := dataframe.NewDataFrame(cut...)
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%d rows: %v", n, r)
+				}
+			}()
+			Default().RunParallel(df, 1)
+		}()
+	}
+}
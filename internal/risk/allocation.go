@@ -0,0 +1,47 @@
+package risk
+
+// Netting rules for conflicting intents of several strategies on one symbol.
+const (
+	// NettingNet lets every strategy trade in its own book; the account only
+	// trades the net change of all books on the bar, so opposite intents
+	// cross internally.
+	NettingNet = "net"
+	// NettingBlock rejects an entry against the side another strategy holds
+	// on the symbol; on the same bar the earlier sleeve wins.
+	NettingBlock = "block"
+	// NettingHedge sends every strategy's orders to the account as they are.
+	NettingHedge = "hedge"
+)
+
+// Sleeve is one strategy's share of the account.
+type Sleeve struct {
+	Strategy string  `yaml:"strategy" json:"strategy"` // registered strategy name
+	Weight   float64 `yaml:"weight" json:"weight"`     // fraction of Allocation.Capital
+
+	// Risk budget. Zero values fall back to the account limits.
+	MaxOpenPositions  int     `yaml:"max_open_positions" json:"maxOpenPositions"`
+	MaxSymbolExposure float64 `yaml:"max_symbol_exposure" json:"maxSymbolExposure"` // fraction of sleeve equity
+	MaxDailyLossPct   float64 `yaml:"max_daily_loss_pct" json:"maxDailyLossPct"`    // % of sleeve capital; 0 = no limit
+}
+
+// Allocation splits the account between strategies run side by side. With
+// no sleeves the executor runs the single strategy.name as before.
+type Allocation struct {
+	Capital float64  `yaml:"capital" json:"capital"`
+	Netting string   `yaml:"netting" json:"netting"` // net | block | hedge
+	Sleeves []Sleeve `yaml:"sleeves" json:"sleeves"`
+}
+
+// DefaultAllocation has no sleeves, i.e. single-strategy mode.
+func DefaultAllocation() Allocation {
+	return Allocation{Capital: 1_000_000, Netting: NettingNet}
+}
+
+// ActiveAllocation is the allocation in force, set from config at startup.
+var ActiveAllocation = DefaultAllocation()
+
+// Enabled reports whether the executor runs the sleeves instead of the
+// single configured strategy.
+func (a Allocation) Enabled() bool {
+	return len(a.Sleeves) > 0
+}
@@ -0,0 +1,21 @@
+package risk
+
+// Limits are the account-wide risk limits shared by the live executor and
+// backtests. Exposures are fractions of equity, see PositionSizing.
+type Limits struct {
+	MaxOpenPositions  int     `yaml:"max_open_positions" json:"maxOpenPositions"`
+	MaxSymbolExposure float64 `yaml:"max_symbol_exposure" json:"maxSymbolExposure"`
+	MaxTotalExposure  float64 `yaml:"max_total_exposure" json:"maxTotalExposure"`
+}
+
+// DefaultLimits allows one open position and up to half of equity per symbol.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxOpenPositions:  1,
+		MaxSymbolExposure: 0.5,
+		MaxTotalExposure:  1.0,
+	}
+}
+
+// ActiveLimits are the limits in force, set from config at startup.
+var ActiveLimits = DefaultLimits()
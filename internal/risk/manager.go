@@ -1,4 +1,48 @@
 package risk
 
+import (
+	"fmt"
+
+	"hft/pkg/types"
+)
+
 // Manager coordinates risk controls.
-type Manager struct{}
+//
+// It is the pre-trade gate between a strategy and the OMS: every intent is
+// checked against the current position before an order is placed.
+type Manager struct {
+	MaxOpenPositions int
+}
+
+// NewManager returns a manager enforcing ActiveLimits.
+func NewManager() *Manager {
+	return &Manager{MaxOpenPositions: ActiveLimits.MaxOpenPositions}
+}
+
+// Approve returns an error if the intent must not be sent to the broker.
+// open is the number of currently open positions and pos the position the
+// intent applies to (nil when flat).
+func (m *Manager) Approve(in types.Intent, open int, pos *types.Position) error {
+	switch in.Type {
+	case "ENTRY":
+		if m.MaxOpenPositions > 0 && open >= m.MaxOpenPositions {
+			return fmt.Errorf("max open positions (%d) reached", m.MaxOpenPositions)
+		}
+		if in.Kind != "BUY" && in.Kind != "SELL" {
+			return fmt.Errorf("unknown side %q", in.Kind)
+		}
+	case "EXIT":
+		if pos == nil {
+			return fmt.Errorf("no open position to exit")
+		}
+		if pos.Kind != in.Kind {
+			return fmt.Errorf("exit side %s does not match open %s position", in.Kind, pos.Kind)
+		}
+	default:
+		return fmt.Errorf("unknown intent type %q", in.Type)
+	}
+	if in.Price <= 0 {
+		return fmt.Errorf("invalid price %.2f", in.Price)
+	}
+	return nil
+}
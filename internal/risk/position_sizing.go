@@ -1,4 +1,29 @@
 package risk
 
+import "math"
+
 // PositionSizing encapsulates position sizing rules.
-type PositionSizing struct{}
+//
+// Exposure is notional (quantity × price) measured against current equity:
+// a new position may use at most MaxSymbolExposure of equity on its own and
+// must keep the book at or below MaxTotalExposure of equity.
+type PositionSizing struct {
+	MaxSymbolExposure float64 // fraction of equity per symbol, e.g. 0.5
+	MaxTotalExposure  float64 // fraction of equity across all symbols, e.g. 1.0
+}
+
+// Size returns the whole-unit quantity to open at price, or 0 if the caps
+// leave no room. symbolExposure and totalExposure are the current notionals.
+func (ps PositionSizing) Size(price, equity, symbolExposure, totalExposure float64) float64 {
+	if price <= 0 || equity <= 0 {
+		return 0
+	}
+	room := ps.MaxSymbolExposure*equity - symbolExposure
+	if total := ps.MaxTotalExposure*equity - totalExposure; total < room {
+		room = total
+	}
+	if room <= 0 {
+		return 0
+	}
+	return math.Floor(room / price)
+}
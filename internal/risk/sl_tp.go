@@ -1,4 +1,122 @@
 package risk
 
+import "encoding/json"
+
 // SLTP handles stop-loss and take-profit logic.
-type SLTP struct{}
+//
+// It tracks a single open position and applies, in order:
+//  1. a fixed stop (or a breakeven stop once activated)
+//  2. breakeven activation
+//  3. a trailing stop off the best close since entry
+//
+// All thresholds are percentages of the entry price.
+type SLTP struct {
+	SLPct    float64 // stop distance at entry
+	BEPct    float64 // breakeven activation
+	TrailAct float64 // trailing stop activation
+	TrailOff float64 // trailing stop offset from peak
+
+	side            int // +1 long, -1 short
+	entryPrice      float64
+	peakPrice       float64
+	breakevenActive bool
+	trailActive     bool
+}
+
+// Open arms the stop for a new position.
+func (s *SLTP) Open(side int, entryPrice float64) {
+	s.side = side
+	s.entryPrice = entryPrice
+	s.peakPrice = entryPrice
+	s.breakevenActive = false
+	s.trailActive = false
+}
+
+// Close disarms the stop.
+func (s *SLTP) Close() {
+	s.side = 0
+	s.breakevenActive = false
+	s.trailActive = false
+}
+
+// Check evaluates the stop on a bar close and returns the exit reason
+// ("STOP_LOSS", "BREAKEVEN" or "TRAILING_SL"), or "" to stay in the trade.
+func (s *SLTP) Check(close float64) string {
+	if s.side == 0 || s.entryPrice == 0 {
+		return ""
+	}
+	unrealPct := (close - s.entryPrice) / s.entryPrice * 100 * float64(s.side)
+
+	// 1) Stop loss (or breakeven stop).
+	effectiveSL := -s.SLPct
+	if s.breakevenActive {
+		effectiveSL = 0.0
+	}
+	if unrealPct <= effectiveSL {
+		if s.breakevenActive {
+			return "BREAKEVEN"
+		}
+		return "STOP_LOSS"
+	}
+
+	// 2) Activate breakeven.
+	if !s.breakevenActive && unrealPct >= s.BEPct {
+		s.breakevenActive = true
+	}
+
+	// 3) Trailing SL.
+	if s.side == 1 && close > s.peakPrice {
+		s.peakPrice = close
+	} else if s.side == -1 && close < s.peakPrice {
+		s.peakPrice = close
+	}
+	if !s.trailActive && unrealPct >= s.TrailAct {
+		s.trailActive = true
+	}
+	if s.trailActive {
+		if s.side == 1 && close <= s.peakPrice*(1-s.TrailOff/100) {
+			return "TRAILING_SL"
+		}
+		if s.side == -1 && close >= s.peakPrice*(1+s.TrailOff/100) {
+			return "TRAILING_SL"
+		}
+	}
+	return ""
+}
+
+// sltpJSON is the serialized form of SLTP, including the armed position.
+type sltpJSON struct {
+	SLPct           float64 `json:"slPct"`
+	BEPct           float64 `json:"bePct"`
+	TrailAct        float64 `json:"trailAct"`
+	TrailOff        float64 `json:"trailOff"`
+	Side            int     `json:"side"`
+	EntryPrice      float64 `json:"entryPrice"`
+	PeakPrice       float64 `json:"peakPrice"`
+	BreakevenActive bool    `json:"breakevenActive"`
+	TrailActive     bool    `json:"trailActive"`
+}
+
+// MarshalJSON serializes the stop with its open-position state, so a
+// strategy holding one can be snapshotted mid-trade.
+func (s SLTP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sltpJSON{
+		SLPct: s.SLPct, BEPct: s.BEPct, TrailAct: s.TrailAct, TrailOff: s.TrailOff,
+		Side: s.side, EntryPrice: s.entryPrice, PeakPrice: s.peakPrice,
+		BreakevenActive: s.breakevenActive, TrailActive: s.trailActive,
+	})
+}
+
+// UnmarshalJSON restores a stop written by MarshalJSON.
+func (s *SLTP) UnmarshalJSON(b []byte) error {
+	var j sltpJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*s = SLTP{
+		SLPct: j.SLPct, BEPct: j.BEPct, TrailAct: j.TrailAct, TrailOff: j.TrailOff,
+		side: j.Side, entryPrice: j.EntryPrice, peakPrice: j.PeakPrice,
+		breakevenActive: j.BreakevenActive, trailActive: j.TrailActive,
+	}
+	return nil
+}
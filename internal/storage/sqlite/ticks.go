@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -246,3 +247,40 @@ FROM ticks
 	}
 	return out, nil
 }
+
+// EpochBefore returns the epoch (as a string usable as a ListTicksFiltered
+// start bound) of the tick `bars` rows before startDate, or of the earliest
+// tick if fewer exist. It returns startDate unchanged when bars <= 0.
+func (s *TickStore) EpochBefore(ctx context.Context, symbol string, tf string, startDate string, bars int) (string, error) {
+	start, ok := parseEpoch(startDate)
+	if !ok || bars <= 0 {
+		return startDate, nil
+	}
+	query := `SELECT time FROM ticks WHERE time < ?`
+	args := []any{start}
+	if symbol != "" {
+		query += " AND symbol = ?"
+		args = append(args, symbol)
+	}
+	if tf != "" {
+		query += " AND tf = ?"
+		args = append(args, tf)
+	}
+	query += " ORDER BY time DESC LIMIT 1 OFFSET ?"
+	args = append(args, bars-1)
+
+	var epoch int64
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&epoch)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Fewer than `bars` ticks before start — take them all.
+		q := strings.Replace(query, "ORDER BY time DESC LIMIT 1 OFFSET ?", "ORDER BY time ASC LIMIT 1", 1)
+		err = s.db.QueryRowContext(ctx, q, args[:len(args)-1]...).Scan(&epoch)
+		if errors.Is(err, sql.ErrNoRows) {
+			return startDate, nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("warmup start: %w", err)
+	}
+	return strconv.FormatInt(epoch, 10), nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"hft/internal/testutil"
+	"hft/internal/timeframe"
+	"hft/pkg/types"
+)
+
+// newTestStore opens a store in a temp dir holding two sessions of 1m
+// NIFTY bars and, interleaved with them, the same history as 5m bars.
+func newTestStore(t *testing.T) (*TickStore, []types.Tick) {
+	t.Helper()
+	s, err := NewTickStore(filepath.Join(t.TempDir(), "ticks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	ticks := testutil.Ticks("nifty", testutil.Candles(1, 2))
+	for i := range ticks {
+		ticks[i].TF = "1"
+	}
+	five := timeframe.Resample(ticks, timeframe.Timeframe{N: 5, Unit: timeframe.Minute})
+	for i := range five {
+		five[i].TF = "5"
+		five[i].Time = five[i].Timestamp.Unix()
+	}
+	ctx := context.Background()
+	if _, err := s.InsertTicks(ctx, ticks); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.InsertTicks(ctx, five); err != nil {
+		t.Fatal(err)
+	}
+	return s, ticks
+}
+
+func TestEpochBefore(t *testing.T) {
+	s, ticks := newTestStore(t)
+	ctx := context.Background()
+	start := ticks[400] // inside the second session
+	epoch := func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) }
+
+	for _, tc := range []struct {
+		name string
+		tf   string
+		bars int
+		want string
+	}{
+		// Counted on 1m rows only, the 30th bar back is 30 minutes of bars back.
+		{"1m rows", "1", 30, epoch(ticks[370].Timestamp)},
+		// Counting every stored timeframe stops short on the mixed rows.
+		{"all rows", "", 30, epoch(ticks[375].Timestamp)},
+		// Fewer rows than asked for: the earliest one.
+		{"short history", "1", 1000, epoch(ticks[0].Timestamp)},
+		{"no warmup", "1", 0, epoch(start.Timestamp)},
+	} {
+		got, err := s.EpochBefore(ctx, "nifty", tc.tf, epoch(start.Timestamp), tc.bars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// Nothing before the start: the start itself.
+	if got, _ := s.EpochBefore(ctx, "nifty", "1", epoch(ticks[0].Timestamp), 30); got != epoch(ticks[0].Timestamp) {
+		t.Errorf("before the first tick: %s", got)
+	}
+	if got, _ := s.EpochBefore(ctx, "banknifty", "1", epoch(start.Timestamp), 30); got != epoch(start.Timestamp) {
+		t.Errorf("unknown symbol: %s", got)
+	}
+}
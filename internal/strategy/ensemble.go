@@ -0,0 +1,407 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"hft/pkg/types"
+)
+
+/*
+   Signal ensemble.
+
+   Combines independent signals into one decision per bar. Every component
+   votes +1 (long), −1 (short) or 0:
+
+     kalman  swap and swap_base both 1 → +1, both −1 → −1
+     regime  pred_prob_bullish ≥ RegimeProb and above bearish → +1, and the
+             mirror for bearish → −1
+     mtf     ema_crossover_5m and ema_crossover_15m beyond ±MTFMinCross on
+             the same side → that side
+
+   and the mode turns the votes into the decision:
+
+     majority   more than half of the components vote the same side
+     weighted   Σ weight×vote / Σ weight reaches ±ScoreThresh
+     unanimous  every component votes the same side
+
+   Entries follow the decision while flat; a position is closed when the
+   decision turns against it (or neutral, with ExitOnNeutral), on a StopPct
+   stop, and at tranche squareoff as in the other tranche strategies. The
+   votes are logged on the bars where they change (LogVotes), or on every
+   bar when asked; with tracing on, every bar's votes, score and decision
+   are in its trace (vote_<component>, score, decision).
+*/
+
+// Ensemble modes.
+const (
+	EnsembleMajority  = "majority"
+	EnsembleWeighted  = "weighted"
+	EnsembleUnanimous = "unanimous"
+)
+
+// Ensemble component names.
+const (
+	EnsembleKalman = "kalman"
+	EnsembleRegime = "regime"
+	EnsembleMTF    = "mtf"
+)
+
+// Ensemble vote logging.
+const (
+	EnsembleLogAll     = "all"     // every bar
+	EnsembleLogChanges = "changes" // bars where a vote or the decision changed
+	EnsembleLogOff     = "off"
+)
+
+// EnsembleComponent is one voter and its weight (weighted mode).
+type EnsembleComponent struct {
+	Name   string  `yaml:"name" json:"name"` // kalman | regime | mtf
+	Weight float64 `yaml:"weight" json:"weight"`
+}
+
+// EnsembleConfig controls the ensemble strategy.
+type EnsembleConfig struct {
+	Mode       string              `yaml:"mode" json:"mode"` // majority | weighted | unanimous
+	Components []EnsembleComponent `yaml:"components" json:"components"`
+
+	RegimeProb  float64 `yaml:"regime_prob" json:"regimeProb"`    // regime component threshold
+	MTFMinCross float64 `yaml:"mtf_min_cross" json:"mtfMinCross"` // mtf dead zone on ema_crossover
+	ScoreThresh float64 `yaml:"score_thresh" json:"scoreThresh"`  // weighted mode, in (0, 1]
+
+	ExitOnNeutral bool    `yaml:"exit_on_neutral" json:"exitOnNeutral"` // also exit when the decision goes flat
+	StopPct       float64 `yaml:"stop_pct" json:"stopPct"`              // % of entry, 0 = none
+	CooldownBars  int     `yaml:"cooldown_bars" json:"cooldownBars"`
+
+	LogVotes string `yaml:"log_votes" json:"logVotes"` // all | changes | off
+
+	Tranches []Tranche `yaml:"tranches" json:"tranches"`
+}
+
+// DefaultEnsembleConfig returns a majority vote of all three components on
+// the regime strategy's tranches.
+func DefaultEnsembleConfig() *EnsembleConfig {
+	return &EnsembleConfig{
+		Mode: EnsembleMajority,
+		Components: []EnsembleComponent{
+			{Name: EnsembleKalman, Weight: 1},
+			{Name: EnsembleRegime, Weight: 1},
+			{Name: EnsembleMTF, Weight: 1},
+		},
+		RegimeProb:   0.6,
+		MTFMinCross:  0.0002,
+		ScoreThresh:  0.5,
+		StopPct:      0.35,
+		CooldownBars: 3,
+		LogVotes:     EnsembleLogChanges,
+		Tranches:     DefaultRegimeSignalConfig().Tranches,
+	}
+}
+
+// ActiveEnsembleConfig is the ensemble config used by the live executor and
+// backtests, set from config at startup.
+var ActiveEnsembleConfig = DefaultEnsembleConfig()
+
+// EnsembleColumns are the columns the ensemble reads per bar.
+var EnsembleColumns = []string{"swap", "swap_base", "pred_prob_bullish", "pred_prob_bearish", "ema_crossover_5m", "ema_crossover_15m"}
+
+// EnsembleComponentNames lists the known components.
+var EnsembleComponentNames = []string{EnsembleKalman, EnsembleRegime, EnsembleMTF}
+
+// Uses reports whether the named component votes.
+func (cfg *EnsembleConfig) Uses(name string) bool {
+	for _, c := range cfg.Components {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsembleVote is one component's contribution to a bar's decision.
+type EnsembleVote struct {
+	Name   string  `json:"name"`
+	Vote   int     `json:"vote"` // +1, −1, 0
+	Weight float64 `json:"weight"`
+}
+
+// EnsembleDecision is the outcome of one bar's vote.
+type EnsembleDecision struct {
+	Votes    []EnsembleVote `json:"votes"`
+	Score    float64        `json:"score"`    // Σ weight×vote / Σ weight
+	Decision int            `json:"decision"` // +1, −1, 0
+}
+
+// String renders the decision as one log line: "kalman=+1 regime=0 mtf=+1 → +1 (0.67)".
+func (d EnsembleDecision) String() string {
+	var b strings.Builder
+	for _, v := range d.Votes {
+		fmt.Fprintf(&b, "%s=%+d ", v.Name, v.Vote)
+	}
+	fmt.Fprintf(&b, "→ %+d (%.2f)", d.Decision, d.Score)
+	return b.String()
+}
+
+// vote returns one component's vote on the bar's features.
+func (cfg *EnsembleConfig) vote(name string, f Features) int {
+	side := func(long, short bool) int {
+		switch {
+		case long && !short:
+			return 1
+		case short && !long:
+			return -1
+		}
+		return 0
+	}
+	switch name {
+	case EnsembleKalman:
+		return side(f["swap"] == 1 && f["swap_base"] == 1, f["swap"] == -1 && f["swap_base"] == -1)
+	case EnsembleRegime:
+		bull, bear := f["pred_prob_bullish"], f["pred_prob_bearish"]
+		return side(bull >= cfg.RegimeProb && bull > bear, bear >= cfg.RegimeProb && bear > bull)
+	case EnsembleMTF:
+		c5, c15 := f["ema_crossover_5m"], f["ema_crossover_15m"]
+		if math.IsNaN(c5) || math.IsNaN(c15) {
+			return 0 // first higher-timeframe bucket
+		}
+		return side(c5 >= cfg.MTFMinCross && c15 >= cfg.MTFMinCross, c5 <= -cfg.MTFMinCross && c15 <= -cfg.MTFMinCross)
+	}
+	return 0
+}
+
+// Decide runs every component on the bar's features and combines the votes.
+func (cfg *EnsembleConfig) Decide(f Features) EnsembleDecision {
+	d := EnsembleDecision{Votes: make([]EnsembleVote, 0, len(cfg.Components))}
+	long, short := 0, 0
+	sum, total := 0.0, 0.0
+	for _, c := range cfg.Components {
+		v := cfg.vote(c.Name, f)
+		d.Votes = append(d.Votes, EnsembleVote{Name: c.Name, Vote: v, Weight: c.Weight})
+		switch v {
+		case 1:
+			long++
+		case -1:
+			short++
+		}
+		sum += c.Weight * float64(v)
+		total += math.Abs(c.Weight)
+	}
+	if total > 0 {
+		d.Score = sum / total
+	}
+
+	n := len(cfg.Components)
+	switch cfg.Mode {
+	case EnsembleMajority:
+		if 2*long > n {
+			d.Decision = 1
+		} else if 2*short > n {
+			d.Decision = -1
+		}
+	case EnsembleWeighted:
+		if d.Score >= cfg.ScoreThresh {
+			d.Decision = 1
+		} else if d.Score <= -cfg.ScoreThresh {
+			d.Decision = -1
+		}
+	case EnsembleUnanimous:
+		if n > 0 && long == n {
+			d.Decision = 1
+		} else if n > 0 && short == n {
+			d.Decision = -1
+		}
+	}
+	return d
+}
+
+// ── Per-bar form ─────────────────────────────────────────────────────────────
+
+// EnsembleBar evaluates the ensemble strategy one bar at a time.
+type EnsembleBar struct {
+	cfg     *EnsembleConfig
+	session *sessionTracker
+	st      ensembleState
+	last    EnsembleDecision
+	tracing bool
+	trace   *Trace
+}
+
+// ensembleState is what EnsembleBar carries between bars, and its serialized
+// form.
+type ensembleState struct {
+	Position   int     `json:"position"` // +1 long, -1 short, 0 flat
+	EntryPrice float64 `json:"entryPrice"`
+	Cooldown   int     `json:"cooldown"`
+	LastVotes  string  `json:"lastVotes"` // last logged decision, for LogVotes "changes"
+}
+
+// NewEnsembleBar returns a flat per-bar ensemble; nil cfg uses the defaults.
+func NewEnsembleBar(cfg *EnsembleConfig) *EnsembleBar {
+	if cfg == nil {
+		cfg = DefaultEnsembleConfig()
+	}
+	return &EnsembleBar{cfg: cfg, session: newSessionTracker(cfg.Tranches, 0)}
+}
+
+// Position returns +1 when long, -1 when short and 0 when flat.
+func (s *EnsembleBar) Position() int {
+	return s.st.Position
+}
+
+// Reject tells the strategy its entry was not filled.
+func (s *EnsembleBar) Reject() {
+	s.st.Position = 0
+	s.st.EntryPrice = 0
+}
+
+// Last returns the decision of the most recent bar.
+func (s *EnsembleBar) Last() EnsembleDecision {
+	return s.last
+}
+
+// SetTracing turns per-bar decision traces on or off.
+func (s *EnsembleBar) SetTracing(on bool) {
+	s.tracing = on
+	s.trace = nil
+}
+
+// LastTrace returns the decision trace of the latest bar.
+func (s *EnsembleBar) LastTrace() *Trace {
+	return s.trace
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *EnsembleBar) OnBar(c types.Candle, f Features) []types.Intent {
+	var tr *Trace
+	if s.tracing {
+		tr = &Trace{Timestamp: c.Timestamp, Inputs: make(map[string]float64)}
+	}
+	s.trace = tr
+	out := s.onBar(c, f, tr)
+	tr.finish(s.st.Position, out)
+	return out
+}
+
+func (s *EnsembleBar) onBar(c types.Candle, f Features, trace *Trace) []types.Intent {
+	cfg := s.cfg
+	t := c.Timestamp.In(ist)
+	mins := t.Hour()*60 + t.Minute()
+	close := c.Close
+
+	s.session.update(t.Format("2006-01-02"), mins, close, f["pred_prob_volatile"])
+	d := cfg.Decide(f)
+	s.last = d
+	s.logVotes(t.Format("2006-01-02 15:04"), d)
+	if trace != nil {
+		for _, v := range d.Votes {
+			trace.input("vote_"+v.Name, float64(v.Vote))
+		}
+		trace.input("score", d.Score)
+		trace.input("decision", float64(d.Decision))
+		trace.input("close", close)
+		trace.input("position", float64(s.st.Position))
+		trace.input("cooldown", float64(s.st.Cooldown))
+	}
+
+	var out []types.Intent
+	exit := func(reason string) {
+		kind := "BUY"
+		if s.st.Position == -1 {
+			kind = "SELL"
+		}
+		out = append(out, types.Intent{Kind: kind, Type: "EXIT", Price: close, Timestamp: c.Timestamp, Reason: reason})
+		s.Reject()
+		s.st.Cooldown = cfg.CooldownBars
+	}
+
+	tr, inTranche := trancheAt(cfg.Tranches, mins)
+	if !trace.rule("session", "", inTranche && mins < tr.CloseMin, "tranche %q, %s", tr.Name, hhmm(mins)) {
+		if s.st.Position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.st.Cooldown = 0
+		return out
+	}
+	if s.st.Cooldown > 0 {
+		s.st.Cooldown--
+	}
+
+	// ── Exits (when in position) ─────────────────────────────────
+	reverse := false
+	if s.st.Position != 0 {
+		unrealPct := (close - s.st.EntryPrice) / s.st.EntryPrice * 100 * float64(s.st.Position)
+		switch {
+		case cfg.StopPct > 0 && unrealPct <= -cfg.StopPct:
+			trace.rule("stop", "", false, "%.3f%% <= -%.3f%%", unrealPct, cfg.StopPct)
+			exit("STOP_LOSS")
+			return out
+		case d.Decision == -s.st.Position:
+			trace.rule("decision", "", false, "%s against the position", d)
+			exit("SIGNAL")
+			reverse = true // a flipped vote enters the other side on this bar
+		case cfg.ExitOnNeutral && d.Decision == 0:
+			trace.rule("decision", "", false, "%s, exit on neutral", d)
+			exit("SIGNAL")
+			return out
+		default:
+			return out
+		}
+	}
+
+	// ── Entry (flat) ─────────────────────────────────────────────
+	if !trace.rule("decision", "", d.Decision != 0, "%s", d) ||
+		!trace.rule("cutoff", "", mins < tr.CutoffMin, "%s < %s", hhmm(mins), hhmm(tr.CutoffMin)) ||
+		!trace.rule("cooldown", "", s.st.Cooldown == 0 || reverse, "%d bars left", s.st.Cooldown) {
+		return out
+	}
+	s.st.Position = d.Decision
+	s.st.EntryPrice = close
+	kind := "BUY"
+	if d.Decision == -1 {
+		kind = "SELL"
+	}
+	out = append(out, types.Intent{Kind: kind, Type: "ENTRY", Price: close, Timestamp: c.Timestamp,
+		Context: entryContext(tr.Name, s.session.dayType(), s.session.gapPct, d.Decision, c.Timestamp, RegimeFeaturesOf(f))})
+	return out
+}
+
+// logVotes writes the bar's component votes as LogVotes asks.
+func (s *EnsembleBar) logVotes(at string, d EnsembleDecision) {
+	line := d.String()
+	switch s.cfg.LogVotes {
+	case EnsembleLogOff:
+		return
+	case EnsembleLogChanges:
+		if line == s.st.LastVotes {
+			return
+		}
+	}
+	s.st.LastVotes = line
+	log.Printf("strategy: ensemble %s %s | %s", s.cfg.Mode, at, line)
+}
+
+// ensembleBarState is the serialized form of an EnsembleBar.
+type ensembleBarState struct {
+	State   ensembleState `json:"state"`
+	Session sessionState  `json:"session"`
+}
+
+// Snapshot serializes the position, cooldown, last logged vote and the day
+// gathered so far.
+func (s *EnsembleBar) Snapshot() ([]byte, error) {
+	return json.Marshal(ensembleBarState{State: s.st, Session: s.session.snapshot()})
+}
+
+// Restore replaces the strategy's state with one written by Snapshot.
+func (s *EnsembleBar) Restore(state []byte) error {
+	var st ensembleBarState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore ensemble state: %w", err)
+	}
+	s.st = st.State
+	s.session.restore(st.Session)
+	return nil
+}
@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hft/pkg/types"
+)
+
+// By default the votes are logged only when they change, not every bar.
+func TestEnsembleLogsVoteChanges(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	s := NewEnsembleBar(nil)
+	start := time.Date(2025, 6, 2, 10, 30, 0, 0, ist)
+	for i, swap := range []float64{1, 1, 1, -1, -1} {
+		c := types.Candle{Symbol: "nifty", Timestamp: start.Add(time.Duration(i) * time.Minute), Close: 100}
+		s.OnBar(c, Features{"swap": swap, "swap_base": swap})
+	}
+	if n := strings.Count(buf.String(), "strategy: ensemble"); n != 2 {
+		t.Fatalf("%d vote lines over 5 bars with one change, want 2:\n%s", n, buf.String())
+	}
+}
+
+// votes returns features on which kalman, regime and mtf vote k, r and m;
+// a NaN m leaves the higher-timeframe crossovers undefined.
+func votes(k, r int, m float64) Features {
+	f := Features{"swap": float64(k), "swap_base": float64(k)}
+	switch r {
+	case 1:
+		f["pred_prob_bullish"], f["pred_prob_bearish"] = 0.8, 0.1
+	case -1:
+		f["pred_prob_bullish"], f["pred_prob_bearish"] = 0.1, 0.8
+	}
+	f["ema_crossover_5m"], f["ema_crossover_15m"] = m, m
+	return f
+}
+
+func TestEnsembleDecide(t *testing.T) {
+	nan := math.NaN()
+	all := func(wk, wr, wm float64) []EnsembleComponent {
+		return []EnsembleComponent{{EnsembleKalman, wk}, {EnsembleRegime, wr}, {EnsembleMTF, wm}}
+	}
+	for _, tc := range []struct {
+		name       string
+		mode       string
+		components []EnsembleComponent
+		thresh     float64
+		f          Features
+		want       int
+		score      float64
+	}{
+		{"majority long", EnsembleMajority, all(1, 1, 1), 0, votes(1, 1, 0), 1, 2.0 / 3},
+		{"majority short", EnsembleMajority, all(1, 1, 1), 0, votes(-1, 1, -1), -1, -1.0 / 3},
+		{"majority tie", EnsembleMajority, all(1, 1, 1), 0, votes(1, -1, 0), 0, 0},
+		{"majority of two tied", EnsembleMajority, all(1, 1, 1)[:2], 0, votes(1, -1, 0), 0, 0},
+		{"majority of two needs both", EnsembleMajority, all(1, 1, 1)[:2], 0, votes(1, 0, 0), 0, 0.5},
+		{"majority ignores weights", EnsembleMajority, all(0, -1, 5), 0, votes(1, 1, -1), 1, -6.0 / 6},
+		{"majority with NaN mtf", EnsembleMajority, all(1, 1, 1), 0, votes(1, 1, nan), 1, 2.0 / 3},
+		{"majority with NaN mtf short of half", EnsembleMajority, all(1, 1, 1), 0, votes(1, 0, nan), 0, 1.0 / 3},
+		{"majority of none", EnsembleMajority, nil, 0, votes(1, 1, 1), 0, 0},
+
+		{"weighted at threshold", EnsembleWeighted, all(2, 1, 1), 0.5, votes(1, 0, 0), 1, 0.5},
+		{"weighted at short threshold", EnsembleWeighted, all(2, 1, 1), 0.5, votes(-1, 0, 0), -1, -0.5},
+		{"weighted below threshold", EnsembleWeighted, all(2, 1, 1), 0.51, votes(1, 0, 0), 0, 0.5},
+		{"weighted tie", EnsembleWeighted, all(2, 1, 1), 0.5, votes(1, -1, -1), 0, 0},
+		{"weighted outvoted", EnsembleWeighted, all(2, 1, 1), 0.25, votes(1, -1, 0), 1, 0.25},
+		{"weighted NaN mtf abstains", EnsembleWeighted, all(1, 1, 1), 0.67, votes(1, 1, nan), 0, 2.0 / 3},
+		{"weighted zero weight abstains", EnsembleWeighted, all(1, 0, 0), 0.5, votes(0, 1, 1), 0, 0},
+		{"weighted all zero", EnsembleWeighted, all(0, 0, 0), 0.5, votes(1, 1, 1), 0, 0},
+		// Validate rejects it; a negative weight counts against its vote.
+		{"weighted negative weight", EnsembleWeighted, all(1, -1, 0), 0.5, votes(0, -1, 0), 1, 0.5},
+
+		{"unanimous long", EnsembleUnanimous, all(1, 1, 1), 0, votes(1, 1, 1), 1, 1},
+		{"unanimous short", EnsembleUnanimous, all(1, 1, 1), 0, votes(-1, -1, -1), -1, -1},
+		{"unanimous one abstains", EnsembleUnanimous, all(1, 1, 1), 0, votes(1, 1, 0), 0, 2.0 / 3},
+		{"unanimous NaN mtf abstains", EnsembleUnanimous, all(1, 1, 1), 0, votes(1, 1, nan), 0, 2.0 / 3},
+		{"unanimous ignores weights", EnsembleUnanimous, all(0, 1, -1), 0, votes(-1, -1, -1), -1, 0},
+		{"unanimous of none", EnsembleUnanimous, nil, 0, votes(1, 1, 1), 0, 0},
+	} {
+		cfg := DefaultEnsembleConfig()
+		cfg.Mode, cfg.Components, cfg.ScoreThresh = tc.mode, tc.components, tc.thresh
+		d := cfg.Decide(tc.f)
+		if d.Decision != tc.want || math.Abs(d.Score-tc.score) > 1e-12 {
+			t.Errorf("%s: %s, want %+d (%.2f)", tc.name, d, tc.want, tc.score)
+		}
+		if len(d.Votes) != len(tc.components) {
+			t.Errorf("%s: %d votes for %d components", tc.name, len(d.Votes), len(tc.components))
+		}
+	}
+}
+
+// With tracing on, every bar's votes are in its trace, whatever LogVotes
+// says.
+func TestEnsembleTracesEveryVote(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	s := NewEnsembleBar(nil)
+	s.SetTracing(true)
+	start := time.Date(2025, 6, 2, 10, 30, 0, 0, ist)
+	var actions []string
+	for i, f := range []Features{votes(1, 1, 0), votes(1, 1, 0), votes(1, -1, -1), votes(0, 0, 0)} {
+		c := types.Candle{Symbol: "nifty", Timestamp: start.Add(time.Duration(i) * time.Minute), Close: 100}
+		s.OnBar(c, f)
+		tr := s.LastTrace()
+		if tr == nil {
+			t.Fatalf("bar %d: no trace", i)
+		}
+		d := s.Last()
+		for _, v := range d.Votes {
+			if got, ok := tr.Inputs["vote_"+v.Name]; !ok || got != float64(v.Vote) {
+				t.Errorf("bar %d: vote_%s %v, want %+d", i, v.Name, got, v.Vote)
+			}
+		}
+		if tr.Inputs["score"] != d.Score || tr.Inputs["decision"] != float64(d.Decision) {
+			t.Errorf("bar %d: traced %v, decided %s", i, tr.Inputs, d)
+		}
+		actions = append(actions, strings.TrimSpace(tr.Action+" "+tr.Detail))
+	}
+	if got := strings.Join(actions, ", "); got != "enter BUY, hold, exit SIGNAL → SELL, hold" {
+		t.Fatalf("actions %s", got)
+	}
+}
@@ -0,0 +1,167 @@
+package strategy
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"hft/internal/dataframe"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+// goldenEvent is one event of testdata/golden_trades.json.
+type goldenEvent struct {
+	Kind       string  `json:"kind"`
+	Type       string  `json:"type"`
+	Time       string  `json:"time"`
+	Price      float64 `json:"price"`
+	Reason     string  `json:"reason,omitempty"`
+	PeakProfit float64 `json:"peakProfit"`
+	PeakLoss   float64 `json:"peakLoss"`
+	Tranche    string  `json:"tranche,omitempty"`
+}
+
+// niftyBars loads the stored NIFTY 1-minute bars in example/postgres.json
+// (a database export, newest first) the way a backtest loads history.
+func niftyBars(t *testing.T) *_df_.DataFrame {
+	t.Helper()
+	raw, err := os.ReadFile("../../example/postgres.json")
+	if err != nil {
+		t.Fatalf("read NIFTY bars: %v", err)
+	}
+	var rows []struct {
+		Timestamp              string
+		Open, High, Low, Close float64
+	}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		t.Fatalf("parse NIFTY bars: %v", err)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Timestamp < rows[j].Timestamp })
+	ticks := make([]types.Tick, len(rows))
+	for i, r := range rows {
+		tm, err := time.Parse("2006-01-02T15:04:05-0700", r.Timestamp)
+		if err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+		ticks[i] = types.Tick{Symbol: "nifty", Timestamp: tm, Time: tm.Unix(), Open: r.Open, High: r.High, Low: r.Low, Close: r.Close}
+	}
+	df := dataframe.InitDataFrame()
+	dataframe.LoadHistoryBacktest(df, ticks)
+	return df
+}
+
+// addRegimeProbs stands in for the regime model with smooth probabilities
+// that cross every threshold.
+func addRegimeProbs(df *_df_.DataFrame) {
+	n := df.NRows()
+	bull, bear, vol := make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := range bull {
+		bull[i] = 0.5 + 0.45*math.Sin(float64(i)/23)
+		bear[i] = 0.5 - 0.45*math.Sin(float64(i)/23+0.3)
+		vol[i] = 0.15 + 0.15*math.Pow(math.Sin(float64(i)/211), 2)
+	}
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_bullish", nil, bull), nil)
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_bearish", nil, bear), nil)
+	df.AddSeries(_df_.NewSeriesFloat64("pred_prob_volatile", nil, vol), nil)
+}
+
+func collectEvents(run func(events chan *types.Event)) []goldenEvent {
+	events := make(chan *types.Event)
+	done := make(chan []goldenEvent)
+	go func() {
+		var out []goldenEvent
+		for e := range events {
+			g := goldenEvent{Kind: e.Kind, Type: e.Type, Time: e.Timestamp.Format(time.RFC3339), Price: e.EntryPrice,
+				Reason: e.Reason, PeakProfit: e.PeakProfit, PeakLoss: e.PeakLoss}
+			if e.Context != nil {
+				g.Tranche = e.Context.Tranche
+			}
+			out = append(out, g)
+		}
+		done <- out
+	}()
+	run(events)
+	close(events)
+	return <-done
+}
+
+// The vectorized scans are loops over OnBar. testdata/golden_trades.json
+// holds the events the batch functions they replaced (FindKalmanSignal,
+// FindKalmanSignalv2 and FindRegimeSignalWithConfig as of fba38dd) emitted
+// on the stored NIFTY bars with the default configs, and the registered
+// strategies must still emit exactly those, with two differences by design:
+//   - the old regime scan read a tranche's first 30 minutes ahead of time
+//     for its early-window filters, so those are off on both sides;
+//   - it did not mark the square-off bar, so its EOD exits could report a
+//     peak loss smaller than the loss they closed at. RunBars marks every
+//     bar, and the golden peaks are widened by that bar's profit.
+func TestBatchMatchesGoldenTrades(t *testing.T) {
+	raw, err := os.ReadFile("testdata/golden_trades.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden map[string][]goldenEvent
+	if err := json.Unmarshal(raw, &golden); err != nil {
+		t.Fatal(err)
+	}
+	regime := DefaultRegimeSignalConfig()
+	regime.EarlyDirConfirm, regime.MaxVolProb = false, 0
+	set := &Settings{Regime: regime, KalmanExit: DefaultKalmanExitConfigv2()}
+
+	for _, name := range []string{"kalman_v1", "kalman_v2", "regime"} {
+		s, err := GetWith(name, set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		df := niftyBars(t)
+		s.Indicators(df, nil)
+		if s.NeedsRegime() {
+			addRegimeProbs(df)
+		}
+		got := collectEvents(func(events chan *types.Event) { s.Batch(df, &types.Position{}, nil, events) })
+
+		want := golden[name]
+		if len(want) == 0 {
+			t.Fatalf("%s: no golden events", name)
+		}
+		if name == "regime" {
+			markSquareoffs(want)
+		}
+		for i := 0; i < len(got) || i < len(want); i++ {
+			switch {
+			case i >= len(got):
+				t.Fatalf("%s: missing event %d %+v", name, i, want[i])
+			case i >= len(want):
+				t.Fatalf("%s: extra event %d %+v", name, i, got[i])
+			case got[i] != want[i]:
+				t.Fatalf("%s: event %d\n got %+v\nwant %+v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// markSquareoffs widens the peaks of EOD square-offs by the profit at the
+// square-off bar.
+func markSquareoffs(events []goldenEvent) {
+	var entry goldenEvent
+	for i, e := range events {
+		if e.Type == "ENTRY" {
+			entry = e
+			continue
+		}
+		if e.Reason != "EOD_SQUAREOFF" {
+			continue
+		}
+		profit := e.Price - entry.Price
+		if e.Kind == "SELL" {
+			profit = entry.Price - e.Price
+		}
+		events[i].PeakProfit = math.Max(e.PeakProfit, profit)
+		events[i].PeakLoss = math.Min(e.PeakLoss, profit)
+	}
+}
@@ -3,18 +3,17 @@ package strategy
 import (
 	"hft/internal/indicators"
 	"hft/pkg/types"
-	"math"
 
 	"github.com/rocketlaunchr/dataframe-go"
 )
 
 // KalmanExitConfig holds parameters for MFE-based exits.
 type KalmanExitConfig struct {
-	ActivationMFEPts  float64
-	MFECaptureRatio   float64
-	SignalConfirmBars int
-	EnableFixedSL     bool
-	FixedSL           float64
+	ActivationMFEPts  float64 `yaml:"activation_mfe_pts" json:"activationMFEPts"`
+	MFECaptureRatio   float64 `yaml:"mfe_capture_ratio" json:"mfeCaptureRatio"`
+	SignalConfirmBars int     `yaml:"signal_confirm_bars" json:"signalConfirmBars"`
+	EnableFixedSL     bool    `yaml:"enable_fixed_sl" json:"enableFixedSL"`
+	FixedSL           float64 `yaml:"fixed_sl" json:"fixedSL"`
 }
 
 // DefaultKalmanExitConfig returns the default exit parameters.
@@ -117,149 +116,7 @@ func FindKalmanSignal(df *dataframe.DataFrame, current_position *types.Position,
 }
 
 func FindKalmanSignalWithExitConfig(df *dataframe.DataFrame, current_position *types.Position, positions []*types.Position, events chan *types.Event, exitConfig *KalmanExitConfig) {
-	if exitConfig == nil {
-		exitConfig = DefaultKalmanExitConfig()
-	}
-	fixedSLHit := false
-
-	_dataframe_length := df.NRows()
-	_close := df.Series[indicators.FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
-	_timestamp := df.Series[indicators.FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
-	_fast_tempx_kalman := df.Series[indicators.FindIndexOf(df, "fast_tempx_kalman")].(*dataframe.SeriesFloat64).Values
-	_slow_tempx_kalman := df.Series[indicators.FindIndexOf(df, "slow_tempx_kalman")].(*dataframe.SeriesFloat64).Values
-	_slow_swap := df.Series[indicators.FindIndexOf(df, "swap_base")].(*dataframe.SeriesFloat64).Values
-	_fast_swap := df.Series[indicators.FindIndexOf(df, "swap")].(*dataframe.SeriesFloat64).Values
-	// _tr := df.Series[indicators.FindIndexOf(df, "tr")].(*dataframe.SeriesFloat64).Values
-
-	tradeState := &KalmanTradeState{}
-
-	for i := 0; i < _dataframe_length; i++ {
-		deviation := math.Abs(_slow_tempx_kalman[i] - _fast_tempx_kalman[i])
-		// normalizedDeviation := deviation / _tr[i]
-		// print only for 23 december 2025
-
-		deviationOK := deviation >= 7 && deviation <= 15
-		// deviationOK := normalizedDeviation >= 0.5 && normalizedDeviation <= 2
-
-		slowSwapFlipUp := i > 0 && _slow_swap[i] == 1 && (_slow_swap[i-1] == 0 || _slow_swap[i-1] == -1)
-		slowSwapFlipDown := i > 0 && _slow_swap[i] == -1 && (_slow_swap[i-1] == 0 || _slow_swap[i-1] == 1)
-
-		fastSwapFlipUp := i > 0 && _fast_swap[i] == 1 && _fast_swap[i-1] == -1
-		fastSwapFlipDown := i > 0 && _fast_swap[i] == -1 && _fast_swap[i-1] == 1
-
-		slowSwapFlipSignal := i > 0 && ((_slow_swap[i-1] == 1 && _slow_swap[i] == -1) || (_slow_swap[i-1] == -1 && _slow_swap[i] == 1))
-		fastSwapFlipSignal := i > 0 && ((_fast_swap[i-1] == 1 && _fast_swap[i] == -1) || (_fast_swap[i-1] == -1 && _fast_swap[i] == 1))
-
-		lateBuy := fastSwapFlipUp && _slow_swap[i] == 1
-		lateSell := fastSwapFlipDown && _slow_swap[i] == -1
-		// earlyBuy := i > 0 && _fast_swap[i] == 1 && _fast_swap[i-1] == 0
-		// earlySell := i > 0 && _fast_swap[i] == -1 && _fast_swap[i-1] == 0
-
-		_buy_condition := deviationOK && (slowSwapFlipUp || lateBuy)
-		_sell_condition := deviationOK && (slowSwapFlipDown || lateSell)
-
-		sessionClosed := !indicators.IsActiveSession(_timestamp[i])
-		exitSignal := false
-
-		// update peak profit and peak loss if trade is profitable or in loss (for both buy and sell)
-		// peakprofit = max(peakprofit, current_position.Profit)
-		// peakloss = min(peakloss, current_position.Profit)
-		if current_position.Kind != "" {
-			if current_position.Kind == "BUY" {
-				current_position.Profit = _close[i] - current_position.EntryPrice
-			} else if current_position.Kind == "SELL" {
-				current_position.Profit = current_position.EntryPrice - _close[i]
-			}
-
-			current_position.PeakProfit = math.Max(current_position.PeakProfit, current_position.Profit)
-			current_position.PeakLoss = math.Min(current_position.PeakLoss, current_position.Profit)
-		}
-
-		fixedSLHit = current_position.Profit <= exitConfig.FixedSL && exitConfig.EnableFixedSL
-
-		if current_position.Kind == "BUY" {
-			exitSignal = _sell_condition || slowSwapFlipSignal || fastSwapFlipSignal || fixedSLHit
-		} else if current_position.Kind == "SELL" {
-			exitSignal = _buy_condition || slowSwapFlipSignal || fastSwapFlipSignal || fixedSLHit
-		}
-
-		shouldExitTrade := false
-		if current_position.Kind != "" {
-			tradeState.EntryPrice = current_position.EntryPrice
-			tradeState.Side = current_position.Kind
-			tradeState.CurrentPrice = _close[i]
-			tradeState.ExitSignal = exitSignal
-			tradeState.BarsInTrade++
-			shouldExitTrade = shouldExit(tradeState, exitConfig)
-		}
-
-		shouldCloseBuy := current_position.Kind == "BUY" && (shouldExitTrade || sessionClosed)
-		shouldCloseSell := current_position.Kind == "SELL" && (shouldExitTrade || sessionClosed)
-
-		if shouldCloseSell {
-			current_position.Exit(_close[i], *_timestamp[i])
-			exitEvent := &types.Event{
-				Kind:       "SELL",
-				Type:       "EXIT",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-				Reason:     "SIGNAL",
-				PeakProfit: current_position.PeakProfit,
-				PeakLoss:   current_position.PeakLoss,
-			}
-			current_position.Reset()
-			tradeState.Reset()
-			events <- exitEvent
-		}
-
-		if shouldCloseBuy {
-			current_position.Exit(_close[i], *_timestamp[i])
-			exitEvent := &types.Event{
-				Kind:       "BUY",
-				Type:       "EXIT",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-				Reason:     "SIGNAL",
-				PeakProfit: current_position.PeakProfit,
-				PeakLoss:   current_position.PeakLoss,
-			}
-			current_position.Reset()
-			tradeState.Reset()
-			events <- exitEvent
-		}
-
-		if current_position.Kind == "" && _buy_condition {
-			current_position.Buy(_close[i], *_timestamp[i])
-			current_position.PeakProfit = 0
-			current_position.PeakLoss = 0
-			tradeState.Reset()
-			tradeState.EntryPrice = _close[i]
-			tradeState.Side = "BUY"
-			tradeState.CurrentPrice = _close[i]
-			events <- &types.Event{
-				Kind:       "BUY",
-				Type:       "ENTRY",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-			}
-		}
-
-		if current_position.Kind == "" && _sell_condition {
-			current_position.Sell(_close[i], *_timestamp[i])
-			current_position.PeakProfit = 0
-			current_position.PeakLoss = 0
-			tradeState.Reset()
-			tradeState.EntryPrice = _close[i]
-			tradeState.Side = "SELL"
-			tradeState.CurrentPrice = _close[i]
-			events <- &types.Event{
-				Kind:       "SELL",
-				Type:       "ENTRY",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-			}
-		}
-	}
+	RunBars(df, NewKalmanBar(exitConfig), kalmanColumns, current_position, events)
 }
 
 func RunKalman(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
@@ -3,8 +3,9 @@ package strategy
 import (
 	"fmt"
 	"hft/internal/indicators"
+	"hft/internal/pipeline"
 	"hft/pkg/types"
-	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/rocketlaunchr/dataframe-go"
@@ -12,15 +13,26 @@ import (
 
 // KalmanExitConfig holds parameters for MFE-based exits.
 type KalmanExitConfigv2 struct {
-	ActivationMFEPts  float64
-	MFECaptureRatio   float64
-	SignalConfirmBars int
-	EnableFixedSL     bool
-	FixedSL           float64
+	ActivationMFEPts  float64 `yaml:"activation_mfe_pts" json:"activationMFEPts"`
+	MFECaptureRatio   float64 `yaml:"mfe_capture_ratio" json:"mfeCaptureRatio"`
+	SignalConfirmBars int     `yaml:"signal_confirm_bars" json:"signalConfirmBars"`
+	EnableFixedSL     bool    `yaml:"enable_fixed_sl" json:"enableFixedSL"`
+	FixedSL           float64 `yaml:"fixed_sl" json:"fixedSL"`
 }
 
-// ActiveExitConfig is the live-editable exit config. Strategy reads this on each tick.
-var ActiveExitConfig = DefaultKalmanExitConfigv2()
+// activeExitConfig is the live-editable exit config. Strategy reads this on
+// each tick while live edits swap it, hence atomic.
+var activeExitConfig atomic.Pointer[KalmanExitConfigv2]
+
+func init() { activeExitConfig.Store(DefaultKalmanExitConfigv2()) }
+
+// ActiveExitConfig returns the exit config in force. It is never modified
+// once set; a change swaps in a new one.
+func ActiveExitConfig() *KalmanExitConfigv2 { return activeExitConfig.Load() }
+
+// SetActiveExitConfig swaps in cfg. The caller must not modify it
+// afterwards.
+func SetActiveExitConfig(cfg *KalmanExitConfigv2) { activeExitConfig.Store(cfg) }
 
 // DefaultKalmanExitConfig returns the default exit parameters.
 func DefaultKalmanExitConfigv2() *KalmanExitConfigv2 {
@@ -35,7 +47,7 @@ func DefaultKalmanExitConfigv2() *KalmanExitConfigv2 {
 
 // ExitConfigToJSON returns the active exit config as a map.
 func ExitConfigToJSON() map[string]interface{} {
-	cfg := ActiveExitConfig
+	cfg := ActiveExitConfig()
 	if cfg == nil {
 		cfg = DefaultKalmanExitConfigv2()
 	}
@@ -133,7 +145,7 @@ RULES:
 */
 
 func FindKalmanSignalv2(df *dataframe.DataFrame, current_position *types.Position, positions []*types.Position, events chan *types.Event) {
-	FindKalmanSignalWithExitConfigv2(df, current_position, positions, events, DefaultKalmanExitConfigv2())
+	FindKalmanSignalWithExitConfigv2(df, current_position, positions, events, ActiveExitConfig())
 }
 
 // isAfter915 checks if the timestamp is at or after 9:15 IST
@@ -203,292 +215,41 @@ func FindKalmanSignalWithExitConfigv2(df *dataframe.DataFrame, current_position
 		return
 	}
 
-	_dataframe_length := df.NRows()
-	_close := df.Series[indicators.FindIndexOf(df, "close")].(*dataframe.SeriesFloat64).Values
-	_timestamp := df.Series[indicators.FindIndexOf(df, "timestamp")].(*dataframe.SeriesTime).Values
-	_fast_tempx_kalman := df.Series[indicators.FindIndexOf(df, "fast_tempx_kalman")].(*dataframe.SeriesFloat64).Values
-	_slow_tempx_kalman := df.Series[indicators.FindIndexOf(df, "slow_tempx_kalman")].(*dataframe.SeriesFloat64).Values
-	_slow_swap := df.Series[indicators.FindIndexOf(df, "swap_base")].(*dataframe.SeriesFloat64).Values
-	_fast_swap := df.Series[indicators.FindIndexOf(df, "swap")].(*dataframe.SeriesFloat64).Values
-
-	tradeState := &KalmanTradeStatev2{}
-
-	// Track first trade state
-	firstTradeCompleted := false
-	firstTradeDay := -1             // Track which day we're on
-	waitingForSlowSwapFlip := false // Track if we're waiting for slow swap flip after intersection
-	parallelFromOpen := false       // Track if lines have been parallel from market open
-
-	for i := 0; i < _dataframe_length; i++ {
-		// Check if we're in a new trading day
-		currentDay := _timestamp[i].YearDay()
-		if currentDay != firstTradeDay {
-			firstTradeCompleted = false
-			firstTradeDay = currentDay
-			waitingForSlowSwapFlip = false
-			parallelFromOpen = false
-		}
-
-		// Swap flip conditions
-		slowSwapFlipUp := i > 0 && _slow_swap[i] == 1 && (_slow_swap[i-1] == 0 || _slow_swap[i-1] == -1)
-		slowSwapFlipDown := i > 0 && _slow_swap[i] == -1 && (_slow_swap[i-1] == 0 || _slow_swap[i-1] == 1)
-		fastSwapFlipUp := i > 0 && _fast_swap[i] == 1 && _fast_swap[i-1] == -1
-		fastSwapFlipDown := i > 0 && _fast_swap[i] == -1 && _fast_swap[i-1] == 1
-		slowSwapFlipSignal := i > 0 && ((_slow_swap[i-1] == 1 && _slow_swap[i] == -1) || (_slow_swap[i-1] == -1 && _slow_swap[i] == 1))
-		fastSwapFlipSignal := i > 0 && ((_fast_swap[i-1] == 1 && _fast_swap[i] == -1) || (_fast_swap[i-1] == -1 && _fast_swap[i] == 1))
-
-		// Late entry conditions
-		lateBuy := fastSwapFlipUp && _slow_swap[i] == 1
-		lateSell := fastSwapFlipDown && _slow_swap[i] == -1
-
-		// Check for intersection and parallel conditions (only if we have previous data)
-		hasIntersection := false
-		isParallelNow := false
-		if i > 0 {
-			hasIntersection = hasIntersected(_fast_tempx_kalman[i-1], _fast_tempx_kalman[i],
-				_slow_tempx_kalman[i-1], _slow_tempx_kalman[i])
-			isParallelNow = isParallel(_fast_tempx_kalman[i-1], _fast_tempx_kalman[i],
-				_slow_tempx_kalman[i-1], _slow_tempx_kalman[i])
-		}
-
-		// First trade logic: check for intersection from 9:15
-		if !firstTradeCompleted && isAfter915(_timestamp[i]) {
-			if hasIntersection {
-				// If fast intersects slow and slow is yellow (0) and fast is not yellow, wait for slow flip
-				if isSwapYellow(_slow_swap[i]) && !isSwapYellow(_fast_swap[i]) {
-					waitingForSlowSwapFlip = true
-				} else {
-					waitingForSlowSwapFlip = false
-				}
-				parallelFromOpen = false
-			} else if isParallelNow {
-				// Track if parallel from market open
-				if !parallelFromOpen {
-					parallelFromOpen = true
-				}
-			} else {
-				parallelFromOpen = false
-			}
-
-			// Check if slow swap has flipped (clearing the wait condition)
-			if waitingForSlowSwapFlip && slowSwapFlipSignal {
-				waitingForSlowSwapFlip = false
-			}
-		}
-
-		// Determine buy/sell conditions based on first trade vs subsequent trades
-		var _buy_condition, _sell_condition bool
-
-		if !firstTradeCompleted {
-			// First trade logic
-			if waitingForSlowSwapFlip {
-				// Ignore signals while waiting for slow swap flip
-				_buy_condition = false
-				_sell_condition = false
-			} else if parallelFromOpen && (fastSwapFlipUp || fastSwapFlipDown) {
-				// If parallel from open and fast swap flips, take trade based on fast color
-				// For buy: fast should be above slow and fast swap should be green (1)
-				// For sell: fast should be below slow and fast swap should be pink (-1)
-				if fastSwapFlipUp && _fast_tempx_kalman[i] > _slow_tempx_kalman[i] {
-					_buy_condition = true
-					_sell_condition = false
-				} else if fastSwapFlipDown && _fast_tempx_kalman[i] < _slow_tempx_kalman[i] {
-					_sell_condition = true
-					_buy_condition = false
-				} else {
-					_buy_condition = false
-					_sell_condition = false
-				}
-			} else {
-				// Fallback to original logic for first trade
-				_buy_condition = slowSwapFlipUp || lateBuy
-				_sell_condition = slowSwapFlipDown || lateSell
-			}
-		} else {
-			// Subsequent trades: align when both swaps have same color
-			// Buy: both green (1), fast above slow
-			// Sell: both pink (-1), fast below slow
-			bothGreen := isSwapGreen(_slow_swap[i]) && isSwapGreen(_fast_swap[i])
-			bothPink := isSwapPink(_slow_swap[i]) && isSwapPink(_fast_swap[i])
-			fastAboveSlow := _fast_tempx_kalman[i] > _slow_tempx_kalman[i]
-			fastBelowSlow := _fast_tempx_kalman[i] < _slow_tempx_kalman[i]
-
-			_buy_condition = bothGreen && fastAboveSlow && (slowSwapFlipUp || lateBuy)
-			_sell_condition = bothPink && fastBelowSlow && (slowSwapFlipDown || lateSell)
-		}
-
-		sessionClosed := !indicators.IsActiveSession(_timestamp[i])
-		exitSignal := false
-
-		// Update peak profit and peak loss
-		if current_position.Kind != "" {
-			if current_position.Kind == "BUY" {
-				current_position.Profit = _close[i] - current_position.EntryPrice
-			} else if current_position.Kind == "SELL" {
-				current_position.Profit = current_position.EntryPrice - _close[i]
-			}
-
-			current_position.PeakProfit = math.Max(current_position.PeakProfit, current_position.Profit)
-			current_position.PeakLoss = math.Min(current_position.PeakLoss, current_position.Profit)
-		}
-
-		fixedSLHit := current_position.Profit <= exitConfig.FixedSL && exitConfig.EnableFixedSL
-
-		// Determine exit signal
-		if current_position.Kind == "BUY" {
-			exitSignal = _sell_condition || slowSwapFlipSignal || fastSwapFlipSignal || fixedSLHit
-		} else if current_position.Kind == "SELL" {
-			exitSignal = _buy_condition || slowSwapFlipSignal || fastSwapFlipSignal || fixedSLHit
-		}
-
-		// Check if should exit trade
-		shouldExitTrade := false
-		if current_position.Kind != "" {
-			tradeState.EntryPrice = current_position.EntryPrice
-			tradeState.Side = current_position.Kind
-			tradeState.CurrentPrice = _close[i]
-			tradeState.ExitSignal = exitSignal
-			tradeState.BarsInTrade++
-			shouldExitTrade = shouldExitv2(tradeState, exitConfig)
-		}
+	RunBars(df, NewKalmanV2Bar(exitConfig), kalmanColumns, current_position, events)
+}
 
-		shouldCloseBuy := current_position.Kind == "BUY" && (shouldExitTrade || sessionClosed)
-		shouldCloseSell := current_position.Kind == "SELL" && (shouldExitTrade || sessionClosed)
-
-		// Handle exits
-		if shouldCloseSell {
-			current_position.Exit(_close[i], *_timestamp[i])
-			exitEvent := &types.Event{
-				Kind:       "SELL",
-				Type:       "EXIT",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-				Reason:     "SIGNAL",
-				PeakProfit: current_position.PeakProfit,
-				PeakLoss:   current_position.PeakLoss,
-			}
-			current_position.Reset()
-			tradeState.Reset()
-			events <- exitEvent
-			if !firstTradeCompleted {
-				firstTradeCompleted = true
-			}
-		}
+// KalmanV2WarmupBars is the longest look-back among the RunKalmanv2
+// indicators: EMA(21) on 15-minute bars plus the one-bar MTF shift, in 1m bars.
+// rolling_std_60 → vol_expansion(60) needs 120 and the Kalman FFT window 64.
+const KalmanV2WarmupBars = (21 + 1) * 15
 
-		if shouldCloseBuy {
-			current_position.Exit(_close[i], *_timestamp[i])
-			exitEvent := &types.Event{
-				Kind:       "BUY",
-				Type:       "EXIT",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-				Reason:     "SIGNAL",
-				PeakProfit: current_position.PeakProfit,
-				PeakLoss:   current_position.PeakLoss,
-			}
-			current_position.Reset()
-			tradeState.Reset()
-			events <- exitEvent
-			if !firstTradeCompleted {
-				firstTradeCompleted = true
-			}
-		}
-
-		// Handle entries
-		if current_position.Kind == "" && _buy_condition {
-			current_position.Buy(_close[i], *_timestamp[i])
-			current_position.PeakProfit = 0
-			current_position.PeakLoss = 0
-			tradeState.Reset()
-			tradeState.EntryPrice = _close[i]
-			tradeState.Side = "BUY"
-			tradeState.CurrentPrice = _close[i]
-			events <- &types.Event{
-				Kind:       "BUY",
-				Type:       "ENTRY",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-			}
-		}
-
-		if current_position.Kind == "" && _sell_condition {
-			current_position.Sell(_close[i], *_timestamp[i])
-			current_position.PeakProfit = 0
-			current_position.PeakLoss = 0
-			tradeState.Reset()
-			tradeState.EntryPrice = _close[i]
-			tradeState.Side = "SELL"
-			tradeState.CurrentPrice = _close[i]
-			events <- &types.Event{
-				Kind:       "SELL",
-				Type:       "ENTRY",
-				EntryPrice: _close[i],
-				Timestamp:  *_timestamp[i],
-			}
-		}
-	}
-}
+// ActivePipeline is the indicator pipeline RunKalmanv2 runs: the built-in
+// kalman_v2 spec unless the config names another (config.Apply).
+var ActivePipeline = pipeline.Default()
 
+// RunKalmanv2 appends the ActivePipeline columns (by default the model's
+// feature set, see internal/pipeline/kalman_v2.yaml) to df, computing
+// independent indicator branches concurrently.
 func RunKalmanv2(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
 	// All indicators need at least 2 rows; bail early if broker returned no data.
 	if df.NRows() < 2 {
 		fmt.Println("RunKalmanv2: insufficient data (rows=", df.NRows(), "), skipping indicators")
 		return
 	}
+	// Columns restored from the feature cache are not computed again.
+	if hasColumns(df, ActivePipeline.Columns()) {
+		return
+	}
 	start := time.Now()
-	indicators.CCI(df, "fast_cci", "close", 2)
-	indicators.ATR(df, "tr", "close", 5)
-	indicators.WMA(df, "wma_tr_2", "tr", 30)
-
-	indicators.CalcX(df, "fast_tempx", "close", 0.1, 2, "fast_cci", "wma_tr_2")
-	indicators.CalcX(df, "slow_tempx", "close", 0.1, 2, "fast_cci", "wma_tr_2")
-
-	indicators.EMA(df, "ema_fast_tempx", "fast_tempx", 9) // 3
-	indicators.EMA(df, "ema_slow_tempx", "slow_tempx", 9) // 21
-
-	indicators.KalmanFilter(df, "fast_tempx_kalman", "ema_fast_tempx", 16, 16, true)
-	indicators.KalmanFilter(df, "slow_tempx_kalman", "ema_slow_tempx", 32, 32, true)
-
-	indicators.ATR(df, "atr3", "fast_tempx_kalman", 2)
-	indicators.ATR(df, "atr3_base", "slow_tempx_kalman", 2)
-
-	indicators.CalcSWAPKalman(df, "swap", "fast_tempx_kalman", 0.25)      // 0.349
-	indicators.CalcSWAPKalman(df, "swap_base", "slow_tempx_kalman", 0.25) // 0.295
-
-	// Trend indicators — reduced periods for faster trend detection
-	indicators.EMA(df, "ema_fast", "close", 5)
-	indicators.EMA(df, "ema_slow", "close", 21)
-	indicators.Slope(df, "ema_slope_fast", "ema_fast", 5)
-	indicators.Slope(df, "ema_slope_slow", "ema_slow", 10)
-	indicators.PriceDistance(df, "price_dist_ema_fast", "close", "ema_fast")
-	indicators.PriceDistance(df, "price_dist_ema_slow", "close", "ema_slow")
-	// Python: (ema_fast - ema_slow) / (ema_slow + 1e-10)
-	indicators.PriceDistance(df, "ema_crossover", "ema_fast", "ema_slow")
-
-	// Momentum indicators
-	indicators.RSI(df, "rsi", "close", 7)
-	indicators.ROC(df, "roc", "close", 5)
-	indicators.LogReturn(df, "log_ret", "close", 1)
-	indicators.LogReturn(df, "log_ret_5", "close", 5)
-	indicators.LogReturn(df, "log_ret_15", "close", 15)
-	indicators.LogReturn(df, "log_ret_30", "close", 30)
-
-	// Volatility indicators
-	indicators.ATRSmoothed(df, "atr_computed", 7)
-	indicators.RollingStd(df, "rolling_std", "log_ret", 21)
-	indicators.RollingStd(df, "rolling_std_60", "log_ret", 60)
-	indicators.HLRangePct(df, "hl_range_pct")
-	indicators.VolExpansion(df, "vol_expansion", "rolling_std", 60)
-
-	indicators.AddMicrostructureFeatures(df)
-	indicators.AddMinuteOfDay(df)
-
-	// Volume features (zero columns for index data like Nifty)
-	indicators.AddVolumeFeatures(df)
-
-	// Multi-timeframe features (5m + 15m HT bars, shift(1), forward-filled)
-	indicators.AddMTFFeatures(df, 5)
-	indicators.AddMTFFeatures(df, 15)
-
+	ActivePipeline.RunParallel(df, 0)
 	fmt.Println("time taken to calculate indicators", time.Since(start))
 }
+
+func hasColumns(df *dataframe.DataFrame, cols []string) bool {
+	for _, c := range cols {
+		if indicators.FindIndexOf(df, c) < 0 {
+			return false
+		}
+	}
+	return true
+}
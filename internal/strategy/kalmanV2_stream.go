@@ -0,0 +1,198 @@
+package strategy
+
+import (
+	"hft/internal/indicators"
+	"hft/pkg/types"
+)
+
+/*
+   Streaming RunKalmanv2.
+
+   KalmanV2Stream computes every column RunKalmanv2 appends, one closed bar
+   at a time, from the streaming indicators in internal/indicators. It is
+   seeded with a history: the Kalman noise is estimated over it as
+   RunKalmanv2 estimates it over a frame of those bars, and the history is
+   replayed, so its rows equal RunKalmanv2 over the history (see
+   kalmanV2_stream_test.go). Each later Update keeps that noise, so its
+   Kalman columns can differ from a batch rerun over the longer frame in
+   rounding; the other columns equal RunKalmanv2 over the bars seen so far,
+   with two documented warmup differences from a batch over a longer
+   history:
+
+     fast_cci          row 0 is 0; the batch copies row 1 into it.
+     *_5m, *_15m       zeros until 30 higher-timeframe bars have started;
+                       a batch that already has 30 writes NaN for the first
+                       bucket and values for the rest.
+
+   The live executor does not consume new bars one at a time yet: it loads
+   history, runs the batch indicators and replays them through its
+   BarHandler. KalmanV2Stream is the per-bar form for that loop, seeded
+   with the loaded history. It is written against the built-in kalman_v2
+   pipeline; a config that runs another pipeline spec has no streaming form.
+*/
+
+// KalmanV2StreamColumns are the columns KalmanV2Stream.Update returns, in
+// the order RunKalmanv2 appends them.
+var KalmanV2StreamColumns = func() []string {
+	cols := []string{
+		"fast_cci", "tr", "wma_tr_2", "fast_tempx", "slow_tempx",
+		"ema_fast_tempx", "ema_slow_tempx", "fast_tempx_kalman", "slow_tempx_kalman",
+		"atr3", "atr3_base", "swap", "swap_base",
+		"ema_fast", "ema_slow", "ema_slope_fast", "ema_slope_slow",
+		"price_dist_ema_fast", "price_dist_ema_slow", "ema_crossover",
+		"rsi", "roc", "log_ret", "log_ret_5", "log_ret_15", "log_ret_30",
+		"atr_computed", "rolling_std", "rolling_std_60", "hl_range_pct", "vol_expansion",
+	}
+	cols = append(cols, indicators.MicrostructureNames...)
+	cols = append(cols, "minute_of_day")
+	cols = append(cols, indicators.VolumeNames...)
+	cols = append(cols, indicators.MTFNames(5)...)
+	cols = append(cols, indicators.MTFNames(15)...)
+	return append(cols, "adx", "adx_plus_di", "adx_minus_di")
+}()
+
+// KalmanV2Stream is the incremental form of RunKalmanv2.
+type KalmanV2Stream struct {
+	cci                      *indicators.CCIStream
+	wmaTR                    *indicators.WMAStream
+	fastX, slowX             *indicators.CalcXStream
+	emaFastX, emaSlowX       *indicators.EMAStream
+	fastKalman, slowKalman   *indicators.KalmanStream
+	swap, swapBase           *indicators.SwapKalmanStream
+	emaFast, emaSlow         *indicators.EMAStream
+	slopeFast, slopeSlow     *indicators.LagStream
+	rsi                      *indicators.RSIStream
+	roc                      *indicators.LagStream
+	logRet, logRet5          *indicators.LagStream
+	logRet15, logRet30       *indicators.LagStream
+	atr                      *indicators.ATRSmoothedStream
+	rollingStd, rollingStd60 *indicators.RollingStdStream
+	volExpansion             *indicators.VolExpansionStream
+	micro                    indicators.MicrostructureStream
+	volume                   *indicators.VolumeStream
+	mtf5, mtf15              *indicators.MTFStream
+	names5, names15          []string
+	adx                      *indicators.ADXStream
+}
+
+// NewKalmanV2Stream returns a stream that has seen history, and the
+// columns of each history bar.
+func NewKalmanV2Stream(history []types.Candle) (*KalmanV2Stream, []Features) {
+	// A first pass collects the Kalman filters' inputs to estimate their
+	// noise over the whole history.
+	fastIn := make([]float64, len(history))
+	slowIn := make([]float64, len(history))
+	pre := newKalmanV2Stream(1, 1, 1, 1)
+	for i, c := range history {
+		f := pre.Update(c)
+		fastIn[i], slowIn[i] = f["ema_fast_tempx"], f["ema_slow_tempx"]
+	}
+	fastR, fastQ := indicators.KalmanNoise(fastIn, 16, 16)
+	slowR, slowQ := indicators.KalmanNoise(slowIn, 32, 32)
+
+	s := newKalmanV2Stream(fastR, fastQ, slowR, slowQ)
+	rows := make([]Features, len(history))
+	for i, c := range history {
+		rows[i] = s.Update(c)
+	}
+	return s, rows
+}
+
+func newKalmanV2Stream(fastR, fastQ, slowR, slowQ float64) *KalmanV2Stream {
+	return &KalmanV2Stream{
+		cci:          indicators.NewCCIStream(2),
+		wmaTR:        indicators.NewWMAStream(30),
+		fastX:        indicators.NewCalcXStream(0.1),
+		slowX:        indicators.NewCalcXStream(0.1),
+		emaFastX:     indicators.NewEMAStream(9),
+		emaSlowX:     indicators.NewEMAStream(9),
+		fastKalman:   indicators.NewKalmanStream(16, 16, fastR, fastQ),
+		slowKalman:   indicators.NewKalmanStream(32, 32, slowR, slowQ),
+		swap:         indicators.NewSwapKalmanStream(0.25),
+		swapBase:     indicators.NewSwapKalmanStream(0.25),
+		emaFast:      indicators.NewEMAStream(5),
+		emaSlow:      indicators.NewEMAStream(21),
+		slopeFast:    indicators.NewSlopeStream(5),
+		slopeSlow:    indicators.NewSlopeStream(10),
+		rsi:          indicators.NewRSIStream(7),
+		roc:          indicators.NewROCStream(5),
+		logRet:       indicators.NewLogReturnStream(1),
+		logRet5:      indicators.NewLogReturnStream(5),
+		logRet15:     indicators.NewLogReturnStream(15),
+		logRet30:     indicators.NewLogReturnStream(30),
+		atr:          indicators.NewATRSmoothedStream(7),
+		rollingStd:   indicators.NewRollingStdStream(21),
+		rollingStd60: indicators.NewRollingStdStream(60),
+		volExpansion: indicators.NewVolExpansionStream(60),
+		volume:       indicators.NewVolumeStream(),
+		mtf5:         indicators.NewMTFStream(5),
+		mtf15:        indicators.NewMTFStream(15),
+		names5:       indicators.MTFNames(5),
+		names15:      indicators.MTFNames(15),
+		adx:          indicators.NewADXStream(14),
+	}
+}
+
+// Update consumes one closed bar and returns its RunKalmanv2 columns.
+func (s *KalmanV2Stream) Update(c types.Candle) Features {
+	f := make(Features, len(KalmanV2StreamColumns))
+
+	// ta.Atr ignores its source and period, so tr, atr3 and atr3_base are
+	// the same per-bar true range.
+	tr := indicators.BarTrueRange(c.High, c.Low, c.Close)
+	cci := s.cci.Update(c.Close)
+	wma := s.wmaTR.Update(tr)
+	fastX := s.fastX.Update(c.Close, cci, wma)
+	slowX := s.slowX.Update(c.Close, cci, wma)
+	emaFastX := s.emaFastX.Update(fastX)
+	emaSlowX := s.emaSlowX.Update(slowX)
+	fastK := s.fastKalman.Update(emaFastX)
+	slowK := s.slowKalman.Update(emaSlowX)
+	f["fast_cci"], f["tr"], f["wma_tr_2"] = cci, tr, wma
+	f["fast_tempx"], f["slow_tempx"] = fastX, slowX
+	f["ema_fast_tempx"], f["ema_slow_tempx"] = emaFastX, emaSlowX
+	f["fast_tempx_kalman"], f["slow_tempx_kalman"] = fastK, slowK
+	f["atr3"], f["atr3_base"] = tr, tr
+	f["swap"] = s.swap.Update(c.Timestamp, fastK, tr)
+	f["swap_base"] = s.swapBase.Update(c.Timestamp, slowK, tr)
+
+	emaFast := s.emaFast.Update(c.Close)
+	emaSlow := s.emaSlow.Update(c.Close)
+	f["ema_fast"], f["ema_slow"] = emaFast, emaSlow
+	f["ema_slope_fast"] = s.slopeFast.Update(emaFast)
+	f["ema_slope_slow"] = s.slopeSlow.Update(emaSlow)
+	f["price_dist_ema_fast"] = (c.Close - emaFast) / (emaFast + 1e-10)
+	f["price_dist_ema_slow"] = (c.Close - emaSlow) / (emaSlow + 1e-10)
+	f["ema_crossover"] = (emaFast - emaSlow) / (emaSlow + 1e-10)
+
+	logRet := s.logRet.Update(c.Close)
+	f["rsi"] = s.rsi.Update(c.Close)
+	f["roc"] = s.roc.Update(c.Close)
+	f["log_ret"] = logRet
+	f["log_ret_5"] = s.logRet5.Update(c.Close)
+	f["log_ret_15"] = s.logRet15.Update(c.Close)
+	f["log_ret_30"] = s.logRet30.Update(c.Close)
+
+	rollingStd := s.rollingStd.Update(logRet)
+	f["atr_computed"] = s.atr.Update(c.High, c.Low, c.Close)
+	f["rolling_std"] = rollingStd
+	f["rolling_std_60"] = s.rollingStd60.Update(logRet)
+	f["hl_range_pct"] = (c.High - c.Low) / (c.Close + 1e-10)
+	f["vol_expansion"] = s.volExpansion.Update(rollingStd)
+
+	for k, v := range s.micro.Update(c.Open, c.High, c.Low, c.Close, fastK, slowK) {
+		f[indicators.MicrostructureNames[k]] = v
+	}
+	f["minute_of_day"] = indicators.MinuteOfDay(c.Timestamp)
+	for k, v := range s.volume.Update(c.Close, c.Volume) {
+		f[indicators.VolumeNames[k]] = v
+	}
+	for k, v := range s.mtf5.Update(c.Timestamp, c.High, c.Low, c.Close) {
+		f[s.names5[k]] = v
+	}
+	for k, v := range s.mtf15.Update(c.Timestamp, c.High, c.Low, c.Close) {
+		f[s.names15[k]] = v
+	}
+	f["adx"], f["adx_plus_di"], f["adx_minus_di"] = s.adx.Update(c.High, c.Low, c.Close)
+	return f
+}
@@ -0,0 +1,102 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"hft/internal/indicators"
+	"hft/internal/testutil"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// streamFrame builds days of 1-minute bars, with volume when withVolume is
+// set.
+func streamFrame(days int, withVolume bool) (*dataframe.DataFrame, []types.Candle) {
+	candles := testutil.Candles(11, days)
+	if !withVolume {
+		testutil.WithoutVolume(candles)
+	}
+	return testutil.Frame(candles), candles
+}
+
+func column(df *dataframe.DataFrame, name string) []float64 {
+	idx := indicators.FindIndexOf(df, name)
+	if idx < 0 {
+		return nil
+	}
+	return df.Series[idx].(*dataframe.SeriesFloat64).Values
+}
+
+func TestKalmanV2StreamColumnsMatchRunKalmanv2(t *testing.T) {
+	df, _ := streamFrame(1, true)
+	base := len(df.Names())
+	RunKalmanv2(df, nil)
+	if got, want := KalmanV2StreamColumns, df.Names()[base:]; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("stream columns\n  %v\nRunKalmanv2 appends\n  %v", got, want)
+	}
+}
+
+// A stream seeded with the bars seen so far ends on the row RunKalmanv2
+// computes over them, i.e. what the live executor's growing frame computes.
+func TestKalmanV2StreamMatchesPrefixBatch(t *testing.T) {
+	for _, withVolume := range []bool{true, false} {
+		df, candles := streamFrame(2, withVolume)
+		// RunKalmanv2 indexes past the end of frames shorter than 60 rows.
+		for i := 59; i < 480; i += 7 {
+			_, rows := NewKalmanV2Stream(candles[:i+1])
+			p := df.Copy(dataframe.RangeFinite(0, i))
+			RunKalmanv2(p, nil)
+			for _, name := range KalmanV2StreamColumns {
+				if want, got := column(p, name)[i], rows[i][name]; !testutil.SameFloat(want, got) {
+					t.Fatalf("volume=%v %s row %d: batch %v, stream %v", withVolume, name, i, want, got)
+				}
+			}
+		}
+	}
+}
+
+// fromRow is the first row where a stream over the full history agrees
+// with one batch over it, past the documented warmup differences.
+func fromRow(name string) int {
+	switch {
+	case strings.HasSuffix(name, "_5m"):
+		return 29 * 5
+	case strings.HasSuffix(name, "_15m"):
+		return 29 * 15
+	}
+	return 1
+}
+
+func TestKalmanV2StreamMatchesFullBatch(t *testing.T) {
+	df, candles := streamFrame(3, true)
+	RunKalmanv2(df, nil)
+	_, rows := NewKalmanV2Stream(candles)
+	for i, got := range rows {
+		for _, name := range KalmanV2StreamColumns {
+			if i < fromRow(name) {
+				continue
+			}
+			if want := column(df, name)[i]; !testutil.SameFloat(want, got[name]) {
+				t.Fatalf("%s row %d: batch %v, stream %v", name, i, want, got[name])
+			}
+		}
+	}
+}
+
+// Bars streamed after the seeding history keep its Kalman noise and still
+// agree with one batch over everything.
+func TestKalmanV2StreamContinuesPastHistory(t *testing.T) {
+	df, candles := streamFrame(3, true)
+	RunKalmanv2(df, nil)
+	s, _ := NewKalmanV2Stream(candles[:2*375])
+	for i := 2 * 375; i < len(candles); i++ {
+		got := s.Update(candles[i])
+		for _, name := range KalmanV2StreamColumns {
+			if want := column(df, name)[i]; !testutil.SameFloat(want, got[name]) {
+				t.Fatalf("%s row %d: batch %v, stream %v", name, i, want, got[name])
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"hft/internal/indicators"
+	"hft/pkg/types"
+)
+
+/*
+   Per-bar forms of the Kalman strategies.
+
+   Both read the kalmanColumns of a bar and compare them with the previous
+   bar's, so the swap flips, intersections and parallel checks need nothing
+   beyond the state they carry. FindKalmanSignal* loop them over a frame.
+*/
+
+// kalmanBarState is the state the Kalman strategies carry between bars. It
+// is also their serialized form.
+type kalmanBarState struct {
+	HasPrev  bool    `json:"hasPrev"`
+	PrevFast float64 `json:"prevFast"` // fast_tempx_kalman
+	PrevSlow float64 `json:"prevSlow"` // slow_tempx_kalman
+	PrevSwap float64 `json:"prevSwap"` // swap (fast)
+	PrevBase float64 `json:"prevBase"` // swap_base (slow)
+
+	Kind       string  `json:"kind"` // BUY, SELL or "" when flat
+	EntryPrice float64 `json:"entryPrice"`
+	Profit     float64 `json:"profit"`
+
+	Trade KalmanTradeState `json:"trade"`
+
+	// v2 first-trade-of-day tracking.
+	Day                    int  `json:"day"`
+	FirstTradeCompleted    bool `json:"firstTradeCompleted"`
+	WaitingForSlowSwapFlip bool `json:"waitingForSlowSwapFlip"`
+	ParallelFromOpen       bool `json:"parallelFromOpen"`
+}
+
+// kalmanBar holds what both Kalman versions share.
+type kalmanBar struct {
+	st kalmanBarState
+}
+
+func (s *kalmanBar) Position() int {
+	switch s.st.Kind {
+	case "BUY":
+		return 1
+	case "SELL":
+		return -1
+	}
+	return 0
+}
+
+func (s *kalmanBar) Reject() {
+	s.st.Kind = ""
+	s.st.EntryPrice = 0
+	s.st.Profit = 0
+	s.st.Trade.Reset()
+}
+
+func (s *kalmanBar) Snapshot() ([]byte, error) {
+	return json.Marshal(s.st)
+}
+
+func (s *kalmanBar) Restore(state []byte) error {
+	var st kalmanBarState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore kalman state: %w", err)
+	}
+	s.st = st
+	return nil
+}
+
+// mark updates the open trade's profit at close.
+func (s *kalmanBar) mark(close float64) {
+	if s.st.Kind == "BUY" {
+		s.st.Profit = close - s.st.EntryPrice
+	} else if s.st.Kind == "SELL" {
+		s.st.Profit = s.st.EntryPrice - close
+	}
+}
+
+// enter opens a trade on kind at close.
+func (s *kalmanBar) enter(kind string, c types.Candle) types.Intent {
+	s.st.Kind = kind
+	s.st.EntryPrice = c.Close
+	s.st.Profit = 0
+	s.st.Trade.Reset()
+	s.st.Trade.EntryPrice = c.Close
+	s.st.Trade.Side = kind
+	s.st.Trade.CurrentPrice = c.Close
+	return types.Intent{Kind: kind, Type: "ENTRY", Price: c.Close, Timestamp: c.Timestamp}
+}
+
+// exit closes the open trade at close.
+func (s *kalmanBar) exit(c types.Candle) types.Intent {
+	in := types.Intent{Kind: s.st.Kind, Type: "EXIT", Price: c.Close, Timestamp: c.Timestamp, Reason: "SIGNAL"}
+	s.Reject()
+	return in
+}
+
+// remember stores the bar's values for the next bar's flip checks.
+func (s *kalmanBar) remember(f Features) {
+	s.st.HasPrev = true
+	s.st.PrevFast, s.st.PrevSlow = f["fast_tempx_kalman"], f["slow_tempx_kalman"]
+	s.st.PrevSwap, s.st.PrevBase = f["swap"], f["swap_base"]
+}
+
+// flips are the swap transitions between the previous bar and this one.
+type flips struct {
+	slowUp, slowDown, fastUp, fastDown bool
+	slowSignal, fastSignal             bool
+}
+
+func (s *kalmanBar) flips(f Features) flips {
+	if !s.st.HasPrev {
+		return flips{}
+	}
+	slow, fast := f["swap_base"], f["swap"]
+	pSlow, pFast := s.st.PrevBase, s.st.PrevSwap
+	return flips{
+		slowUp:     slow == 1 && (pSlow == 0 || pSlow == -1),
+		slowDown:   slow == -1 && (pSlow == 0 || pSlow == 1),
+		fastUp:     fast == 1 && pFast == -1,
+		fastDown:   fast == -1 && pFast == 1,
+		slowSignal: (pSlow == 1 && slow == -1) || (pSlow == -1 && slow == 1),
+		fastSignal: (pFast == 1 && fast == -1) || (pFast == -1 && fast == 1),
+	}
+}
+
+// ── v1 ──────────────────────────────────────────────────────────────────────
+
+// KalmanBar evaluates the v1 Kalman strategy (FindKalmanSignal) one bar at
+// a time.
+type KalmanBar struct {
+	kalmanBar
+	cfg *KalmanExitConfig
+}
+
+// NewKalmanBar returns a flat per-bar v1 strategy; nil cfg uses the defaults.
+func NewKalmanBar(cfg *KalmanExitConfig) *KalmanBar {
+	if cfg == nil {
+		cfg = DefaultKalmanExitConfig()
+	}
+	return &KalmanBar{cfg: cfg}
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *KalmanBar) OnBar(c types.Candle, f Features) []types.Intent {
+	cfg := s.cfg
+	defer s.remember(f)
+
+	deviation := math.Abs(f["slow_tempx_kalman"] - f["fast_tempx_kalman"])
+	deviationOK := deviation >= 7 && deviation <= 15
+
+	fl := s.flips(f)
+	lateBuy := fl.fastUp && f["swap_base"] == 1
+	lateSell := fl.fastDown && f["swap_base"] == -1
+	buy := deviationOK && (fl.slowUp || lateBuy)
+	sell := deviationOK && (fl.slowDown || lateSell)
+
+	var out []types.Intent
+	if s.st.Kind != "" {
+		s.mark(c.Close)
+		fixedSLHit := s.st.Profit <= cfg.FixedSL && cfg.EnableFixedSL
+		exitSignal := fl.slowSignal || fl.fastSignal || fixedSLHit
+		if s.st.Kind == "BUY" {
+			exitSignal = exitSignal || sell
+		} else {
+			exitSignal = exitSignal || buy
+		}
+
+		s.st.Trade.EntryPrice = s.st.EntryPrice
+		s.st.Trade.Side = s.st.Kind
+		s.st.Trade.CurrentPrice = c.Close
+		s.st.Trade.ExitSignal = exitSignal
+		s.st.Trade.BarsInTrade++
+		if shouldExit(&s.st.Trade, cfg) || !indicators.IsActiveSession(&c.Timestamp) {
+			out = append(out, s.exit(c))
+		}
+	}
+
+	if s.st.Kind == "" && buy {
+		out = append(out, s.enter("BUY", c))
+	} else if s.st.Kind == "" && sell {
+		out = append(out, s.enter("SELL", c))
+	}
+	return out
+}
+
+// ── v2 ──────────────────────────────────────────────────────────────────────
+
+// KalmanV2Bar evaluates the v2 Kalman strategy (FindKalmanSignalv2) one bar
+// at a time.
+type KalmanV2Bar struct {
+	kalmanBar
+	cfg *KalmanExitConfigv2
+}
+
+// NewKalmanV2Bar returns a flat per-bar v2 strategy. A nil cfg follows
+// ActiveExitConfig, so live edits apply from the next bar.
+func NewKalmanV2Bar(cfg *KalmanExitConfigv2) *KalmanV2Bar {
+	return &KalmanV2Bar{cfg: cfg}
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *KalmanV2Bar) OnBar(c types.Candle, f Features) []types.Intent {
+	cfg := s.cfg
+	if cfg == nil {
+		cfg = ActiveExitConfig()
+	}
+	if cfg == nil {
+		cfg = DefaultKalmanExitConfigv2()
+	}
+	defer s.remember(f)
+	st := &s.st
+
+	// New trading day: the first-trade rules apply again.
+	if day := c.Timestamp.YearDay(); day != st.Day {
+		st.Day = day
+		st.FirstTradeCompleted = false
+		st.WaitingForSlowSwapFlip = false
+		st.ParallelFromOpen = false
+	}
+
+	fast, slow := f["fast_tempx_kalman"], f["slow_tempx_kalman"]
+	fastSwap, slowSwap := f["swap"], f["swap_base"]
+	fl := s.flips(f)
+	lateBuy := fl.fastUp && slowSwap == 1
+	lateSell := fl.fastDown && slowSwap == -1
+
+	hasIntersection, isParallelNow := false, false
+	if st.HasPrev {
+		hasIntersection = hasIntersected(st.PrevFast, fast, st.PrevSlow, slow)
+		isParallelNow = isParallel(st.PrevFast, fast, st.PrevSlow, slow)
+	}
+
+	// First trade: track intersections and parallel runs from 9:15.
+	if !st.FirstTradeCompleted && isAfter915(&c.Timestamp) {
+		if hasIntersection {
+			// Fast crossing a yellow slow line waits for the slow flip.
+			st.WaitingForSlowSwapFlip = isSwapYellow(slowSwap) && !isSwapYellow(fastSwap)
+			st.ParallelFromOpen = false
+		} else {
+			st.ParallelFromOpen = isParallelNow
+		}
+		if st.WaitingForSlowSwapFlip && fl.slowSignal {
+			st.WaitingForSlowSwapFlip = false
+		}
+	}
+
+	var buy, sell bool
+	if !st.FirstTradeCompleted {
+		if st.WaitingForSlowSwapFlip {
+			// Ignore signals while waiting for the slow swap flip.
+		} else if st.ParallelFromOpen && (fl.fastUp || fl.fastDown) {
+			// Parallel from open: trade the fast flip on the right side of slow.
+			buy = fl.fastUp && fast > slow
+			sell = !buy && fl.fastDown && fast < slow
+		} else {
+			buy = fl.slowUp || lateBuy
+			sell = fl.slowDown || lateSell
+		}
+	} else {
+		// Later trades need both swaps the same colour, fast on the right side.
+		bothGreen := isSwapGreen(slowSwap) && isSwapGreen(fastSwap)
+		bothPink := isSwapPink(slowSwap) && isSwapPink(fastSwap)
+		buy = bothGreen && fast > slow && (fl.slowUp || lateBuy)
+		sell = bothPink && fast < slow && (fl.slowDown || lateSell)
+	}
+
+	var out []types.Intent
+	if st.Kind != "" {
+		s.mark(c.Close)
+		fixedSLHit := st.Profit <= cfg.FixedSL && cfg.EnableFixedSL
+		exitSignal := fl.slowSignal || fl.fastSignal || fixedSLHit
+		if st.Kind == "BUY" {
+			exitSignal = exitSignal || sell
+		} else {
+			exitSignal = exitSignal || buy
+		}
+
+		trade := KalmanTradeStatev2(st.Trade)
+		trade.EntryPrice = st.EntryPrice
+		trade.Side = st.Kind
+		trade.CurrentPrice = c.Close
+		trade.ExitSignal = exitSignal
+		trade.BarsInTrade++
+		shouldExitTrade := shouldExitv2(&trade, cfg)
+		st.Trade = KalmanTradeState(trade)
+		if shouldExitTrade || !indicators.IsActiveSession(&c.Timestamp) {
+			out = append(out, s.exit(c))
+			st.FirstTradeCompleted = true
+		}
+	}
+
+	if st.Kind == "" && buy {
+		out = append(out, s.enter("BUY", c))
+	} else if st.Kind == "" && sell {
+		out = append(out, s.enter("SELL", c))
+	}
+	return out
+}
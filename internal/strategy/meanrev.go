@@ -0,0 +1,392 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"hft/internal/indicators"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Volatile-regime mean reversion.
+
+   The regime strategy stands aside when the model says volatile
+   (MaxVolProb). This strategy trades only then, fading stretched moves.
+   A bar is stretched up (short) or down (long) by up to three measures:
+
+     kalman_fast_dist   (close - fast Kalman) / close beyond ±DistThresh
+     rsi                above RSIHigh / below RSILow
+     bb_z               Bollinger z-score beyond ±BandZ
+
+   and at least MinSignals of them must agree. Exits are tight: a % stop,
+   a time stop after TimeStopBars, and a target once bb_z has reverted to
+   ±ExitZ. The target is armed only once bb_z has been stretched beyond
+   ExitZ in the trade, so an entry on the other two measures with bb_z
+   already near 0 does not take it on the next bar. Tranche squareoff works
+   as in the regime strategy.
+
+   RouterBar runs it side by side with the trend (regime) strategy: on
+   volatile bars only mean reversion may enter, otherwise only the trend
+   strategy may, and whichever holds the position manages its exit.
+*/
+
+// MeanRevConfig controls the volatile-regime mean-reversion strategy.
+type MeanRevConfig struct {
+	MinVolProb float64 `yaml:"min_vol_prob" json:"minVolProb"` // volatile prob needed (and it must be the top regime)
+
+	DistThresh float64 `yaml:"dist_thresh" json:"distThresh"` // |kalman_fast_dist| (fraction of price)
+	RSILow     float64 `yaml:"rsi_low" json:"rsiLow"`
+	RSIHigh    float64 `yaml:"rsi_high" json:"rsiHigh"`
+	BandPeriod int     `yaml:"band_period" json:"bandPeriod"` // bb_z window
+	BandZ      float64 `yaml:"band_z" json:"bandZ"`
+	MinSignals int     `yaml:"min_signals" json:"minSignals"` // of the three stretch measures
+
+	StopPct      float64 `yaml:"stop_pct" json:"stopPct"`            // % of entry
+	TimeStopBars int     `yaml:"time_stop_bars" json:"timeStopBars"` // bars in trade before giving up
+	ExitZ        float64 `yaml:"exit_z" json:"exitZ"`                // target: bb_z back within ±ExitZ
+	CooldownBars int     `yaml:"cooldown_bars" json:"cooldownBars"`  // after any exit
+	MaxTrades    int     `yaml:"max_trades_per_tranche" json:"maxTradesPerTranche"`
+
+	Tranches []Tranche `yaml:"tranches" json:"tranches"`
+}
+
+// DefaultMeanRevConfig returns the default mean-reversion parameters on the
+// regime strategy's tranches.
+func DefaultMeanRevConfig() *MeanRevConfig {
+	return &MeanRevConfig{
+		MinVolProb:   0.4,
+		DistThresh:   0.0015,
+		RSILow:       25,
+		RSIHigh:      75,
+		BandPeriod:   20,
+		BandZ:        2,
+		MinSignals:   2,
+		StopPct:      0.2,
+		TimeStopBars: 10,
+		ExitZ:        0.25,
+		CooldownBars: 5,
+		MaxTrades:    4,
+		Tranches:     DefaultRegimeSignalConfig().Tranches,
+	}
+}
+
+// ActiveMeanRevConfig is the mean-reversion config used by the live
+// executor and backtests, set from config at startup.
+var ActiveMeanRevConfig = DefaultMeanRevConfig()
+
+// MeanRevColumns are the columns the mean-reversion strategy reads per bar.
+var MeanRevColumns = []string{"pred_prob_bullish", "pred_prob_bearish", "pred_prob_volatile", "kalman_fast_dist", "rsi", "bb_z"}
+
+// RunMeanRevIndicators adds the mean-reversion columns: the RunKalmanv2 set
+// (kalman_fast_dist, rsi, atr3) plus bb_z.
+func RunMeanRevIndicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent, cfg *MeanRevConfig) {
+	RunKalmanv2(df, logEvents)
+	if df.NRows() >= 2 {
+		indicators.BollingerZ(df, "bb_z", "close", cfg.BandPeriod)
+	}
+}
+
+// isVolatile reports whether the model calls the bar volatile.
+func (cfg *MeanRevConfig) isVolatile(f Features) bool {
+	vol := f["pred_prob_volatile"]
+	return vol >= cfg.MinVolProb && vol > f["pred_prob_bullish"] && vol > f["pred_prob_bearish"]
+}
+
+// stretch returns +1 when the bar is stretched up, -1 when stretched down.
+func (cfg *MeanRevConfig) stretch(f Features) int {
+	up, down := 0, 0
+	if d := f["kalman_fast_dist"]; d >= cfg.DistThresh {
+		up++
+	} else if d <= -cfg.DistThresh {
+		down++
+	}
+	if r := f["rsi"]; r >= cfg.RSIHigh {
+		up++
+	} else if r > 0 && r <= cfg.RSILow {
+		down++
+	}
+	if z := f["bb_z"]; z >= cfg.BandZ {
+		up++
+	} else if z <= -cfg.BandZ {
+		down++
+	}
+	switch {
+	case up >= cfg.MinSignals && up > down:
+		return 1
+	case down >= cfg.MinSignals && down > up:
+		return -1
+	}
+	return 0
+}
+
+// ── Per-bar form ─────────────────────────────────────────────────────────────
+
+// MeanRevBar evaluates the mean-reversion strategy one bar at a time.
+type MeanRevBar struct {
+	cfg     *MeanRevConfig
+	session *sessionTracker
+	st      meanRevState
+}
+
+// meanRevState is what MeanRevBar carries between bars, and its serialized
+// form.
+type meanRevState struct {
+	Position   int     `json:"position"` // +1 long, -1 short, 0 flat
+	EntryPrice float64 `json:"entryPrice"`
+	Bars       int     `json:"bars"`  // bars in trade
+	Armed      bool    `json:"armed"` // bb_z has been beyond ExitZ since entry
+	Cooldown   int     `json:"cooldown"`
+	Tranche    string  `json:"tranche"` // day/tranche key
+	Trades     int     `json:"trades"`  // in the tranche
+}
+
+// NewMeanRevBar returns a flat per-bar mean-reversion strategy; nil cfg
+// uses the defaults.
+func NewMeanRevBar(cfg *MeanRevConfig) *MeanRevBar {
+	if cfg == nil {
+		cfg = DefaultMeanRevConfig()
+	}
+	return &MeanRevBar{cfg: cfg, session: newSessionTracker(cfg.Tranches, 0)}
+}
+
+// Position returns +1 when long, -1 when short and 0 when flat.
+func (s *MeanRevBar) Position() int {
+	return s.st.Position
+}
+
+// Reject tells the strategy its entry was not filled. The tranche count
+// still treats the attempt as a trade.
+func (s *MeanRevBar) Reject() {
+	s.st.Position = 0
+	s.st.EntryPrice = 0
+	s.st.Bars = 0
+	s.st.Armed = false
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *MeanRevBar) OnBar(c types.Candle, f Features) []types.Intent {
+	cfg := s.cfg
+	t := c.Timestamp.In(ist)
+	dayKey := t.Format("2006-01-02")
+	mins := t.Hour()*60 + t.Minute()
+	close := c.Close
+
+	s.session.update(dayKey, mins, close, f["pred_prob_volatile"])
+
+	var out []types.Intent
+	exit := func(reason string) {
+		kind := "BUY"
+		if s.st.Position == -1 {
+			kind = "SELL"
+		}
+		out = append(out, types.Intent{Kind: kind, Type: "EXIT", Price: close, Timestamp: c.Timestamp, Reason: reason})
+		s.Reject()
+		s.st.Cooldown = cfg.CooldownBars
+	}
+
+	tr, inTranche := trancheAt(cfg.Tranches, mins)
+	if !inTranche || mins >= tr.CloseMin {
+		if s.st.Position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.st.Cooldown = 0
+		return out
+	}
+	if key := dayKey + "/" + tr.Name; key != s.st.Tranche {
+		if s.st.Position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.st.Tranche = key
+		s.st.Trades = 0
+		s.st.Cooldown = 0
+	}
+	if s.st.Cooldown > 0 {
+		s.st.Cooldown--
+	}
+
+	// ── Exits (when in position) ─────────────────────────────────
+	if s.st.Position != 0 {
+		s.st.Bars++
+		side := float64(s.st.Position)
+		unrealPct := (close - s.st.EntryPrice) / s.st.EntryPrice * 100 * side
+		z := f["bb_z"]
+		switch {
+		case unrealPct <= -cfg.StopPct:
+			exit("STOP_LOSS")
+		case s.st.Armed && side*z >= -cfg.ExitZ:
+			exit("PROFIT_TARGET")
+		case s.st.Bars >= cfg.TimeStopBars:
+			exit("TIME_STOP")
+		case side*z < -cfg.ExitZ:
+			s.st.Armed = true
+		}
+		return out
+	}
+
+	// ── Fade entry (flat, volatile) ──────────────────────────────
+	if mins >= tr.CutoffMin || s.st.Cooldown > 0 || s.st.Trades >= cfg.MaxTrades || !cfg.isVolatile(f) {
+		return out
+	}
+	side := -cfg.stretch(f)
+	if side == 0 {
+		return out
+	}
+	s.st.Position = side
+	s.st.EntryPrice = close
+	s.st.Bars = 0
+	s.st.Armed = float64(side)*f["bb_z"] < -cfg.ExitZ
+	s.st.Trades++
+	kind := "BUY"
+	if side == -1 {
+		kind = "SELL"
+	}
+	out = append(out, types.Intent{Kind: kind, Type: "ENTRY", Price: close, Timestamp: c.Timestamp,
+		Context: entryContext(tr.Name, s.session.dayType(), s.session.gapPct, side, c.Timestamp, RegimeFeaturesOf(f))})
+	return out
+}
+
+// meanRevBarState is the serialized form of a MeanRevBar.
+type meanRevBarState struct {
+	State   meanRevState `json:"state"`
+	Session sessionState `json:"session"`
+}
+
+// Snapshot serializes the position, time stop, cooldown, tranche count and
+// the day gathered so far.
+func (s *MeanRevBar) Snapshot() ([]byte, error) {
+	return json.Marshal(meanRevBarState{State: s.st, Session: s.session.snapshot()})
+}
+
+// Restore replaces the strategy's state with one written by Snapshot.
+func (s *MeanRevBar) Restore(state []byte) error {
+	var st meanRevBarState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore meanrev state: %w", err)
+	}
+	s.st = st.State
+	s.session.restore(st.Session)
+	return nil
+}
+
+// ── Regime router ────────────────────────────────────────────────────────────
+
+// RouterBar runs the trend (regime) and mean-reversion strategies side by
+// side on one position. Both see every bar so their state stays current;
+// entries are let through only from the strategy the bar's regime routes to
+// (mean reversion on volatile bars, trend otherwise) and only while flat.
+// Entries that are not let through are rejected back to their strategy.
+type RouterBar struct {
+	trend   BarStrategy
+	meanRev BarStrategy
+	cfg     *MeanRevConfig
+	owner   int // 0 none, 1 trend, 2 mean reversion
+}
+
+// NewRouterBar routes between trend and meanRev by cfg's volatile test.
+func NewRouterBar(trend, meanRev BarStrategy, cfg *MeanRevConfig) *RouterBar {
+	if cfg == nil {
+		cfg = DefaultMeanRevConfig()
+	}
+	return &RouterBar{trend: trend, meanRev: meanRev, cfg: cfg}
+}
+
+// Position returns the position of the strategy that holds it.
+func (r *RouterBar) Position() int {
+	switch r.owner {
+	case 1:
+		return r.trend.Position()
+	case 2:
+		return r.meanRev.Position()
+	}
+	return 0
+}
+
+// Reject hands the rejection to the strategy whose entry it was.
+func (r *RouterBar) Reject() {
+	switch r.owner {
+	case 1:
+		r.trend.Reject()
+	case 2:
+		r.meanRev.Reject()
+	}
+	r.owner = 0
+}
+
+// OnBar feeds the bar to both strategies and merges their intents.
+func (r *RouterBar) OnBar(c types.Candle, f Features) []types.Intent {
+	routed := 1
+	if r.cfg.isVolatile(f) {
+		routed = 2
+	}
+	var out []types.Intent
+	for id, s := range []BarStrategy{r.trend, r.meanRev} {
+		id++
+		for _, in := range s.OnBar(c, f) {
+			switch {
+			case in.Type == "EXIT" && r.owner == id:
+				out = append(out, in)
+				r.owner = 0
+			case in.Type == "ENTRY" && r.owner == 0 && routed == id:
+				out = append(out, in)
+				r.owner = id
+			case in.Type == "ENTRY":
+				s.Reject()
+			}
+		}
+	}
+	return out
+}
+
+// routerState is the serialized form of a RouterBar.
+type routerState struct {
+	Owner   int             `json:"owner"`
+	Trend   json.RawMessage `json:"trend"`
+	MeanRev json.RawMessage `json:"meanRev"`
+}
+
+// Snapshot serializes which strategy holds the position and both states.
+func (r *RouterBar) Snapshot() ([]byte, error) {
+	trend, err := r.trend.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	meanRev, err := r.meanRev.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(routerState{Owner: r.owner, Trend: trend, MeanRev: meanRev})
+}
+
+// Restore replaces both strategies' state with one written by Snapshot.
+func (r *RouterBar) Restore(state []byte) error {
+	var st routerState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore router state: %w", err)
+	}
+	if err := r.trend.Restore(st.Trend); err != nil {
+		return err
+	}
+	if err := r.meanRev.Restore(st.MeanRev); err != nil {
+		return err
+	}
+	r.owner = st.Owner
+	return nil
+}
+
+// routerColumns is the union of the trend and mean-reversion columns.
+func routerColumns() []string {
+	cols := append([]string(nil), RegimeColumns...)
+	seen := make(map[string]bool)
+	for _, c := range cols {
+		seen[c] = true
+	}
+	for _, c := range MeanRevColumns {
+		if !seen[c] {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
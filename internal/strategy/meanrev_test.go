@@ -0,0 +1,79 @@
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hft/pkg/types"
+)
+
+// stretchedDown is a volatile bar stretched down on the Kalman distance and
+// RSI, with bb_z given.
+func stretchedDown(z float64) Features {
+	return Features{
+		"pred_prob_volatile": 0.6, "pred_prob_bullish": 0.2, "pred_prob_bearish": 0.2,
+		"kalman_fast_dist": -0.002, "rsi": 20, "bb_z": z,
+	}
+}
+
+// feedMeanRev runs one bar a minute from 10:30 with close 100 and the given
+// bb_z values (the first bar is the entry) and returns the reason of the
+// exit and the bar it came on, or "" and -1.
+func feedMeanRev(t *testing.T, zs ...float64) (string, int) {
+	t.Helper()
+	s := NewMeanRevBar(nil)
+	start := time.Date(2025, 6, 2, 10, 30, 0, 0, ist)
+	for i, z := range zs {
+		c := types.Candle{Symbol: "nifty", Timestamp: start.Add(time.Duration(i) * time.Minute), Open: 100, High: 100, Low: 100, Close: 100}
+		for _, in := range s.OnBar(c, stretchedDown(z)) {
+			if in.Type == "ENTRY" && (i != 0 || in.Kind != "BUY") {
+				t.Fatalf("bar %d: unexpected %s entry", i, in.Kind)
+			}
+			if in.Type == "EXIT" {
+				return in.Reason, i
+			}
+		}
+		if i == 0 && s.Position() != 1 {
+			t.Fatal("no long entry on the stretched bar")
+		}
+	}
+	return "", -1
+}
+
+func TestMeanRevProfitTargetArmsOnStretch(t *testing.T) {
+	// Entered on distance and RSI with bb_z already inside ExitZ: the target
+	// waits until bb_z has stretched beyond it and come back.
+	if reason, bar := feedMeanRev(t, -0.1, -0.1, -0.1); reason != "" {
+		t.Fatalf("%s on bar %d before bb_z was ever stretched", reason, bar)
+	}
+	if reason, bar := feedMeanRev(t, -0.1, -0.1, -1, -0.2); reason != "PROFIT_TARGET" || bar != 3 {
+		t.Fatalf("exit %q on bar %d, want PROFIT_TARGET on bar 3", reason, bar)
+	}
+	// Entered with bb_z stretched: armed from the entry bar.
+	if reason, bar := feedMeanRev(t, -2.5, 0); reason != "PROFIT_TARGET" || bar != 1 {
+		t.Fatalf("exit %q on bar %d, want PROFIT_TARGET on bar 1", reason, bar)
+	}
+	// An unarmed trade still ends on the time stop.
+	zs := make([]float64, DefaultMeanRevConfig().TimeStopBars+1)
+	if reason, bar := feedMeanRev(t, zs...); reason != "TIME_STOP" || bar != len(zs)-1 {
+		t.Fatalf("exit %q on bar %d, want TIME_STOP on bar %d", reason, bar, len(zs)-1)
+	}
+}
+
+// Every strategy's Schema describes the struct its Config returns.
+func TestSchemaDescribesConfig(t *testing.T) {
+	names := func(fields []ConfigField) []string {
+		var out []string
+		for _, f := range fields {
+			out = append(out, f.Name+" "+f.Type)
+		}
+		return out
+	}
+	for _, name := range Names() {
+		s, _ := Get(name)
+		if got, want := names(s.Schema()), names(SchemaOf(s.Config())); len(got) == 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: schema %v, config fields %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"hft/pkg/types"
+)
+
+/*
+   Opening-range breakout.
+
+   Each tranche's opening range is the high/low of the closes in its first
+   RangeMinutes (the orbHigh/orbLow of trancheMeta, gathered causally by
+   sessionTracker). Once the range is complete, a close beyond it by the
+   buffer enters in the breakout's direction:
+
+     buffer   BufferMode "points": Buffer points
+              BufferMode "atr":    Buffer × atr3
+     confirm  RegimeConfirm: the regime model must agree (pred_prob_bullish
+              for longs, pred_prob_bearish for shorts, above ConfirmProb)
+     trend    MinADX: adx must be at least MinADX, so breakouts of a
+              ranging market are skipped
+     stop     StopMode "range": the opposite side of the range
+              StopMode "atr":   StopATRMult × atr3 from entry
+              StopMode "fixed": StopPoints from entry
+
+   Positions are squared off like the regime strategy's: at the tranche's
+   CloseMin, on a tranche change and outside all tranches. No entries after
+   the tranche's CutoffMin.
+*/
+
+// ORBConfig controls the opening-range breakout strategy.
+type ORBConfig struct {
+	RangeMinutes int `yaml:"range_minutes" json:"rangeMinutes"` // opening range length from tranche open
+
+	BufferMode string  `yaml:"buffer_mode" json:"bufferMode"` // points | atr
+	Buffer     float64 `yaml:"buffer" json:"buffer"`          // points, or ATR multiple
+
+	RegimeConfirm bool    `yaml:"regime_confirm" json:"regimeConfirm"`
+	ConfirmProb   float64 `yaml:"confirm_prob" json:"confirmProb"` // min probability of the breakout's regime
+
+	MinADX float64 `yaml:"min_adx" json:"minADX"` // no entries while adx < MinADX (0 disables)
+
+	StopMode    string  `yaml:"stop_mode" json:"stopMode"`        // range | atr | fixed
+	StopATRMult float64 `yaml:"stop_atr_mult" json:"stopATRMult"` // StopMode atr
+	StopPoints  float64 `yaml:"stop_points" json:"stopPoints"`    // StopMode fixed
+
+	MaxTradesPerTranche int `yaml:"max_trades_per_tranche" json:"maxTradesPerTranche"`
+
+	Tranches []Tranche `yaml:"tranches" json:"tranches"`
+}
+
+// ORB buffer and stop modes.
+const (
+	ORBBufferPoints = "points"
+	ORBBufferATR    = "atr"
+	ORBStopRange    = "range"
+	ORBStopATR      = "atr"
+	ORBStopFixed    = "fixed"
+)
+
+// DefaultORBConfig returns the default ORB parameters on the regime
+// strategy's tranches.
+func DefaultORBConfig() *ORBConfig {
+	return &ORBConfig{
+		RangeMinutes:        30,
+		BufferMode:          ORBBufferATR,
+		Buffer:              0.5,
+		ConfirmProb:         0.6,
+		StopMode:            ORBStopRange,
+		StopATRMult:         2,
+		StopPoints:          40,
+		MaxTradesPerTranche: 1,
+		Tranches:            DefaultRegimeSignalConfig().Tranches,
+	}
+}
+
+// ActiveORBConfig is the ORB config used by the live executor and
+// backtests, set from config at startup.
+var ActiveORBConfig = DefaultORBConfig()
+
+// ── Per-bar form ─────────────────────────────────────────────────────────────
+
+// ORBBar evaluates the ORB strategy one bar at a time.
+type ORBBar struct {
+	cfg     *ORBConfig
+	session *sessionTracker
+	st      orbState
+}
+
+// orbState is what ORBBar carries between bars, and its serialized form.
+type orbState struct {
+	Position   int     `json:"position"` // +1 long, -1 short, 0 flat
+	StopPrice  float64 `json:"stopPrice"`
+	Tranche    string  `json:"tranche"`
+	LongCount  int     `json:"longCount"`
+	ShortCount int     `json:"shortCount"`
+}
+
+// NewORBBar returns a flat per-bar ORB strategy; nil cfg uses the defaults.
+func NewORBBar(cfg *ORBConfig) *ORBBar {
+	if cfg == nil {
+		cfg = DefaultORBConfig()
+	}
+	return &ORBBar{cfg: cfg, session: newSessionTracker(cfg.Tranches, cfg.RangeMinutes)}
+}
+
+// Position returns +1 when long, -1 when short and 0 when flat.
+func (s *ORBBar) Position() int {
+	return s.st.Position
+}
+
+// Reject tells the strategy its entry was not filled. The tranche count
+// still treats the attempt as a trade.
+func (s *ORBBar) Reject() {
+	s.st.Position = 0
+	s.st.StopPrice = 0
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *ORBBar) OnBar(c types.Candle, feat Features) []types.Intent {
+	cfg := s.cfg
+	f := RegimeFeaturesOf(feat)
+	t := c.Timestamp.In(ist)
+	dayKey := t.Format("2006-01-02")
+	mins := t.Hour()*60 + t.Minute()
+	close := c.Close
+
+	s.session.update(dayKey, mins, close, f.ProbVol)
+
+	var out []types.Intent
+	exit := func(reason string) {
+		kind := "BUY"
+		if s.st.Position == -1 {
+			kind = "SELL"
+		}
+		out = append(out, types.Intent{Kind: kind, Type: "EXIT", Price: close, Timestamp: c.Timestamp, Reason: reason})
+		s.Reject()
+	}
+
+	tr, inTranche := trancheAt(cfg.Tranches, mins)
+
+	// Outside all tranches, or at the tranche close — squareoff.
+	if !inTranche || mins >= tr.CloseMin {
+		if s.st.Position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		return out
+	}
+
+	// Tranche change (or new day) — squareoff carry-over and reset counts.
+	if key := dayKey + "/" + tr.Name; key != s.st.Tranche {
+		if s.st.Position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.st.Tranche = key
+		s.st.LongCount, s.st.ShortCount = 0, 0
+	}
+
+	// ── Stop (when in position) ──────────────────────────────────
+	if s.st.Position != 0 {
+		if (s.st.Position == 1 && close <= s.st.StopPrice) || (s.st.Position == -1 && close >= s.st.StopPrice) {
+			exit("STOP_LOSS")
+		}
+		return out
+	}
+
+	// ── Breakout entry (flat) ────────────────────────────────────
+	if mins >= tr.CutoffMin {
+		return out
+	}
+	tm := s.session.tranche(tr.Name)
+	if tm == nil {
+		return out // range still printing
+	}
+
+	buf := cfg.Buffer
+	if cfg.BufferMode == ORBBufferATR {
+		buf = cfg.Buffer * f.ATR
+	}
+	wantLong := close > tm.orbHigh+buf && s.st.LongCount < cfg.MaxTradesPerTranche
+	wantShort := close < tm.orbLow-buf && s.st.ShortCount < cfg.MaxTradesPerTranche
+	if cfg.RegimeConfirm {
+		wantLong = wantLong && f.ProbBull >= cfg.ConfirmProb
+		wantShort = wantShort && f.ProbBear >= cfg.ConfirmProb
+	}
+	if cfg.MinADX > 0 && !(f.ADX >= cfg.MinADX) { // NaN during warmup blocks too
+		wantLong, wantShort = false, false
+	}
+
+	side := 0
+	if wantLong {
+		side = 1
+		s.st.LongCount++
+	} else if wantShort {
+		side = -1
+		s.st.ShortCount++
+	} else {
+		return out
+	}
+
+	s.st.Position = side
+	s.st.StopPrice = s.stopPrice(side, close, f.ATR, tm)
+	kind := "BUY"
+	if side == -1 {
+		kind = "SELL"
+	}
+	out = append(out, types.Intent{Kind: kind, Type: "ENTRY", Price: close, Timestamp: c.Timestamp,
+		Context: entryContext(tr.Name, s.session.dayType(), s.session.gapPct, side, c.Timestamp, f)})
+	return out
+}
+
+// stopPrice places the stop for an entry on side at close.
+func (s *ORBBar) stopPrice(side int, close, atr float64, tm *trancheMeta) float64 {
+	cfg := s.cfg
+	dist := cfg.StopPoints
+	switch cfg.StopMode {
+	case ORBStopRange:
+		if side == 1 {
+			return tm.orbLow
+		}
+		return tm.orbHigh
+	case ORBStopATR:
+		dist = cfg.StopATRMult * atr
+	}
+	return close - float64(side)*math.Max(dist, 0)
+}
+
+// orbBarState is the serialized form of an ORBBar.
+type orbBarState struct {
+	State   orbState     `json:"state"`
+	Session sessionState `json:"session"`
+}
+
+// Snapshot serializes the position, stop, tranche counts and the opening
+// ranges gathered so far.
+func (s *ORBBar) Snapshot() ([]byte, error) {
+	return json.Marshal(orbBarState{State: s.st, Session: s.session.snapshot()})
+}
+
+// Restore replaces the strategy's state with one written by Snapshot.
+func (s *ORBBar) Restore(state []byte) error {
+	var st orbBarState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore orb state: %w", err)
+	}
+	s.st = st.State
+	s.session.restore(st.Session)
+	return nil
+}
+
+// trancheAt returns the tranche of trs containing mins.
+func trancheAt(trs []Tranche, mins int) (Tranche, bool) {
+	for _, tr := range trs {
+		if mins >= tr.OpenMin && mins <= tr.CloseMin {
+			return tr, true
+		}
+	}
+	return Tranche{}, false
+}
+
+// inEntryWindow reports whether t is at least delay minutes into one of trs
+// and before its cutoff.
+func inEntryWindow(trs []Tranche, t time.Time, delay int) bool {
+	t = t.In(ist)
+	mins := t.Hour()*60 + t.Minute()
+	tr, ok := trancheAt(trs, mins)
+	return ok && mins >= tr.OpenMin+delay && mins < tr.CutoffMin
+}
@@ -0,0 +1,270 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"hft/internal/testutil"
+	"hft/internal/timeframe"
+	"hft/pkg/types"
+)
+
+// On 5m bars ORB must square off on the bar whose close is the tranche
+// close, at the price the 1m strategy would see at that minute, not on the
+// next tranche's first bar.
+func TestORBOnFiveMinuteBars(t *testing.T) {
+	ticks := testutil.Ticks("nifty", testutil.Candles(3, 10))
+	closeAt := make(map[int64]float64, len(ticks))
+	for _, tk := range ticks {
+		closeAt[tk.Timestamp.Unix()] = tk.Close
+	}
+	tf, _ := timeframe.Parse("5m")
+	if err := CheckTimeframe(orb{}, tf); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultORBConfig()
+	s := NewORBBar(cfg)
+	var entries, squareoffs int
+	var entry types.Intent
+	for _, b := range timeframe.Resample(ticks, tf) {
+		c := types.Candle{Symbol: b.Symbol, Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close}
+		for _, in := range s.OnBar(c, Features{"atr3": (b.High - b.Low) * 1.5}) {
+			if want := closeAt[in.Timestamp.Unix()]; in.Price != want {
+				t.Fatalf("%s %s at %s: price %.2f, but the 1m close then is %.2f", in.Type, in.Reason, in.Timestamp.Format("01-02 15:04"), in.Price, want)
+			}
+			if in.Type == "ENTRY" {
+				entries++
+				entry = in
+				continue
+			}
+			tr, _ := trancheAt(cfg.Tranches, minuteOfDay(entry.Timestamp))
+			if mins := minuteOfDay(in.Timestamp); mins > tr.CloseMin+tf.N-1 {
+				t.Errorf("%s entry at %s exited at %s, after its close at %d", tr.Name, entry.Timestamp.Format("01-02 15:04"), in.Timestamp.Format("01-02 15:04"), tr.CloseMin)
+			}
+			if in.Reason == "EOD_SQUAREOFF" {
+				squareoffs++
+			}
+		}
+	}
+	if entries == 0 || squareoffs == 0 {
+		t.Fatalf("%d entries, %d square-offs on synthetic 5m bars; the test needs both", entries, squareoffs)
+	}
+}
+
+func minuteOfDay(t time.Time) int {
+	t = t.In(ist)
+	return t.Hour()*60 + t.Minute()
+}
+
+func TestCheckTimeframe(t *testing.T) {
+	regimeConfirm := ActiveORBConfig.RegimeConfirm
+	defer func() { ActiveORBConfig.RegimeConfirm = regimeConfirm }()
+	ActiveORBConfig.RegimeConfirm = false
+
+	for _, c := range []struct {
+		strategy Strategy
+		tf       string
+		ok       bool
+	}{
+		{regime{}, "1m", true},
+		{regime{}, "5m", false}, // regime model
+		{orb{}, "15m", true},
+		{orb{}, "1d", false}, // intraday
+		{meanReversion{}, "1w", false},
+		{kalmanV2{}, "1d", false}, // intraday
+		{kalmanV2{}, "5m", true},
+		{kalmanV1{}, "1h", true},
+		{kalmanV1{}, "1d", false},
+		{kalmanV1{}, "1w", false},
+	} {
+		tf, err := timeframe.Parse(c.tf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckTimeframe(c.strategy, tf); (err == nil) != c.ok {
+			t.Errorf("%s on %s: err %v, want ok=%v", c.strategy.Name(), c.tf, err, c.ok)
+		}
+	}
+
+	ActiveORBConfig.RegimeConfirm = true
+	if err := CheckTimeframe(orb{}, timeframe.Timeframe{N: 15, Unit: timeframe.Minute}); err == nil {
+		t.Error("orb with regime confirmation accepted on 15m")
+	}
+}
+
+// orbTranches are two short test tranches: a enters 10:00–10:59 and
+// squares off at 11:30, b enters 11:40–12:09 and squares off at 12:20.
+var orbTranches = []Tranche{{"a", 600, 660, 690}, {"b", 700, 730, 740}}
+
+// orbConfig is a 10-minute range with no buffer, stops at the range and
+// one trade per side and tranche, on orbTranches.
+func orbConfig() *ORBConfig {
+	cfg := DefaultORBConfig()
+	cfg.RangeMinutes = 10
+	cfg.BufferMode, cfg.Buffer = ORBBufferPoints, 0
+	cfg.StopMode = ORBStopRange
+	cfg.MaxTradesPerTranche = 1
+	cfg.Tranches = orbTranches
+	return cfg
+}
+
+type orbBar struct {
+	min   int
+	close float64
+	f     Features
+}
+
+func bar(min int, close float64, f ...Features) []orbBar {
+	b := orbBar{min: min, close: close}
+	if len(f) > 0 {
+		b.f = f[0]
+	}
+	return []orbBar{b}
+}
+
+// openingRange prints a range of closes at 100 from open for minutes, with
+// one close at high and one at low.
+func openingRange(open, minutes int, high, low float64) []orbBar {
+	var bars []orbBar
+	for m := open; m <= open+minutes; m++ {
+		c := 100.0
+		switch m {
+		case open + 3:
+			c = high
+		case open + 6:
+			c = low
+		}
+		bars = append(bars, orbBar{min: m, close: c})
+	}
+	return bars
+}
+
+// runORB feeds bars on 2025-06-03 to an ORBBar on cfg and returns its
+// intents as "HH:MM TYPE KIND price reason".
+func runORB(cfg *ORBConfig, bars ...[]orbBar) []string {
+	s := NewORBBar(cfg)
+	day := time.Date(2025, 6, 3, 0, 0, 0, 0, ist)
+	var out []string
+	for _, bs := range bars {
+		for _, b := range bs {
+			c := types.Candle{Timestamp: day.Add(time.Duration(b.min) * time.Minute), Open: b.close, High: b.close, Low: b.close, Close: b.close}
+			for _, in := range s.OnBar(c, b.f) {
+				out = append(out, strings.TrimSpace(fmt.Sprintf("%s %s %s %g %s", hhmm(b.min), in.Type, in.Kind, in.Price, in.Reason)))
+			}
+		}
+	}
+	return out
+}
+
+// Entries wait for the opening range, then need a close beyond it by the
+// buffer and whatever confirmation is configured.
+func TestORBEntries(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  func(c *ORBConfig)
+		bars [][]orbBar
+		want []string
+	}{
+		{"breakout above", nil,
+			[][]orbBar{bar(611, 102), bar(612, 102.5)}, []string{"10:12 ENTRY BUY 102.5"}},
+		{"breakdown below", nil,
+			[][]orbBar{bar(611, 98), bar(612, 97.5)}, []string{"10:12 ENTRY SELL 97.5"}},
+		{"range still printing", func(c *ORBConfig) { c.RangeMinutes = 20 },
+			// 10:12 widens the 20-minute range to 102.5.
+			[][]orbBar{bar(612, 102.5), bar(620, 100), bar(621, 102.5), bar(622, 102.6)}, []string{"10:22 ENTRY BUY 102.6"}},
+		{"points buffer", func(c *ORBConfig) { c.Buffer = 1 },
+			[][]orbBar{bar(611, 102.9), bar(612, 103), bar(613, 103.1)}, []string{"10:13 ENTRY BUY 103.1"}},
+		{"points buffer short", func(c *ORBConfig) { c.Buffer = 1 },
+			[][]orbBar{bar(611, 97), bar(612, 96.9)}, []string{"10:12 ENTRY SELL 96.9"}},
+		{"atr buffer", func(c *ORBConfig) { c.BufferMode, c.Buffer = ORBBufferATR, 0.5 },
+			[][]orbBar{bar(611, 103.9, Features{"atr3": 4}), bar(612, 104.1, Features{"atr3": 4})}, []string{"10:12 ENTRY BUY 104.1"}},
+		{"atr buffer short", func(c *ORBConfig) { c.BufferMode, c.Buffer = ORBBufferATR, 0.5 },
+			[][]orbBar{bar(611, 96.1, Features{"atr3": 4}), bar(612, 95.9, Features{"atr3": 4})}, []string{"10:12 ENTRY SELL 95.9"}},
+		{"regime confirms long", func(c *ORBConfig) { c.RegimeConfirm, c.ConfirmProb = true, 0.6 },
+			[][]orbBar{bar(611, 103, Features{"pred_prob_bullish": 0.59, "pred_prob_bearish": 0.9}), bar(612, 103, Features{"pred_prob_bullish": 0.6})},
+			[]string{"10:12 ENTRY BUY 103"}},
+		{"regime confirms short", func(c *ORBConfig) { c.RegimeConfirm, c.ConfirmProb = true, 0.6 },
+			[][]orbBar{bar(611, 97, Features{"pred_prob_bullish": 0.9, "pred_prob_bearish": 0.5}), bar(612, 97, Features{"pred_prob_bearish": 0.65})},
+			[]string{"10:12 ENTRY SELL 97"}},
+		{"min adx", func(c *ORBConfig) { c.MinADX = 20 },
+			[][]orbBar{bar(611, 103, Features{"adx": math.NaN()}), bar(612, 103, Features{"adx": 19.9}), bar(613, 103, Features{"adx": 20})},
+			[]string{"10:13 ENTRY BUY 103"}},
+		{"after cutoff", nil,
+			[][]orbBar{bar(660, 103), bar(661, 97)}, nil},
+	} {
+		cfg := orbConfig()
+		if tc.cfg != nil {
+			tc.cfg(cfg)
+		}
+		bars := append([][]orbBar{openingRange(600, 10, 102, 98)}, tc.bars...)
+		if got := runORB(cfg, bars...); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Each stop mode exits on the first close at or beyond its stop.
+func TestORBStopModes(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		cfg   func(c *ORBConfig)
+		entry float64
+		bars  [][]orbBar
+		want  string
+	}{
+		{"range long", nil, 103, [][]orbBar{bar(612, 98.1), bar(613, 98)}, "10:13 EXIT BUY 98 STOP_LOSS"},
+		{"range short", nil, 97, [][]orbBar{bar(612, 101.9), bar(613, 102)}, "10:13 EXIT SELL 102 STOP_LOSS"},
+		{"atr", func(c *ORBConfig) { c.StopMode, c.StopATRMult = ORBStopATR, 2 },
+			103, [][]orbBar{bar(612, 99.1), bar(613, 99)}, "10:13 EXIT BUY 99 STOP_LOSS"},
+		{"atr short", func(c *ORBConfig) { c.StopMode, c.StopATRMult = ORBStopATR, 2 },
+			97, [][]orbBar{bar(612, 100.9), bar(613, 101)}, "10:13 EXIT SELL 101 STOP_LOSS"},
+		{"fixed", func(c *ORBConfig) { c.StopMode, c.StopPoints = ORBStopFixed, 3 },
+			103, [][]orbBar{bar(612, 100.1), bar(613, 100)}, "10:13 EXIT BUY 100 STOP_LOSS"},
+	} {
+		cfg := orbConfig()
+		if tc.cfg != nil {
+			tc.cfg(cfg)
+		}
+		bars := append([][]orbBar{openingRange(600, 10, 102, 98), bar(611, tc.entry, Features{"atr3": 2})}, tc.bars...)
+		got := runORB(cfg, bars...)
+		if len(got) != 2 || got[1] != tc.want {
+			t.Errorf("%s: %q, want entry then %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Positions are squared off at the tranche close, on a tranche change and
+// outside all tranches; trade counts are per side and tranche.
+func TestORBTranches(t *testing.T) {
+	rangeA := openingRange(600, 10, 102, 98)
+	for _, tc := range []struct {
+		name string
+		max  int
+		bars [][]orbBar
+		want []string
+	}{
+		{"tranche close", 1, [][]orbBar{bar(611, 103), bar(689, 104), bar(690, 104.5)},
+			[]string{"10:11 ENTRY BUY 103", "11:30 EXIT BUY 104.5 EOD_SQUAREOFF"}},
+		{"tranche change", 1, [][]orbBar{bar(611, 97), bar(700, 96)},
+			[]string{"10:11 ENTRY SELL 97", "11:40 EXIT SELL 96 EOD_SQUAREOFF"}},
+		{"outside tranches", 1, [][]orbBar{bar(611, 103), bar(695, 105)},
+			[]string{"10:11 ENTRY BUY 103", "11:35 EXIT BUY 105 EOD_SQUAREOFF"}},
+		{"one trade per tranche", 1, [][]orbBar{bar(611, 103), bar(612, 98), bar(613, 103), bar(614, 97)},
+			[]string{"10:11 ENTRY BUY 103", "10:12 EXIT BUY 98 STOP_LOSS", "10:14 ENTRY SELL 97"}},
+		{"two trades per tranche", 2, [][]orbBar{bar(611, 103), bar(612, 98), bar(613, 103)},
+			[]string{"10:11 ENTRY BUY 103", "10:12 EXIT BUY 98 STOP_LOSS", "10:13 ENTRY BUY 103"}},
+		{"count resets by tranche", 1, [][]orbBar{bar(611, 103), bar(612, 98), openingRange(700, 10, 102, 98), bar(711, 103)},
+			[]string{"10:11 ENTRY BUY 103", "10:12 EXIT BUY 98 STOP_LOSS", "11:51 ENTRY BUY 103"}},
+	} {
+		cfg := orbConfig()
+		cfg.MaxTradesPerTranche = tc.max
+		if got := runORB(cfg, append([][]orbBar{rangeA}, tc.bars...)...); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
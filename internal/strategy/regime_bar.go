@@ -0,0 +1,526 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"hft/internal/risk"
+	"hft/pkg/types"
+)
+
+/*
+   Per-bar form of the regime strategy, and the only place its decisions
+   are made: FindRegimeSignalWithConfig loops it over a frame, and the live
+   executor and event-driven backtest feed it bar by bar. It only sees the
+   bars it has been given so far:
+
+     - the gap is known at the first in-session bar of the day
+     - a tranche's early direction, ORB and average volatile probability are
+       known once its first 30 minutes have printed; until then the
+       EarlyDirConfirm and MaxVolProb filters block entries
+     - the MinADX trend-strength filter reads the bar's adx, which is NaN
+       (and blocks entries) until 27 bars of history exist
+     - stops are handled by risk.SLTP with the long/short risk params
+*/
+
+// RegimeFeatures are the per-bar model and indicator inputs of the regime
+// strategy (the RegimeColumns of a bar).
+type RegimeFeatures struct {
+	ATR      float64 // atr3
+	ADX      float64 // adx, Wilder's 14-bar trend strength
+	ProbBull float64
+	ProbBear float64
+	ProbVol  float64
+}
+
+// RegimeFeaturesOf reads the RegimeColumns from f.
+func RegimeFeaturesOf(f Features) RegimeFeatures {
+	return RegimeFeatures{
+		ATR:      f["atr3"],
+		ADX:      f["adx"],
+		ProbBull: f["pred_prob_bullish"],
+		ProbBear: f["pred_prob_bearish"],
+		ProbVol:  f["pred_prob_volatile"],
+	}
+}
+
+// RegimeBar evaluates the regime strategy one bar at a time.
+type RegimeBar struct {
+	cfg     *RegimeSignalConfig
+	live    bool // follow ActiveRegimeConfig
+	session *sessionTracker
+	stop    risk.SLTP
+
+	position          int // +1 long, -1 short, 0 flat
+	currentDayKey     string
+	lastTrancheName   string
+	longTrancheCount  int
+	shortTrancheCount int
+	longCooldown      int
+	shortCooldown     int
+
+	tracing bool
+	trace   *Trace // latest bar's decision, when tracing
+}
+
+// NewRegimeBar returns a flat per-bar regime strategy.
+func NewRegimeBar(cfg *RegimeSignalConfig) *RegimeBar {
+	if cfg == nil {
+		cfg = DefaultRegimeSignalConfig()
+	}
+	return &RegimeBar{
+		cfg:     cfg,
+		session: newSessionTracker(cfg.Tranches, 30),
+	}
+}
+
+// NewLiveRegimeBar returns a flat per-bar regime strategy that follows
+// ActiveRegimeConfig: a config swapped in by a live edit is used from the
+// next bar on. An open position keeps the stop it was entered with.
+func NewLiveRegimeBar() *RegimeBar {
+	s := NewRegimeBar(ActiveRegimeConfig())
+	s.live = true
+	return s
+}
+
+// Position returns +1 when long, -1 when short and 0 when flat.
+func (s *RegimeBar) Position() int {
+	return s.position
+}
+
+// SetTracing turns per-bar decision traces on or off.
+func (s *RegimeBar) SetTracing(on bool) {
+	s.tracing = on
+	s.trace = nil
+}
+
+// LastTrace returns the decision trace of the latest bar.
+func (s *RegimeBar) LastTrace() *Trace {
+	return s.trace
+}
+
+// OnBar consumes one closed bar and returns the intents it triggers.
+func (s *RegimeBar) OnBar(c types.Candle, feat Features) []types.Intent {
+	var tr *Trace
+	if s.tracing {
+		tr = &Trace{Timestamp: c.Timestamp, Inputs: make(map[string]float64)}
+	}
+	s.trace = tr
+	out := s.onBar(c, feat, tr)
+	tr.finish(s.position, out)
+	return out
+}
+
+func (s *RegimeBar) onBar(c types.Candle, feat Features, trace *Trace) []types.Intent {
+	if active := ActiveRegimeConfig(); s.live && active != nil && active != s.cfg {
+		s.cfg = active
+		s.session.setTranches(active.Tranches)
+	}
+	cfg := s.cfg
+	f := RegimeFeaturesOf(feat)
+	t := c.Timestamp.In(ist)
+	dayKey := t.Format("2006-01-02")
+	mins := t.Hour()*60 + t.Minute()
+	close := c.Close
+
+	s.session.update(dayKey, mins, close, f.ProbVol)
+	if trace != nil {
+		for name, v := range map[string]float64{
+			"close": close, "atr3": f.ATR, "prob_bull": f.ProbBull, "prob_bear": f.ProbBear, "prob_vol": f.ProbVol,
+			"gap_pct": s.session.gapPct, "position": float64(s.position),
+		} {
+			trace.input(name, v)
+		}
+	}
+
+	var out []types.Intent
+	exit := func(reason string) {
+		kind := "BUY"
+		if s.position == -1 {
+			kind = "SELL"
+			s.shortCooldown = cfg.CooldownBars
+		} else {
+			s.longCooldown = cfg.CooldownBars
+		}
+		out = append(out, types.Intent{Kind: kind, Type: "EXIT", Price: close, Timestamp: c.Timestamp, Reason: reason})
+		s.position = 0
+		s.stop.Close()
+	}
+
+	// Day boundary reset.
+	if dayKey != s.currentDayKey {
+		s.currentDayKey = dayKey
+		s.lastTrancheName = ""
+		s.longTrancheCount = 0
+		s.shortTrancheCount = 0
+	}
+
+	tr, inTranche := cfg.TrancheAt(mins)
+
+	// Outside all tranches, or at the tranche close — squareoff.
+	if !trace.rule("session", "", inTranche && mins < tr.CloseMin, "tranche %q, %s", tr.Name, hhmm(mins)) {
+		if s.position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.longCooldown = 0
+		s.shortCooldown = 0
+		return out
+	}
+
+	// Tranche change — squareoff carry-over and reset counts.
+	if tr.Name != s.lastTrancheName {
+		if s.position != 0 {
+			exit("EOD_SQUAREOFF")
+		}
+		s.lastTrancheName = tr.Name
+		s.longTrancheCount = 0
+		s.shortTrancheCount = 0
+		s.longCooldown = 0
+		s.shortCooldown = 0
+	}
+
+	if s.longCooldown > 0 {
+		s.longCooldown--
+	}
+	if s.shortCooldown > 0 {
+		s.shortCooldown--
+	}
+
+	trace.input("long_cooldown", float64(s.longCooldown))
+	trace.input("short_cooldown", float64(s.shortCooldown))
+	trace.input("long_count", float64(s.longTrancheCount))
+	trace.input("short_count", float64(s.shortTrancheCount))
+
+	// ── Risk management (when in position) ───────────────────────
+	if s.position != 0 {
+		reason := s.stop.Check(close)
+		if !trace.rule("stop", "", reason == "", "%s, SL %.3f%%", reason, s.stop.SLPct) {
+			exit(reason)
+		}
+		return out
+	}
+
+	// ── Entry logic (flat) ───────────────────────────────────────
+	if !trace.rule("cutoff", "", mins < tr.CutoffMin, "%s < %s", hhmm(mins), hhmm(tr.CutoffMin)) {
+		return out
+	}
+
+	tm := s.session.tranche(tr.Name)
+	if tm == nil && (cfg.MaxVolProb > 0 || cfg.EarlyDirConfirm) {
+		trace.rule("early_window", "", false, "tranche %q opening window still printing", tr.Name)
+		return out
+	}
+	if tm != nil {
+		trace.input("avg_vol_prob", tm.avgVolProb)
+		trace.input("early_dir", float64(tm.earlyDir))
+	}
+	if cfg.MaxVolProb > 0 && !trace.rule("max_vol_prob", "", tm.avgVolProb <= cfg.MaxVolProb, "avg %.3f <= %.3f", tm.avgVolProb, cfg.MaxVolProb) {
+		return out
+	}
+	if cfg.MinADX > 0 {
+		trace.input("adx", f.ADX)
+		if !trace.rule("min_adx", "", f.ADX >= cfg.MinADX, "adx %.1f >= %.1f", f.ADX, cfg.MinADX) {
+			return out
+		}
+	}
+
+	wantLong := trace.rule("threshold", "long", f.ProbBull > cfg.BullProbThresh, "bull %.3f > %.3f", f.ProbBull, cfg.BullProbThresh)
+	wantLong = trace.rule("cooldown", "long", s.longCooldown == 0, "%d bars left", s.longCooldown) && wantLong
+	wantLong = trace.rule("max_trades", "long", s.longTrancheCount < cfg.MaxTradesPerDay, "%d < %d", s.longTrancheCount, cfg.MaxTradesPerDay) && wantLong
+	wantShort := trace.rule("threshold", "short", f.ProbBear > cfg.BearProbThresh, "bear %.3f > %.3f", f.ProbBear, cfg.BearProbThresh)
+	wantShort = trace.rule("cooldown", "short", s.shortCooldown == 0, "%d bars left", s.shortCooldown) && wantShort
+	wantShort = trace.rule("max_trades", "short", s.shortTrancheCount < cfg.MaxTradesPerDay, "%d < %d", s.shortTrancheCount, cfg.MaxTradesPerDay) && wantShort
+
+	if gap := s.session.gapPct; cfg.GapFollow {
+		wantLong = trace.rule("gap_follow", "long", gap >= -0.15, "gap %.2f%% >= -0.15%%", gap) && wantLong
+		wantShort = trace.rule("gap_follow", "short", gap <= 0.15, "gap %.2f%% <= 0.15%%", gap) && wantShort
+	}
+
+	if cfg.EarlyDirConfirm {
+		wantLong = trace.rule("early_dir_confirm", "long", tm.earlyDir > 0, "early direction %+d", tm.earlyDir) && wantLong
+		wantShort = trace.rule("early_dir_confirm", "short", tm.earlyDir < 0, "early direction %+d", tm.earlyDir) && wantShort
+	}
+
+	// The day type is the session so far; the rest of the day has not printed.
+	if wantLong {
+		s.enter(1, close, f.ATR)
+		s.longTrancheCount++
+		out = append(out, types.Intent{Kind: "BUY", Type: "ENTRY", Price: close, Timestamp: c.Timestamp,
+			Context: entryContext(tr.Name, s.session.dayType(), s.session.gapPct, 1, c.Timestamp, f)})
+	} else if wantShort {
+		s.enter(-1, close, f.ATR)
+		s.shortTrancheCount++
+		out = append(out, types.Intent{Kind: "SELL", Type: "ENTRY", Price: close, Timestamp: c.Timestamp,
+			Context: entryContext(tr.Name, s.session.dayType(), s.session.gapPct, -1, c.Timestamp, f)})
+	}
+	return out
+}
+
+// enter arms the stop with the side's risk params; SL is max(ATR-based, fixed).
+func (s *RegimeBar) enter(side int, close, atr float64) {
+	cfg := s.cfg
+	slPct, atrMult := cfg.LongSLPct, cfg.LongATRMult
+	s.stop.BEPct, s.stop.TrailAct, s.stop.TrailOff = cfg.LongBEPct, cfg.LongTrailAct, cfg.LongTrailOff
+	if side == -1 {
+		slPct, atrMult = cfg.ShortSLPct, cfg.ShortATRMult
+		s.stop.BEPct, s.stop.TrailAct, s.stop.TrailOff = cfg.ShortBEPct, cfg.ShortTrailAct, cfg.ShortTrailOff
+	}
+	s.stop.SLPct = slPct
+	if atrMult > 0 && atr > 0 {
+		s.stop.SLPct = math.Max(atr*atrMult/close*100, slPct)
+	}
+	s.stop.Open(side, close)
+	s.position = side
+}
+
+// Reject tells the strategy its entry was not filled, so it is flat again.
+// The tranche count and cooldowns still treat the attempt as a trade.
+func (s *RegimeBar) Reject() {
+	s.position = 0
+	s.stop.Close()
+}
+
+// StopPct returns the stop distance (% of entry) armed for the open position.
+func (s *RegimeBar) StopPct() float64 {
+	return s.stop.SLPct
+}
+
+// regimeBarState is the serialized form of a RegimeBar.
+type regimeBarState struct {
+	Position      int          `json:"position"`
+	DayKey        string       `json:"dayKey"`
+	Tranche       string       `json:"tranche"`
+	LongCount     int          `json:"longCount"`
+	ShortCount    int          `json:"shortCount"`
+	LongCooldown  int          `json:"longCooldown"`
+	ShortCooldown int          `json:"shortCooldown"`
+	Stop          risk.SLTP    `json:"stop"`
+	Session       sessionState `json:"session"`
+}
+
+// Snapshot serializes the strategy's state: position, stop, tranche counts,
+// cooldowns and the day metadata gathered so far.
+func (s *RegimeBar) Snapshot() ([]byte, error) {
+	return json.Marshal(regimeBarState{
+		Position:      s.position,
+		DayKey:        s.currentDayKey,
+		Tranche:       s.lastTrancheName,
+		LongCount:     s.longTrancheCount,
+		ShortCount:    s.shortTrancheCount,
+		LongCooldown:  s.longCooldown,
+		ShortCooldown: s.shortCooldown,
+		Stop:          s.stop,
+		Session:       s.session.snapshot(),
+	})
+}
+
+// Restore replaces the strategy's state with one written by Snapshot. The
+// config is kept.
+func (s *RegimeBar) Restore(state []byte) error {
+	var st regimeBarState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("restore regime state: %w", err)
+	}
+	s.position = st.Position
+	s.currentDayKey = st.DayKey
+	s.lastTrancheName = st.Tranche
+	s.longTrancheCount, s.shortTrancheCount = st.LongCount, st.ShortCount
+	s.longCooldown, s.shortCooldown = st.LongCooldown, st.ShortCooldown
+	s.stop = st.Stop
+	s.session.restore(st.Session)
+	return nil
+}
+
+// TrancheAt returns the tranche containing mins (minutes from midnight IST).
+func (cfg *RegimeSignalConfig) TrancheAt(mins int) (Tranche, bool) {
+	return trancheAt(cfg.Tranches, mins)
+}
+
+var ist = time.FixedZone("IST", 19800)
+
+// hhmm formats minutes from midnight as "15:04".
+func hhmm(mins int) string {
+	return fmt.Sprintf("%02d:%02d", mins/60, mins%60)
+}
+
+// ─── Causal day metadata ─────────────────────────────────────────────────────
+
+// sessionTracker builds day and tranche metadata incrementally from the
+// bars seen so far.
+type sessionTracker struct {
+	trs          []Tranche
+	window       int // minutes of each tranche's opening window
+	sessionOpen  int
+	sessionClose int
+
+	dayKey       string
+	prevDayClose float64
+	prevDayValid bool
+
+	ohlcInit bool
+	dOpen    float64
+	dHigh    float64
+	dLow     float64
+	dClose   float64
+	gapPct   float64
+	tranches map[string]*trancheWindow
+}
+
+// trancheWindow accumulates the opening window of a tranche.
+type trancheWindow struct {
+	FirstMin int     `json:"firstMin"`
+	First    float64 `json:"first"`
+	Last     float64 `json:"last"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	VolSum   float64 `json:"volSum"`
+	Count    int     `json:"count"`
+	Ready    bool    `json:"ready"`
+}
+
+// newSessionTracker tracks trs with an opening window of window minutes.
+func newSessionTracker(trs []Tranche, window int) *sessionTracker {
+	st := &sessionTracker{window: window}
+	st.setTranches(trs)
+	return st
+}
+
+// setTranches switches the tranche layout; the day gathered so far is kept.
+func (st *sessionTracker) setTranches(trs []Tranche) {
+	st.trs = trs
+	st.sessionOpen, st.sessionClose = 9*60+50, 15*60+20
+	if len(trs) > 0 {
+		st.sessionOpen = trs[0].OpenMin
+		st.sessionClose = trs[len(trs)-1].CloseMin
+	}
+}
+
+// update folds one bar into the current day's metadata.
+func (st *sessionTracker) update(dayKey string, mins int, close, probVol float64) {
+	if dayKey != st.dayKey {
+		st.rollDay(dayKey)
+	}
+
+	if mins >= st.sessionOpen && mins <= st.sessionClose {
+		if !st.ohlcInit {
+			st.ohlcInit = true
+			st.dOpen, st.dHigh, st.dLow = close, close, close
+			if st.prevDayValid {
+				st.gapPct = (close - st.prevDayClose) / st.prevDayClose * 100
+			}
+		}
+		st.dHigh = math.Max(st.dHigh, close)
+		st.dLow = math.Min(st.dLow, close)
+		st.dClose = close
+	}
+
+	for _, tr := range st.trs {
+		if mins < tr.OpenMin || mins > tr.CloseMin {
+			continue
+		}
+		w := st.tranches[tr.Name]
+		if w == nil {
+			w = &trancheWindow{FirstMin: mins, First: close, High: close, Low: close}
+			st.tranches[tr.Name] = w
+		}
+		if mins <= w.FirstMin+st.window {
+			w.Last = close
+			w.High = math.Max(w.High, close)
+			w.Low = math.Min(w.Low, close)
+			w.VolSum += probVol
+			w.Count++
+		}
+		w.Ready = mins >= w.FirstMin+st.window
+	}
+}
+
+// rollDay closes out the previous day and starts a new one.
+func (st *sessionTracker) rollDay(dayKey string) {
+	if st.ohlcInit {
+		st.prevDayClose = st.dClose
+		st.prevDayValid = true
+	}
+	st.dayKey = dayKey
+	st.ohlcInit = false
+	st.gapPct = 0
+	st.tranches = make(map[string]*trancheWindow)
+}
+
+// dayType labels the day "trend", "chop" or "gap_reversal" from its
+// in-session closes so far: a gap of more than 0.15% that the session has
+// since moved against is a gap reversal, a body of more than 0.45 of the
+// range a trend. The first day, with no previous close, is chop.
+func (st *sessionTracker) dayType() string {
+	dRange := st.dHigh - st.dLow
+	if !st.ohlcInit || !st.prevDayValid || dRange <= 0 {
+		return "chop"
+	}
+	switch {
+	case st.gapPct > 0.15 && st.dClose < st.dOpen, st.gapPct < -0.15 && st.dClose > st.dOpen:
+		return "gap_reversal"
+	case math.Abs(st.dClose-st.dOpen)/dRange > 0.45:
+		return "trend"
+	}
+	return "chop"
+}
+
+// sessionState is the serialized form of a sessionTracker.
+type sessionState struct {
+	DayKey       string                    `json:"dayKey"`
+	PrevDayClose float64                   `json:"prevDayClose"`
+	PrevDayValid bool                      `json:"prevDayValid"`
+	OHLCInit     bool                      `json:"ohlcInit"`
+	DayOpen      float64                   `json:"dayOpen"`
+	DayHigh      float64                   `json:"dayHigh"`
+	DayLow       float64                   `json:"dayLow"`
+	DayClose     float64                   `json:"dayClose"`
+	GapPct       float64                   `json:"gapPct"`
+	Tranches     map[string]*trancheWindow `json:"tranches"`
+}
+
+func (st *sessionTracker) snapshot() sessionState {
+	return sessionState{
+		DayKey:       st.dayKey,
+		PrevDayClose: st.prevDayClose,
+		PrevDayValid: st.prevDayValid,
+		OHLCInit:     st.ohlcInit,
+		DayOpen:      st.dOpen,
+		DayHigh:      st.dHigh,
+		DayLow:       st.dLow,
+		DayClose:     st.dClose,
+		GapPct:       st.gapPct,
+		Tranches:     st.tranches,
+	}
+}
+
+func (st *sessionTracker) restore(s sessionState) {
+	st.dayKey = s.DayKey
+	st.prevDayClose, st.prevDayValid = s.PrevDayClose, s.PrevDayValid
+	st.ohlcInit, st.dClose, st.gapPct = s.OHLCInit, s.DayClose, s.GapPct
+	st.dOpen, st.dHigh, st.dLow = s.DayOpen, s.DayHigh, s.DayLow
+	st.tranches = s.Tranches
+	if st.tranches == nil {
+		st.tranches = make(map[string]*trancheWindow)
+	}
+}
+
+// tranche returns the early-window metadata, or nil while it is still printing.
+func (st *sessionTracker) tranche(name string) *trancheMeta {
+	w := st.tranches[name]
+	if w == nil || !w.Ready || w.Count == 0 {
+		return nil
+	}
+	tm := &trancheMeta{
+		avgVolProb: w.VolSum / float64(w.Count),
+		orbHigh:    w.High,
+		orbLow:     w.Low,
+	}
+	if move := w.Last - w.First; move > 0 {
+		tm.earlyDir = 1
+	} else if move < 0 {
+		tm.earlyDir = -1
+	}
+	return tm
+}
@@ -0,0 +1,51 @@
+package strategy
+
+import "testing"
+
+// The day type is the session so far, so every path labels an entry with
+// what was known when it was taken.
+func TestSessionTrackerDayType(t *testing.T) {
+	open := DefaultRegimeSignalConfig().Tranches[0].OpenMin
+	for _, tc := range []struct {
+		name   string
+		prev   float64 // previous day's last in-session close, 0 for none
+		closes []float64
+		want   string
+	}{
+		{"first day", 0, []float64{100, 101, 102, 103}, "chop"},
+		{"trend", 100, []float64{100, 101, 102, 103}, "trend"},
+		{"round trip", 100, []float64{100, 103, 100.5}, "chop"},
+		{"gap up faded", 100, []float64{101, 101.2, 100.4}, "gap_reversal"},
+		{"gap down bought", 100, []float64{99, 98.8, 99.6}, "gap_reversal"},
+		{"gap up held", 100, []float64{101, 101.5, 102}, "trend"},
+		{"flat", 100, []float64{100, 100, 100}, "chop"},
+	} {
+		st := newSessionTracker(DefaultRegimeSignalConfig().Tranches, 30)
+		if tc.prev > 0 {
+			st.update("2025-06-02", open, tc.prev, 0)
+		}
+		for i, c := range tc.closes {
+			st.update("2025-06-03", open+i, c, 0)
+		}
+		if got := st.dayType(); got != tc.want {
+			t.Errorf("%s: %s, want %s", tc.name, got, tc.want)
+		}
+
+		restored := newSessionTracker(DefaultRegimeSignalConfig().Tranches, 30)
+		restored.restore(st.snapshot())
+		if got := restored.dayType(); got != tc.want {
+			t.Errorf("%s: %s after restore, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// Bars outside the session do not move the label.
+	st := newSessionTracker(DefaultRegimeSignalConfig().Tranches, 30)
+	st.update("2025-06-02", open, 100, 0)
+	for i, c := range []float64{100, 101, 102} {
+		st.update("2025-06-03", open+i, c, 0)
+	}
+	st.update("2025-06-03", open-5, 90, 0)
+	if got := st.dayType(); got != "trend" {
+		t.Fatalf("pre-session bar changed the label to %s", got)
+	}
+}
@@ -0,0 +1,238 @@
+package strategy
+
+import (
+	"time"
+
+	"hft/internal/indicators"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// ── Registered strategies ────────────────────────────────────────────────────
+
+func init() {
+	Register(kalmanV1{})
+	Register(kalmanV2{})
+	Register(regime{})
+	Register(orb{})
+	Register(meanReversion{})
+	Register(regimeRouter{})
+	Register(ensemble{})
+}
+
+// KalmanV1WarmupBars is the longest look-back among the RunKalman
+// indicators: the 128-bar Kalman window on top of EMA(21).
+const KalmanV1WarmupBars = 128 + 21
+
+// RegimeColumns are the columns the regime strategy reads per bar.
+var RegimeColumns = []string{"atr3", "adx", "pred_prob_bullish", "pred_prob_bearish", "pred_prob_volatile"}
+
+var kalmanColumns = []string{"fast_tempx_kalman", "slow_tempx_kalman", "swap", "swap_base"}
+
+// kalmanV1 is the original Kalman swap-flip strategy (RunKalman +
+// FindKalmanSignal).
+type kalmanV1 struct{}
+
+func (kalmanV1) Name() string { return "kalman_v1" }
+func (kalmanV1) Description() string {
+	return "Kalman-filtered tempx swap flips with MFE-capture exits (original parameters)"
+}
+func (kalmanV1) Config() interface{}   { return DefaultKalmanExitConfig() }
+func (kalmanV1) Schema() []ConfigField { return SchemaOf(DefaultKalmanExitConfig()) }
+func (kalmanV1) Columns() []string     { return kalmanColumns }
+func (kalmanV1) NeedsRegime() bool     { return false }
+func (kalmanV1) Intraday() bool        { return true }
+func (kalmanV1) WarmupBars() int       { return KalmanV1WarmupBars }
+func (kalmanV1) NewBar() BarStrategy   { return NewKalmanBar(nil) }
+func (kalmanV1) CanEnter(t time.Time) bool {
+	return indicators.IsActiveSession(&t)
+}
+func (kalmanV1) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunKalman(df, logEvents)
+}
+func (kalmanV1) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	FindKalmanSignal(df, currentPos, positions, events)
+}
+
+// kalmanV2 is the Kalman swap-flip strategy with intersection/parallel
+// filters (RunKalmanv2 + FindKalmanSignalv2).
+type kalmanV2 struct{ set *Settings }
+
+func (kalmanV2) withSettings(set *Settings) Strategy { return kalmanV2{set} }
+
+func (kalmanV2) Name() string { return "kalman_v2" }
+func (kalmanV2) Description() string {
+	return "Kalman swap flips gated by fast/slow intersection, with live-editable MFE exits"
+}
+func (k kalmanV2) Config() interface{} { return k.set.kalmanExit() }
+func (kalmanV2) Schema() []ConfigField { return SchemaOf(DefaultKalmanExitConfigv2()) }
+func (kalmanV2) Columns() []string     { return kalmanColumns }
+func (kalmanV2) NeedsRegime() bool     { return false }
+func (kalmanV2) Intraday() bool        { return true }
+func (kalmanV2) WarmupBars() int       { return KalmanV2WarmupBars }
+func (kalmanV2) UsesPipeline() bool    { return true }
+func (k kalmanV2) NewBar() BarStrategy { return k.set.kalmanV2Bar() }
+func (kalmanV2) CanEnter(t time.Time) bool {
+	return indicators.IsActiveSession(&t)
+}
+func (kalmanV2) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunKalmanv2(df, logEvents)
+}
+func (k kalmanV2) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	FindKalmanSignalWithExitConfigv2(df, currentPos, positions, events, k.set.kalmanExit())
+}
+
+// regime trades the regime model's probabilities inside the session
+// tranches (RunKalmanv2 + predictions + FindRegimeSignal / RegimeBar).
+type regime struct{ set *Settings }
+
+func (regime) withSettings(set *Settings) Strategy { return regime{set} }
+
+func (regime) Name() string { return "regime" }
+func (regime) Description() string {
+	return "Regime-model probabilities traded inside session tranches with ATR/trailing stops"
+}
+func (r regime) Config() interface{} { return r.set.regime() }
+func (regime) Schema() []ConfigField { return SchemaOf(DefaultRegimeSignalConfig()) }
+func (regime) Columns() []string     { return RegimeColumns }
+func (regime) NeedsRegime() bool     { return true }
+func (regime) Intraday() bool        { return true }
+func (regime) WarmupBars() int       { return KalmanV2WarmupBars }
+func (regime) UsesPipeline() bool    { return true }
+func (r regime) NewBar() BarStrategy { return r.set.regimeBar() }
+func (r regime) CanEnter(t time.Time) bool {
+	return inEntryWindow(r.set.regime().Tranches, t, 0)
+}
+func (regime) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunKalmanv2(df, logEvents)
+}
+func (r regime) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	FindRegimeSignalWithConfig(df, currentPos, positions, events, r.set.regime())
+}
+
+// orb trades breakouts of each tranche's opening range (ORBBar).
+type orb struct{ set *Settings }
+
+func (orb) withSettings(set *Settings) Strategy { return orb{set} }
+
+func (orb) Name() string { return "orb" }
+func (orb) Description() string {
+	return "Opening-range breakout per tranche with point/ATR buffer, optional regime confirmation and range/ATR/fixed stops"
+}
+func (o orb) Config() interface{} { return o.set.orb() }
+func (orb) Schema() []ConfigField { return SchemaOf(DefaultORBConfig()) }
+func (orb) Columns() []string     { return RegimeColumns }
+func (o orb) NeedsRegime() bool   { return o.set.orb().RegimeConfirm }
+func (orb) Intraday() bool        { return true }
+func (orb) WarmupBars() int       { return KalmanV2WarmupBars }
+func (orb) UsesPipeline() bool    { return true }
+func (o orb) NewBar() BarStrategy { return NewORBBar(o.set.orb()) }
+func (o orb) CanEnter(t time.Time) bool {
+	cfg := o.set.orb()
+	return inEntryWindow(cfg.Tranches, t, cfg.RangeMinutes)
+}
+func (orb) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunKalmanv2(df, logEvents)
+}
+func (o orb) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	RunBars(df, o.NewBar(), RegimeColumns, currentPos, events)
+}
+
+// meanReversion fades stretched moves while the model says volatile
+// (MeanRevBar).
+type meanReversion struct{ set *Settings }
+
+func (meanReversion) withSettings(set *Settings) Strategy { return meanReversion{set} }
+
+func (meanReversion) Name() string { return "mean_reversion" }
+func (meanReversion) Description() string {
+	return "Volatile-regime mean reversion on Kalman distance, RSI and Bollinger z with tight time stops"
+}
+func (m meanReversion) Config() interface{} { return m.set.meanRev() }
+func (meanReversion) Schema() []ConfigField { return SchemaOf(DefaultMeanRevConfig()) }
+func (meanReversion) Columns() []string     { return MeanRevColumns }
+func (meanReversion) NeedsRegime() bool     { return true }
+func (meanReversion) Intraday() bool        { return true }
+func (meanReversion) WarmupBars() int       { return KalmanV2WarmupBars }
+func (meanReversion) UsesPipeline() bool    { return true }
+func (m meanReversion) NewBar() BarStrategy { return NewMeanRevBar(m.set.meanRev()) }
+func (m meanReversion) CanEnter(t time.Time) bool {
+	return inEntryWindow(m.set.meanRev().Tranches, t, 0)
+}
+func (m meanReversion) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunMeanRevIndicators(df, logEvents, m.set.meanRev())
+}
+func (m meanReversion) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	RunBars(df, m.NewBar(), MeanRevColumns, currentPos, events)
+}
+
+// regimeRouter runs the regime (trend) and mean-reversion strategies on one
+// position, routing entries by the model's regime (RouterBar).
+type regimeRouter struct{ set *Settings }
+
+func (regimeRouter) withSettings(set *Settings) Strategy { return regimeRouter{set} }
+
+// routerConfig is the regime router's config: the sections of the two
+// strategies it routes between.
+type routerConfig struct {
+	Regime  *RegimeSignalConfig `yaml:"regime" json:"regime"`
+	MeanRev *MeanRevConfig      `yaml:"mean_reversion" json:"meanReversion"`
+}
+
+func (regimeRouter) Name() string { return "regime_router" }
+func (regimeRouter) Description() string {
+	return "Regime strategy on trending bars, mean reversion on volatile bars, sharing one position"
+}
+func (r regimeRouter) Config() interface{} {
+	return &routerConfig{Regime: r.set.regime(), MeanRev: r.set.meanRev()}
+}
+func (regimeRouter) Schema() []ConfigField {
+	return SchemaOf(&routerConfig{Regime: DefaultRegimeSignalConfig(), MeanRev: DefaultMeanRevConfig()})
+}
+func (regimeRouter) Columns() []string  { return routerColumns() }
+func (regimeRouter) NeedsRegime() bool  { return true }
+func (regimeRouter) Intraday() bool     { return true }
+func (regimeRouter) WarmupBars() int    { return KalmanV2WarmupBars }
+func (regimeRouter) UsesPipeline() bool { return true }
+func (r regimeRouter) CanEnter(t time.Time) bool {
+	return inEntryWindow(r.set.regime().Tranches, t, 0) || inEntryWindow(r.set.meanRev().Tranches, t, 0)
+}
+func (r regimeRouter) NewBar() BarStrategy {
+	mr := r.set.meanRev()
+	return NewRouterBar(r.set.regimeBar(), NewMeanRevBar(mr), mr)
+}
+func (r regimeRouter) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunMeanRevIndicators(df, logEvents, r.set.meanRev())
+}
+func (r regimeRouter) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	RunBars(df, r.NewBar(), routerColumns(), currentPos, events)
+}
+
+// ensemble trades the combined vote of the Kalman swap, regime and MTF
+// signals (EnsembleBar).
+type ensemble struct{ set *Settings }
+
+func (ensemble) withSettings(set *Settings) Strategy { return ensemble{set} }
+
+func (ensemble) Name() string { return "ensemble" }
+func (ensemble) Description() string {
+	return "Majority, weighted or unanimous vote of the Kalman swap, regime model and multi-timeframe signals"
+}
+func (e ensemble) Config() interface{} { return e.set.ensemble() }
+func (ensemble) Schema() []ConfigField { return SchemaOf(DefaultEnsembleConfig()) }
+func (ensemble) Columns() []string     { return EnsembleColumns }
+func (e ensemble) NeedsRegime() bool   { return e.set.ensemble().Uses(EnsembleRegime) }
+func (ensemble) Intraday() bool        { return true }
+func (ensemble) WarmupBars() int       { return KalmanV2WarmupBars }
+func (ensemble) UsesPipeline() bool    { return true }
+func (e ensemble) NewBar() BarStrategy { return NewEnsembleBar(e.set.ensemble()) }
+func (e ensemble) CanEnter(t time.Time) bool {
+	return inEntryWindow(e.set.ensemble().Tranches, t, 0)
+}
+func (ensemble) Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent) {
+	RunKalmanv2(df, logEvents)
+}
+func (e ensemble) Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event) {
+	RunBars(df, e.NewBar(), EnsembleColumns, currentPos, events)
+}
@@ -0,0 +1,296 @@
+package strategy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hft/internal/timeframe"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Strategy registry.
+
+   The backtest engine, the simulation and the live executor select a
+   strategy by name (config strategy.name, or the API) instead of calling
+   one of the Find* functions directly. A Strategy bundles everything those
+   callers need to run it:
+
+     Indicators   computes the DataFrame columns the strategy reads
+     Columns      the columns it reads, so bar feeds know what to pass
+     NeedsRegime  whether pred_prob_* columns must be predicted first
+     WarmupBars   bars of history before its first valid signal
+     Batch        scans a whole DataFrame (vectorized backtests)
+     NewBar       a per-bar evaluator (event backtests, simulation, live)
+
+   Strategies with a per-bar form implement Batch as RunBars over a fresh
+   NewBar, so the vectorized and the live decisions cannot drift apart.
+*/
+
+// Features are the per-bar indicator and model values a BarStrategy reads,
+// keyed by DataFrame column name. Missing keys read as zero.
+type Features map[string]float64
+
+// BarStrategy evaluates a strategy one closed bar at a time.
+type BarStrategy interface {
+	// OnBar consumes one closed bar and returns the intents it triggers.
+	OnBar(c types.Candle, f Features) []types.Intent
+	// Position returns +1 when long, -1 when short and 0 when flat.
+	Position() int
+	// Reject undoes the last entry after risk or the OMS refused it.
+	Reject()
+	// Snapshot serializes everything OnBar carries between calls (position,
+	// stops, cooldowns, counts, day metadata); Restore loads it back, so a
+	// restarted executor resumes where it stopped.
+	Snapshot() ([]byte, error)
+	Restore(state []byte) error
+}
+
+// Strategy is a registered trading strategy.
+type Strategy interface {
+	Name() string
+	Description() string
+	// Config returns the strategy's active config struct (a pointer); its
+	// fields are described by Schema.
+	Config() interface{}
+	Schema() []ConfigField
+	Indicators(df *dataframe.DataFrame, logEvents chan *types.LogEvent)
+	Columns() []string
+	NeedsRegime() bool
+	WarmupBars() int
+	Batch(df *dataframe.DataFrame, currentPos *types.Position, positions []*types.Position, events chan *types.Event)
+	// NewBar returns a flat per-bar evaluator, or nil when the strategy only
+	// runs in batch.
+	NewBar() BarStrategy
+}
+
+// PipelineStrategy is implemented by strategies whose Indicators compute
+// ActivePipeline (through RunKalmanv2), so the feature cache can restore
+// those columns instead.
+type PipelineStrategy interface {
+	UsesPipeline() bool
+}
+
+// IntradayStrategy is implemented by strategies that trade inside the
+// session or its tranches and square off by their close. A daily or weekly
+// bar has no time of day to do that on.
+type IntradayStrategy interface {
+	Intraday() bool
+}
+
+// SessionStrategy is implemented by strategies that open positions only in
+// part of the session. CanEnter reports whether a bar closing at t may open
+// one; benchmarks draw their random entries from the same bars.
+type SessionStrategy interface {
+	CanEnter(t time.Time) bool
+}
+
+// CheckTimeframe reports whether s can run on bars of tf: intraday
+// strategies need minute bars, and the regime model is trained on 1-minute
+// features, so strategies reading its predictions need 1-minute bars.
+func CheckTimeframe(s Strategy, tf timeframe.Timeframe) error {
+	if in, ok := s.(IntradayStrategy); ok && in.Intraday() && tf.Unit != timeframe.Minute {
+		return fmt.Errorf("strategy %s trades intraday and cannot run on %s bars", s.Name(), tf)
+	}
+	if s.NeedsRegime() && !tf.IsOneMinute() {
+		return fmt.Errorf("strategy %s reads the regime model, which is trained on 1m bars, not %s", s.Name(), tf)
+	}
+	return nil
+}
+
+// ConfigField describes one config field of a strategy.
+type ConfigField struct {
+	Name    string      `json:"name"` // YAML key
+	Type    string      `json:"type"`
+	Default interface{} `json:"default"`
+}
+
+// DefaultStrategy is the strategy used when none is configured.
+const DefaultStrategy = "regime"
+
+// ActiveStrategy is the name of the strategy the backtest, simulation and
+// live executor run unless a caller picks another.
+var ActiveStrategy = DefaultStrategy
+
+// ActiveTimeframe is the bar timeframe the backtest, simulation and live
+// executor resample the 1-minute history to unless a caller picks another.
+// Indicator periods and warmups count bars of this timeframe.
+var ActiveTimeframe = timeframe.OneMinute
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Strategy)
+)
+
+// Register adds s to the registry. It panics on a duplicate name, like
+// http.Handle, since registration happens at init.
+func Register(s Strategy) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[s.Name()]; dup {
+		panic("strategy: duplicate registration of " + s.Name())
+	}
+	registry[s.Name()] = s
+}
+
+// Get returns the registered strategy called name. An empty name returns
+// ActiveStrategy.
+func Get(name string) (Strategy, error) {
+	if name == "" {
+		name = ActiveStrategy
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	s, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (registered: %s)", name, strings.Join(namesLocked(), ", "))
+	}
+	return s, nil
+}
+
+// Settings are the config sections a strategy trades with. A nil section,
+// or a nil *Settings, uses the Active* config of the live executor, so runs
+// that reproduce a recorded configuration never have to swap those.
+type Settings struct {
+	Regime     *RegimeSignalConfig
+	KalmanExit *KalmanExitConfigv2
+	ORB        *ORBConfig
+	MeanRev    *MeanRevConfig
+	Ensemble   *EnsembleConfig
+}
+
+// configurable is implemented by registered strategies that take Settings.
+type configurable interface {
+	withSettings(set *Settings) Strategy
+}
+
+// GetWith is Get for a strategy that trades with set instead of the Active*
+// configs; a nil set is Get.
+func GetWith(name string, set *Settings) (Strategy, error) {
+	s, err := Get(name)
+	if err != nil || set == nil {
+		return s, err
+	}
+	if c, ok := s.(configurable); ok {
+		return c.withSettings(set), nil
+	}
+	return s, nil
+}
+
+func (s *Settings) regime() *RegimeSignalConfig {
+	if s != nil && s.Regime != nil {
+		return s.Regime
+	}
+	return ActiveRegimeConfig()
+}
+
+// regimeBar is a flat RegimeBar on the regime section; without one it
+// follows live edits of the active config, as the live executor's does.
+func (s *Settings) regimeBar() *RegimeBar {
+	if s != nil && s.Regime != nil {
+		return NewRegimeBar(s.Regime)
+	}
+	return NewLiveRegimeBar()
+}
+
+// kalmanV2Bar is a flat KalmanV2Bar on the kalman_exit section; without
+// one it follows ActiveExitConfig.
+func (s *Settings) kalmanV2Bar() *KalmanV2Bar {
+	if s != nil && s.KalmanExit != nil {
+		return NewKalmanV2Bar(s.KalmanExit)
+	}
+	return NewKalmanV2Bar(nil)
+}
+
+func (s *Settings) kalmanExit() *KalmanExitConfigv2 {
+	if s != nil && s.KalmanExit != nil {
+		return s.KalmanExit
+	}
+	return ActiveExitConfig()
+}
+
+func (s *Settings) orb() *ORBConfig {
+	if s != nil && s.ORB != nil {
+		return s.ORB
+	}
+	return ActiveORBConfig
+}
+
+func (s *Settings) meanRev() *MeanRevConfig {
+	if s != nil && s.MeanRev != nil {
+		return s.MeanRev
+	}
+	return ActiveMeanRevConfig
+}
+
+func (s *Settings) ensemble() *EnsembleConfig {
+	if s != nil && s.Ensemble != nil {
+		return s.Ensemble
+	}
+	return ActiveEnsembleConfig
+}
+
+// Names returns the registered strategy names, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Describe returns a JSON-friendly summary of every registered strategy.
+func Describe() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0)
+	for _, name := range Names() {
+		s, _ := Get(name)
+		out = append(out, map[string]interface{}{
+			"name":        s.Name(),
+			"description": s.Description(),
+			"active":      s.Name() == ActiveStrategy,
+			"columns":     s.Columns(),
+			"needsRegime": s.NeedsRegime(),
+			"warmupBars":  s.WarmupBars(),
+			"perBar":      s.NewBar() != nil,
+			"schema":      s.Schema(),
+			"config":      s.Config(),
+		})
+	}
+	return out
+}
+
+// SchemaOf describes the fields of the config struct cfg points to, using
+// their yaml tags as names and cfg's values as defaults.
+func SchemaOf(cfg interface{}) []ConfigField {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	fields := make([]ConfigField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			name = f.Name
+		}
+		fields = append(fields, ConfigField{Name: name, Type: f.Type.String(), Default: v.Field(i).Interface()})
+	}
+	return fields
+}
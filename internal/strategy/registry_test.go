@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var registered = []string{"ensemble", "kalman_v1", "kalman_v2", "mean_reversion", "orb", "regime", "regime_router"}
+
+func TestRegisterPanicsOnDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "strategy: duplicate registration of regime" {
+			t.Fatalf("recovered %v", r)
+		}
+		if got := Names(); !reflect.DeepEqual(got, registered) {
+			t.Fatalf("registry changed to %v", got)
+		}
+	}()
+	Register(regime{})
+}
+
+func TestGet(t *testing.T) {
+	for _, name := range registered {
+		s, err := Get(name)
+		if err != nil || s.Name() != name {
+			t.Fatalf("%s: %v, %v", name, s, err)
+		}
+	}
+
+	_, err := Get("kalman")
+	if err == nil || !strings.Contains(err.Error(), `unknown strategy "kalman"`) ||
+		!strings.Contains(err.Error(), strings.Join(registered, ", ")) {
+		t.Fatalf("unknown name: %v", err)
+	}
+
+	// An empty name is the active strategy, whatever that names.
+	saved := ActiveStrategy
+	defer func() { ActiveStrategy = saved }()
+	ActiveStrategy = "orb"
+	if s, err := Get(""); err != nil || s.Name() != "orb" {
+		t.Fatalf("active orb: %v, %v", s, err)
+	}
+	ActiveStrategy = "gone"
+	if _, err := Get(""); err == nil || !strings.Contains(err.Error(), `"gone"`) {
+		t.Fatalf("unregistered active strategy: %v", err)
+	}
+}
+
+func TestNamesAndDescribe(t *testing.T) {
+	if got := Names(); !reflect.DeepEqual(got, registered) {
+		t.Fatalf("names %v, want %v", got, registered)
+	}
+
+	saved := ActiveStrategy
+	defer func() { ActiveStrategy = saved }()
+	ActiveStrategy = "mean_reversion"
+	d := Describe()
+	if len(d) != len(registered) {
+		t.Fatalf("%d descriptions for %d strategies", len(d), len(registered))
+	}
+	for i, m := range d {
+		s, _ := Get(registered[i])
+		if m["name"] != registered[i] || m["description"] != s.Description() || m["description"] == "" {
+			t.Errorf("%s: described as %v: %v", registered[i], m["name"], m["description"])
+		}
+		if m["active"] != (registered[i] == "mean_reversion") {
+			t.Errorf("%s: active %v", registered[i], m["active"])
+		}
+		if m["perBar"] != true || m["warmupBars"] != s.WarmupBars() || m["needsRegime"] != s.NeedsRegime() {
+			t.Errorf("%s: %v", registered[i], m)
+		}
+		if !reflect.DeepEqual(m["schema"], SchemaOf(s.Config())) || len(s.Schema()) == 0 {
+			t.Errorf("%s: schema does not describe its config", registered[i])
+		}
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type cfg struct {
+		Period int     `yaml:"period"`
+		Thresh float64 `yaml:"thresh,omitempty"`
+		Label  string
+		Skip   bool `yaml:"-"`
+		hidden int
+	}
+	want := []ConfigField{
+		{Name: "period", Type: "int", Default: 14},
+		{Name: "thresh", Type: "float64", Default: 0.5},
+		{Name: "Label", Type: "string", Default: "x"},
+		{Name: "Skip", Type: "bool", Default: false},
+	}
+	c := cfg{Period: 14, Thresh: 0.5, Label: "x", hidden: 1}
+	for _, v := range []interface{}{c, &c} {
+		if got := SchemaOf(v); !reflect.DeepEqual(got, want) {
+			t.Fatalf("SchemaOf(%T) = %+v", v, got)
+		}
+	}
+	if got := SchemaOf(3); got != nil {
+		t.Fatalf("non-struct: %+v", got)
+	}
+
+	// Registered configs are described by their YAML keys.
+	names := make(map[string]bool)
+	for _, f := range SchemaOf(DefaultORBConfig()) {
+		names[f.Name] = true
+	}
+	if !names["range_minutes"] || !names["stop_mode"] {
+		t.Fatalf("orb schema: %v", names)
+	}
+}
+
+// GetWith trades with the given sections and leaves the active ones alone.
+func TestGetWith(t *testing.T) {
+	orbCfg := DefaultORBConfig()
+	orbCfg.RangeMinutes = 5
+	regimeCfg := DefaultRegimeSignalConfig()
+	regimeCfg.Tranches = regimeCfg.Tranches[:1]
+	set := &Settings{ORB: orbCfg, Regime: regimeCfg}
+	activeORB, activeRegime := ActiveORBConfig, ActiveRegimeConfig()
+
+	s, err := GetWith("orb", set)
+	if err != nil || s.Config() != orbCfg || s.NewBar().(*ORBBar).cfg != orbCfg {
+		t.Fatalf("orb does not trade with the given config: %v", err)
+	}
+	s, _ = GetWith("regime", set)
+	if s.Config() != regimeCfg {
+		t.Fatal("regime does not trade with the given config")
+	}
+	midday := time.Date(2025, 6, 3, 12, 30, 0, 0, ist)
+	if s.(SessionStrategy).CanEnter(midday) {
+		t.Fatal("regime enters outside the given tranches")
+	}
+	if s, _ := GetWith("regime", nil); s.Config() != activeRegime || !s.(SessionStrategy).CanEnter(midday) {
+		t.Fatal("nil settings do not use the active config")
+	}
+	if s, _ := GetWith("regime", &Settings{ORB: orbCfg}); s.Config() != activeRegime {
+		t.Fatal("a missing section does not use the active config")
+	}
+	if ActiveORBConfig != activeORB || ActiveRegimeConfig() != activeRegime {
+		t.Fatal("GetWith changed the active configs")
+	}
+}
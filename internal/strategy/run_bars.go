@@ -0,0 +1,97 @@
+package strategy
+
+import (
+	"math"
+
+	"hft/internal/indicators"
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+// ── Vectorized form of a per-bar strategy ───────────────────────────────────
+
+// RunBars feeds every timestamped row of df through s in order and fills
+// its intents at the bar close: currentPos is updated and one event is sent
+// per intent. columns are read into the Features of each bar; missing
+// columns read as zero.
+//
+// This is the batch form of every strategy that has a per-bar one, so a
+// vectorized backtest takes exactly the decisions the live executor would.
+func RunBars(df *dataframe.DataFrame, s BarStrategy, columns []string, currentPos *types.Position, events chan *types.Event) {
+	n := df.NRows()
+	tsIdx := indicators.FindIndexOf(df, "timestamp")
+	if n == 0 || tsIdx < 0 {
+		return
+	}
+	_ts := df.Series[tsIdx].(*dataframe.SeriesTime).Values
+
+	col := func(name string) []float64 {
+		if idx := indicators.FindIndexOf(df, name); idx >= 0 {
+			if s, ok := df.Series[idx].(*dataframe.SeriesFloat64); ok {
+				return s.Values
+			}
+		}
+		return make([]float64, n)
+	}
+	_open, _high, _low, _close, _volume := col("open"), col("high"), col("low"), col("close"), col("volume")
+	values := make([][]float64, len(columns))
+	for k, name := range columns {
+		values[k] = col(name)
+	}
+
+	for i := 0; i < n; i++ {
+		if _ts[i] == nil {
+			continue
+		}
+		c := types.Candle{
+			Timestamp: *_ts[i],
+			Open:      _open[i],
+			High:      _high[i],
+			Low:       _low[i],
+			Close:     _close[i],
+			Volume:    _volume[i],
+		}
+		f := make(Features, len(columns))
+		for k, name := range columns {
+			f[name] = values[k][i]
+		}
+
+		// Mark the open position before the strategy sees the bar.
+		if currentPos.Kind != "" {
+			if currentPos.Kind == "BUY" {
+				currentPos.Profit = c.Close - currentPos.EntryPrice
+			} else {
+				currentPos.Profit = currentPos.EntryPrice - c.Close
+			}
+			currentPos.PeakProfit = math.Max(currentPos.PeakProfit, currentPos.Profit)
+			currentPos.PeakLoss = math.Min(currentPos.PeakLoss, currentPos.Profit)
+		}
+
+		for _, in := range s.OnBar(c, f) {
+			ev := &types.Event{
+				Kind:       in.Kind,
+				Type:       in.Type,
+				EntryPrice: in.Price,
+				Timestamp:  in.Timestamp,
+				Reason:     in.Reason,
+				Context:    in.Context,
+			}
+			if in.Type == "EXIT" {
+				currentPos.Exit(in.Price, in.Timestamp)
+				ev.PeakProfit = currentPos.PeakProfit
+				ev.PeakLoss = currentPos.PeakLoss
+				currentPos.Reset()
+			} else {
+				if in.Kind == "BUY" {
+					currentPos.Buy(in.Price, in.Timestamp)
+				} else {
+					currentPos.Sell(in.Price, in.Timestamp)
+				}
+				currentPos.PeakProfit = 0
+				currentPos.PeakLoss = 0
+			}
+			events <- ev
+		}
+	}
+}
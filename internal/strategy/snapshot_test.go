@@ -0,0 +1,117 @@
+package strategy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"testing"
+
+	"hft/internal/indicators"
+	"hft/pkg/types"
+
+	_df_ "github.com/rocketlaunchr/dataframe-go"
+)
+
+// barsOf turns df's rows into the candles and features RunBars would feed
+// a strategy reading columns.
+func barsOf(df *_df_.DataFrame, columns []string) ([]types.Candle, []Features) {
+	col := func(name string) []float64 {
+		if idx := indicators.FindIndexOf(df, name); idx >= 0 {
+			return df.Series[idx].(*_df_.SeriesFloat64).Values
+		}
+		return make([]float64, df.NRows())
+	}
+	open, high, low, close := col("open"), col("high"), col("low"), col("close")
+	ts := df.Series[indicators.FindIndexOf(df, "timestamp")].(*_df_.SeriesTime).Values
+	candles := make([]types.Candle, len(ts))
+	features := make([]Features, len(ts))
+	for i := range ts {
+		candles[i] = types.Candle{Symbol: "nifty", Timestamp: *ts[i], Open: open[i], High: high[i], Low: low[i], Close: close[i]}
+		features[i] = make(Features, len(columns))
+	}
+	for _, name := range columns {
+		for i, v := range col(name) {
+			features[i][name] = v
+		}
+	}
+	return candles, features
+}
+
+func intentsString(in []types.Intent) string {
+	var b bytes.Buffer
+	for _, x := range in {
+		fmt.Fprintf(&b, "%s %s %s %.2f %s", x.Timestamp.Format("01-02 15:04"), x.Type, x.Kind, x.Price, x.Reason)
+		if x.Context != nil {
+			fmt.Fprintf(&b, " %+v", *x.Context)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// A strategy restored from a snapshot taken mid-trade must go on exactly as
+// one that never stopped: same exits, same later entries, same tags.
+func TestSnapshotRestoreMidTrade(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	for _, name := range Names() {
+		s, _ := Get(name)
+		df := niftyBars(t)
+		s.Indicators(df, nil)
+		addRegimeProbs(df)
+		// Volatile spells, so the mean-reversion side trades as well.
+		vol := df.Series[indicators.FindIndexOf(df, "pred_prob_volatile")].(*_df_.SeriesFloat64).Values
+		for i := range vol {
+			vol[i] = 0.15 + 0.6*math.Pow(math.Sin(float64(i)/211), 2)
+		}
+		candles, features := barsOf(df, s.Columns())
+
+		whole := s.NewBar()
+		var want []types.Intent
+		var held []int // bars after which the uninterrupted run holds a position
+		for i, c := range candles {
+			want = append(want, whole.OnBar(c, features[i])...)
+			if whole.Position() != 0 {
+				held = append(held, i)
+			}
+		}
+		if len(held) < 6 {
+			t.Fatalf("%s: %d mid-trade bars; the test needs a few", name, len(held))
+		}
+		cuts := make([]int, 6)
+		for k := range cuts {
+			cuts[k] = held[k*len(held)/len(cuts)]
+		}
+
+		for _, cut := range cuts {
+			first := s.NewBar()
+			var got []types.Intent
+			for i := 0; i <= cut; i++ {
+				got = append(got, first.OnBar(candles[i], features[i])...)
+			}
+			state, err := first.Snapshot()
+			if err != nil {
+				t.Fatalf("%s: snapshot: %v", name, err)
+			}
+			resumed := s.NewBar()
+			if err := resumed.Restore(state); err != nil {
+				t.Fatalf("%s: restore: %v", name, err)
+			}
+			if again, _ := resumed.Snapshot(); !bytes.Equal(again, state) {
+				t.Fatalf("%s: restore at bar %d lost state\n%s\n%s", name, cut, state, again)
+			}
+			if resumed.Position() == 0 {
+				t.Fatalf("%s: restored flat at bar %d", name, cut)
+			}
+			for i := cut + 1; i < len(candles); i++ {
+				got = append(got, resumed.OnBar(candles[i], features[i])...)
+			}
+			if g, w := intentsString(got), intentsString(want); g != w {
+				t.Fatalf("%s: resumed after bar %d diverges\n got:\n%s\nwant:\n%s", name, cut, g, w)
+			}
+		}
+	}
+}
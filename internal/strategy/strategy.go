@@ -12,20 +12,20 @@ import (
 
 // TrailingStopConfig holds configuration for trailing stop-loss
 type TrailingStopConfig struct {
-	UseTrailingStop            bool
-	TrailActivationPoints      float64 // Start trailing after this profit (in points)
-	TrailDistancePoints        float64 // Distance to maintain from highest profit
-	UseATRTrailing             bool
-	ATRTrailingMultiplier      float64
-	UseBreakeven               bool
-	BreakevenActivationPoints  float64 // Move stop to breakeven after this profit
-	CapturePoints              float64 // Target profit points
-	StopLossPoints             float64 // Fixed stop loss points
-	StopLossAtrMultiplier      float64 // ATR multiplier for stop loss points
-	TightStopLossAtrMultiplier float64 // ATR multiplier after streak threshold
-	StreakMinProfitPoints      float64 // Min profit per trade for streak (inclusive)
-	StreakMaxProfitPoints      float64 // Max profit per trade for streak (inclusive)
-	StreakLengthForTighten     int     // Streak length to activate tighter stop
+	UseTrailingStop            bool    `yaml:"use_trailing_stop" json:"useTrailingStop"`
+	TrailActivationPoints      float64 `yaml:"trail_activation_points" json:"trailActivationPoints"` // Start trailing after this profit (in points)
+	TrailDistancePoints        float64 `yaml:"trail_distance_points" json:"trailDistancePoints"`     // Distance to maintain from highest profit
+	UseATRTrailing             bool    `yaml:"use_atr_trailing" json:"useATRTrailing"`
+	ATRTrailingMultiplier      float64 `yaml:"atr_trailing_multiplier" json:"atrTrailingMultiplier"`
+	UseBreakeven               bool    `yaml:"use_breakeven" json:"useBreakeven"`
+	BreakevenActivationPoints  float64 `yaml:"breakeven_activation_points" json:"breakevenActivationPoints"`     // Move stop to breakeven after this profit
+	CapturePoints              float64 `yaml:"capture_points" json:"capturePoints"`                              // Target profit points
+	StopLossPoints             float64 `yaml:"stop_loss_points" json:"stopLossPoints"`                           // Fixed stop loss points
+	StopLossAtrMultiplier      float64 `yaml:"stop_loss_atr_multiplier" json:"stopLossAtrMultiplier"`            // ATR multiplier for stop loss points
+	TightStopLossAtrMultiplier float64 `yaml:"tight_stop_loss_atr_multiplier" json:"tightStopLossAtrMultiplier"` // ATR multiplier after streak threshold
+	StreakMinProfitPoints      float64 `yaml:"streak_min_profit_points" json:"streakMinProfitPoints"`            // Min profit per trade for streak (inclusive)
+	StreakMaxProfitPoints      float64 `yaml:"streak_max_profit_points" json:"streakMaxProfitPoints"`            // Max profit per trade for streak (inclusive)
+	StreakLengthForTighten     int     `yaml:"streak_length_for_tighten" json:"streakLengthForTighten"`          // Streak length to activate tighter stop
 }
 
 // DefaultTrailingStopConfig returns default trailing stop configuration matching Pine Script
@@ -48,6 +48,10 @@ func DefaultTrailingStopConfig() *TrailingStopConfig {
 	}
 }
 
+// ActiveTrailingStopConfig is the trailing stop config used by FindSignals,
+// set from config at startup.
+var ActiveTrailingStopConfig = DefaultTrailingStopConfig()
+
 // TrailingStopState holds state for trailing stop calculation
 type TrailingStopState struct {
 	HighestProfitLong       float64
@@ -110,7 +114,7 @@ func Run(df *dataframe.DataFrame) {
 }
 
 func FindSignals(df *dataframe.DataFrame, current_position *types.Position, positions []*types.Position, events chan *types.Event) {
-	FindSignalsWithTrailingStop(df, current_position, positions, events, DefaultTrailingStopConfig(), NewTrailingStopState())
+	FindSignalsWithTrailingStop(df, current_position, positions, events, ActiveTrailingStopConfig, NewTrailingStopState())
 }
 
 // FindSignalsWithTrailingStop implements signal finding with configurable trailing stop-loss
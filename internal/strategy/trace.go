@@ -0,0 +1,174 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hft/pkg/types"
+)
+
+/*
+   Decision traces.
+
+   A trace explains one bar's decision: the inputs the strategy saw, every
+   rule it evaluated with pass/fail, and the action it took. A missed trade
+   is then the first failing rule on the side that was expected to enter:
+
+     {"action": "none", "rules": [
+        {"name": "cutoff", "pass": true, ...},
+        {"name": "max_vol_prob", "pass": true, ...},
+        {"name": "threshold", "side": "long", "pass": true, ...},
+        {"name": "gap_follow", "side": "long", "pass": false, "detail": "gap -0.31% < -0.15%"}]}
+
+   Strategies that can explain themselves implement Tracer. Tracing is off
+   until SetTracing(true), so backtests pay nothing for it.
+*/
+
+// Trace actions.
+const (
+	TraceNone  = "none" // flat and stayed flat
+	TraceHold  = "hold" // in position and kept it
+	TraceEnter = "enter"
+	TraceExit  = "exit"
+)
+
+// TraceRule is one rule's verdict on a bar.
+type TraceRule struct {
+	Name   string `json:"name"`
+	Side   string `json:"side,omitempty"` // long | short, "" for both
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Trace is the decision record of one bar.
+type Trace struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Strategy  string             `json:"strategy,omitempty"`
+	Inputs    map[string]float64 `json:"inputs"`
+	Rules     []TraceRule        `json:"rules"`
+	Action    string             `json:"action"`           // none | hold | enter | exit
+	Detail    string             `json:"detail,omitempty"` // "BUY", "STOP_LOSS", "EOD_SQUAREOFF → SELL" ...
+}
+
+// Tracer is implemented by per-bar strategies that can record why they did
+// or did not trade.
+type Tracer interface {
+	// SetTracing turns recording on or off.
+	SetTracing(on bool)
+	// LastTrace returns the trace of the latest OnBar, nil when tracing is off.
+	LastTrace() *Trace
+}
+
+// input records a named input; a nil trace ignores it.
+func (t *Trace) input(name string, v float64) {
+	if t != nil {
+		t.Inputs[name] = v
+	}
+}
+
+// rule records a rule's verdict and returns pass, so a check can be traced
+// where it is made. A nil trace ignores it.
+func (t *Trace) rule(name, side string, pass bool, format string, args ...interface{}) bool {
+	if t != nil {
+		t.Rules = append(t.Rules, TraceRule{Name: name, Side: side, Pass: pass, Detail: fmt.Sprintf(format, args...)})
+	}
+	return pass
+}
+
+// finish sets the action from the intents the bar produced.
+func (t *Trace) finish(position int, intents []types.Intent) {
+	if t == nil {
+		return
+	}
+	var parts []string
+	t.Action = TraceNone
+	if position != 0 {
+		t.Action = TraceHold
+	}
+	for _, in := range intents {
+		if in.Type == "EXIT" {
+			t.Action = TraceExit
+			parts = append(parts, in.Reason)
+		} else {
+			if t.Action != TraceExit {
+				t.Action = TraceEnter
+			}
+			parts = append(parts, in.Kind)
+		}
+	}
+	t.Detail = strings.Join(parts, " → ")
+}
+
+// Blocked returns the failing rules of a trace, i.e. why it did not enter.
+func (t *Trace) Blocked() []TraceRule {
+	var out []TraceRule
+	for _, r := range t.Rules {
+		if !r.Pass {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ── Trace buffer ─────────────────────────────────────────────────────────────
+
+// TraceBuffer keeps the most recent traces in timestamp order, up to a
+// capacity. It is safe for concurrent use.
+type TraceBuffer struct {
+	mu     sync.Mutex
+	cap    int
+	traces []Trace
+}
+
+// NewTraceBuffer returns a buffer holding up to capacity traces.
+func NewTraceBuffer(capacity int) *TraceBuffer {
+	return &TraceBuffer{cap: capacity}
+}
+
+// Add inserts a trace in timestamp order, after any of the same bar; bars
+// usually arrive in order, so that is an append. Once the buffer is full
+// the oldest quarter is dropped, so the copy is paid once per cap/4 traces.
+func (b *TraceBuffer) Add(t Trace) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cap > 0 && len(b.traces) >= b.cap {
+		n := copy(b.traces, b.traces[b.cap/4+1:])
+		b.traces = b.traces[:n]
+	}
+	i := len(b.traces)
+	if i > 0 && t.Timestamp.Before(b.traces[i-1].Timestamp) {
+		i = sort.Search(len(b.traces), func(j int) bool { return b.traces[j].Timestamp.After(t.Timestamp) })
+	}
+	b.traces = append(b.traces, Trace{})
+	copy(b.traces[i+1:], b.traces[i:])
+	b.traces[i] = t
+}
+
+// Reset drops every trace.
+func (b *TraceBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.traces = nil
+}
+
+// Range returns the traces with from <= Timestamp <= to. A zero from or to
+// leaves that end open.
+func (b *TraceBuffer) Range(from, to time.Time) []Trace {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lo := 0
+	if !from.IsZero() {
+		lo = sort.Search(len(b.traces), func(i int) bool { return !b.traces[i].Timestamp.Before(from) })
+	}
+	hi := len(b.traces)
+	if !to.IsZero() {
+		hi = sort.Search(len(b.traces), func(i int) bool { return b.traces[i].Timestamp.After(to) })
+	}
+	if lo >= hi {
+		return []Trace{}
+	}
+	return append([]Trace(nil), b.traces[lo:hi]...)
+}
@@ -0,0 +1,123 @@
+package strategy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hft/pkg/types"
+)
+
+func traceTimes(traces []Trace) []string {
+	out := make([]string, len(traces))
+	for i, t := range traces {
+		out[i] = t.Timestamp.Format("15:04") + t.Strategy
+	}
+	return out
+}
+
+// Range bounds are inclusive and either may be open, whatever order the
+// traces arrived in.
+func TestTraceBufferRange(t *testing.T) {
+	at := func(min int) time.Time { return time.Date(2025, 6, 3, 10, min, 0, 0, ist) }
+	b := NewTraceBuffer(0)
+	// Sleeves trace the same bar, and a replay can add bars older than the
+	// live ones already kept.
+	for _, tr := range []Trace{{Timestamp: at(3), Strategy: "a"}, {Timestamp: at(5)}, {Timestamp: at(3), Strategy: "b"},
+		{Timestamp: at(1)}, {Timestamp: at(4)}, {Timestamp: at(2)}, {Timestamp: at(6)}} {
+		b.Add(tr)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"all", time.Time{}, time.Time{}, []string{"10:01", "10:02", "10:03a", "10:03b", "10:04", "10:05", "10:06"}},
+		{"inclusive", at(2), at(4), []string{"10:02", "10:03a", "10:03b", "10:04"}},
+		{"open from", time.Time{}, at(2), []string{"10:01", "10:02"}},
+		{"open to", at(5), time.Time{}, []string{"10:05", "10:06"}},
+		{"one bar", at(3), at(3), []string{"10:03a", "10:03b"}},
+		{"between bars", at(3).Add(time.Second), at(4).Add(-time.Second), []string{}},
+		{"reversed", at(4), at(2), []string{}},
+		{"after all", at(7), time.Time{}, []string{}},
+	} {
+		got := b.Range(tc.from, tc.to)
+		if got == nil || !reflect.DeepEqual(traceTimes(got), tc.want) {
+			t.Errorf("%s: %v, want %v", tc.name, traceTimes(got), tc.want)
+		}
+	}
+
+	// Range returns a copy.
+	b.Range(time.Time{}, time.Time{})[0].Strategy = "changed"
+	if b.Range(at(1), at(1))[0].Strategy != "" {
+		t.Fatal("Range shares the buffer")
+	}
+
+	b.Reset()
+	if got := b.Range(time.Time{}, time.Time{}); len(got) != 0 {
+		t.Fatalf("%d traces after Reset", len(got))
+	}
+}
+
+// A full buffer drops its oldest quarter.
+func TestTraceBufferCapacity(t *testing.T) {
+	b := NewTraceBuffer(8)
+	start := time.Date(2025, 6, 3, 10, 0, 0, 0, ist)
+	for i := 0; i < 9; i++ {
+		b.Add(Trace{Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	want := []string{"10:03", "10:04", "10:05", "10:06", "10:07", "10:08"}
+	if got := traceTimes(b.Range(time.Time{}, time.Time{})); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%v, want %v", got, want)
+	}
+}
+
+func TestTraceFinish(t *testing.T) {
+	entry := types.Intent{Type: "ENTRY", Kind: "SELL"}
+	exit := types.Intent{Type: "EXIT", Kind: "BUY", Reason: "EOD_SQUAREOFF"}
+	for _, tc := range []struct {
+		position int
+		intents  []types.Intent
+		action   string
+		detail   string
+	}{
+		{0, nil, TraceNone, ""},
+		{1, nil, TraceHold, ""},
+		{-1, []types.Intent{entry}, TraceEnter, "SELL"},
+		{0, []types.Intent{exit}, TraceExit, "EOD_SQUAREOFF"},
+		{-1, []types.Intent{exit, entry}, TraceExit, "EOD_SQUAREOFF → SELL"},
+	} {
+		tr := &Trace{}
+		tr.finish(tc.position, tc.intents)
+		if tr.Action != tc.action || tr.Detail != tc.detail {
+			t.Errorf("position %d, %d intents: %s %q, want %s %q", tc.position, len(tc.intents), tr.Action, tr.Detail, tc.action, tc.detail)
+		}
+	}
+
+	// Untraced bars record nothing.
+	var none *Trace
+	none.finish(1, []types.Intent{entry})
+	none.input("close", 1)
+	if !none.rule("cutoff", "", true, "") || none.rule("cutoff", "", false, "") {
+		t.Fatal("a nil trace changed a rule's verdict")
+	}
+}
+
+func TestTraceBlocked(t *testing.T) {
+	tr := &Trace{Inputs: map[string]float64{}}
+	tr.rule("cutoff", "", true, "%s < %s", "10:30", "11:00")
+	tr.rule("threshold", "long", false, "bull %.3f > %.3f", 0.466, 0.75)
+	tr.rule("threshold", "short", true, "")
+	tr.rule("gap_follow", "short", false, "gap %.2f%% <= 0.15%%", 0.31)
+	want := []TraceRule{
+		{Name: "threshold", Side: "long", Detail: "bull 0.466 > 0.750"},
+		{Name: "gap_follow", Side: "short", Detail: "gap 0.31% <= 0.15%"},
+	}
+	if got := tr.Blocked(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("blocked %+v, want %+v", got, want)
+	}
+	if got := (&Trace{}).Blocked(); got != nil {
+		t.Fatalf("no rules blocked %+v", got)
+	}
+}
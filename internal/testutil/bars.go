@@ -0,0 +1,114 @@
+package testutil
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"hft/pkg/types"
+
+	"github.com/rocketlaunchr/dataframe-go"
+)
+
+/*
+   Synthetic bars for tests.
+
+   Candles is the one generator of test history: weekday sessions of
+   1-minute bars (09:15–15:29 IST) from a seeded random walk with an
+   overnight gap, starting at 22000 on Monday 2 June 2025. Frame and Ticks
+   turn them into the DataFrame and tick forms the packages load, and
+   SameFloat is the bitwise comparison the batch/stream and
+   sequential/parallel equivalence tests use.
+*/
+
+// IST is the exchange time zone.
+var IST = time.FixedZone("IST", 19800)
+
+// Candles returns days sessions of 1-minute bars from a random walk seeded
+// with seed. Each bar has a volume of 1000–1500; see WithoutVolume.
+func Candles(seed int64, days int) []types.Candle {
+	rng := rand.New(rand.NewSource(seed))
+
+	out := make([]types.Candle, 0, days*375)
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, IST)
+	price := 22000.0
+	for len(out) < days*375 {
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			price *= 1 + (rng.Float64()-0.5)*0.01 // overnight gap
+			for m := 9*60 + 15; m < 15*60+30; m++ {
+				open := price
+				price += (rng.Float64() - 0.5) * 12
+				out = append(out, types.Candle{
+					Timestamp: day.Add(time.Duration(m) * time.Minute),
+					Open:      open,
+					High:      math.Max(open, price) + rng.Float64()*3,
+					Low:       math.Min(open, price) - rng.Float64()*3,
+					Close:     price,
+					Volume:    1000 + rng.Float64()*500,
+				})
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return out
+}
+
+// WithoutVolume zeroes the volume of candles, as for an index, and returns
+// them.
+func WithoutVolume(candles []types.Candle) []types.Candle {
+	for i := range candles {
+		candles[i].Volume = 0
+	}
+	return candles
+}
+
+// Series returns the open, high, low, close, volume and timestamp columns
+// of candles.
+func Series(candles []types.Candle) []dataframe.Series {
+	n := len(candles)
+	o, h, l, c, v := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	ts := make([]*time.Time, n)
+	for i := range candles {
+		o[i], h[i], l[i], c[i], v[i] = candles[i].Open, candles[i].High, candles[i].Low, candles[i].Close, candles[i].Volume
+		t := candles[i].Timestamp
+		ts[i] = &t
+	}
+	timestamps := dataframe.NewSeriesTime("timestamp", nil)
+	timestamps.Values = ts
+	return []dataframe.Series{
+		dataframe.NewSeriesFloat64("open", nil, o),
+		dataframe.NewSeriesFloat64("high", nil, h),
+		dataframe.NewSeriesFloat64("low", nil, l),
+		dataframe.NewSeriesFloat64("close", nil, c),
+		dataframe.NewSeriesFloat64("volume", nil, v),
+		timestamps,
+	}
+}
+
+// Frame returns candles as a DataFrame of Series.
+func Frame(candles []types.Candle) *dataframe.DataFrame {
+	return dataframe.NewDataFrame(Series(candles)...)
+}
+
+// Ticks returns candles as stored 1-minute ticks of symbol.
+func Ticks(symbol string, candles []types.Candle) []types.Tick {
+	out := make([]types.Tick, len(candles))
+	for i, c := range candles {
+		out[i] = types.Tick{
+			Timestamp: c.Timestamp,
+			Symbol:    symbol,
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Time:      c.Timestamp.Unix(),
+			Volume:    c.Volume,
+		}
+	}
+	return out
+}
+
+// SameFloat is bitwise float equality with NaN equal to NaN.
+func SameFloat(a, b float64) bool {
+	return math.Float64bits(a) == math.Float64bits(b) || (math.IsNaN(a) && math.IsNaN(b))
+}
@@ -0,0 +1,175 @@
+package timeframe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hft/pkg/types"
+)
+
+/*
+   Bar timeframes and resampling.
+
+   History is stored as 1-minute bars (ticks.tf = "1"). Resample builds any
+   coarser timeframe from them, aligned to the 09:15 IST session open:
+
+     Nm   N-minute bars counted from 09:15, so 5m bars start 09:15, 09:20,
+          ... and 1h bars 09:15, 10:15, ..., 15:15 (the last one 15
+          minutes long). A bar never spans two sessions.
+     1d   one bar per session
+     1w   one bar per week (Monday to Sunday)
+
+   A bar takes the open of its first 1m bar, the high and low over all of
+   them, the close of its last and the summed volume. It is stamped like a
+   1m bar, with the minute its close comes from: the timestamp of its last
+   1m bar, e.g. 09:19 for the 09:15 5m bar and 15:29 for a full session.
+   A strategy deciding on a bar thus sees the same clock as one deciding on
+   the 1m bar with the same close, so time-of-day rules (cutoffs, square
+   off) hold on any timeframe. Start gives the bucket a bar belongs to.
+
+   Names are "1m", "5m", "15m", "1h", "2h", "1d", "1w". Bare numbers are
+   minutes, as in ticks.tf ("1", "5"); "60m" is "1h"; "d" and "w" alone
+   mean 1d and 1w.
+*/
+
+// Unit is the unit of a Timeframe.
+type Unit int
+
+const (
+	Minute Unit = iota
+	Day
+	Week
+)
+
+// Timeframe is a bar length: N minutes, one day or one week.
+type Timeframe struct {
+	N    int
+	Unit Unit
+}
+
+// OneMinute is the timeframe of the stored bars.
+var OneMinute = Timeframe{1, Minute}
+
+// Session bounds in minutes after midnight IST.
+const (
+	SessionOpen    = 9*60 + 15
+	SessionMinutes = 375 // 09:15–15:30
+)
+
+// IST is the exchange time zone.
+var IST = time.FixedZone("IST", 19800)
+
+// Parse reads a timeframe name (see the package comment).
+func Parse(s string) (Timeframe, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	unit, num := Minute, name
+	switch {
+	case strings.HasSuffix(name, "m"):
+		num = strings.TrimSuffix(name, "m")
+	case strings.HasSuffix(name, "h"):
+		num = strings.TrimSuffix(name, "h")
+	case strings.HasSuffix(name, "d"):
+		unit, num = Day, strings.TrimSuffix(name, "d")
+	case strings.HasSuffix(name, "w"):
+		unit, num = Week, strings.TrimSuffix(name, "w")
+	}
+	if num == "" && unit != Minute {
+		num = "1"
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return Timeframe{}, fmt.Errorf("invalid timeframe %q (want e.g. 1m, 5m, 1h, 1d, 1w)", s)
+	}
+	if strings.HasSuffix(name, "h") {
+		n *= 60
+	}
+	if unit != Minute && n != 1 {
+		return Timeframe{}, fmt.Errorf("invalid timeframe %q: only 1d and 1w are supported", s)
+	}
+	return Timeframe{n, unit}, nil
+}
+
+// String returns the canonical name: whole hours as "Nh", other minute
+// counts as "Nm", then "1d" and "1w".
+func (tf Timeframe) String() string {
+	switch {
+	case tf.Unit == Day:
+		return "1d"
+	case tf.Unit == Week:
+		return "1w"
+	case tf.N%60 == 0:
+		return strconv.Itoa(tf.N/60) + "h"
+	}
+	return strconv.Itoa(tf.N) + "m"
+}
+
+// IsOneMinute reports whether tf is the stored timeframe, which Resample
+// returns unchanged.
+func (tf Timeframe) IsOneMinute() bool { return tf == OneMinute }
+
+// Minutes is the most 1-minute bars one bar of tf covers within sessions,
+// e.g. to turn a warmup in bars of tf into stored bars.
+func (tf Timeframe) Minutes() int {
+	switch tf.Unit {
+	case Day:
+		return SessionMinutes
+	case Week:
+		return 5 * SessionMinutes
+	}
+	return tf.N
+}
+
+// Start returns the start of the bar of tf that t falls in.
+func (tf Timeframe) Start(t time.Time) time.Time {
+	t = t.In(IST)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, IST)
+	switch tf.Unit {
+	case Day:
+		return day.Add(SessionOpen * time.Minute)
+	case Week:
+		back := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -back).Add(SessionOpen * time.Minute)
+	}
+	off := int(t.Sub(day)/time.Minute) - SessionOpen
+	bucket := off / tf.N * tf.N
+	if off < 0 && off%tf.N != 0 {
+		bucket -= tf.N // floor for pre-open bars
+	}
+	return day.Add(time.Duration(SessionOpen+bucket) * time.Minute)
+}
+
+// Resample builds bars of tf from 1-minute bars in time order, each stamped
+// with its last 1m bar. Bars of different symbols are never merged.
+// OneMinute returns ticks as they are.
+func Resample(ticks []types.Tick, tf Timeframe) []types.Tick {
+	if tf.IsOneMinute() || len(ticks) == 0 {
+		return ticks
+	}
+	out := make([]types.Tick, 0, len(ticks)/tf.Minutes()+1)
+	var cur *types.Tick
+	var curStart time.Time
+	for _, t := range ticks {
+		start := tf.Start(t.Timestamp)
+		if cur != nil && cur.Symbol == t.Symbol && curStart.Equal(start) {
+			if t.High > cur.High {
+				cur.High = t.High
+			}
+			if t.Low < cur.Low {
+				cur.Low = t.Low
+			}
+			cur.Close = t.Close
+			cur.Volume += t.Volume
+			cur.Timestamp, cur.Time = t.Timestamp, t.Timestamp.Unix()
+			cur.UpdatedAt = t.UpdatedAt
+			continue
+		}
+		bar := t
+		bar.Time = t.Timestamp.Unix()
+		bar.TF = tf.String()
+		out = append(out, bar)
+		cur, curStart = &out[len(out)-1], start
+	}
+	return out
+}
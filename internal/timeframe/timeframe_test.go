@@ -0,0 +1,121 @@
+package timeframe
+
+import (
+	"testing"
+	"time"
+
+	"hft/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]string{
+		"1": "1m", "1m": "1m", "5": "5m", "15m": "15m", "60m": "1h", "1h": "1h",
+		"2H": "2h", "90m": "90m", "d": "1d", "1d": "1d", "W": "1w", "1w": "1w",
+	} {
+		tf, err := Parse(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if tf.String() != want {
+			t.Errorf("%s parses as %s, want %s", name, tf, want)
+		}
+	}
+	for _, name := range []string{"", "m", "0m", "-5m", "2d", "3w", "1y", "five"} {
+		if _, err := Parse(name); err == nil {
+			t.Errorf("%q parsed", name)
+		}
+	}
+}
+
+func at(day, hhmm string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", day+" "+hhmm, IST)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestStartAlignsToSessionOpen(t *testing.T) {
+	for _, c := range []struct {
+		tf      string
+		in, out string
+	}{
+		{"5m", "09:15", "09:15"},
+		{"5m", "09:19", "09:15"},
+		{"5m", "09:20", "09:20"},
+		{"15m", "15:29", "15:15"},
+		{"1h", "10:14", "09:15"},
+		{"1h", "15:29", "15:15"},
+		{"75m", "10:30", "10:30"},
+		{"75m", "10:29", "09:15"},
+		{"5m", "09:08", "09:05"}, // pre-open
+		{"1d", "15:29", "09:15"},
+	} {
+		tf, _ := Parse(c.tf)
+		if got, want := tf.Start(at("2025-01-08", c.in)), at("2025-01-08", c.out); !got.Equal(want) {
+			t.Errorf("%s %s: start %s, want %s", c.tf, c.in, got.Format("15:04"), want.Format("15:04"))
+		}
+	}
+
+	week := Timeframe{1, Week}
+	for _, day := range []string{"2025-01-06", "2025-01-08", "2025-01-10", "2025-01-12"} {
+		if got, want := week.Start(at(day, "11:00")), at("2025-01-06", "09:15"); !got.Equal(want) {
+			t.Errorf("1w %s: start %s, want %s", day, got, want)
+		}
+	}
+	// The same instant given in UTC.
+	if got, want := (Timeframe{15, Minute}).Start(at("2025-01-08", "09:44").UTC()), at("2025-01-08", "09:30"); !got.Equal(want) {
+		t.Errorf("UTC input: start %s, want %s", got, want)
+	}
+}
+
+// session returns the 1m bars of one session: open rises by 1 per minute,
+// high/low are ±0.5 around it and volume is 1.
+func session(day string) []types.Tick {
+	var out []types.Tick
+	for m := 0; m < SessionMinutes; m++ {
+		ts := at(day, "09:15").Add(time.Duration(m) * time.Minute)
+		p := float64(m)
+		out = append(out, types.Tick{Symbol: "nifty", TF: "1", Timestamp: ts, Time: ts.Unix(),
+			Open: p, High: p + 0.5, Low: p - 0.5, Close: p + 0.25, Volume: 1})
+	}
+	return out
+}
+
+func TestResample(t *testing.T) {
+	ticks := append(session("2025-01-09"), session("2025-01-10")...)
+
+	bars := Resample(ticks, Timeframe{60, Minute})
+	if len(bars) != 14 {
+		t.Fatalf("%d hourly bars over two sessions, want 14", len(bars))
+	}
+	first, last := bars[0], bars[6]
+	if first.Open != 0 || first.High != 59.5 || first.Low != -0.5 || first.Close != 59.25 || first.Volume != 60 {
+		t.Errorf("09:15 bar %+v", first)
+	}
+	if !first.Timestamp.Equal(at("2025-01-09", "10:14")) {
+		t.Errorf("09:15 bar stamped %s, want its last minute 10:14", first.Timestamp.Format("15:04"))
+	}
+	if !last.Timestamp.Equal(at("2025-01-09", "15:29")) || last.Open != 360 || last.Close != 374.25 || last.Volume != 15 {
+		t.Errorf("15:15 bar %+v", last)
+	}
+	if first.TF != "1h" || first.Time != first.Timestamp.Unix() {
+		t.Errorf("bar tf %q time %d", first.TF, first.Time)
+	}
+	if !bars[7].Timestamp.Equal(at("2025-01-10", "10:14")) {
+		t.Errorf("second session's first bar stamped %s", bars[7].Timestamp)
+	}
+
+	daily := Resample(ticks, Timeframe{1, Day})
+	if len(daily) != 2 || daily[1].Open != 0 || daily[1].High != 374.5 || daily[1].Volume != SessionMinutes || !daily[1].Timestamp.Equal(at("2025-01-10", "15:29")) {
+		t.Errorf("daily bars %+v", daily)
+	}
+	weekly := Resample(ticks, Timeframe{1, Week})
+	if len(weekly) != 1 || !weekly[0].Timestamp.Equal(at("2025-01-10", "15:29")) || weekly[0].Volume != 2*SessionMinutes {
+		t.Errorf("weekly bars %+v", weekly)
+	}
+
+	if got := Resample(ticks, OneMinute); len(got) != len(ticks) || &got[0] != &ticks[0] {
+		t.Error("1m resample copied the bars")
+	}
+}
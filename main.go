@@ -22,6 +22,10 @@ func main() {
 		runSubcommand("go", []string{"run", "./scripts/replay_ticks"})
 	case "migrate":
 		runSubcommand("go", []string{"run", "./scripts/migrate"})
+	case "report":
+		runSubcommand("go", append([]string{"run", "./scripts/backtest_report"}, os.Args[2:]...))
+	case "verify":
+		runSubcommand("go", append([]string{"run", "./scripts/backtest_verify"}, os.Args[2:]...))
 	case "help", "-h", "--help":
 		usage()
 	default:
@@ -61,4 +65,8 @@ func usage() {
 	fmt.Println("  hft server [-config path] [-mode live|backtest]   # start API/webapp + executor")
 	fmt.Println("  hft replay                         # run tick replay utility")
 	fmt.Println("  hft migrate                        # run migrations")
+	fmt.Println("  hft report [-config path] [-strategy name] [-start D -end D] [-mode vectorized|event] [-out dir] [-formats html,json,csv,xlsx]")
+	fmt.Println("                                     # run a backtest and write its report")
+	fmt.Println("  hft verify [-config path] -manifest path.json")
+	fmt.Println("                                     # rerun a run manifest and check it reproduces")
 }
@@ -1,4 +1,14 @@
 package types
 
+import "time"
+
 // Candle models OHLCV candle data.
-type Candle struct{}
+type Candle struct {
+	Symbol    string
+	Timestamp time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
@@ -14,9 +14,36 @@ type Event struct {
 	Reason     string // Exit reason: PROFIT_TARGET, STOP_LOSS, TRAILING_STOP, SIGNAL
 	PeakProfit float64
 	PeakLoss   float64
+	Context    *EntryContext // ENTRY only, nil when the strategy does not tag
+	Strategy   string        // strategy the event belongs to, empty in single-strategy runs
 }
 
 type LogEvent struct {
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// Intent is a strategy's request to open or close a position. The OMS turns
+// a filled intent into an Event.
+type Intent struct {
+	Kind      string // ( BUY, SELL ) — for exits, the side being closed
+	Type      string // ( ENTRY, EXIT )
+	Price     float64
+	Quantity  float64 // 0 means one unit; exits close the whole position
+	Timestamp time.Time
+	Reason    string        // exit reason, empty for entries
+	Context   *EntryContext // ENTRY only
+}
+
+// EntryContext is the market context a position was opened in. Strategies
+// attach it to ENTRY intents and events so trades can be attributed.
+type EntryContext struct {
+	Tranche   string  `json:"tranche"`
+	DayType   string  `json:"dayType"`   // trend, chop, gap_reversal of the session up to the entry
+	GapPct    float64 `json:"gapPct"`    // day open vs previous close, %
+	Regime    string  `json:"regime"`    // most likely of bullish, bearish, volatile
+	EntryProb float64 `json:"entryProb"` // model probability of the traded direction
+	ATR       float64 `json:"atr"`
+	Weekday   string  `json:"weekday"`
+	Strategy  string  `json:"strategy"` // set by the allocator when several strategies run
+}
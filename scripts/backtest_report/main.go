@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"hft/internal/backtest"
+	"hft/internal/config"
+	"hft/internal/ml_model"
+	"hft/internal/storage/sqlite"
+)
+
+// backtest_report runs a backtest and writes its report files.
+func main() {
+	configPath := flag.String("config", "configs/backtest.yaml", "path to YAML config")
+	start := flag.String("start", "", "analysis start date (YYYY-MM-DD, default: config backtest.start_date)")
+	end := flag.String("end", "", "end date (YYYY-MM-DD, default: config backtest.end_date)")
+	mode := flag.String("mode", "", "backtest mode: vectorized|event (default: config backtest.mode)")
+	strat := flag.String("strategy", "", "registered strategy (default: config strategy.name)")
+	tf := flag.String("tf", "", "bar timeframe, e.g. 5m, 1h, 1d (default: config strategy.timeframe)")
+	warmupBars := flag.Int("warmup-bars", 0, "warmup bars before start (0 = config/default, -1 = none)")
+	out := flag.String("out", "export/reports", "output directory")
+	formats := flag.String("formats", strings.Join(backtest.ReportFormats, ","), "comma-separated formats: html,json,csv,xlsx")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("report: load config: %v", err)
+	}
+	if *start == "" {
+		*start = cfg.Backtest.StartDate
+	}
+	if *end == "" {
+		*end = cfg.Backtest.EndDate
+	}
+	if *warmupBars == 0 {
+		*warmupBars = cfg.Backtest.WarmupBars
+	}
+	sqlite.MustInitDefault(cfg.DBPath)
+	if err := ml_model.InitPredictor(cfg.ModelDir, cfg.OrtLibPath); err != nil {
+		log.Fatalf("report: ml_model init: %v", err)
+	}
+	if err := cfg.Apply(); err != nil {
+		log.Fatalf("report: config: %v", err)
+	}
+
+	opts := backtest.RunOptions{Strategy: *strat, StartDate: *start, EndDate: *end, Mode: *mode, Timeframe: *tf, WarmupFrom: cfg.Backtest.WarmupFrom, WarmupBars: *warmupBars}
+	if err := backtest.RunWithOptions(opts); err != nil {
+		log.Fatalf("report: backtest: %v", err)
+	}
+
+	report, err := backtest.BuildReport()
+	if err != nil {
+		log.Fatalf("report: %v", err)
+	}
+	prefix := "backtest_" + *start + "_" + *end + "_"
+	files, err := report.Export(*out, prefix, strings.Split(*formats, ",")...)
+	if err != nil {
+		log.Fatalf("report: export: %v", err)
+	}
+	for _, f := range files {
+		log.Printf("report: wrote %s", f)
+	}
+}
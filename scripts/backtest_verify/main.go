@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"hft/internal/backtest"
+	"hft/internal/config"
+	"hft/internal/manifest"
+	"hft/internal/ml_model"
+	"hft/internal/storage/sqlite"
+)
+
+// backtest_verify reruns a backtest, portfolio or simulation manifest and
+// checks that the rerun reproduces it. It exits 1 when it does not.
+func main() {
+	configPath := flag.String("config", "configs/backtest.yaml", "path to YAML config (database and model location)")
+	path := flag.String("manifest", "", "manifest JSON to verify")
+	flag.Parse()
+	if *path == "" && flag.NArg() > 0 {
+		*path = flag.Arg(0)
+	}
+	if *path == "" {
+		log.Fatalf("verify: -manifest is required")
+	}
+
+	m, err := manifest.Load(*path)
+	if err != nil {
+		log.Fatalf("verify: %v", err)
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("verify: load config: %v", err)
+	}
+	sqlite.MustInitDefault(cfg.DBPath)
+	if err := ml_model.InitPredictor(cfg.ModelDir, cfg.OrtLibPath); err != nil {
+		log.Fatalf("verify: ml_model init: %v", err)
+	}
+	if err := cfg.Apply(); err != nil {
+		log.Fatalf("verify: config: %v", err)
+	}
+
+	v, err := backtest.Verify(m)
+	if err != nil {
+		log.Fatalf("verify: %v", err)
+	}
+
+	for _, c := range v.Checks {
+		status := "ok"
+		switch {
+		case c.Warning:
+			status = "WARN"
+		case !c.OK:
+			status = "FAIL"
+		}
+		if c.OK {
+			fmt.Printf("%-4s  %s\n", status, c.Name)
+		} else {
+			fmt.Printf("%-4s  %s\n        want %s\n        got  %s\n", status, c.Name, c.Want, c.Got)
+		}
+	}
+	if !v.OK {
+		fmt.Printf("%s: NOT reproduced\n", *path)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: reproduced\n", *path)
+}
@@ -60,6 +60,9 @@ func main() {
 	if err := ml_model.InitPredictor(cfg.ModelDir, cfg.OrtLibPath); err != nil {
 		log.Fatalf("ml_model init: %v", err)
 	}
+	if err := cfg.Apply(); err != nil {
+		log.Fatalf("config: %v", err)
+	}
 
 	brokers.Init()
 	loginURL := brokers.LoginURL(cfg)
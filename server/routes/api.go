@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"hft/internal/config"
 	"hft/internal/executor"
 	"hft/internal/storage/sqlite"
 )
@@ -22,6 +23,8 @@ func APIHandler(mode string, dbPath string, wsHub *Hub) http.Handler {
 	// General endpoints
 	mux.HandleFunc("/hft/status", HFTStatusHandler)
 	mux.HandleFunc("/db/query", DBQueryHandler(dbPath))
+	mux.HandleFunc("/config/effective", ConfigEffectiveHandler)
+	mux.HandleFunc("/strategies", StrategiesHandler)
 
 	mux.HandleFunc("/broker/fyers/callback", FyersLoginHandler)
 	mux.HandleFunc("/broker/fyers/margin", FyersMarginHandler) // Get margin from Fyerss
@@ -34,16 +37,27 @@ func APIHandler(mode string, dbPath string, wsHub *Hub) http.Handler {
 	mux.HandleFunc("/live/trades", LiveTradesHandler)
 	mux.HandleFunc("/live/position", LivePositionHandler)
 	mux.HandleFunc("/live/stats", LiveStatsHandler)
+	mux.HandleFunc("/live/allocator", LiveAllocatorHandler)
+	mux.HandleFunc("/live/trace", TraceHandler(executor.LiveTraces))
 	mux.HandleFunc("/live/config", LiveConfigHandler)
+	mux.HandleFunc("/live/config/history", LiveConfigHistoryHandler)
+	mux.HandleFunc("/live/config/revert", LiveConfigRevertHandler)
 
 	// Backtest endpoints
 	mux.HandleFunc("/backtest/run", BacktestRunHandler(dbPath))
 	mux.HandleFunc("/backtest/trades", BacktestTradesHandler)
 	mux.HandleFunc("/backtest/ticks", BacktestTicksHandler(dbPath))
 	mux.HandleFunc("/backtest/data", BacktestDataHandler)
+	mux.HandleFunc("/backtest/portfolio", BacktestPortfolioHandler)
+	mux.HandleFunc("/backtest/benchmarks", BacktestBenchmarksHandler)
+	mux.HandleFunc("/backtest/report", BacktestReportHandler)
+	mux.HandleFunc("/backtest/breakdown", BacktestBreakdownHandler)
+	mux.HandleFunc("/backtest/manifest", BacktestManifestHandler)
+	mux.HandleFunc("/backtest/verify", BacktestVerifyHandler)
 
 	// Simulation endpoint
 	mux.HandleFunc("/simulate", SimulateHandler(wsHub))
+	mux.HandleFunc("/simulate/trace", TraceHandler(executor.SimTraces))
 
 	return mux
 }
@@ -106,3 +120,24 @@ func DBQueryHandler(dbPath string) http.HandlerFunc {
 		}
 	}
 }
+
+// ConfigEffectiveHandler returns the configuration in force, including live
+// edits to strategy settings. Broker secrets are redacted.
+func ConfigEffectiveHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(config.Effective()); err != nil {
+		http.Error(w, "failed to encode config", http.StatusInternalServerError)
+		return
+	}
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"hft/internal/backtest"
+	"hft/internal/executor"
+	"hft/internal/manifest"
 	"hft/internal/storage/sqlite"
+	"hft/internal/strategy"
+	"hft/internal/timeframe"
 )
 
 // BacktestRunHandler handles POST requests to run a backtest with custom date range.
@@ -27,6 +33,14 @@ func BacktestRunHandler(dbPath string) http.HandlerFunc {
 		var request struct {
 			StartDate string `json:"startDate"`
 			EndDate   string `json:"endDate"`
+			Mode      string `json:"mode"`      // "vectorized" or "event"; default config backtest.mode
+			Strategy  string `json:"strategy"`  // registered name, default the active strategy
+			Timeframe string `json:"timeframe"` // e.g. "5m", "1h", "1d"; default the active timeframe
+
+			// Warmup history before startDate: warmupFrom (date) wins over
+			// warmupBars; warmupBars 0 uses backtest.DefaultWarmupBars(), -1 none.
+			WarmupFrom string `json:"warmupFrom"`
+			WarmupBars int    `json:"warmupBars"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -39,8 +53,34 @@ func BacktestRunHandler(dbPath string) http.HandlerFunc {
 			return
 		}
 
+		// An empty mode is left to the engine, which applies backtest.mode.
+		if request.Mode != "" && request.Mode != backtest.ModeVectorized && request.Mode != backtest.ModeEvent {
+			http.Error(w, fmt.Sprintf("unknown mode %q", request.Mode), http.StatusBadRequest)
+			return
+		}
+		strat, err := strategy.Get(request.Strategy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Timeframe != "" {
+			if _, err := timeframe.Parse(request.Timeframe); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		// Run backtest with provided dates
-		if err := backtest.RunWithDates(request.StartDate, request.EndDate); err != nil {
+		opts := backtest.RunOptions{
+			Strategy:   strat.Name(),
+			StartDate:  request.StartDate,
+			EndDate:    request.EndDate,
+			Mode:       request.Mode,
+			Timeframe:  request.Timeframe,
+			WarmupFrom: request.WarmupFrom,
+			WarmupBars: request.WarmupBars,
+		}
+		if err := backtest.RunWithOptions(opts); err != nil {
 			if err.Error() == "backtest already running" {
 				http.Error(w, "backtest already running", http.StatusConflict)
 				return
@@ -49,12 +89,23 @@ func BacktestRunHandler(dbPath string) http.HandlerFunc {
 			return
 		}
 
+		mode := request.Mode
+		if m := backtest.LastManifest(); m != nil {
+			mode = m.Run.Mode
+		}
 		w.Header().Set("Content-Type", "application/json")
 		response := map[string]interface{}{
 			"status":    "success",
 			"startDate": request.StartDate,
 			"endDate":   request.EndDate,
+			"mode":      mode,
+			"strategy":  strat.Name(),
+			"warmup":    backtest.WarmupRows(),
 			"message":   "Backtest completed successfully",
+			"manifest":  backtest.LastManifest(),
+		}
+		if report, err := backtest.Benchmarks(backtest.BenchmarkConfig{}); err == nil {
+			response["benchmarks"] = report
 		}
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			http.Error(w, "failed to encode response", http.StatusInternalServerError)
@@ -83,7 +134,9 @@ func BacktestTradesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// BacktestTicksHandler handles GET requests to return backtest ticks from the database.
+// BacktestTicksHandler handles GET requests to return backtest ticks from the
+// database. tf resamples the 1-minute bars to that timeframe ("5m", "1h",
+// "1d", ...); omitted or "1" returns them as stored.
 func BacktestTicksHandler(dbPath string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enableCORS(w)
@@ -99,7 +152,14 @@ func BacktestTicksHandler(dbPath string) http.HandlerFunc {
 
 		// Get query parameters for filtering
 		symbol := r.URL.Query().Get("symbol")
-		tf := r.URL.Query().Get("tf")
+		tf := timeframe.OneMinute
+		if name := r.URL.Query().Get("tf"); name != "" {
+			var err error
+			if tf, err = timeframe.Parse(name); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		startDate := r.URL.Query().Get("startDate")
 		endDate := r.URL.Query().Get("endDate")
 
@@ -112,11 +172,12 @@ func BacktestTicksHandler(dbPath string) http.HandlerFunc {
 
 		// Query ticks from database
 		ctx := context.Background()
-		ticks, err := db.Ticks.ListTicksFiltered(ctx, symbol, tf, 0, startDate, endDate)
+		ticks, err := db.Ticks.ListTicksFiltered(ctx, symbol, "", 0, startDate, endDate)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed to query ticks: %v", err), http.StatusInternalServerError)
 			return
 		}
+		ticks = timeframe.Resample(ticks, tf)
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(ticks); err != nil {
@@ -147,3 +208,312 @@ func BacktestDataHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// BacktestPortfolioHandler runs (POST) or returns the last (GET) portfolio
+// backtest across several symbols with shared capital.
+//
+//	POST /backtest/portfolio {"symbols": ["nifty", "reliance"], "startDate": "2025-01-01",
+//	  "endDate": "2025-06-30", "timeframe": "15m", "capital": 1000000, "maxSymbolExposure": 0.5, "maxTotalExposure": 1.0}
+func BacktestPortfolioHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var result *backtest.PortfolioResult
+	switch r.Method {
+	case http.MethodGet:
+		result = backtest.LastPortfolio()
+		if result == nil {
+			http.Error(w, "no portfolio backtest has run", http.StatusNotFound)
+			return
+		}
+	case http.MethodPost:
+		var request struct {
+			Strategy          string   `json:"strategy"`
+			Symbols           []string `json:"symbols"`
+			StartDate         string   `json:"startDate"`
+			EndDate           string   `json:"endDate"`
+			Timeframe         string   `json:"timeframe"`
+			WarmupBars        int      `json:"warmupBars"`
+			Capital           float64  `json:"capital"`
+			MaxSymbolExposure float64  `json:"maxSymbolExposure"`
+			MaxTotalExposure  float64  `json:"maxTotalExposure"`
+			MaxOpenPositions  int      `json:"maxOpenPositions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(request.Symbols) == 0 || request.StartDate == "" || request.EndDate == "" {
+			http.Error(w, "symbols, startDate and endDate are required", http.StatusBadRequest)
+			return
+		}
+		if request.Timeframe != "" {
+			if _, err := timeframe.Parse(request.Timeframe); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var err error
+		result, err = backtest.RunPortfolio(backtest.PortfolioConfig{
+			Strategy:          request.Strategy,
+			Symbols:           request.Symbols,
+			StartDate:         request.StartDate,
+			EndDate:           request.EndDate,
+			Timeframe:         request.Timeframe,
+			WarmupBars:        request.WarmupBars,
+			Capital:           request.Capital,
+			MaxSymbolExposure: request.MaxSymbolExposure,
+			MaxTotalExposure:  request.MaxTotalExposure,
+			MaxOpenPositions:  request.MaxOpenPositions,
+		})
+		if err != nil {
+			if err.Error() == "backtest already running" {
+				http.Error(w, "backtest already running", http.StatusConflict)
+				return
+			}
+			http.Error(w, fmt.Sprintf("failed to run portfolio backtest: %v", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "failed to encode portfolio result", http.StatusInternalServerError)
+		return
+	}
+}
+
+// BacktestBenchmarksHandler compares the last backtest run against
+// buy-and-hold, random entries and an optional second strategy.
+//
+//	GET /backtest/benchmarks?second=kalman_v2&randomRuns=100&seed=1
+func BacktestBenchmarksHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	cfg := backtest.BenchmarkConfig{SecondStrategy: q.Get("second")}
+	if v := q.Get("randomRuns"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid randomRuns: %v", err), http.StatusBadRequest)
+			return
+		}
+		cfg.RandomRuns = n
+	}
+	if v := q.Get("seed"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid seed: %v", err), http.StatusBadRequest)
+			return
+		}
+		cfg.Seed = n
+	}
+
+	report, err := backtest.Benchmarks(cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "failed to encode benchmarks", http.StatusInternalServerError)
+		return
+	}
+}
+
+// BacktestReportHandler renders the last backtest run as a report.
+//
+//	GET /backtest/report?format=html|json|xlsx
+//	GET /backtest/report?format=csv&table=trades|equity
+func BacktestReportHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := backtest.BuildReport()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = backtest.FormatHTML
+	}
+	var write func(io.Writer) error
+	switch format {
+	case backtest.FormatHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		write = report.WriteHTML
+	case backtest.FormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		write = report.WriteJSON
+	case backtest.FormatCSV:
+		table := r.URL.Query().Get("table")
+		switch table {
+		case "", "trades":
+			table, write = "trades", report.WriteTradesCSV
+		case "equity":
+			write = report.WriteEquityCSV
+		default:
+			http.Error(w, fmt.Sprintf("unknown table %q", table), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=backtest_%s.csv", table))
+	case backtest.FormatXLSX:
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", "attachment; filename=backtest_report.xlsx")
+		write = report.WriteXLSX
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	if err := write(w); err != nil {
+		http.Error(w, "failed to write report", http.StatusInternalServerError)
+		return
+	}
+}
+
+// BacktestBreakdownHandler groups the last run's trades by an entry-context
+// tag and returns count, win rate and PnL per group.
+//
+//	GET /backtest/breakdown?by=tranche
+func BacktestBreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		http.Error(w, fmt.Sprintf("by is required, one of %v", backtest.BreakdownTags), http.StatusBadRequest)
+		return
+	}
+	rows, err := backtest.Breakdown(by)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"by":     by,
+		"groups": rows,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "failed to encode breakdown", http.StatusInternalServerError)
+		return
+	}
+}
+
+// BacktestManifestHandler returns the reproducibility manifest of the last
+// run of a kind: backtest (default), portfolio or simulation.
+//
+//	GET /backtest/manifest?kind=simulation
+func BacktestManifestHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var m *manifest.Manifest
+	switch kind := r.URL.Query().Get("kind"); kind {
+	case "", manifest.KindBacktest:
+		m = backtest.LastManifest()
+	case manifest.KindPortfolio:
+		if res := backtest.LastPortfolio(); res != nil {
+			m = res.Manifest
+		}
+	case manifest.KindSimulation:
+		m = executor.LastSimulationManifest()
+	default:
+		http.Error(w, fmt.Sprintf("unknown kind %q", kind), http.StatusBadRequest)
+		return
+	}
+	if m == nil {
+		http.Error(w, "no run of this kind yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		http.Error(w, "failed to encode manifest", http.StatusInternalServerError)
+		return
+	}
+}
+
+// BacktestVerifyHandler reruns the posted manifest and reports every check
+// against the recorded run.
+//
+//	POST /backtest/verify <manifest JSON>
+func BacktestVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	m := new(manifest.Manifest)
+	if err := json.NewDecoder(r.Body).Decode(m); err != nil {
+		http.Error(w, fmt.Sprintf("invalid manifest: %v", err), http.StatusBadRequest)
+		return
+	}
+	v, err := backtest.Verify(m)
+	if err != nil {
+		if backtest.IsRunning() {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("verify failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "failed to encode verification", http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,11 +2,12 @@ package routes
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"hft/internal/brokers"
+	"hft/internal/config"
 	"hft/internal/executor"
-	"hft/internal/strategy"
 )
 
 func FyersMarginHandler(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +69,23 @@ func LiveStatsHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(executor.StatsToJSON())
 }
 
+// LiveAllocatorHandler returns the allocator's account position and every
+// strategy sleeve's capital, equity and position; null when a single
+// strategy runs.
+func LiveAllocatorHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(executor.AllocatorToJSON())
+}
+
 // LiveTicksHandler handles GET requests to return live ticks.
 func LiveTicksHandler(w http.ResponseWriter, r *http.Request) {
 	enableCORS(w)
@@ -88,7 +106,12 @@ func LiveTicksHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// LiveConfigHandler handles GET/POST for strategy exit config.
+// LiveConfigHandler handles GET/POST for the live-editable settings
+// (regime strategy, Kalman exits, regime smoothing).
+//
+// GET returns the current version. POST takes a partial settings object,
+// plus optional "author" and "note", and returns the new version; an
+// invalid change is rejected with 400 and nothing is applied.
 func LiveConfigHandler(w http.ResponseWriter, r *http.Request) {
 	enableCORS(w)
 	if r.Method == http.MethodOptions {
@@ -98,45 +121,81 @@ func LiveConfigHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == http.MethodGet {
-		json.NewEncoder(w).Encode(strategy.ExitConfigToJSON())
+		json.NewEncoder(w).Encode(config.LiveCurrent())
 		return
 	}
 
 	if r.Method == http.MethodPost {
-		var body struct {
-			ActivationMFEPts  *float64 `json:"activationMFEPts"`
-			MFECaptureRatio   *float64 `json:"mfeCaptureRatio"`
-			SignalConfirmBars *int     `json:"signalConfirmBars"`
-			EnableFixedSL     *bool    `json:"enableFixedSL"`
-			FixedSL           *float64 `json:"fixedSL"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, "invalid JSON", http.StatusBadRequest)
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
 			return
 		}
-		cfg := strategy.ActiveExitConfig
-		if body.ActivationMFEPts != nil {
-			cfg.ActivationMFEPts = *body.ActivationMFEPts
-		}
-		if body.MFECaptureRatio != nil {
-			cfg.MFECaptureRatio = *body.MFECaptureRatio
+		var meta struct {
+			Author string `json:"author"`
+			Note   string `json:"note"`
 		}
-		if body.SignalConfirmBars != nil {
-			cfg.SignalConfirmBars = *body.SignalConfirmBars
-		}
-		if body.EnableFixedSL != nil {
-			cfg.EnableFixedSL = *body.EnableFixedSL
+		if err := json.Unmarshal(patch, &meta); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
 		}
-		if body.FixedSL != nil {
-			cfg.FixedSL = *body.FixedSL
+		v, err := config.UpdateLive(patch, meta.Author, meta.Note)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		json.NewEncoder(w).Encode(strategy.ExitConfigToJSON())
+		json.NewEncoder(w).Encode(v)
 		return
 	}
 
 	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 }
 
+// LiveConfigHistoryHandler returns every live config version, oldest first.
+func LiveConfigHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config.LiveHistory())
+}
+
+// LiveConfigRevertHandler handles POST {"version": N, "author", "note"}: it
+// re-applies version N as a new version.
+func LiveConfigRevertHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Version int    `json:"version"`
+		Author  string `json:"author"`
+		Note    string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	v, err := config.RevertLive(body.Version, body.Author, body.Note)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
 // LiveTradesHandler handles GET requests to return live trades.
 func LiveTradesHandler(w http.ResponseWriter, r *http.Request) {
 	enableCORS(w)
@@ -8,14 +8,16 @@ import (
 	"time"
 
 	"hft/internal/executor"
+	"hft/internal/timeframe"
 )
 
 // SimulateHandler handles POST /simulate to replay a past date bar-by-bar.
 //
-//	POST /simulate {"date": "2026-03-13", "warmupDays": 100, "tickDelay": 10}
+//	POST /simulate {"date": "2026-03-13", "warmupDays": 100, "tickDelay": 10, "strategy": "regime", "timeframe": "5m"}
 //
 // tickDelay is seconds between bars (default 10). Runs in background;
-// events stream to /ws/events WebSocket clients.
+// events stream to /ws/events WebSocket clients, including a sim_trace per
+// bar explaining the decision (also kept for GET /simulate/trace).
 func SimulateHandler(wsHub *Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enableCORS(w)
@@ -32,6 +34,8 @@ func SimulateHandler(wsHub *Hub) http.HandlerFunc {
 			Date       string `json:"date"`
 			WarmupDays int    `json:"warmupDays"`
 			TickDelay  int    `json:"tickDelay"` // seconds
+			Strategy   string `json:"strategy"`  // registered name, default the active strategy
+			Timeframe  string `json:"timeframe"` // e.g. "5m", default the active timeframe
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
@@ -41,6 +45,12 @@ func SimulateHandler(wsHub *Hub) http.HandlerFunc {
 			http.Error(w, "date is required (e.g. 2026-03-13)", http.StatusBadRequest)
 			return
 		}
+		if req.Timeframe != "" {
+			if _, err := timeframe.Parse(req.Timeframe); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		if req.WarmupDays <= 0 {
 			req.WarmupDays = 100
 		}
@@ -49,8 +59,10 @@ func SimulateHandler(wsHub *Hub) http.HandlerFunc {
 		}
 
 		cfg := executor.SimConfig{
+			Strategy:   req.Strategy,
 			SimDate:    req.Date,
 			WarmupDays: req.WarmupDays,
+			Timeframe:  req.Timeframe,
 			TickDelay:  time.Duration(req.TickDelay) * time.Second,
 			OnEvent: func(eventType string, data map[string]interface{}) {
 				wsHub.BroadcastMessage(eventType, data)
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"hft/internal/strategy"
+)
+
+// StrategiesHandler lists the registered strategies (GET) or selects the
+// active one (POST). The active strategy is the default for backtests,
+// simulations and the next executor start.
+//
+//	POST /strategies {"active": "kalman_v2"}
+func StrategiesHandler(w http.ResponseWriter, r *http.Request) {
+	enableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request struct {
+			Active string `json:"active"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if _, err := strategy.Get(request.Active); request.Active == "" || err != nil {
+			http.Error(w, fmt.Sprintf("active must be one of %v", strategy.Names()), http.StatusBadRequest)
+			return
+		}
+		strategy.ActiveStrategy = request.Active
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(strategy.Describe()); err != nil {
+		http.Error(w, "failed to encode strategies", http.StatusInternalServerError)
+		return
+	}
+}
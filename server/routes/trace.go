@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hft/internal/strategy"
+)
+
+// TraceHandler serves the per-bar decision traces kept in buf.
+//
+//	GET /live/trace?from=2026-03-13 10:00&to=2026-03-13 11:00&strategy=regime&action=none
+//
+// from and to are RFC3339, "2006-01-02 15:04" or "2006-01-02" (IST); a
+// missing one leaves that end open, and a bare date as to covers that
+// whole day. strategy and action (none | hold | enter | exit) filter the
+// traces.
+func TraceHandler(buf *strategy.TraceBuffer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enableCORS(w)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		from, err := parseTraceTime(q.Get("from"), false)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTraceTime(q.Get("to"), true)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		name, action := q.Get("strategy"), q.Get("action")
+		traces := buf.Range(from, to)
+		out := traces[:0]
+		for _, t := range traces {
+			if (name == "" || t.Strategy == name) && (action == "" || t.Action == action) {
+				out = append(out, t)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false) // rule details read "0.466 > 0.750"
+		enc.Encode(out)
+	}
+}
+
+// parseTraceTime parses a trace range bound; a bare date as the upper bound
+// means the end of that day.
+func parseTraceTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	ist := time.FixedZone("IST", 19800)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, ist); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, ist)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want RFC3339, \"2006-01-02 15:04\" or \"2006-01-02\", got %q", s)
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hft/internal/strategy"
+)
+
+func TestParseTraceTime(t *testing.T) {
+	ist := time.FixedZone("IST", 19800)
+	for _, tc := range []struct {
+		in   string
+		end  bool
+		want time.Time
+	}{
+		{"", false, time.Time{}},
+		{"", true, time.Time{}},
+		{"2026-03-13T10:00:00Z", false, time.Date(2026, 3, 13, 10, 0, 0, 0, time.UTC)},
+		{"2026-03-13T10:00:00+05:30", true, time.Date(2026, 3, 13, 10, 0, 0, 0, ist)},
+		{"2026-03-13 10:00", false, time.Date(2026, 3, 13, 10, 0, 0, 0, ist)},
+		{"2026-03-13 10:00", true, time.Date(2026, 3, 13, 10, 0, 0, 0, ist)},
+		{"2026-03-13", false, time.Date(2026, 3, 13, 0, 0, 0, 0, ist)},
+		// A bare date as the upper bound covers the whole day.
+		{"2026-03-13", true, time.Date(2026, 3, 13, 23, 59, 59, 999999999, ist)},
+	} {
+		got, err := parseTraceTime(tc.in, tc.end)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("%q (end %v): %v, %v, want %v", tc.in, tc.end, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"13-03-2026", "2026-03-13 10", "yesterday"} {
+		if _, err := parseTraceTime(in, false); err == nil {
+			t.Errorf("%q accepted", in)
+		}
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	ist := time.FixedZone("IST", 19800)
+	buf := strategy.NewTraceBuffer(0)
+	for _, tr := range []strategy.Trace{
+		{Timestamp: time.Date(2026, 3, 12, 15, 0, 0, 0, ist), Strategy: "regime", Action: strategy.TraceNone},
+		{Timestamp: time.Date(2026, 3, 13, 10, 0, 0, 0, ist), Strategy: "regime", Action: strategy.TraceEnter},
+		{Timestamp: time.Date(2026, 3, 13, 10, 0, 0, 0, ist), Strategy: "orb", Action: strategy.TraceNone},
+		{Timestamp: time.Date(2026, 3, 13, 15, 29, 0, 0, ist), Strategy: "regime", Action: strategy.TraceNone},
+		{Timestamp: time.Date(2026, 3, 14, 9, 15, 0, 0, ist), Strategy: "regime", Action: strategy.TraceNone},
+	} {
+		buf.Add(tr)
+	}
+	h := TraceHandler(buf)
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"", 5},
+		{"?from=2026-03-13&to=2026-03-13", 3},
+		{"?to=2026-03-13+10:00", 3},
+		{"?from=2026-03-13&strategy=regime", 3},
+		{"?from=2026-03-13&to=2026-03-13&action=none", 2},
+		{"?strategy=orb&action=enter", 0},
+	} {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/live/trace"+tc.query, nil))
+		var got []strategy.Trace
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); rec.Code != http.StatusOK || err != nil || got == nil {
+			t.Fatalf("%s: %d %s", tc.query, rec.Code, rec.Body)
+		}
+		if len(got) != tc.want {
+			t.Errorf("%s: %d traces, want %d", tc.query, len(got), tc.want)
+		}
+	}
+
+	for _, query := range []string{"?from=soon", "?to=2026-13-01"} {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/live/trace"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", query, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/live/trace", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+import { useEffect, useState } from "react";
+import axios from "axios";
+
+// Per-strategy sleeves of the live allocator. Renders nothing when the
+// executor runs a single strategy (/live/allocator returns null).
+function AllocationCard() {
+  const [allocator, setAllocator] = useState(null);
+  const [stats, setStats] = useState({});
+
+  useEffect(() => {
+    const controller = new AbortController();
+    const poll = async () => {
+      try {
+        const { data } = await axios.get("http://localhost:5001/live/allocator", {
+          signal: controller.signal,
+        });
+        setAllocator(data);
+      } catch (err) {
+        if (!controller.signal.aborted) setAllocator(null);
+      }
+      try {
+        const { data } = await axios.get("http://localhost:5001/live/stats", {
+          signal: controller.signal,
+        });
+        setStats(data?.strategies || {});
+      } catch {}
+    };
+
+    poll();
+    const interval = setInterval(poll, 5000);
+    return () => {
+      controller.abort();
+      clearInterval(interval);
+    };
+  }, []);
+
+  if (!allocator?.sleeves?.length) return null;
+
+  const formatMoney = (v) =>
+    Number(v || 0).toLocaleString("en-IN", { minimumFractionDigits: 0, maximumFractionDigits: 0 });
+  const formatPts = (v) => {
+    const n = Number(v || 0);
+    return `${n >= 0 ? "+" : ""}${n.toFixed(2)}`;
+  };
+  const pnlClass = (v) => (v > 0 ? "profit-positive" : v < 0 ? "profit-negative" : "");
+
+  return (
+    <div className="allocation-card">
+      <div className="allocation-card__header">
+        <span className="position-card__label">Allocation</span>
+        <span className="allocation-card__meta">
+          netting {allocator.netting} · account {allocator.net > 0 ? "+" : ""}
+          {allocator.net} · {allocator.orders} orders
+        </span>
+      </div>
+      <table className="trades-table allocation-card__table">
+        <thead>
+          <tr>
+            <th>Strategy</th>
+            <th>Weight</th>
+            <th>Equity ₹</th>
+            <th>Realized ₹</th>
+            <th>Position</th>
+            <th>Trades</th>
+            <th>Win %</th>
+            <th>Net pts</th>
+            <th>Rejected</th>
+          </tr>
+        </thead>
+        <tbody>
+          {allocator.sleeves.map((s) => {
+            const st = stats[s.strategy] || {};
+            const winRate = st.totalTrades ? (st.winningTrades / st.totalTrades) * 100 : 0;
+            return (
+              <tr key={s.strategy}>
+                <td>{s.strategy}</td>
+                <td>{(s.weight * 100).toFixed(0)}%</td>
+                <td>{formatMoney(s.equity)}</td>
+                <td className={pnlClass(s.realized)}>{formatMoney(s.realized)}</td>
+                <td className={`trade-type ${s.position === "LONG" ? "buy" : s.position === "SHORT" ? "sell" : ""}`}>
+                  {s.position}
+                  {s.quantity ? ` ×${s.quantity}` : ""}
+                  {s.halted ? " (halted)" : ""}
+                </td>
+                <td>{st.totalTrades ?? 0}</td>
+                <td>{winRate.toFixed(1)}%</td>
+                <td className={pnlClass(st.netProfit)}>{formatPts(st.netProfit)}</td>
+                <td>{s.rejected}</td>
+              </tr>
+            );
+          })}
+        </tbody>
+      </table>
+    </div>
+  );
+}
+
+export default AllocationCard;
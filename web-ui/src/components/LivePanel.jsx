@@ -1,5 +1,6 @@
 import { useEffect, useState } from "react";
 import axios from "axios";
+import AllocationCard from "./AllocationCard";
 import ChartPanel from "./ChartPanel";
 import DrawdownAlert from "./DrawdownAlert";
 import { useAppDispatch, useAppSelector } from "../store/hooks";
@@ -81,6 +82,7 @@ function LivePanel() {
         </div>
       </div>
       {/* <DrawdownAlert trades={liveTrades} /> */}
+      <AllocationCard />
       <ChartPanel apiEndpoint="http://localhost:5001/live/ticks" />
     </div>
   );
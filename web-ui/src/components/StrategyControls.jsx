@@ -9,7 +9,7 @@ function StrategyControls() {
   useEffect(() => {
     axios
       .get("http://localhost:5001/live/config")
-      .then(({ data }) => setConfig(data))
+      .then(({ data }) => setConfig(data.settings.kalmanExit))
       .catch(() => {});
   }, []);
 
@@ -21,11 +21,11 @@ function StrategyControls() {
   const save = async () => {
     setSaving(true);
     try {
-      const { data } = await axios.post(
-        "http://localhost:5001/live/config",
-        config
-      );
-      setConfig(data);
+      const { data } = await axios.post("http://localhost:5001/live/config", {
+        kalmanExit: config,
+        author: "web-ui",
+      });
+      setConfig(data.settings.kalmanExit);
       setDirty(false);
     } catch {}
     setSaving(false);
@@ -34,13 +34,17 @@ function StrategyControls() {
   const reset = async () => {
     try {
       const { data } = await axios.post("http://localhost:5001/live/config", {
-        activationMFEPts: 500,
-        mfeCaptureRatio: 0.4,
-        signalConfirmBars: 0,
-        enableFixedSL: false,
-        fixedSL: -50,
+        kalmanExit: {
+          activationMFEPts: 500,
+          mfeCaptureRatio: 0.4,
+          signalConfirmBars: 0,
+          enableFixedSL: false,
+          fixedSL: -50,
+        },
+        author: "web-ui",
+        note: "reset",
       });
-      setConfig(data);
+      setConfig(data.settings.kalmanExit);
       setDirty(false);
     } catch {}
   };
@@ -29,6 +29,8 @@ function TradesDrawer() {
   
   const trades = activePanel === 'live' ? liveTrades : backtestTrades;
   const metrics = activePanel === 'live' ? liveMetrics : backtestMetrics;
+  // Only allocator runs tag trades with the strategy that made them.
+  const showStrategy = trades.some((t) => t?.strategy);
   
   const filterValue = useAppSelector(selectTradesFilter);
   const sortValue = useAppSelector(selectTradesSort);
@@ -90,7 +92,7 @@ function TradesDrawer() {
   };
 
   const downloadCSV = () => {
-    const csv = trades.map((trade) => `${trade.entryTime},${trade.exitTime},${trade.type},${trade.entryPrice},${trade.exitPrice},${trade.profit}, ${trade.peakProfit}, ${trade.peakLoss}, ${trade.reason}`).join("\n");
+    const csv = trades.map((trade) => `${trade.entryTime},${trade.exitTime},${trade.type},${trade.entryPrice},${trade.exitPrice},${trade.profit}, ${trade.peakProfit}, ${trade.peakLoss}, ${trade.reason}${showStrategy ? `,${trade.strategy || ""}` : ""}`).join("\n");
     const blob = new Blob([csv], { type: "text/csv" });
     const url = URL.createObjectURL(blob);
     const a = document.createElement("a");
@@ -183,6 +185,11 @@ function TradesDrawer() {
                   role="button"
                 >Peak Loss {renderSortIndicator("peakLoss")}</th>
                 <th>Reason</th>
+                {showStrategy && (
+                  <th className="sortable" onClick={() => toggleSort("strategy")} role="button">
+                    Strategy {renderSortIndicator("strategy")}
+                  </th>
+                )}
               </tr>
             </thead>
             <tbody>
@@ -218,6 +225,7 @@ function TradesDrawer() {
                       {formatPrice(trade?.peakLoss)}
                     </td>
                     <td>{getReasonBadge(trade?.reason)}</td>
+                    {showStrategy && <td>{trade?.strategy || "-"}</td>}
                   </tr>
                 );
               })}
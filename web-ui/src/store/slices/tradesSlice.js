@@ -178,7 +178,7 @@ const filterAndSortTrades = (trades, filter, sort) => {
   const term = filter.trim().toLowerCase();
   const filtered = term
     ? trades.filter((t) =>
-        [t?.entryTime, t?.exitTime, t?.type, t?.entryPrice, t?.exitPrice, t?.profit, t?.reason]
+        [t?.entryTime, t?.exitTime, t?.type, t?.entryPrice, t?.exitPrice, t?.profit, t?.reason, t?.strategy]
           .map((v) => (v === undefined || v === null ? '' : String(v).toLowerCase()))
           .some((v) => v.includes(term))
       )
@@ -196,6 +196,8 @@ const filterAndSortTrades = (trades, filter, sort) => {
         return mult * ((Number(a?.profit) || 0) - (Number(b?.profit) || 0));
       case 'type':
         return mult * String(a?.type || '').localeCompare(String(b?.type || ''));
+      case 'strategy':
+        return mult * String(a?.strategy || '').localeCompare(String(b?.strategy || ''));
       case 'exitTime': {
         const ta = new Date(a?.exitTime || '').getTime() || 0;
         const tb = new Date(b?.exitTime || '').getTime() || 0;